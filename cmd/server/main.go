@@ -1,64 +1,406 @@
 // Description: This is the main entry point for our HTTP server application.
 // It's responsible for setting up the router, registering our API endpoints (handlers),
-// and starting the server.
+// starting the server, and shutting it down gracefully on SIGINT or SIGTERM, or
+// handing over to a new binary on SIGUSR2.
 
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/hanzalaareeb/HTTPGolang/pkg/acme"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/admin"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/auth"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/blob"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/buildinfo"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/config"
 	"github.com/hanzalaareeb/HTTPGolang/pkg/handlers"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/health"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logfile"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
 	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
 	"github.com/hanzalaareeb/HTTPGolang/pkg/server"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/yaml"
 )
 
 // main is the function where the execution of the program begins.
 func main() {
+	// ctx is canceled on the first Ctrl+C (SIGINT) or SIGTERM, the signal
+	// orchestrators such as Kubernetes and systemd send to stop a service.
+	// Once it is, the signals get their default behavior back, so a second
+	// Ctrl+C kills a server that's stuck draining.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+
+	err := run(ctx, os.Args[1:])
+	stop()
+	switch {
+	case err == nil:
+		logger.Info("Server stopped")
+	case errors.Is(err, flag.ErrHelp):
+		// -h prints the usage; that's not a failure.
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		logger.Error("Server error", "err", err)
+		os.Exit(1)
+	}
+}
+
+// connections counts the server's connections by state, and requests the
+// requests served by route, failures, and the uptime. They're published with
+// expvar, so the admin server's /metrics shows them.
+var (
+	connections = new(server.ConnMetrics)
+	requests    = middleware.NewRequestStats()
+)
+
+func init() {
+	expvar.Publish("connections", connections)
+	expvar.Publish("requests", requests)
+}
+
+// upgradeTimeout bounds how long a new process started on SIGUSR2 may take to
+// start serving before we give up on it.
+const upgradeTimeout = 30 * time.Second
+
+// errUsage reports invalid command-line flags or settings. What's wrong has
+// already been printed.
+var errUsage = errors.New("invalid usage")
+
+// run parses the flags and serves until ctx is canceled. It returns nil after
+// a clean shutdown. The settings come from config.Default, the -config file,
+// the HTTPGOLANG_* environment variables, and the flags, each overriding the
+// ones before.
+func run(ctx context.Context, args []string) error {
+	defaults := config.Default()
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flags.String("config", "", "JSON, YAML, or TOML settings file; HTTPGOLANG_* environment variables and flags override it")
+	addr := flags.String("addr", defaults.Server.Addr, "address to listen on")
+	drainTimeout := flags.Duration("shutdown-timeout", time.Duration(defaults.Server.ShutdownTimeout),
+		"how long to wait for in-flight requests when shutting down")
+	certFile := flags.String("tls-cert", "", "PEM certificate file; serves HTTPS together with -tls-key")
+	keyFile := flags.String("tls-key", "", "PEM private key file for -tls-cert")
+	tlsPolicy := flags.String("tls-policy", defaults.TLS.Policy, "TLS preset: modern (TLS 1.3 only) or intermediate (also TLS 1.2)")
+	redirectAddr := flags.String("redirect-addr", "", "with HTTPS, also listen for plain HTTP here and redirect to HTTPS, e.g. :80")
+	bindRetry := flags.Duration("bind-retry", 0, "keep trying this long to listen on an address still in use, e.g. by the previous instance")
+	maxConns := flags.Int("max-conns", 0, "most connections open at once; more wait in the listen backlog (0 means no limit)")
+	adminAddr := flags.String("admin-addr", "", "serve the admin endpoints (health, metrics, pprof, maintenance) here, e.g. 127.0.0.1:9090")
+	h2c := flags.Bool("h2c", false, "also accept HTTP/2 without TLS (prior knowledge), for trusted networks")
+	acmeHosts := flags.String("acme-hosts", "", "comma-separated host names to get Let's Encrypt certificates for, instead of -tls-cert")
+	acmeEmail := flags.String("acme-email", "", "contact email for the Let's Encrypt account")
+	acmeCache := flags.String("acme-cache", defaults.ACME.Cache, "directory caching the Let's Encrypt account and certificates")
+	version := flags.Bool("version", false, "print the version and exit")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	build := buildinfo.Get()
+	if *version {
+		fmt.Fprintf(flags.Output(), "%s (commit %s, built %s, %s)\n", build.Version, build.Short(), build.Date, build.GoVersion)
+		return nil
+	}
+
+	// Only the flags given on the command line override the settings.
+	overrides := map[string]func(c *config.Config){
+		"addr":             func(c *config.Config) { c.Server.Addr = *addr },
+		"shutdown-timeout": func(c *config.Config) { c.Server.ShutdownTimeout = config.Duration(*drainTimeout) },
+		"tls-cert":         func(c *config.Config) { c.TLS.Cert = *certFile },
+		"tls-key":          func(c *config.Config) { c.TLS.Key = *keyFile },
+		"tls-policy":       func(c *config.Config) { c.TLS.Policy = *tlsPolicy },
+		"redirect-addr":    func(c *config.Config) { c.TLS.RedirectAddr = *redirectAddr },
+		"bind-retry":       func(c *config.Config) { c.Server.BindRetry = config.Duration(*bindRetry) },
+		"max-conns":        func(c *config.Config) { c.Server.MaxConnections = *maxConns },
+		"admin-addr":       func(c *config.Config) { c.Admin.Addr = *adminAddr },
+		"h2c":              func(c *config.Config) { c.Server.H2C = *h2c },
+		"acme-hosts":       func(c *config.Config) { c.ACME.Hosts = strings.Split(*acmeHosts, ",") },
+		"acme-email":       func(c *config.Config) { c.ACME.Email = *acmeEmail },
+		"acme-cache":       func(c *config.Config) { c.ACME.Cache = *acmeCache },
+	}
+	cfg, err := config.LoadWith(*configFile, func(c *config.Config) {
+		flags.Visit(func(f *flag.Flag) {
+			if override, ok := overrides[f.Name]; ok {
+				override(c)
+			}
+		})
+	})
+	if err != nil {
+		fmt.Fprintln(flags.Output(), err)
+		return errUsage
+	}
+	// From here on, everything logs at the configured level and format.
+	level, _ := logger.ParseLevel(cfg.Log.Level) // validated by config.Load
+	logFormat := logger.FormatText
+	if cfg.Log.Format == "json" {
+		logFormat = logger.FormatJSON
+	}
+	logger.SetDefault(logger.New(logger.Options{Level: level, Format: logFormat}))
+	if logger.Enabled(logger.Default(), logger.LevelDebug) {
+		shown := *cfg
+		if shown.Admin.DebugToken != "" {
+			shown.Admin.DebugToken = "[redacted]"
+		}
+		if shown.Log.Sample.Token != "" {
+			shown.Log.Sample.Token = "[redacted]"
+		}
+		if shown.DB.DSN != "" {
+			shown.DB.DSN = "[redacted]"
+		}
+		if shown.Auth.Secret != "" {
+			shown.Auth.Secret = "[redacted]"
+		}
+		if dump, err := yaml.Marshal(shown); err == nil {
+			logger.Debug("Configuration:\n" + string(dump))
+		}
+	}
+
+	logger.Info("Starting", "version", build.Version, "commit", build.Short(), "built", build.Date, "go", build.GoVersion)
+
 	// 1. Create a new instance of our custom router.
 	// The router will be responsible for mapping incoming requests to the correct handler.
-	log.Println("Initializing router...")
+	logger.Info("Initializing router...")
 	r := router.New()
 
 	// 2. Register application routes.
 	// We delegate the registration of specific routes to the handlers package
 	// to keep our main function clean and organized. This is a good practice
-	// for modularity.
-	log.Println("Registering application handlers...")
-	handlers.RegisterRoutes(r)
+	// for modularity. The users live in the configured database or, without
+	// one, in memory, starting with a couple of examples.
+	logger.Info("Registering application handlers...")
+	var users store.UserStore = store.NewMemoryUserStore(store.User{ID: 1, Name: "Hanzala"}, store.User{ID: 2, Name: "Areeb"})
+	var db *store.DB
+	if cfg.DB.Driver != "" {
+		db, err = store.OpenSQL(ctx, store.SQLOptions{
+			Driver:          cfg.DB.Driver,
+			DSN:             cfg.DB.DSN,
+			MaxOpenConns:    cfg.DB.MaxOpenConns,
+			MaxIdleConns:    cfg.DB.MaxIdleConns,
+			ConnMaxLifetime: time.Duration(cfg.DB.ConnMaxLifetime),
+			ConnMaxIdleTime: time.Duration(cfg.DB.ConnMaxIdleTime),
+		})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		users = store.NewSQLUserStore(db)
+	}
+	var accounts store.AuthStore
+	if cfg.Auth.Secret != "" {
+		accounts = store.NewMemoryAuthStore()
+		if db != nil {
+			accounts = store.NewSQLAuthStore(db)
+		}
+		// A deleted user's login goes with it.
+		users = store.WithAccounts(users, accounts)
+	}
+	handlers.RegisterRoutes(r, users)
+	if cfg.Blobs.Dir != "" {
+		blobs, err := blob.NewFileStore(cfg.Blobs.Dir)
+		if err != nil {
+			return err
+		}
+		handlers.RegisterAvatarRoutes(r, users, blobs)
+	}
+	var tokens *auth.Tokens
+	if cfg.Auth.Secret != "" {
+		tokens, err = auth.NewTokens(auth.TokenOptions{
+			Secret:     []byte(cfg.Auth.Secret),
+			AccessTTL:  time.Duration(cfg.Auth.AccessTTL),
+			RefreshTTL: time.Duration(cfg.Auth.RefreshTTL),
+		})
+		if err != nil {
+			return err
+		}
+		if err := handlers.CheckAdmins(ctx, users, cfg.Auth.AdminIDs); err != nil {
+			return fmt.Errorf("auth.admin_ids: %w", err)
+		}
+		handlers.RegisterAuthRoutes(r, users, accounts, tokens)
+	}
+	r.Use(requests.Middleware())
+
+	// A few requests log their debug lines whatever the level, so there's
+	// detail to go on without the volume of logging every request's.
+	if sample := cfg.Log.Sample; sample.Rate > 0 || sample.Token != "" {
+		r.Use(middleware.Sample(middleware.SampleOptions{Rate: sample.Rate, Token: sample.Token}))
+	}
+
+	// The access log goes to its own file, rotated as configured, while the
+	// application's logs stay on stderr.
+	if access := cfg.Log.Access; access.Path != "" {
+		f, err := logfile.Open(logfile.Options{
+			Path:       access.Path,
+			MaxSize:    int64(access.MaxSizeMB) << 20,
+			Every:      time.Duration(access.RotateEvery),
+			MaxBackups: access.MaxBackups,
+			Compress:   access.Compress,
+		})
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		format := middleware.LogText
+		if access.Format == "json" {
+			format = middleware.LogJSON
+		}
+		r.Use(middleware.LoggerWith(middleware.LoggerOptions{Format: format, Output: f, SkipPaths: []string{"/health"}}))
+	}
+
+	// Components register their health checks here as they're set up;
+	// the admin server's /readyz runs them.
+	checks := health.New(health.Options{})
+	if db != nil {
+		checks.Register("db", health.PingCheck(db))
+	}
+
+	// Maintenance mode is switched on the admin server; the health check
+	// keeps answering meanwhile.
+	maintenance := middleware.NewMaintenance(middleware.MaintenanceOptions{Exempt: []string{"/health"}})
+	r.Use(maintenance.Middleware())
+
+	// With logins, only admins may create and delete users. The check runs
+	// innermost, so refused requests are still logged and counted, and
+	// maintenance mode covers them too.
+	if tokens != nil {
+		r.Use(handlers.Authenticate(users, tokens, cfg.Auth.AdminIDs), middleware.RBAC(middleware.RBACOptions{Roles: handlers.UserRoles}))
+	}
+
+	// Without an admin server, profiling in production needs the debug
+	// endpoints on the public port, so they take a token there.
+	if cfg.Admin.DebugToken != "" {
+		admin.RegisterDebug(r, "/debug", router.With(middleware.APIKey(middleware.APIKeyOptions{
+			Header: "X-Debug-Token",
+			Lookup: middleware.APIKeys(map[string]string{cfg.Admin.DebugToken: "debug"}),
+		})))
+	}
 
 	// 3. Create a new server instance.
-	// We configure it to listen on port 8080 and use our custom router
-	// to handle all incoming requests.
 	// The server package abstracts away the details of the underlying http.Server.
-	port := ":8080"
-	s := server.New(port, r)
+	// Under systemd socket activation, serve on the sockets systemd passed
+	// instead of -addr; elsewhere this changes nothing.
+	opts := []server.Option{
+		server.WithSystemd(),
+		server.WithConnState(connections),
+		server.WithTimeouts(time.Duration(cfg.Server.ReadTimeout), time.Duration(cfg.Server.WriteTimeout), time.Duration(cfg.Server.IdleTimeout)),
+	}
+	switch {
+	case cfg.TLS.Cert != "":
+		opts = append(opts, server.WithTLS(cfg.TLS.Cert, cfg.TLS.Key))
+	case len(cfg.ACME.Hosts) > 0:
+		m, err := acme.NewManager(acme.Config{
+			Hosts: cfg.ACME.Hosts,
+			Email: cfg.ACME.Email,
+			Cache: acme.DirCache(cfg.ACME.Cache),
+		})
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithAutoCert(m))
+	}
+	if cfg.HTTPS() {
+		policy, err := server.ParseTLSPolicy(cfg.TLS.Policy)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithTLSPolicy(policy))
+	}
+	if cfg.TLS.RedirectAddr != "" {
+		opts = append(opts, server.WithHTTPRedirect(cfg.TLS.RedirectAddr))
+	}
+	if cfg.Server.BindRetry > 0 {
+		opts = append(opts, server.WithBindRetry(server.BindRetryOptions{For: time.Duration(cfg.Server.BindRetry)}))
+	}
+	if cfg.Server.MaxConnections > 0 {
+		opts = append(opts, server.WithConnLimit(server.ConnLimitOptions{Max: cfg.Server.MaxConnections}))
+	}
+	var a *router.Router
+	if cfg.Admin.Addr != "" {
+		a = admin.New(admin.Options{Routes: r, Maintenance: maintenance, Health: checks})
+		opts = append(opts, server.WithAdmin(cfg.Admin.Addr, a))
+	}
+	if cfg.Server.H2C {
+		opts = append(opts, server.WithH2C())
+	}
+	s := server.New(cfg.Server.Addr, r, opts...)
+	if a != nil {
+		admin.RegisterDrain(a, checks, s)
+	}
 
+	return serve(ctx, s, cfg.Server.Addr, time.Duration(cfg.Server.ShutdownTimeout))
+}
+
+// serve runs s until ctx is canceled, then shuts it down, giving in-flight
+// requests up to drainTimeout to finish.
+func serve(ctx context.Context, s *server.Server, addr string, drainTimeout time.Duration) error {
 	// 4. Start the server.
-	// We run this in a goroutine so it doesn't block the main thread.
-	// This allows us to listen for shutdown signals gracefully.
+	// We run this in a goroutine so it doesn't block: we still have to
+	// wait for the shutdown signal.
+	// With port 0 in -addr the system picks the port, so say which.
+	s.OnStart(func(context.Context) error {
+		logger.Info("Listening", "addr", s.Addr())
+		return nil
+	})
+	errc := make(chan error, 1)
 	go func() {
-		log.Printf("Server starting on port %s...", port)
-		if err := s.Start(); err != nil {
-			// If the server fails to start (e.g., port is already in use),
-			// log the error and exit.
-			log.Fatalf("Server failed to start: %v", err)
-		}
+		logger.Info("Server starting...", "addr", addr)
+		errc <- s.Start()
 	}()
 
-	// 5. Graceful Shutdown
-	// The code below waits for a shutdown signal (like Ctrl+C).
-	// Currently, our server doesn't have a Stop method, but this is where
-	// you would call it. For now, we just block and wait.
-	// In a real-world application, you would use a channel to listen for
-	// signals like syscall.SIGINT and syscall.SIGTERM.
-	log.Println("Application started. Press Ctrl+C to exit.")
-	quit := make(chan os.Signal, 1)
-	// In a complete implementation, you'd use signal.Notify(quit, os.Interrupt)
-	// and then call a s.Stop() method when a signal is received.
-	<-quit // Block until a signal is received.
-	log.Println("Shutting down server...")
-
-	// Here you would call s.Stop() to gracefully shut down the server.
-	// more changes are required
+	// 5. Wait for either a shutdown signal or the server failing on its own
+	// (e.g. the port is already in use).
+	// On SIGUSR2 a new copy of the binary takes over the listening socket
+	// (see server.Upgrade), and this process then drains like on SIGTERM.
+	logger.Info("Application started. Press Ctrl+C to exit.")
+	upgrade := make(chan os.Signal, 1)
+	if len(upgradeSignals) > 0 {
+		signal.Notify(upgrade, upgradeSignals...)
+		defer signal.Stop(upgrade)
+	}
+wait:
+	for {
+		select {
+		case err := <-errc:
+			if err == nil {
+				err = errors.New("server stopped unexpectedly")
+			}
+			return fmt.Errorf("serving on %s: %w", addr, err)
+		case <-ctx.Done():
+			break wait
+		case <-upgrade:
+			logger.Info("Starting a new process to take over the listening sockets...")
+			upgradeCtx, cancel := context.WithTimeout(ctx, upgradeTimeout)
+			proc, err := s.Upgrade(upgradeCtx)
+			cancel()
+			if err != nil {
+				logger.Error("Upgrade failed, carrying on", "err", err)
+				continue
+			}
+			logger.Info("New process is serving", "pid", proc.Pid)
+			break wait
+		}
+	}
+
+	// 6. Graceful shutdown.
+	// Stop closes the listener right away, so no new requests come in, and
+	// then waits for the active ones. Requests still running at the deadline
+	// are cut off, and that's reported as an error.
+	logger.Info("Shutting down server, waiting for in-flight requests...", "timeout", drainTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := s.Stop(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	return <-errc
 }
@@ -0,0 +1,369 @@
+// Description: This file contains integration tests for starting and shutting
+// down the server.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/server"
+)
+
+// freeAddr returns a local address nothing is listening on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitUp polls url until the server answers.
+func waitUp(t *testing.T, url string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			return
+		}
+	}
+	t.Fatalf("server at %s didn't come up", url)
+}
+
+// TestRun tests serving the application routes and a clean shutdown.
+func TestRun(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-addr", addr, "-shutdown-timeout", "2s"}) }()
+	waitUp(t, "http://"+addr+"/health")
+
+	// 2. Execute: "send the signal".
+	cancel()
+
+	// 3. Assert
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run didn't return after the shutdown signal")
+	}
+	if _, err := http.Get("http://" + addr + "/health"); err == nil {
+		t.Error("server still answering after shutdown")
+	}
+}
+
+// TestServe_Drain tests that shutdown waits for in-flight requests, up to the
+// drain timeout.
+func TestServe_Drain(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		wantErr      bool
+	}{
+		{name: "request finishes in time", drainTimeout: 5 * time.Second},
+		{name: "drain timeout exceeded", drainTimeout: 50 * time.Millisecond, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup: /slow runs until released.
+			addr := freeAddr(t)
+			started, release := make(chan struct{}), make(chan struct{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("/up", func(w http.ResponseWriter, r *http.Request) {})
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+				io.WriteString(w, "done")
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- serve(ctx, server.New(addr, mux), addr, tc.drainTimeout) }()
+			waitUp(t, "http://"+addr+"/up")
+
+			body := make(chan string, 1)
+			go func() {
+				resp, err := http.Get("http://" + addr + "/slow")
+				if err != nil {
+					body <- err.Error()
+					return
+				}
+				defer resp.Body.Close()
+				b, _ := io.ReadAll(resp.Body)
+				body <- string(b)
+			}()
+			<-started
+
+			// 2. Execute: Shut down while /slow is running.
+			cancel()
+			if !tc.wantErr {
+				time.Sleep(50 * time.Millisecond)
+				close(release)
+			}
+			err := <-done
+			if tc.wantErr {
+				close(release)
+			}
+
+			// 3. Assert
+			if tc.wantErr {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("expected a drain timeout, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected a clean shutdown, got %v", err)
+			}
+			if got := <-body; got != "done" {
+				t.Errorf("in-flight request got %q, want \"done\"", got)
+			}
+		})
+	}
+}
+
+// TestRun_Usage tests the exit paths for bad, help, and version flags.
+func TestRun_Usage(t *testing.T) {
+	if err := run(context.Background(), []string{"-bogus"}); !errors.Is(err, errUsage) {
+		t.Errorf("expected errUsage, got %v", err)
+	}
+	if err := run(context.Background(), []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("expected flag.ErrHelp, got %v", err)
+	}
+	if err := run(context.Background(), []string{"-version"}); err != nil {
+		t.Errorf("expected -version to exit cleanly, got %v", err)
+	}
+}
+
+// TestRun_Config tests taking the settings from a file, with flags winning.
+func TestRun_Config(t *testing.T) {
+	// 1. Setup: The file picks the address; a flag shortens the drain.
+	addr := freeAddr(t)
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  addr: \""+addr+"\"\n  shutdown_timeout: 1m\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-config", path, "-shutdown-timeout", "2s"}) }()
+
+	// 2. Execute
+	waitUp(t, "http://"+addr+"/health")
+	cancel()
+
+	// 3. Assert
+	if err := <-done; err != nil {
+		t.Fatalf("expected a clean shutdown, got %v", err)
+	}
+
+	// Invalid settings are a usage error, wherever they come from.
+	t.Setenv("HTTPGOLANG_LOG_LEVEL", "loud")
+	if err := run(context.Background(), []string{"-config", path}); !errors.Is(err, errUsage) {
+		t.Errorf("expected errUsage, got %v", err)
+	}
+}
+
+// TestRun_Admin tests the admin server and switching maintenance mode on it.
+func TestRun_Admin(t *testing.T) {
+	// 1. Setup
+	addr, adminAddr := freeAddr(t), freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-addr", addr, "-admin-addr", adminAddr}) }()
+	waitUp(t, "http://"+adminAddr+"/healthz")
+	if resp, err := http.Get("http://" + addr + "/healthz"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("admin endpoint reachable on the public port: %d", resp.StatusCode)
+		}
+	}
+
+	// 2. Execute
+	req, _ := http.NewRequest(http.MethodPut, "http://"+adminAddr+"/maintenance?enabled=true", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodPut, "http://"+adminAddr+"/drain?enabled=true", nil)
+	drain, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drain.Body.Close()
+	ready, err := http.Get("http://" + adminAddr + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready.Body.Close()
+
+	// 3. Assert: The API is down for maintenance, the health check isn't,
+	// and readiness fails while draining.
+	if drain.StatusCode != http.StatusOK || ready.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected draining to fail readiness, got %d and %d", drain.StatusCode, ready.StatusCode)
+	}
+	for path, want := range map[string]int{"/users": http.StatusServiceUnavailable, "/health": http.StatusOK} {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: got %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+	metrics, err := http.Get("http://" + adminAddr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metrics.Body.Close()
+	var vars struct {
+		Requests middleware.RequestStatsSnapshot
+	}
+	if err := json.NewDecoder(metrics.Body).Decode(&vars); err != nil || vars.Requests.ServerErrors == 0 || vars.Requests.Routes["GET /health"] == 0 {
+		t.Errorf("expected the requests in /metrics, got %+v (%v)", vars.Requests, err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
+
+// TestRun_AccessLog tests writing the access log to a file.
+func TestRun_AccessLog(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	path := filepath.Join(t.TempDir(), "access.log")
+	t.Setenv("HTTPGOLANG_LOG_ACCESS_PATH", path)
+	t.Setenv("HTTPGOLANG_LOG_ACCESS_FORMAT", "json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-addr", addr}) }()
+	waitUp(t, "http://"+addr+"/health")
+
+	// 2. Execute
+	resp, err := http.Get("http://" + addr + "/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected a clean shutdown, got %v", err)
+	}
+
+	// 3. Assert: The request is logged; the health checks aren't.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"path":"/users"`) || strings.Contains(string(data), "/health") {
+		t.Errorf("unexpected access log:\n%s", data)
+	}
+}
+
+// TestRun_AuthLogged tests that requests refused for want of a login still
+// reach the access log.
+func TestRun_AuthLogged(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	path := filepath.Join(t.TempDir(), "access.log")
+	t.Setenv("HTTPGOLANG_LOG_ACCESS_PATH", path)
+	t.Setenv("HTTPGOLANG_LOG_ACCESS_FORMAT", "json")
+	t.Setenv("HTTPGOLANG_AUTH_SECRET", strings.Repeat("s", 32))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-addr", addr}) }()
+	waitUp(t, "http://"+addr+"/health")
+
+	// 2. Execute
+	resp, err := http.Post("http://"+addr+"/users", "application/json", strings.NewReader(`{"name":"Cy"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected a clean shutdown, got %v", err)
+	}
+
+	// 3. Assert: The 401 is logged.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || !strings.Contains(string(data), `"status":401`) {
+		t.Errorf("expected a logged 401, got %d and access log:\n%s", resp.StatusCode, data)
+	}
+}
+
+// TestRun_DebugToken tests the debug endpoints on the public port.
+func TestRun_DebugToken(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	t.Setenv("HTTPGOLANG_ADMIN_DEBUG_TOKEN", "0123456789abcdef")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, []string{"-addr", addr}) }()
+	waitUp(t, "http://"+addr+"/health")
+
+	// 2. Execute
+	anonymous, err := http.Get("http://" + addr + "/debug/runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymous.Body.Close()
+	req, _ := http.NewRequest("GET", "http://"+addr+"/debug/runtime", nil)
+	req.Header.Set("X-Debug-Token", "0123456789abcdef")
+	authorized, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized.Body.Close()
+
+	// 3. Assert
+	if anonymous.StatusCode != http.StatusUnauthorized || authorized.StatusCode != http.StatusOK {
+		t.Errorf("got %d without and %d with the token", anonymous.StatusCode, authorized.StatusCode)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
+
+// TestServe_StartFailure tests that a server failing to start is reported.
+func TestServe_StartFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	err = serve(context.Background(), server.New(addr, http.NotFoundHandler()), addr, time.Second)
+	if err == nil {
+		t.Fatal("expected an error for an address in use")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+// Description: This file stands in for the restart signal on systems without
+// SIGUSR2.
+
+package main
+
+import "os"
+
+// upgradeSignals is empty: restarts with socket handoff need Unix.
+var upgradeSignals []os.Signal
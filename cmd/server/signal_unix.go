@@ -0,0 +1,14 @@
+//go:build unix
+
+// Description: This file lists the signals asking for a zero-downtime restart
+// on Unix systems.
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals make serve hand the listening sockets to a new process.
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,420 @@
+// Description: This package obtains and renews TLS certificates automatically
+// from an ACME certificate authority such as Let's Encrypt (RFC 8555), so the
+// server can terminate HTTPS without a separate proxy or manual certificate
+// handling.
+//
+// A Manager hands out certificates during TLS handshakes. The first handshake
+// for a host name orders its certificate, proving control over the name with
+// the http-01 challenge: the CA fetches a token from
+// http://<host>/.well-known/acme-challenge/, which HTTPHandler answers, so
+// port 80 must reach the server. Certificates and the account key are cached
+// (e.g. on disk with DirCache) so restarts don't order new ones, and are
+// renewed in the background well before they expire.
+//
+//	m, err := acme.NewManager(acme.Config{
+//		Hosts: []string{"api.example.com"},
+//		Email: "ops@example.com",
+//		Cache: acme.DirCache("/var/lib/myapp/certs"),
+//	})
+//	s := server.New(":443", r, server.WithAutoCert(m), server.WithHTTPRedirect(":80"))
+
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// LetsEncryptURL is the directory of Let's Encrypt's production CA.
+// LetsEncryptStagingURL has far higher rate limits but issues certificates
+// browsers don't trust; use it while setting things up.
+const (
+	LetsEncryptURL        = "https://acme-v02.api.letsencrypt.org/directory"
+	LetsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+)
+
+// challengePath is where the CA fetches http-01 tokens.
+const challengePath = "/.well-known/acme-challenge/"
+
+// accountKeyName is the cache entry of the account key.
+const accountKeyName = "acme_account.key"
+
+// Config configures a Manager.
+type Config struct {
+	// Hosts lists the host names certificates are obtained for. Handshakes
+	// for other names fail, so nobody can make the server order
+	// certificates for arbitrary names pointed at it.
+	Hosts []string
+
+	// Email is given to the CA as the account's contact, for expiry
+	// warnings and policy notices. Optional.
+	Email string
+
+	// Cache stores the account key and the certificates. Without one,
+	// every restart orders new certificates, which quickly runs into the
+	// CA's rate limits.
+	Cache Cache
+
+	// DirectoryURL is the CA's directory. The default is LetsEncryptURL.
+	DirectoryURL string
+
+	// RenewBefore is how long before expiry a certificate is renewed. The
+	// default is 30 days.
+	RenewBefore time.Duration
+
+	// HTTPClient talks to the CA. The default is http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Manager obtains, caches, and renews certificates. It's safe for concurrent
+// use.
+type Manager struct {
+	cfg          Config
+	now          func() time.Time
+	pollInterval time.Duration
+
+	mu       sync.Mutex
+	client   *client
+	certs    map[string]*tls.Certificate
+	inflight map[string]*certCall
+	renewing map[string]bool
+	tokens   map[string]string
+}
+
+// certCall is a certificate being obtained, which concurrent handshakes for
+// the same host wait for.
+type certCall struct {
+	done chan struct{}
+	cert *tls.Certificate
+	err  error
+}
+
+// NewManager creates a Manager.
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, errors.New("acme: Config.Hosts is required")
+	}
+	hosts := make([]string, len(cfg.Hosts))
+	for i, h := range cfg.Hosts {
+		hosts[i] = normalizeHost(h)
+	}
+	cfg.Hosts = hosts
+	if cfg.DirectoryURL == "" {
+		cfg.DirectoryURL = LetsEncryptURL
+	}
+	if cfg.RenewBefore <= 0 {
+		cfg.RenewBefore = 30 * 24 * time.Hour
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &Manager{
+		cfg:          cfg,
+		now:          time.Now,
+		pollInterval: time.Second,
+		certs:        make(map[string]*tls.Certificate),
+		inflight:     make(map[string]*certCall),
+		renewing:     make(map[string]bool),
+		tokens:       make(map[string]string),
+	}, nil
+}
+
+// normalizeHost lowercases a host name and drops a trailing dot.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// TLSConfig returns a TLS configuration serving the Manager's certificates.
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := normalizeHost(hello.ServerName)
+	if host == "" {
+		return nil, errors.New("acme: client sent no server name (SNI)")
+	}
+	if !slices.Contains(m.cfg.Hosts, host) {
+		return nil, fmt.Errorf("acme: host %q not configured", host)
+	}
+	ctx := hello.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return m.certificate(ctx, host)
+}
+
+// certificate returns a usable certificate for host: from memory, from the
+// cache, or newly obtained. One that's due for renewal is still returned while
+// a new one is obtained in the background.
+func (m *Manager) certificate(ctx context.Context, host string) (*tls.Certificate, error) {
+	m.mu.Lock()
+	cert := m.certs[host]
+	m.mu.Unlock()
+	if cert == nil {
+		cert = m.loadCached(ctx, host)
+	}
+	if cert != nil && m.now().Before(cert.Leaf.NotAfter) {
+		if m.now().After(cert.Leaf.NotAfter.Add(-m.cfg.RenewBefore)) {
+			m.renewInBackground(host)
+		}
+		return cert, nil
+	}
+
+	call := m.obtainOnce(host)
+	select {
+	case <-call.done:
+		return call.cert, call.err
+	case <-ctx.Done():
+		// This handshake gives up; the order carries on for the next one.
+		return nil, ctx.Err()
+	}
+}
+
+// loadCached loads host's certificate from the cache into memory.
+func (m *Manager) loadCached(ctx context.Context, host string) *tls.Certificate {
+	if m.cfg.Cache == nil {
+		return nil
+	}
+	data, err := m.cfg.Cache.Get(ctx, host)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			logger.Warn("Reading cached certificate failed", "host", host, "err", err)
+		}
+		return nil
+	}
+	cert, err := parseCertPEM(data)
+	if err != nil {
+		logger.Warn("Ignoring cached certificate", "host", host, "err", err)
+		return nil
+	}
+	m.mu.Lock()
+	m.certs[host] = cert
+	m.mu.Unlock()
+	return cert
+}
+
+// renewInBackground starts renewing host's certificate, unless that's already
+// happening.
+func (m *Manager) renewInBackground(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.renewing[host] {
+		return
+	}
+	m.renewing[host] = true
+	go func() {
+		call := m.obtainOnce(host)
+		<-call.done
+		if call.err != nil {
+			logger.Error("Renewing certificate failed", "host", host, "err", call.err)
+		}
+		m.mu.Lock()
+		delete(m.renewing, host)
+		m.mu.Unlock()
+	}()
+}
+
+// obtainOnce starts obtaining host's certificate, or joins the attempt that's
+// already running.
+func (m *Manager) obtainOnce(host string) *certCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if call, ok := m.inflight[host]; ok {
+		return call
+	}
+	call := &certCall{done: make(chan struct{})}
+	m.inflight[host] = call
+	go func() {
+		// Not tied to any one handshake: the order is worth finishing.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		call.cert, call.err = m.obtain(ctx, host)
+		m.mu.Lock()
+		delete(m.inflight, host)
+		if call.err == nil {
+			m.certs[host] = call.cert
+		}
+		m.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// obtain orders a new certificate for host and caches it.
+func (m *Manager) obtain(ctx context.Context, host string) (*tls.Certificate, error) {
+	c, err := m.acmeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.register(ctx, m.cfg.Email); err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := c.obtain(ctx, host, key, m.presentToken, m.removeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := certPEM(key, chain)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseCertPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if m.cfg.Cache != nil {
+		if err := m.cfg.Cache.Put(ctx, host, data); err != nil {
+			logger.Warn("Caching certificate failed", "host", host, "err", err)
+		}
+	}
+	logger.Info("Obtained certificate", "host", host, "valid_until", cert.Leaf.NotAfter.Format(time.RFC3339))
+	return cert, nil
+}
+
+// acmeClient returns the client, loading or creating the account key first.
+func (m *Manager) acmeClient(ctx context.Context) (*client, error) {
+	m.mu.Lock()
+	c := m.client
+	m.mu.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	key, err := m.accountKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client == nil {
+		m.client = &client{
+			http:         m.cfg.HTTPClient,
+			directoryURL: m.cfg.DirectoryURL,
+			key:          key,
+			pollInterval: m.pollInterval,
+		}
+	}
+	return m.client, nil
+}
+
+// accountKey loads the account key from the cache, or creates and caches one.
+func (m *Manager) accountKey(ctx context.Context) (*ecdsa.PrivateKey, error) {
+	if m.cfg.Cache != nil {
+		data, err := m.cfg.Cache.Get(ctx, accountKeyName)
+		switch {
+		case err == nil:
+			block, _ := pem.Decode(data)
+			if block == nil {
+				return nil, errors.New("acme: cached account key isn't PEM")
+			}
+			return x509.ParseECPrivateKey(block.Bytes)
+		case !errors.Is(err, ErrCacheMiss):
+			return nil, fmt.Errorf("acme: reading account key: %w", err)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if m.cfg.Cache != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		if err := m.cfg.Cache.Put(ctx, accountKeyName, data); err != nil {
+			return nil, fmt.Errorf("acme: caching account key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// presentToken makes HTTPHandler answer a challenge.
+func (m *Manager) presentToken(token, keyAuth string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[token] = keyAuth
+}
+
+// removeToken stops answering a challenge.
+func (m *Manager) removeToken(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, token)
+}
+
+// HTTPHandler answers http-01 challenges and passes every other request to
+// fallback, such as a redirect to HTTPS. A nil fallback answers 404.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	if fallback == nil {
+		fallback = http.NotFoundHandler()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.URL.Path, challengePath)
+		if !ok {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		m.mu.Lock()
+		keyAuth, found := m.tokens[token]
+		m.mu.Unlock()
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(keyAuth))
+	})
+}
+
+// certPEM encodes a private key and its chain for the cache.
+func certPEM(key *ecdsa.PrivateKey, chain [][]byte) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	for _, c := range chain {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c})...)
+	}
+	return data, nil
+}
+
+// parseCertPEM decodes what certPEM encoded, with the leaf parsed.
+func parseCertPEM(data []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &cert, nil
+}
@@ -0,0 +1,467 @@
+// Description: This file contains tests for the certificate manager, run
+// against a small fake ACME server that checks the signatures, nonces, and
+// http-01 challenge responses just like a real CA would.
+
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCA is a minimal ACME server issuing certificates from a test CA.
+type fakeCA struct {
+	t        *testing.T
+	srv      *httptest.Server
+	caKey    *ecdsa.PrivateKey
+	caCert   *x509.Certificate
+	validity time.Duration
+
+	// challengeURL is where the http-01 tokens are fetched from.
+	challengeURL string
+
+	mu         sync.Mutex
+	nonce      int
+	nonces     map[string]bool
+	badNonce   bool // reject the next nonce once
+	accounts   map[string]*ecdsa.PublicKey
+	orders     []*fakeOrder
+	issued     int
+	registered int
+}
+
+// fakeOrder is an order and its single authorization.
+type fakeOrder struct {
+	host   string
+	token  string
+	authz  string
+	status string
+	kid    string
+	cert   []byte
+}
+
+// newFakeCA starts a fake ACME server.
+func newFakeCA(t *testing.T, validity time.Duration) *fakeCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(der)
+	ca := &fakeCA{
+		t: t, caKey: key, caCert: caCert, validity: validity,
+		nonces:   make(map[string]bool),
+		accounts: make(map[string]*ecdsa.PublicKey),
+	}
+	ca.srv = httptest.NewServer(http.HandlerFunc(ca.serve))
+	t.Cleanup(ca.srv.Close)
+	return ca
+}
+
+// issuedCount returns how many certificates were issued.
+func (ca *fakeCA) issuedCount() int {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	return ca.issued
+}
+
+// newNonce hands out a fresh nonce. ca.mu must be held.
+func (ca *fakeCA) newNonce() string {
+	ca.nonce++
+	n := fmt.Sprintf("nonce-%d", ca.nonce)
+	ca.nonces[n] = true
+	return n
+}
+
+// problem sends an ACME error document.
+func problem(w http.ResponseWriter, status int, typ, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"type": "urn:ietf:params:acme:error:" + typ, "detail": detail, "status": status})
+}
+
+// serve handles every ACME endpoint.
+func (ca *fakeCA) serve(w http.ResponseWriter, r *http.Request) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	w.Header().Set("Replay-Nonce", ca.newNonce())
+	base := ca.srv.URL
+
+	if r.URL.Path == "/dir" {
+		json.NewEncoder(w).Encode(map[string]string{
+			"newNonce": base + "/new-nonce", "newAccount": base + "/new-account", "newOrder": base + "/new-order",
+		})
+		return
+	}
+	if r.URL.Path == "/new-nonce" {
+		return
+	}
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/jose+json" {
+		problem(w, http.StatusMethodNotAllowed, "malformed", "POST with JWS expected")
+		return
+	}
+	kid, pub, payload, ok := ca.verify(w, r)
+	if !ok {
+		return
+	}
+
+	id := -1
+	if i := strings.LastIndex(r.URL.Path, "/"); i > 0 {
+		fmt.Sscanf(r.URL.Path[i+1:], "%d", &id)
+	}
+	var o *fakeOrder
+	if id >= 0 && id < len(ca.orders) {
+		o = ca.orders[id]
+	}
+
+	switch {
+	case r.URL.Path == "/new-account":
+		thumb, _ := thumbprint(pub)
+		if _, exists := ca.accounts[base+"/acct/"+thumb]; !exists {
+			ca.registered++
+			ca.accounts[base+"/acct/"+thumb] = pub
+			w.Header().Set("Location", base+"/acct/"+thumb)
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.Header().Set("Location", base+"/acct/"+thumb)
+		}
+		io.WriteString(w, `{"status":"valid"}`)
+
+	case r.URL.Path == "/new-order":
+		var req struct {
+			Identifiers []struct{ Type, Value string }
+		}
+		json.Unmarshal(payload, &req)
+		o := &fakeOrder{host: req.Identifiers[0].Value, token: fmt.Sprintf("token%d", len(ca.orders)), authz: "pending", status: "pending", kid: kid}
+		ca.orders = append(ca.orders, o)
+		w.Header().Set("Location", fmt.Sprintf("%s/order/%d", base, len(ca.orders)-1))
+		w.WriteHeader(http.StatusCreated)
+		ca.writeOrder(w, len(ca.orders)-1)
+
+	case o != nil && strings.HasPrefix(r.URL.Path, "/authz/"):
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": o.authz,
+			"challenges": []map[string]string{
+				{"type": "dns-01", "url": base + "/unused", "token": "x"},
+				{"type": "http-01", "url": fmt.Sprintf("%s/chal/%d", base, id), "token": o.token},
+			},
+		})
+
+	case o != nil && strings.HasPrefix(r.URL.Path, "/chal/"):
+		// Check the response like a CA: fetch it over plain HTTP.
+		resp, err := http.Get(ca.challengeURL + challengePath + o.token)
+		var got []byte
+		if err == nil {
+			got, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		thumb, _ := thumbprint(ca.accounts[kid])
+		if string(got) == o.token+"."+thumb {
+			o.authz = "valid"
+			o.status = "ready"
+		} else {
+			o.authz, o.status = "invalid", "invalid"
+		}
+		io.WriteString(w, `{"status":"processing"}`)
+
+	case o != nil && strings.HasPrefix(r.URL.Path, "/finalize/"):
+		if o.status != "ready" {
+			problem(w, http.StatusForbidden, "orderNotReady", "order not ready")
+			return
+		}
+		var req struct{ CSR string }
+		json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil || csr.CheckSignature() != nil || len(csr.DNSNames) != 1 || csr.DNSNames[0] != o.host {
+			problem(w, http.StatusBadRequest, "badCSR", "bad CSR")
+			return
+		}
+		ca.issued++
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(ca.issued + 1)),
+			Subject:      pkix.Name{CommonName: o.host},
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(ca.validity),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+		}
+		o.cert, _ = x509.CreateCertificate(rand.Reader, tmpl, ca.caCert, csr.PublicKey, ca.caKey)
+		// Make the client poll once before the order is valid.
+		o.status = "processing"
+		ca.writeOrder(w, id)
+		o.status = "valid"
+
+	case o != nil && strings.HasPrefix(r.URL.Path, "/order/"):
+		ca.writeOrder(w, id)
+
+	case o != nil && strings.HasPrefix(r.URL.Path, "/cert/"):
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: o.cert})
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: ca.caCert.Raw})
+
+	default:
+		problem(w, http.StatusNotFound, "malformed", "no such resource")
+	}
+}
+
+// writeOrder sends an order object.
+func (ca *fakeCA) writeOrder(w http.ResponseWriter, id int) {
+	o := ca.orders[id]
+	body := map[string]interface{}{
+		"status":         o.status,
+		"authorizations": []string{fmt.Sprintf("%s/authz/%d", ca.srv.URL, id)},
+		"finalize":       fmt.Sprintf("%s/finalize/%d", ca.srv.URL, id),
+	}
+	if o.status == "valid" {
+		body["certificate"] = fmt.Sprintf("%s/cert/%d", ca.srv.URL, id)
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+// verify checks a request's JWS: its nonce, URL, and signature. It returns
+// the account URL (empty for requests signed with a jwk), the key, and the
+// payload.
+func (ca *fakeCA) verify(w http.ResponseWriter, r *http.Request) (string, *ecdsa.PublicKey, []byte, bool) {
+	var jws struct{ Protected, Payload, Signature string }
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		problem(w, http.StatusBadRequest, "malformed", err.Error())
+		return "", nil, nil, false
+	}
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(jws.Protected)
+	var header struct {
+		Alg, Nonce, URL, Kid string
+		JWK                  *struct{ Crv, Kty, X, Y string }
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "ES256" {
+		problem(w, http.StatusBadRequest, "malformed", "bad protected header")
+		return "", nil, nil, false
+	}
+	if !ca.nonces[header.Nonce] || ca.badNonce {
+		ca.badNonce = false
+		problem(w, http.StatusBadRequest, "badNonce", "stale nonce")
+		return "", nil, nil, false
+	}
+	delete(ca.nonces, header.Nonce)
+	if header.URL != ca.srv.URL+r.URL.Path {
+		problem(w, http.StatusUnauthorized, "unauthorized", "url mismatch")
+		return "", nil, nil, false
+	}
+
+	var pub *ecdsa.PublicKey
+	switch {
+	case header.JWK != nil && r.URL.Path == "/new-account":
+		x, _ := base64.RawURLEncoding.DecodeString(header.JWK.X)
+		y, _ := base64.RawURLEncoding.DecodeString(header.JWK.Y)
+		pub = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	case header.Kid != "" && ca.accounts[header.Kid] != nil:
+		pub = ca.accounts[header.Kid]
+	default:
+		problem(w, http.StatusUnauthorized, "accountDoesNotExist", "unknown account")
+		return "", nil, nil, false
+	}
+
+	sig, _ := base64.RawURLEncoding.DecodeString(jws.Signature)
+	hash := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	if len(sig) != 64 || !ecdsa.Verify(pub, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		problem(w, http.StatusUnauthorized, "unauthorized", "bad signature")
+		return "", nil, nil, false
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+	return header.Kid, pub, payload, true
+}
+
+// newTestManager creates a Manager using ca, with its challenge responder
+// served where ca looks for it.
+func newTestManager(t *testing.T, ca *fakeCA, cache Cache) *Manager {
+	t.Helper()
+	m, err := NewManager(Config{
+		Hosts:        []string{"Example.com."},
+		Email:        "ops@example.com",
+		Cache:        cache,
+		DirectoryURL: ca.srv.URL + "/dir",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.pollInterval = 5 * time.Millisecond
+	challenges := httptest.NewServer(m.HTTPHandler(nil))
+	t.Cleanup(challenges.Close)
+	ca.challengeURL = challenges.URL
+	return m
+}
+
+// TestManager_GetCertificate tests obtaining a certificate, then reusing it
+// from memory and, after a restart, from the cache.
+func TestManager_GetCertificate(t *testing.T) {
+	// 1. Setup: The first nonce is rejected, as happens when a nonce
+	// expires; the client must retry.
+	ca := newFakeCA(t, 90*24*time.Hour)
+	ca.badNonce = true
+	cache := DirCache(t.TempDir())
+	m := newTestManager(t, ca, cache)
+	hello := &tls.ClientHelloInfo{ServerName: "example.com"}
+
+	// 2. Execute
+	cert, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted := newTestManager(t, ca, cache)
+	cached, err := restarted.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Assert
+	if len(cert.Leaf.DNSNames) != 1 || cert.Leaf.DNSNames[0] != "example.com" {
+		t.Errorf("unexpected names %v", cert.Leaf.DNSNames)
+	}
+	if len(cert.Certificate) != 2 {
+		t.Errorf("expected the chain with 2 certificates, got %d", len(cert.Certificate))
+	}
+	if again != cert || ca.issuedCount() != 1 {
+		t.Errorf("expected the certificate to be reused, issued %d", ca.issuedCount())
+	}
+	if !cached.Leaf.Equal(cert.Leaf) {
+		t.Error("expected the cached certificate after a restart")
+	}
+	if ca.registered != 1 {
+		t.Errorf("expected the cached account key to be reused, got %d accounts", ca.registered)
+	}
+}
+
+// TestManager_Renew tests background renewal of a certificate close to expiry.
+func TestManager_Renew(t *testing.T) {
+	// 1. Setup: Certificates are valid for 10 days, renewed 30 days ahead.
+	ca := newFakeCA(t, 10*24*time.Hour)
+	m := newTestManager(t, ca, nil)
+	hello := &tls.ClientHelloInfo{ServerName: "example.com"}
+	first, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Execute: The next handshake still gets the current certificate
+	// and starts the renewal.
+	current, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ca.issuedCount() < 2 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 3. Assert
+	if current != first {
+		t.Error("expected the current certificate while renewing")
+	}
+	if ca.issuedCount() != 2 {
+		t.Fatalf("expected a renewal, issued %d", ca.issuedCount())
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if renewed, _ := m.GetCertificate(hello); renewed != first {
+			return
+		}
+	}
+	t.Error("renewed certificate never served")
+}
+
+// TestManager_RefusedHosts tests handshakes the Manager doesn't serve.
+func TestManager_RefusedHosts(t *testing.T) {
+	ca := newFakeCA(t, time.Hour)
+	m := newTestManager(t, ca, nil)
+	for _, name := range []string{"", "evil.example"} {
+		if _, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: name}); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+	if ca.issuedCount() != 0 {
+		t.Error("no certificate should have been ordered")
+	}
+	if _, err := NewManager(Config{}); err == nil {
+		t.Error("expected an error without hosts")
+	}
+}
+
+// TestManager_HTTPHandler tests challenge responses and the fallback.
+func TestManager_HTTPHandler(t *testing.T) {
+	m, _ := NewManager(Config{Hosts: []string{"example.com"}})
+	m.presentToken("tok", "tok.thumb")
+	h := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{challengePath + "tok", http.StatusOK, "tok.thumb"},
+		{challengePath + "other", http.StatusNotFound, ""},
+		{"/users", http.StatusTeapot, ""},
+	}
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+		if rr.Code != tc.wantStatus || (tc.wantBody != "" && rr.Body.String() != tc.wantBody) {
+			t.Errorf("%s: got %d %q", tc.path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+// TestDirCache tests storing entries and refusing unsafe keys.
+func TestDirCache(t *testing.T) {
+	ctx := context.Background()
+	d := DirCache(t.TempDir() + "/certs")
+	if _, err := d.Get(ctx, "example.com"); err != ErrCacheMiss {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+	if err := d.Put(ctx, "example.com", []byte("pem")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := d.Get(ctx, "example.com"); err != nil || string(data) != "pem" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	for _, key := range []string{"../escape", "a/b", ".hidden", ""} {
+		if err := d.Put(ctx, key, nil); err == nil {
+			t.Errorf("expected %q to be refused", key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Description: This file contains the certificate cache: the Cache interface
+// and DirCache, which keeps one file per entry in a directory only the server's
+// user can read, since the entries include private keys.
+
+package acme
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCacheMiss is returned by Cache.Get for a key that isn't cached.
+var ErrCacheMiss = errors.New("acme: cache miss")
+
+// Cache stores the Manager's account key and certificates. Keys are host
+// names or "acme_account.key"; values are PEM data including private keys,
+// so implementations must keep them confidential.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// DirCache is a Cache storing each entry as a file in the named directory,
+// which is created if needed.
+type DirCache string
+
+// Get implements Cache.
+func (d DirCache) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	return data, err
+}
+
+// Put implements Cache. The file is written next to its final name and then
+// renamed, so a crash can't leave half a certificate behind.
+func (d DirCache) Put(_ context.Context, key string, data []byte) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(string(d), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path returns the file of key, refusing keys that would leave the directory.
+func (d DirCache) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", errors.New("acme: invalid cache key " + key)
+	}
+	return filepath.Join(string(d), key), nil
+}
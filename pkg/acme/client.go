@@ -0,0 +1,374 @@
+// Description: This file contains the ACME protocol client (RFC 8555): the
+// directory, nonces, the account, and the order → authorization → challenge
+// → finalize → certificate sequence for one host name.
+
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// directory lists the endpoints of an ACME server.
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+// Error is an error document returned by the ACME server, such as
+// "urn:ietf:params:acme:error:rateLimited".
+type Error struct {
+	Status int    `json:"status"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("acme: %s (%d): %s", e.Type, e.Status, e.Detail)
+}
+
+// order is an ACME order for a certificate.
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+	Error          *Error   `json:"error"`
+}
+
+// authorization is the server's record of proving control over one name.
+type authorization struct {
+	Status     string      `json:"status"`
+	Challenges []challenge `json:"challenges"`
+}
+
+// challenge is one way of proving control over a name.
+type challenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+	Error  *Error `json:"error"`
+}
+
+// client talks to one ACME server with one account key.
+type client struct {
+	http         *http.Client
+	directoryURL string
+	key          *ecdsa.PrivateKey
+	pollInterval time.Duration
+
+	mu     sync.Mutex
+	dir    *directory
+	kid    string
+	nonces []string
+}
+
+// discover loads the directory, once.
+func (c *client) discover(ctx context.Context) (*directory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dir != nil {
+		return c.dir, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.directoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("acme: loading directory: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("acme: loading directory: %s", resp.Status)
+	}
+	var dir directory
+	if err := json.NewDecoder(resp.Body).Decode(&dir); err != nil {
+		return nil, fmt.Errorf("acme: decoding directory: %w", err)
+	}
+	if dir.NewNonce == "" || dir.NewAccount == "" || dir.NewOrder == "" {
+		return nil, errors.New("acme: incomplete directory")
+	}
+	c.dir = &dir
+	return c.dir, nil
+}
+
+// nonce returns an unused anti-replay nonce, fetching a fresh one if none
+// is left over from earlier responses.
+func (c *client) nonce(ctx context.Context, dir *directory) (string, error) {
+	c.mu.Lock()
+	if n := len(c.nonces); n > 0 {
+		nonce := c.nonces[n-1]
+		c.nonces = c.nonces[:n-1]
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("acme: fetching nonce: %w", err)
+	}
+	resp.Body.Close()
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errors.New("acme: server sent no nonce")
+	}
+	return nonce, nil
+}
+
+// post sends a signed request and decodes a JSON response into out, if
+// non-nil. A nil payload is a POST-as-GET. A stale nonce is retried once,
+// as RFC 8555 section 6.5 expects clients to.
+func (c *client) post(ctx context.Context, url string, payload interface{}, out interface{}) (http.Header, []byte, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	dir, err := c.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nonce(ctx, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.mu.Lock()
+		kid := c.kid
+		c.mu.Unlock()
+		signed, err := signJWS(c.key, kid, nonce, url, body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(signed))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("acme: POST %s: %w", url, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("acme: POST %s: %w", url, err)
+		}
+		if nonce := resp.Header.Get("Replay-Nonce"); nonce != "" {
+			c.mu.Lock()
+			c.nonces = append(c.nonces, nonce)
+			c.mu.Unlock()
+		}
+
+		if resp.StatusCode >= 400 {
+			acmeErr := &Error{Status: resp.StatusCode}
+			if json.Unmarshal(data, acmeErr) != nil || acmeErr.Type == "" {
+				acmeErr.Type, acmeErr.Detail = "unknown", string(data)
+			}
+			if acmeErr.Type == "urn:ietf:params:acme:error:badNonce" && attempt == 0 {
+				continue
+			}
+			return nil, nil, acmeErr
+		}
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				return nil, nil, fmt.Errorf("acme: decoding response of %s: %w", url, err)
+			}
+		}
+		return resp.Header, data, nil
+	}
+}
+
+// register creates the account, or finds the existing one for the key, and
+// remembers its URL for signing later requests.
+func (c *client) register(ctx context.Context, email string) error {
+	c.mu.Lock()
+	registered := c.kid != ""
+	c.mu.Unlock()
+	if registered {
+		return nil
+	}
+	dir, err := c.discover(ctx)
+	if err != nil {
+		return err
+	}
+	account := map[string]interface{}{"termsOfServiceAgreed": true}
+	if email != "" {
+		account["contact"] = []string{"mailto:" + email}
+	}
+	header, _, err := c.post(ctx, dir.NewAccount, account, nil)
+	if err != nil {
+		return fmt.Errorf("acme: registering account: %w", err)
+	}
+	kid := header.Get("Location")
+	if kid == "" {
+		return errors.New("acme: account URL missing")
+	}
+	c.mu.Lock()
+	c.kid = kid
+	c.mu.Unlock()
+	return nil
+}
+
+// obtain gets a certificate for host, answering its http-01 challenge
+// through present. It returns the DER chain, leaf first.
+func (c *client) obtain(ctx context.Context, host string, certKey crypto.Signer, present func(token, keyAuth string), cleanup func(token string)) ([][]byte, error) {
+	dir, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Place the order.
+	var o order
+	header, _, err := c.post(ctx, dir.NewOrder, map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": host}},
+	}, &o)
+	if err != nil {
+		return nil, fmt.Errorf("acme: ordering certificate: %w", err)
+	}
+	orderURL := header.Get("Location")
+
+	// 2. Prove control over the name.
+	for _, authzURL := range o.Authorizations {
+		if err := c.authorize(ctx, authzURL, present, cleanup); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Send the certificate request, then wait for the certificate.
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: host},
+		DNSNames: []string{host},
+	}, certKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := c.post(ctx, o.Finalize, map[string]string{"csr": b64(csr)}, &o); err != nil {
+		return nil, fmt.Errorf("acme: finalizing order: %w", err)
+	}
+	for o.Status != "valid" {
+		if o.Status == "invalid" {
+			return nil, fmt.Errorf("acme: order for %s failed: %v", host, o.Error)
+		}
+		if err := c.wait(ctx, nil); err != nil {
+			return nil, err
+		}
+		if _, _, err := c.post(ctx, orderURL, nil, &o); err != nil {
+			return nil, fmt.Errorf("acme: polling order: %w", err)
+		}
+	}
+
+	// 4. Download the chain.
+	_, data, err := c.post(ctx, o.Certificate, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("acme: downloading certificate: %w", err)
+	}
+	var chain [][]byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("acme: no certificate in the server's response")
+	}
+	return chain, nil
+}
+
+// authorize completes one authorization with its http-01 challenge.
+func (c *client) authorize(ctx context.Context, url string, present func(token, keyAuth string), cleanup func(token string)) error {
+	var authz authorization
+	if _, _, err := c.post(ctx, url, nil, &authz); err != nil {
+		return fmt.Errorf("acme: loading authorization: %w", err)
+	}
+	if authz.Status == "valid" {
+		// Authorizations are reused for a while after they succeeded.
+		return nil
+	}
+
+	var chal *challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == "http-01" {
+			chal = &authz.Challenges[i]
+		}
+	}
+	if chal == nil {
+		return errors.New("acme: server offers no http-01 challenge")
+	}
+	thumb, err := thumbprint(&c.key.PublicKey)
+	if err != nil {
+		return err
+	}
+	present(chal.Token, chal.Token+"."+thumb)
+	defer cleanup(chal.Token)
+
+	// An empty object tells the server we're ready to be checked.
+	if _, _, err := c.post(ctx, chal.URL, struct{}{}, nil); err != nil {
+		return fmt.Errorf("acme: accepting challenge: %w", err)
+	}
+	for {
+		header, _, err := c.post(ctx, url, nil, &authz)
+		if err != nil {
+			return fmt.Errorf("acme: polling authorization: %w", err)
+		}
+		switch authz.Status {
+		case "valid":
+			return nil
+		case "pending", "processing":
+			if err := c.wait(ctx, header); err != nil {
+				return err
+			}
+		default:
+			for _, ch := range authz.Challenges {
+				if ch.Type == "http-01" && ch.Error != nil {
+					return fmt.Errorf("acme: http-01 challenge failed: %w", ch.Error)
+				}
+			}
+			return fmt.Errorf("acme: authorization %s", authz.Status)
+		}
+	}
+}
+
+// wait sleeps before polling again, for as long as the server's Retry-After
+// asks if it's reasonable.
+func (c *client) wait(ctx context.Context, header http.Header) error {
+	d := c.pollInterval
+	if header != nil {
+		if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil && secs > 0 && secs <= 60 {
+			d = time.Duration(secs) * time.Second
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
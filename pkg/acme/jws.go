@@ -0,0 +1,83 @@
+// Description: This file contains the JSON Web Signatures (RFC 7515) that
+// ACME wraps every request in. We only need one algorithm, ES256 with the
+// account's P-256 key, and the flattened JSON serialization.
+
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// b64 is the unpadded base64url encoding used throughout JOSE.
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// jwkJSON returns the public key as a JWK with its members in lexicographic
+// order and no whitespace, which is both a valid "jwk" header and the exact
+// input of the RFC 7638 thumbprint.
+func jwkJSON(pub *ecdsa.PublicKey) (string, error) {
+	ecdhKey, err := pub.ECDH()
+	if err != nil {
+		return "", err
+	}
+	// The uncompressed point is 0x04 || X || Y, 32 bytes each for P-256.
+	point := ecdhKey.Bytes()
+	if len(point) != 65 {
+		return "", fmt.Errorf("acme: account key must be P-256")
+	}
+	return `{"crv":"P-256","kty":"EC","x":"` + b64(point[1:33]) + `","y":"` + b64(point[33:]) + `"}`, nil
+}
+
+// thumbprint returns the RFC 7638 thumbprint of the public key, which ties
+// challenge responses to the account.
+func thumbprint(pub *ecdsa.PublicKey) (string, error) {
+	jwk, err := jwkJSON(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(jwk))
+	return b64(sum[:]), nil
+}
+
+// signJWS signs payload for url. The account is identified by kid once it's
+// registered; before that (and for newAccount) the full public key is sent.
+// A nil payload produces ACME's "POST-as-GET" request with an empty payload.
+func signJWS(key *ecdsa.PrivateKey, kid, nonce, url string, payload []byte) ([]byte, error) {
+	protected := map[string]interface{}{"alg": "ES256", "nonce": nonce, "url": url}
+	if kid != "" {
+		protected["kid"] = kid
+	} else {
+		jwk, err := jwkJSON(&key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		protected["jwk"] = json.RawMessage(jwk)
+	}
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	signingInput := b64(header) + "." + b64(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	// JWS wants the fixed-size r || s, not the ASN.1 encoding Go uses.
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return json.Marshal(map[string]string{
+		"protected": b64(header),
+		"payload":   b64(payload),
+		"signature": b64(sig),
+	})
+}
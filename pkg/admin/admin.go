@@ -0,0 +1,110 @@
+// Description: This package contains the admin handler: the operational
+// endpoints that shouldn't be reachable by the public, served on a separate,
+// internal port with server.WithAdmin:
+//
+//	GET  /healthz           liveness, see the health package
+//	GET  /readyz            readiness, with the result of every check
+//	GET  /metrics           metrics, expvar's JSON by default
+//	GET  /routes            the application's routes
+//	GET  /version           the running build, see the buildinfo package
+//	GET  /maintenance       maintenance mode, also PUT/POST to switch it
+//	GET  /drain             drain mode, also PUT/POST to switch it, see RegisterDrain
+//	GET  /debug/pprof/...   the net/http/pprof profiles
+//	GET  /debug/runtime     goroutine, heap, and GC statistics
+//
+// Wiring it up:
+//
+//	m := middleware.NewMaintenance(middleware.MaintenanceOptions{Exempt: []string{"/health"}})
+//	r.Use(m.Middleware())
+//	a := admin.New(admin.Options{Routes: r, Maintenance: m, Health: checks})
+//	s := server.New(":8080", r, server.WithAdmin("127.0.0.1:9090", a))
+//
+// Importing net/http/pprof also registers its handlers on
+// http.DefaultServeMux, so don't serve DefaultServeMux publicly.
+
+package admin
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/buildinfo"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/health"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// Options configures the admin handler. Endpoints whose option is unset
+// aren't registered, except /metrics and /version.
+type Options struct {
+	// Routes provides the routes listed on /routes, usually the public
+	// *router.Router.
+	Routes interface {
+		Routes() []httpcontext.RouteInfo
+	}
+
+	// Maintenance is the switch served on /maintenance.
+	Maintenance *middleware.Maintenance
+
+	// Health holds the checks behind /healthz and /readyz. Without it,
+	// the application is live and ready as long as it's up.
+	Health *health.Registry
+
+	// Metrics serves /metrics. The default is expvar.Handler().
+	Metrics http.Handler
+
+	// DisablePprof leaves out /debug/pprof and /debug/runtime.
+	DisablePprof bool
+}
+
+// Route is an entry of the /routes listing.
+type Route struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// New returns a router serving the admin endpoints. More can be added to it.
+func New(opts Options) *router.Router {
+	if opts.Health == nil {
+		opts.Health = health.New(health.Options{})
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = expvar.Handler()
+	}
+
+	r := router.New()
+	r.GET("/healthz", opts.Health.LivenessHandler())
+	r.GET("/readyz", opts.Health.ReadinessHandler())
+	r.GET("/metrics", router.WrapH(opts.Metrics))
+	r.GET("/version", func(c *httpcontext.Context) { c.OK(buildinfo.Get()) })
+	if opts.Routes != nil {
+		r.GET("/routes", routesHandler(opts.Routes))
+	}
+	if opts.Maintenance != nil {
+		h := opts.Maintenance.Handler()
+		r.GET("/maintenance", h)
+		r.Handle(http.MethodPut, "/maintenance", h)
+		r.POST("/maintenance", h)
+	}
+	if !opts.DisablePprof {
+		RegisterDebug(r, "/debug")
+	}
+	return r
+}
+
+// routesHandler lists the routes of src.
+func routesHandler(src interface {
+	Routes() []httpcontext.RouteInfo
+}) router.HandlerFunc {
+	return func(c *httpcontext.Context) {
+		infos := src.Routes()
+		routes := make([]Route, len(infos))
+		for i, info := range infos {
+			routes[i] = Route{Method: info.Method, Path: info.Path, Description: info.Description, Tags: info.Tags}
+		}
+		c.OK(routes)
+	}
+}
@@ -0,0 +1,109 @@
+// Description: This file contains tests for the admin endpoints.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/health"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// serve sends a request to h and returns the recorded response.
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serveRequest(h, req)
+}
+
+// serveRequest sends req to h and returns the recorded response.
+func serveRequest(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestNew tests the admin endpoints.
+func TestNew(t *testing.T) {
+	// 1. Setup
+	app := router.New()
+	app.GET("/users", func(c *httpcontext.Context) {}, router.Describe("List users"))
+	m := middleware.NewMaintenance(middleware.MaintenanceOptions{})
+	var notReady error
+	checks := health.New(health.Options{})
+	checks.Register("db", func(ctx context.Context) error { return notReady })
+	a := New(Options{
+		Routes:      app,
+		Maintenance: m,
+		Health:      checks,
+	})
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		setup        func()
+		wantStatus   int
+		wantBody     string
+	}{
+		{name: "liveness", method: "GET", path: "/healthz", wantStatus: 200, wantBody: `{"status":"ok","checks":{}}`},
+		{name: "ready", method: "GET", path: "/readyz", wantStatus: 200, wantBody: `"db":{"status":"ok"`},
+		{name: "not ready", method: "GET", path: "/readyz", setup: func() { notReady = errors.New("database unreachable") },
+			wantStatus: 503, wantBody: "database unreachable"},
+		{name: "metrics", method: "GET", path: "/metrics", wantStatus: 200, wantBody: `"memstats"`},
+		{name: "routes", method: "GET", path: "/routes", wantStatus: 200,
+			wantBody: `[{"method":"GET","path":"/users","description":"List users"}]`},
+		{name: "version", method: "GET", path: "/version", wantStatus: 200, wantBody: `"go_version":"go`},
+		{name: "maintenance on", method: "PUT", path: "/maintenance", body: `{"enabled": true}`, wantStatus: 200, wantBody: `"maintenance":true`},
+		{name: "maintenance state", method: "GET", path: "/maintenance", wantStatus: 200, wantBody: `"maintenance":true`},
+		{name: "pprof index", method: "GET", path: "/debug/pprof/", wantStatus: 200, wantBody: "goroutine"},
+		{name: "pprof profile", method: "GET", path: "/debug/pprof/goroutine?debug=1", wantStatus: 200, wantBody: "goroutine profile"},
+		{name: "pprof cmdline", method: "GET", path: "/debug/pprof/cmdline", wantStatus: 200},
+		{name: "runtime stats", method: "GET", path: "/debug/runtime", wantStatus: 200, wantBody: `"goroutines":`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setup != nil {
+				tc.setup()
+			}
+
+			// 2. Execute
+			rr := serve(a, tc.method, tc.path, tc.body)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+	if !m.Enabled() {
+		t.Error("maintenance mode not switched on")
+	}
+}
+
+// TestNew_Optional tests leaving out the optional endpoints.
+func TestNew_Optional(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]int{"requests": 7})
+	})
+	a := New(Options{Metrics: metrics, DisablePprof: true})
+
+	for _, path := range []string{"/routes", "/maintenance", "/debug/pprof/", "/debug/runtime"} {
+		if rr := serve(a, "GET", path, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want 404", path, rr.Code)
+		}
+	}
+	if rr := serve(a, "GET", "/metrics", ""); !strings.Contains(rr.Body.String(), `"requests":7`) {
+		t.Errorf("custom metrics handler not used: %s", rr.Body)
+	}
+}
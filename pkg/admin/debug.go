@@ -0,0 +1,115 @@
+// Description: This file contains the debugging endpoints: the net/http/pprof
+// profiles and a snapshot of the runtime's statistics. They're on the admin
+// server by default; to reach them on a server without one, register them on
+// the public router behind authentication:
+//
+//	admin.RegisterDebug(r, "/debug", router.With(middleware.APIKey(middleware.APIKeyOptions{
+//		Header: "X-Debug-Token",
+//		Lookup: middleware.APIKeys(map[string]string{token: "debug"}),
+//	})))
+//
+// Profiles reveal a lot about the process, down to its command line, so never
+// register them unprotected on a public port.
+
+package admin
+
+import (
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// RegisterDebug registers the debugging endpoints on r: the profiles under
+// prefix+"/pprof/" and the runtime statistics on prefix+"/runtime". The route
+// options, e.g. router.With(auth), apply to every one of them.
+func RegisterDebug(r *router.Router, prefix string, opts ...router.RouteOption) {
+	pp := prefix + "/pprof"
+	r.GET(pp+"/cmdline", router.WrapF(pprof.Cmdline), opts...)
+	r.GET(pp+"/profile", router.WrapF(pprof.Profile), opts...)
+	r.GET(pp+"/symbol", router.WrapF(pprof.Symbol), opts...)
+	r.POST(pp+"/symbol", router.WrapF(pprof.Symbol), opts...)
+	r.GET(pp+"/trace", router.WrapF(pprof.Trace), opts...)
+	// The index, which links to the profiles relative to itself, and the
+	// named profiles (heap, goroutine, ...).
+	r.GET(pp+"/*name", func(c *httpcontext.Context) {
+		if name := c.Param("name"); name != "" {
+			pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+			return
+		}
+		pprof.Index(c.Writer, c.Request)
+	}, opts...)
+	r.GET(prefix+"/runtime", RuntimeStatsHandler(), opts...)
+}
+
+// RuntimeStats is a snapshot of the Go runtime's statistics. Sizes are in
+// bytes and durations in nanoseconds.
+type RuntimeStats struct {
+	Goroutines int    `json:"goroutines"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"num_cpu"`
+	CgoCalls   int64  `json:"cgo_calls"`
+	GoVersion  string `json:"go_version"`
+
+	Heap struct {
+		Alloc    uint64 `json:"alloc"`    // live objects
+		InUse    uint64 `json:"in_use"`   // spans in use
+		Idle     uint64 `json:"idle"`     // spans waiting for reuse
+		Released uint64 `json:"released"` // returned to the OS
+		Objects  uint64 `json:"objects"`
+	} `json:"heap"`
+
+	// Sys is all the memory obtained from the OS.
+	Sys uint64 `json:"sys"`
+
+	GC struct {
+		Count        uint32    `json:"count"`
+		Forced       uint32    `json:"forced"`
+		Last         time.Time `json:"last"`
+		NextTarget   uint64    `json:"next_target"` // heap size triggering the next GC
+		PauseTotalNs uint64    `json:"pause_total_ns"`
+		RecentPauses []uint64  `json:"recent_pauses_ns"` // newest first
+		CPUFraction  float64   `json:"cpu_fraction"`
+	} `json:"gc"`
+}
+
+// recentPauses is how many GC pauses RuntimeStats reports.
+const recentPauses = 10
+
+// ReadRuntimeStats takes a snapshot of the runtime's statistics. It stops the
+// world very briefly, like runtime.ReadMemStats.
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	var s RuntimeStats
+	s.Goroutines = runtime.NumGoroutine()
+	s.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	s.NumCPU = runtime.NumCPU()
+	s.CgoCalls = runtime.NumCgoCall()
+	s.GoVersion = runtime.Version()
+	s.Heap.Alloc, s.Heap.InUse, s.Heap.Idle = m.HeapAlloc, m.HeapInuse, m.HeapIdle
+	s.Heap.Released, s.Heap.Objects = m.HeapReleased, m.HeapObjects
+	s.Sys = m.Sys
+	s.GC.Count, s.GC.Forced = m.NumGC, m.NumForcedGC
+	if m.LastGC != 0 {
+		s.GC.Last = time.Unix(0, int64(m.LastGC)).UTC()
+	}
+	s.GC.NextTarget, s.GC.PauseTotalNs, s.GC.CPUFraction = m.NextGC, m.PauseTotalNs, m.GCCPUFraction
+	// PauseNs is a circular buffer; the latest pause is at (NumGC+255)%256.
+	s.GC.RecentPauses = []uint64{}
+	for i := uint32(0); i < recentPauses && i < m.NumGC; i++ {
+		s.GC.RecentPauses = append(s.GC.RecentPauses, m.PauseNs[(m.NumGC-1-i)%uint32(len(m.PauseNs))])
+	}
+	return s
+}
+
+// RuntimeStatsHandler serves ReadRuntimeStats.
+func RuntimeStatsHandler() router.HandlerFunc {
+	return func(c *httpcontext.Context) {
+		c.SetHeader("Cache-Control", "no-store")
+		c.OK(ReadRuntimeStats())
+	}
+}
@@ -0,0 +1,68 @@
+// Description: This file contains tests for the debugging endpoints.
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestReadRuntimeStats tests the runtime statistics snapshot.
+func TestReadRuntimeStats(t *testing.T) {
+	// 1. Setup: Make sure there's a GC pause to report.
+	runtime.GC()
+
+	// 2. Execute
+	s := ReadRuntimeStats()
+
+	// 3. Assert
+	if s.Goroutines < 1 || s.GOMAXPROCS < 1 || s.Heap.Alloc == 0 || s.Sys == 0 {
+		t.Errorf("implausible stats: %+v", s)
+	}
+	if s.GC.Count < 1 || s.GC.Forced < 1 || s.GC.Last.IsZero() {
+		t.Errorf("forced GC not counted: %+v", s.GC)
+	}
+	if len(s.GC.RecentPauses) == 0 || len(s.GC.RecentPauses) > recentPauses {
+		t.Errorf("got %d recent pauses", len(s.GC.RecentPauses))
+	}
+}
+
+// TestRegisterDebug tests gating the debugging endpoints with middleware.
+func TestRegisterDebug(t *testing.T) {
+	// 1. Setup
+	r := router.New()
+	RegisterDebug(r, "/internal", router.With(middleware.APIKey(middleware.APIKeyOptions{
+		Header: "X-Debug-Token",
+		Lookup: middleware.APIKeys(map[string]string{"s3cret-debug-token": "debug"}),
+	})))
+
+	for _, path := range []string{"/internal/runtime", "/internal/pprof/", "/internal/pprof/heap", "/internal/pprof/cmdline"} {
+		t.Run(path, func(t *testing.T) {
+			// 2. Execute
+			anonymous := serve(r, "GET", path, "")
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("X-Debug-Token", "s3cret-debug-token")
+			authorized := serveRequest(r, req)
+
+			// 3. Assert
+			if anonymous.Code != http.StatusUnauthorized {
+				t.Errorf("without the token: got %d, want 401", anonymous.Code)
+			}
+			if authorized.Code != http.StatusOK {
+				t.Errorf("with the token: got %d %s", authorized.Code, authorized.Body)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/internal/runtime", nil)
+	req.Header.Set("X-Debug-Token", "s3cret-debug-token")
+	var body struct{ Data RuntimeStats }
+	if err := json.Unmarshal(serveRequest(r, req).Body.Bytes(), &body); err != nil || body.Data.Goroutines == 0 {
+		t.Errorf("unexpected runtime stats response: %+v, %v", body, err)
+	}
+}
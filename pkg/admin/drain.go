@@ -0,0 +1,79 @@
+// Description: This file contains the drain endpoint. Before an instance is
+// stopped, the operator (or the deploy script) switches on drain mode:
+//
+//	admin.RegisterDrain(a, checks, s)
+//
+//	curl -X PUT 'localhost:9090/drain?enabled=true'
+//
+// /readyz then fails, so the load balancer stops sending new connections,
+// while the server finishes the requests it has and closes connections
+// after them. See server.Server.SetDraining.
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/health"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// Drainer is what /drain switches, usually the *server.Server.
+type Drainer interface {
+	SetDraining(on bool)
+	Draining() bool
+}
+
+// errDraining is the readiness check's failure while draining.
+var errDraining = errors.New("draining")
+
+// RegisterDrain serves /drain on r and adds a readiness check named "drain"
+// to checks, failing while d drains. GET reports the state as
+// {"data": {"draining": true}}; PUT and POST set it from the "enabled" query
+// parameter or a JSON body like {"enabled": true}.
+func RegisterDrain(r *router.Router, checks *health.Registry, d Drainer) {
+	checks.Register("drain", func(context.Context) error {
+		if d.Draining() {
+			return errDraining
+		}
+		return nil
+	})
+	h := drainHandler(d)
+	r.GET("/drain", h)
+	r.Handle(http.MethodPut, "/drain", h)
+	r.POST("/drain", h)
+}
+
+// drainHandler reports or sets d's drain mode.
+func drainHandler(d Drainer) router.HandlerFunc {
+	return func(c *httpcontext.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			enabled, err := c.QueryBool("enabled", false)
+			if err != nil {
+				c.Fail(http.StatusBadRequest, err)
+				return
+			}
+			if c.Query("enabled") == "" {
+				var body struct {
+					Enabled *bool `json:"enabled"`
+				}
+				if err := c.BindJSON(&body); err != nil {
+					c.Fail(http.StatusBadRequest, err)
+					return
+				}
+				if body.Enabled == nil {
+					c.Fail(http.StatusBadRequest, &httpcontext.BindError{
+						Status: http.StatusBadRequest, Field: "enabled", Message: `"enabled" is required`,
+					})
+					return
+				}
+				enabled = *body.Enabled
+			}
+			d.SetDraining(enabled)
+		}
+		c.OK(map[string]bool{"draining": d.Draining()})
+	}
+}
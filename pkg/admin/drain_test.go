@@ -0,0 +1,54 @@
+// Description: This file contains tests for the drain endpoint.
+
+package admin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/health"
+)
+
+// drainer is a Drainer recording the switch.
+type drainer struct{ on bool }
+
+func (d *drainer) SetDraining(on bool) { d.on = on }
+func (d *drainer) Draining() bool      { return d.on }
+
+// TestRegisterDrain tests switching drain mode and its effect on readiness.
+func TestRegisterDrain(t *testing.T) {
+	// 1. Setup
+	checks := health.New(health.Options{})
+	a := New(Options{Health: checks})
+	d := &drainer{}
+	RegisterDrain(a, checks, d)
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		wantStatus   int
+		wantBody     string
+	}{
+		{name: "ready", method: "GET", path: "/readyz", wantStatus: 200, wantBody: `"drain":{"status":"ok"`},
+		{name: "drain on", method: "PUT", path: "/drain?enabled=true", wantStatus: 200, wantBody: `"draining":true`},
+		{name: "not ready", method: "GET", path: "/readyz", wantStatus: 503, wantBody: `"error":"draining"`},
+		{name: "still live", method: "GET", path: "/healthz", wantStatus: 200},
+		{name: "state", method: "GET", path: "/drain", wantStatus: 200, wantBody: `"draining":true`},
+		{name: "bad query", method: "POST", path: "/drain?enabled=maybe", wantStatus: 400},
+		{name: "missing field", method: "POST", path: "/drain", body: `{}`, wantStatus: 400, wantBody: "enabled"},
+		{name: "drain off", method: "POST", path: "/drain", body: `{"enabled": false}`, wantStatus: 200, wantBody: `"draining":false`},
+		{name: "ready again", method: "GET", path: "/readyz", wantStatus: 200},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			rr := serve(a, tc.method, tc.path, tc.body)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Description: This package implements browser login with an OpenID Connect
+// provider such as Google, Keycloak, or Auth0, using the OAuth2
+// authorization-code flow with PKCE:
+//
+//  1. LoginHandler redirects the browser to the provider, remembering a
+//     random state, nonce, and PKCE verifier in a short-lived signed cookie.
+//  2. The user logs in there and is sent back to CallbackHandler with a code.
+//  3. CallbackHandler checks the state, exchanges the code for tokens,
+//     verifies the ID token (signature, issuer, audience, expiry, nonce), and
+//     stores a Session in a signed cookie.
+//  4. RequireSession protects routes, and SessionFrom tells handlers who is
+//     logged in.
+//
+// Sessions and flow state live in cookies signed with the keys given to
+// httpcontext.SetCookieSigningKeys, so those must be configured first.
+//
+//	p, err := auth.New(ctx, auth.Config{
+//		Issuer:       "https://accounts.google.com",
+//		ClientID:     id,
+//		ClientSecret: secret,
+//		RedirectURL:  "https://app.example.com/auth/callback",
+//	})
+//	r.GET("/auth/login", p.LoginHandler)
+//	r.GET("/auth/callback", p.CallbackHandler)
+//	r.POST("/auth/logout", p.LogoutHandler)
+//	r.GET("/me", me, router.With(p.RequireSession()))
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Config describes the application's registration with a provider.
+type Config struct {
+	// Issuer is the provider's issuer URL. Its discovery document is loaded
+	// from Issuer + "/.well-known/openid-configuration".
+	Issuer string
+
+	// ClientID and ClientSecret identify the application to the provider.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the absolute URL of CallbackHandler, exactly as
+	// registered with the provider.
+	RedirectURL string
+
+	// Scopes are requested in addition to "openid". The default is
+	// "email" and "profile".
+	Scopes []string
+
+	// SessionCookie names the session cookie. The default is "session".
+	SessionCookie string
+
+	// SessionTTL is how long a login lasts. The default is 24 hours.
+	SessionTTL time.Duration
+
+	// LoginPath is where RequireSession sends browsers that aren't logged
+	// in, e.g. "/auth/login". Empty means answering 401 instead.
+	LoginPath string
+
+	// AfterLogin is where CallbackHandler redirects when the login didn't
+	// carry a ?next= path. The default is "/".
+	AfterLogin string
+
+	// AfterLogout is where LogoutHandler redirects. The default is "/".
+	AfterLogout string
+
+	// OnLogin, if set, runs after the ID token was verified and before the
+	// session starts, e.g. to create the user's account on first login or
+	// to restrict logins to one email domain. An error refuses the login
+	// with 403 Forbidden and the error's message.
+	OnLogin func(c *httpcontext.Context, claims *Claims) error
+
+	// HTTPClient makes the requests to the provider. The default is a client
+	// with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// endpoints is the part of the provider's discovery document we use.
+type endpoints struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is a configured OpenID Connect provider. It's safe for concurrent
+// use.
+type Provider struct {
+	cfg       Config
+	endpoints endpoints
+
+	// keys caches the provider's signing keys by key ID. They're refetched
+	// when a token names an unknown key, which is how providers rotate.
+	keysMu      sync.Mutex
+	keys        map[string]interface{}
+	keysFetched time.Time
+
+	// now returns the current time; tests replace it.
+	now func() time.Time
+}
+
+// New loads the provider's discovery document and returns a Provider.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("auth: Issuer, ClientID, and RedirectURL are required")
+	}
+	if cfg.Scopes == nil {
+		cfg.Scopes = []string{"email", "profile"}
+	}
+	if cfg.SessionCookie == "" {
+		cfg.SessionCookie = "session"
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = 24 * time.Hour
+	}
+	if cfg.AfterLogin == "" {
+		cfg.AfterLogin = "/"
+	}
+	if cfg.AfterLogout == "" {
+		cfg.AfterLogout = "/"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	p := &Provider{cfg: cfg, now: time.Now}
+	discovery := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discovery, &p.endpoints); err != nil {
+		return nil, fmt.Errorf("auth: discovery: %w", err)
+	}
+	// The discovery document must be about the issuer we asked for;
+	// otherwise tokens from a different provider could be accepted.
+	if p.endpoints.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("auth: discovery issuer %q does not match %q", p.endpoints.Issuer, cfg.Issuer)
+	}
+	if p.endpoints.AuthorizationEndpoint == "" || p.endpoints.TokenEndpoint == "" || p.endpoints.JWKSURI == "" {
+		return nil, fmt.Errorf("auth: discovery document is missing endpoints")
+	}
+	return p, nil
+}
+
+// getJSON fetches a URL and decodes its JSON body into v.
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
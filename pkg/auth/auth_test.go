@@ -0,0 +1,263 @@
+// Description: This file contains tests for the login flow, run against a
+// fake OpenID Connect provider.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// fakeProvider is a minimal OpenID Connect provider. It hands out the code
+// "good-code" and answers the token request with an ID token for claims.
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	// challenge is the PKCE challenge the login was started with.
+	challenge string
+}
+
+// newFakeProvider starts a fake provider with a fresh RSA signing key.
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if id != "app" || secret != "shh" || r.PostFormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != f.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": f.sign(t, "k1", f.claims)})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// sign creates an RS256 JWT.
+func (f *fakeProvider) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims of a valid ID token for nonce.
+func (f *fakeProvider) validClaims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss": f.URL, "sub": "user-42", "aud": "app", "nonce": nonce,
+		"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+		"email": "ann@example.com", "name": "Ann",
+	}
+}
+
+// newTestProvider sets up signing keys, a fake provider, and a Provider for it.
+func newTestProvider(t *testing.T) (*fakeProvider, *Provider) {
+	t.Helper()
+	httpcontext.SetCookieSigningKeys([]byte("0123456789abcdef0123456789abcdef"))
+	t.Cleanup(func() { httpcontext.SetCookieSigningKeys() })
+
+	f := newFakeProvider(t)
+	p, err := New(context.Background(), Config{
+		Issuer:       f.URL,
+		ClientID:     "app",
+		ClientSecret: "shh",
+		RedirectURL:  "http://app.test/auth/callback",
+		LoginPath:    "/auth/login",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, p
+}
+
+// serve sends a request to h with the given cookies and returns the response.
+func serve(h http.Handler, target string, cookies []*http.Cookie, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestLoginFlow walks through a complete login and the use of the session.
+func TestLoginFlow(t *testing.T) {
+	// 1. Setup
+	f, p := newTestProvider(t)
+	r := router.New()
+	r.GET("/auth/login", p.LoginHandler)
+	r.GET("/auth/callback", p.CallbackHandler)
+	r.GET("/me", func(c *httpcontext.Context) {
+		s, _ := SessionFrom(c)
+		c.String(http.StatusOK, "%s %s", s.Subject, s.Email)
+	}, router.With(p.RequireSession()))
+
+	// 2. Execute & 3. Assert: Unauthenticated browsers are sent to log in.
+	rr := serve(r, "/me", nil, "Accept", "text/html")
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/auth/login?next=%2Fme" {
+		t.Fatalf("expected a redirect to the login, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if rr := serve(r, "/me", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for API clients, got %d", rr.Code)
+	}
+
+	// The login redirects to the provider with the flow parameters.
+	rr = serve(r, "/auth/login?next=/me", nil)
+	location, _ := url.Parse(rr.Header().Get("Location"))
+	q := location.Query()
+	if rr.Code != http.StatusFound || !strings.HasPrefix(location.String(), f.URL+"/authorize?") {
+		t.Fatalf("expected a redirect to the provider, got %d %s", rr.Code, location)
+	}
+	if q.Get("client_id") != "app" || q.Get("scope") != "openid email profile" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("state") == "" {
+		t.Fatalf("unexpected authorization parameters: %v", q)
+	}
+	flowCookies := rr.Result().Cookies()
+	f.challenge = q.Get("code_challenge")
+	f.claims = f.validClaims(q.Get("nonce"))
+
+	// A callback with the wrong state is refused.
+	if rr := serve(r, "/auth/callback?code=good-code&state=forged", flowCookies); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a forged state, got %d", rr.Code)
+	}
+
+	// The real callback starts a session and returns to the original page.
+	rr = serve(r, "/auth/callback?code=good-code&state="+q.Get("state"), flowCookies)
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/me" {
+		t.Fatalf("expected a redirect to /me, got %d %q: %s", rr.Code, rr.Header().Get("Location"), rr.Body)
+	}
+	var session []*http.Cookie
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == "session" {
+			session = append(session, cookie)
+		}
+	}
+	if len(session) != 1 || !session[0].HttpOnly {
+		t.Fatalf("expected an HttpOnly session cookie, got %v", rr.Result().Cookies())
+	}
+
+	rr = serve(r, "/me", session)
+	if rr.Code != http.StatusOK || rr.Body.String() != "user-42 ann@example.com" {
+		t.Errorf("expected the session's user, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	// A tampered session cookie is rejected.
+	forged := *session[0]
+	forged.Value = "x" + forged.Value
+	if rr := serve(r, "/me", []*http.Cookie{&forged}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a tampered session, got %d", rr.Code)
+	}
+}
+
+// TestCallback_Errors tests callbacks that must not log anybody in.
+func TestCallback_Errors(t *testing.T) {
+	// 1. Setup: Start a login to get a valid flow cookie.
+	f, p := newTestProvider(t)
+	h := router.HandlerFunc(p.CallbackHandler)
+	rr := serve(router.HandlerFunc(p.LoginHandler), "/auth/login", nil)
+	location, _ := url.Parse(rr.Header().Get("Location"))
+	q := location.Query()
+	flowCookies := rr.Result().Cookies()
+	f.challenge = q.Get("code_challenge")
+	state := q.Get("state")
+
+	tests := []struct {
+		name       string
+		query      string
+		cookies    []*http.Cookie
+		claims     map[string]interface{}
+		wantStatus int
+	}{
+		{"provider error", "error=access_denied", flowCookies, nil, http.StatusUnauthorized},
+		{"no flow cookie", "code=good-code&state=" + state, nil, nil, http.StatusBadRequest},
+		{"bad code", "code=bad-code&state=" + state, flowCookies, f.validClaims(q.Get("nonce")), http.StatusBadGateway},
+		{"wrong nonce", "code=good-code&state=" + state, flowCookies, f.validClaims("other"), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.claims = tt.claims
+
+			// 2. Execute
+			rr := serve(h, "/auth/callback?"+tt.query, tt.cookies)
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body)
+			}
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == "session" {
+					t.Error("expected no session cookie")
+				}
+			}
+		})
+	}
+}
+
+// TestLocalPath tests the open redirect protection for ?next=.
+func TestLocalPath(t *testing.T) {
+	tests := map[string]bool{
+		"/me":                  true,
+		"/a?b=c":               true,
+		"":                     false,
+		"//evil.example":       false,
+		"/\\evil.example":      false,
+		"https://evil.example": false,
+	}
+	for next, want := range tests {
+		if got := localPath(next); got != want {
+			t.Errorf("localPath(%q) = %v, want %v", next, got, want)
+		}
+	}
+}
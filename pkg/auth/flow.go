@@ -0,0 +1,240 @@
+// Description: This file contains the HTTP handlers of the login flow: the
+// redirect to the provider, the callback that completes the login, and logout.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// flowCookie names the cookie holding a login in progress.
+const flowCookie = "oidc_flow"
+
+// flowTTL is how long a user has to complete the login at the provider.
+const flowTTL = 10 * time.Minute
+
+// flowState is what LoginHandler remembers for CallbackHandler.
+type flowState struct {
+	// State ties the callback to this browser, which prevents login CSRF:
+	// an attacker can't make a victim complete the attacker's login.
+	State string `json:"s"`
+
+	// Nonce ties the ID token to this login, so a token can't be replayed.
+	Nonce string `json:"n"`
+
+	// Verifier is the PKCE secret (RFC 7636), which makes a stolen code
+	// useless without it.
+	Verifier string `json:"v"`
+
+	// Next is the local path to return to after the login.
+	Next string `json:"next,omitempty"`
+}
+
+// randomString returns 32 random bytes in base64url.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// localPath reports whether next is a path on this site. Anything else, like
+// "//evil.example" or "https://evil.example", would make the login an open
+// redirect.
+func localPath(next string) bool {
+	return strings.HasPrefix(next, "/") && !strings.HasPrefix(next, "//") && !strings.HasPrefix(next, "/\\")
+}
+
+// secure reports whether the app runs on HTTPS, judging by the redirect URL,
+// and so whether cookies should be marked Secure.
+func (p *Provider) secure() bool {
+	return strings.HasPrefix(p.cfg.RedirectURL, "https://")
+}
+
+// LoginHandler starts a login by redirecting to the provider. A ?next= query
+// parameter with a local path is where the user lands afterwards.
+func (p *Provider) LoginHandler(c *httpcontext.Context) {
+	var flow flowState
+	var err error
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *v, err = randomString(); err != nil {
+			c.Fail(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if next := c.Query("next"); localPath(next) {
+		flow.Next = next
+	}
+
+	value, _ := json.Marshal(flow)
+	err = c.SetSignedCookie(&http.Cookie{
+		Name:     flowCookie,
+		Value:    string(value),
+		MaxAge:   int(flowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   p.secure(),
+		// Lax (not Strict) so the cookie comes along on the provider's
+		// top-level redirect back to us.
+		SameSite: http.SameSiteLaxMode,
+	})
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " ")},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	target := p.endpoints.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + query.Encode()
+	} else {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(c.Writer, c.Request, target, http.StatusFound)
+}
+
+// CallbackHandler completes a login: it validates the provider's answer,
+// exchanges the code for tokens, verifies the ID token, and starts a session.
+func (p *Provider) CallbackHandler(c *httpcontext.Context) {
+	if reason := c.Query("error"); reason != "" {
+		if description := c.Query("error_description"); description != "" {
+			reason += ": " + description
+		}
+		c.Fail(http.StatusUnauthorized, fmt.Errorf("login failed: %s", reason))
+		return
+	}
+
+	raw, err := c.SignedCookie(flowCookie)
+	var flow flowState
+	if err == nil {
+		err = json.Unmarshal([]byte(raw), &flow)
+	}
+	if err != nil {
+		c.Fail(http.StatusBadRequest, errors.New("login expired, please try again"))
+		return
+	}
+	// The flow is single-use, whatever happens next.
+	c.SetCookie(&http.Cookie{Name: flowCookie, MaxAge: -1, HttpOnly: true, Secure: p.secure()})
+
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(flow.State)) != 1 {
+		c.Fail(http.StatusBadRequest, errors.New("login state mismatch"))
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.Fail(http.StatusBadRequest, errors.New("missing authorization code"))
+		return
+	}
+
+	idToken, err := p.exchange(c, code, flow.Verifier)
+	if err != nil {
+		c.Fail(http.StatusBadGateway, err)
+		return
+	}
+	claims, err := p.verifyIDToken(c, idToken, flow.Nonce)
+	if err != nil {
+		c.Logger().Warn("Rejected ID token", "err", err)
+		c.Fail(http.StatusUnauthorized, errors.New("invalid ID token"))
+		return
+	}
+	if p.cfg.OnLogin != nil {
+		if err := p.cfg.OnLogin(c, claims); err != nil {
+			c.Fail(http.StatusForbidden, err)
+			return
+		}
+	}
+
+	session := &Session{
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Name:    claims.Name,
+		Expires: p.now().Add(p.cfg.SessionTTL).Truncate(time.Second),
+	}
+	if err := p.setSession(c, session); err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+
+	next := flow.Next
+	if next == "" {
+		next = p.cfg.AfterLogin
+	}
+	http.Redirect(c.Writer, c.Request, next, http.StatusFound)
+}
+
+// LogoutHandler ends the session and redirects to Config.AfterLogout. It
+// should be registered for POST, so other sites can't log users out with a
+// link or an image.
+func (p *Provider) LogoutHandler(c *httpcontext.Context) {
+	c.SetCookie(&http.Cookie{Name: p.cfg.SessionCookie, MaxAge: -1, HttpOnly: true, Secure: p.secure()})
+	http.Redirect(c.Writer, c.Request, p.cfg.AfterLogout, http.StatusSeeOther)
+}
+
+// tokenResponse is the token endpoint's answer (RFC 6749 sections 5.1, 5.2).
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange trades an authorization code for tokens and returns the ID token.
+func (p *Provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// RFC 6749 section 2.3.1 wants both parts form-encoded first.
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("token exchange: %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("token exchange: %s: %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token exchange: no ID token in the response")
+	}
+	return token.IDToken, nil
+}
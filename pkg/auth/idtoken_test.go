@@ -0,0 +1,124 @@
+// Description: This file contains tests for ID token verification.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVerifyIDToken tests the signature and claim checks.
+func TestVerifyIDToken(t *testing.T) {
+	// 1. Setup
+	f, p := newTestProvider(t)
+	now := time.Now()
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		claims := f.validClaims("n-1")
+		for k, v := range changes {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", f.sign(t, "k1", with(nil)), ""},
+		{"audience array", f.sign(t, "k1", with(map[string]interface{}{"aud": []string{"app", "other"}, "azp": "app"})), ""},
+		{"foreign azp", f.sign(t, "k1", with(map[string]interface{}{"aud": []string{"app", "other"}, "azp": "other"})), "authorized for another client"},
+		{"wrong audience", f.sign(t, "k1", with(map[string]interface{}{"aud": "other"})), "another client"},
+		{"wrong issuer", f.sign(t, "k1", with(map[string]interface{}{"iss": "https://evil.example"})), "issued by"},
+		{"expired", f.sign(t, "k1", with(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), "expired"},
+		{"from the future", f.sign(t, "k1", with(map[string]interface{}{"iat": now.Add(time.Hour).Unix()})), "future"},
+		{"wrong nonce", f.sign(t, "k1", with(map[string]interface{}{"nonce": "n-2"})), "nonce"},
+		{"unknown key", f.sign(t, "k2", with(nil)), "unknown signing key"},
+		{"tampered", tamper(f.sign(t, "k1", with(nil))), "signature"},
+		{"alg none", unsigned(with(nil)), "unexpected algorithm"},
+		{"malformed", "not-a-jwt", "malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2. Execute
+			claims, err := p.verifyIDToken(context.Background(), tt.token, "n-1")
+
+			// 3. Assert
+			if tt.wantErr == "" {
+				if err != nil || claims.Subject != "user-42" || claims.Email != "ann@example.com" {
+					t.Fatalf("expected valid claims, got %+v, %v", claims, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestVerifyIDToken_ES256 tests tokens signed with an elliptic curve key.
+func TestVerifyIDToken_ES256(t *testing.T) {
+	// 1. Setup: Sign with a P-256 key, published as a JWK.
+	f, p := newTestProvider(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwkKey, err := jwk{
+		Kty: "EC", Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}.publicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.keys = map[string]interface{}{"ec": jwkKey}
+	p.keysFetched = time.Now()
+
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "ec"})
+	payload, _ := json.Marshal(f.validClaims("n-1"))
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	// 2. Execute
+	claims, err := p.verifyIDToken(context.Background(), signed+"."+base64.RawURLEncoding.EncodeToString(signature), "n-1")
+
+	// 3. Assert
+	if err != nil || claims.Subject != "user-42" {
+		t.Fatalf("expected valid claims, got %+v, %v", claims, err)
+	}
+}
+
+// tamper flips the subject of a signed token, keeping the signature.
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	var claims map[string]interface{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(payload, &claims)
+	claims["sub"] = "admin"
+	payload, _ = json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
+
+// unsigned builds a token with "alg": "none" for key k1.
+func unsigned(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
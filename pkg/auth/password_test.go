@@ -0,0 +1,51 @@
+// Description: This file contains tests for password hashing.
+
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckPassword tests passwords against hashes, good and malformed.
+func TestCheckPassword(t *testing.T) {
+	// 1. Setup
+	defer func(n int) { PasswordIterations = n }(PasswordIterations)
+	PasswordIterations = 1000
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := HashPassword("correct horse")
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		{"right password", hash, "correct horse", true},
+		{"wrong password", hash, "correct horsE", false},
+		{"empty password", hash, "", false},
+		{"other salt", other, "correct horse", true},
+		{"raised iterations", strings.Replace(hash, "$1000$", "$2000$", 1), "correct horse", false},
+		{"other scheme", strings.Replace(hash, "pbkdf2-sha256", "md5", 1), "correct horse", false},
+		{"no iterations", strings.Replace(hash, "$1000$", "$0$", 1), "correct horse", false},
+		{"truncated", hash[:strings.LastIndex(hash, "$")], "correct horse", false},
+		{"empty", "", "", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			got := CheckPassword(tc.hash, tc.password)
+
+			// 3. Assert
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+	if hash == other {
+		t.Error("expected two hashes of a password to differ by their salt")
+	}
+}
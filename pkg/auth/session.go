@@ -0,0 +1,103 @@
+// Description: This file contains login sessions. A session is stored in a
+// signed cookie, so the server keeps no state, and it can't be forged or
+// extended by the client.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// Session describes a logged-in user.
+type Session struct {
+	// Subject is the provider's stable, unique ID for the user. Use it,
+	// not the email address, as the key of your own user records.
+	Subject string `json:"sub"`
+
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+
+	// Expires is when the session ends and the user must log in again.
+	Expires time.Time `json:"exp"`
+}
+
+// sessionKey is the request context key for the current session.
+type sessionKey struct{}
+
+// SessionFrom returns the session that RequireSession found for the request.
+func SessionFrom(c *httpcontext.Context) (*Session, bool) {
+	s, ok := c.Value(sessionKey{}).(*Session)
+	return s, ok
+}
+
+// setSession stores a session in its cookie.
+func (p *Provider) setSession(c *httpcontext.Context, s *Session) error {
+	value, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return c.SetSignedCookie(&http.Cookie{
+		Name:     p.cfg.SessionCookie,
+		Value:    string(value),
+		Expires:  s.Expires,
+		HttpOnly: true,
+		Secure:   p.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Session returns the request's session, if it has a valid, unexpired one.
+func (p *Provider) Session(c *httpcontext.Context) (*Session, bool) {
+	raw, err := c.SignedCookie(p.cfg.SessionCookie)
+	if err != nil {
+		return nil, false
+	}
+	var s Session
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, false
+	}
+	// The cookie's own expiry is up to the browser; this one can't be
+	// changed without breaking the signature.
+	if !p.now().Before(s.Expires) {
+		return nil, false
+	}
+	return &s, true
+}
+
+// RequireSession returns middleware that lets only logged-in users through.
+// Browsers navigating to a page are sent to Config.LoginPath, if set, and
+// come back afterwards; other requests get 401 Unauthorized.
+func (p *Provider) RequireSession() router.Middleware {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *httpcontext.Context) {
+			s, ok := p.Session(c)
+			if !ok {
+				if p.cfg.LoginPath != "" && c.Request.Method == http.MethodGet &&
+					strings.Contains(c.GetHeader("Accept"), "text/html") {
+					target := p.cfg.LoginPath + "?next=" + url.QueryEscape(c.Request.URL.RequestURI())
+					http.Redirect(c.Writer, c.Request, target, http.StatusFound)
+					c.Abort()
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]interface{}{
+					httpcontext.DefaultEnvelope.ErrorKey: httpcontext.EnvelopeError{
+						Status:    http.StatusUnauthorized,
+						Message:   "login required",
+						RequestID: c.RequestID(),
+					},
+				})
+				return
+			}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), sessionKey{}, s))
+			next(c)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Description: This file contains the tokens of password logins. An access
+// token is a short-lived JWT (RFC 7519) signed with HMAC-SHA256, which any
+// instance holding the secret verifies without a lookup; it's sent as
+// "Authorization: Bearer <token>" and checked by RequireAccess. A refresh
+// token is a random string, stored only as its hash, that gets a new pair of
+// tokens once (see handlers/auth.go for the rotation).
+//
+//	tokens, err := auth.NewTokens(auth.TokenOptions{Secret: secret})
+//	access, expires, err := tokens.IssueAccess("42")
+//	r.GET("/me", me, router.With(tokens.RequireAccess()))
+
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// MinSecretLength is the shortest signing secret accepted, in bytes:
+// HMAC-SHA256 keys shorter than the hash are easier to brute-force.
+const MinSecretLength = 32
+
+// TokenOptions configures NewTokens.
+type TokenOptions struct {
+	// Secret signs the access tokens. It must be at least MinSecretLength
+	// bytes, and be the same on every instance.
+	Secret []byte
+
+	// Issuer goes in the tokens' "iss" claim and must match on
+	// verification. The default is "httpgolang".
+	Issuer string
+
+	// AccessTTL is how long access tokens last. The default is 15 minutes.
+	AccessTTL time.Duration
+
+	// RefreshTTL is how long refresh tokens last. The default is 30 days.
+	RefreshTTL time.Duration
+}
+
+// Tokens issues and verifies tokens. It's safe for concurrent use.
+type Tokens struct {
+	opts TokenOptions
+
+	// now returns the current time; tests replace it.
+	now func() time.Time
+}
+
+// NewTokens returns Tokens configured by opts.
+func NewTokens(opts TokenOptions) (*Tokens, error) {
+	if len(opts.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("auth: the token secret must be at least %d bytes", MinSecretLength)
+	}
+	if opts.Issuer == "" {
+		opts.Issuer = "httpgolang"
+	}
+	if opts.AccessTTL == 0 {
+		opts.AccessTTL = 15 * time.Minute
+	}
+	if opts.RefreshTTL == 0 {
+		opts.RefreshTTL = 30 * 24 * time.Hour
+	}
+	return &Tokens{opts: opts, now: time.Now}, nil
+}
+
+// AccessTTL returns how long access tokens last.
+func (t *Tokens) AccessTTL() time.Duration { return t.opts.AccessTTL }
+
+// RefreshTTL returns how long refresh tokens last.
+func (t *Tokens) RefreshTTL() time.Duration { return t.opts.RefreshTTL }
+
+// AccessClaims are the claims of an access token.
+type AccessClaims struct {
+	Issuer   string `json:"iss"`
+	Subject  string `json:"sub"`
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+// accessHeader is the JOSE header of every access token.
+var accessHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueAccess returns an access token for subject, usually a user ID, and
+// when it expires.
+func (t *Tokens) IssueAccess(subject string) (string, time.Time, error) {
+	now := t.now()
+	expires := now.Add(t.opts.AccessTTL)
+	claims, err := json.Marshal(AccessClaims{
+		Issuer:   t.opts.Issuer,
+		Subject:  subject,
+		IssuedAt: now.Unix(),
+		Expires:  expires.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signed := accessHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signed + "." + t.sign(signed), expires, nil
+}
+
+// sign returns the base64url HMAC of the token's first two segments.
+func (t *Tokens) sign(signed string) string {
+	mac := hmac.New(sha256.New, t.opts.Secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyAccess checks an access token's signature, issuer, and expiry, and
+// returns its claims.
+func (t *Tokens) VerifyAccess(token string) (*AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed access token")
+	}
+	// Only our own header is accepted, so the token can't pick another
+	// algorithm (or "none").
+	if parts[0] != accessHeader {
+		return nil, errors.New("unexpected access token header")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(parts[0]+"."+parts[1]))) {
+		return nil, errors.New("invalid access token signature")
+	}
+	var claims AccessClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed access token claims: %w", err)
+	}
+	switch {
+	case claims.Issuer != t.opts.Issuer:
+		return nil, fmt.Errorf("access token issued by %q", claims.Issuer)
+	case !t.now().Before(time.Unix(claims.Expires, 0)):
+		return nil, errors.New("access token expired")
+	case claims.Subject == "":
+		return nil, errors.New("access token has no subject")
+	}
+	return &claims, nil
+}
+
+// NewRefreshToken returns a new refresh token for the client, and the
+// hash to store instead of it.
+func NewRefreshToken() (token, hash string, err error) {
+	if token, err = randomString(); err != nil {
+		return "", "", err
+	}
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the stored form of a refresh token. A leaked
+// table of hashes can't be used to log in. The tokens are random, so
+// unlike passwords, a fast hash is enough.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// accessClaimsKey is the request context key for the access token's claims.
+type accessClaimsKey struct{}
+
+// AccessClaimsFrom returns the claims of the access token RequireAccess
+// accepted for the request.
+func AccessClaimsFrom(c *httpcontext.Context) (*AccessClaims, bool) {
+	claims, ok := c.Value(accessClaimsKey{}).(*AccessClaims)
+	return claims, ok
+}
+
+// RequireAccess returns middleware that lets through requests with a valid
+// access token in the Authorization header, and answers the others with 401
+// Unauthorized and a Bearer challenge (RFC 6750).
+func (t *Tokens) RequireAccess() router.Middleware {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *httpcontext.Context) {
+			token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok {
+				c.SetHeader("WWW-Authenticate", `Bearer realm="api"`)
+				abortUnauthorized(c, "access token required")
+				return
+			}
+			claims, err := t.VerifyAccess(strings.TrimSpace(token))
+			if err != nil {
+				c.SetHeader("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				abortUnauthorized(c, err.Error())
+				return
+			}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), accessClaimsKey{}, claims))
+			next(c)
+		}
+	}
+}
+
+// abortUnauthorized stops the chain with a 401 in the standard envelope.
+func abortUnauthorized(c *httpcontext.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]interface{}{
+		httpcontext.DefaultEnvelope.ErrorKey: httpcontext.EnvelopeError{
+			Status:    http.StatusUnauthorized,
+			Message:   message,
+			RequestID: c.RequestID(),
+		},
+	})
+}
@@ -0,0 +1,133 @@
+// Description: This file contains tests for access and refresh tokens.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// testSecret is a signing secret of the minimum length.
+var testSecret = []byte(strings.Repeat("s", MinSecretLength))
+
+// TestNewTokens_ShortSecret tests that weak secrets are refused.
+func TestNewTokens_ShortSecret(t *testing.T) {
+	if _, err := NewTokens(TokenOptions{Secret: []byte("hunter2")}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+// TestVerifyAccess tests tokens that are accepted and those that are not.
+func TestVerifyAccess(t *testing.T) {
+	// 1. Setup
+	tokens, _ := NewTokens(TokenOptions{Secret: testSecret})
+	now := time.Unix(1_700_000_000, 0)
+	tokens.now = func() time.Time { return now }
+	good, expires, err := tokens.IssueAccess("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(15 * time.Minute); !expires.Equal(want) {
+		t.Errorf("got expiry %v, want %v", expires, want)
+	}
+	other, _ := NewTokens(TokenOptions{Secret: []byte(strings.Repeat("o", MinSecretLength))})
+	forged, _, _ := other.IssueAccess("42")
+	foreign, _ := NewTokens(TokenOptions{Secret: testSecret, Issuer: "elsewhere"})
+	foreignToken, _, _ := foreign.IssueAccess("42")
+	parts := strings.Split(good, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		at      time.Time
+		wantErr string
+	}{
+		{name: "valid", token: good, at: now},
+		{name: "expired", token: good, at: expires, wantErr: "expired"},
+		{name: "other secret", token: forged, at: now, wantErr: "signature"},
+		{name: "other issuer", token: foreignToken, at: now, wantErr: `issued by "elsewhere"`},
+		{name: "alg none", token: "eyJhbGciOiJub25lIn0." + parts[1] + ".", at: now, wantErr: "header"},
+		{name: "tampered claims", token: parts[0] + "." + parts[1] + "x." + parts[2], at: now, wantErr: "signature"},
+		{name: "malformed", token: "abc", at: now, wantErr: "malformed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			tokens.now = func() time.Time { return tc.at }
+			claims, err := tokens.VerifyAccess(tc.token)
+
+			// 3. Assert
+			if tc.wantErr == "" {
+				if err != nil || claims.Subject != "42" {
+					t.Errorf("got %v, %v, want subject 42", claims, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestRequireAccess tests the middleware with and without a token.
+func TestRequireAccess(t *testing.T) {
+	// 1. Setup
+	tokens, _ := NewTokens(TokenOptions{Secret: testSecret})
+	token, _, _ := tokens.IssueAccess("42")
+	r := router.New()
+	r.GET("/me", func(c *httpcontext.Context) {
+		claims, _ := AccessClaimsFrom(c)
+		c.OK(claims.Subject)
+	}, router.With(tokens.RequireAccess()))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+		wantChallenge string
+	}{
+		{"valid", "Bearer " + token, http.StatusOK, `{"data":"42"}`, ""},
+		{"missing", "", http.StatusUnauthorized, "access token required", `Bearer realm="api"`},
+		{"invalid", "Bearer " + token + "x", http.StatusUnauthorized, "signature", `Bearer realm="api", error="invalid_token"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if got := rr.Header().Get("WWW-Authenticate"); got != tc.wantChallenge {
+				t.Errorf("got WWW-Authenticate %q, want %q", got, tc.wantChallenge)
+			}
+		})
+	}
+}
+
+// TestHashRefreshToken tests that refresh tokens are random and hashed.
+func TestHashRefreshToken(t *testing.T) {
+	a, hashA, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, _ := NewRefreshToken()
+	if a == b || hashA == a || hashA != HashRefreshToken(a) {
+		t.Errorf("unexpected tokens %q and %q, hash %q", a, b, hashA)
+	}
+}
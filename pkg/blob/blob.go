@@ -0,0 +1,46 @@
+// Description: This package contains blob storage: opaque files, such as
+// avatars, stored under a key and served back as they were. Store is the
+// interface the handlers use, so where the files live can change without
+// them changing; FileStore, a directory on disk, is the first
+// implementation.
+//
+//	blobs, err := blob.NewFileStore("/var/lib/httpgolang/blobs")
+//	err = blobs.Put(ctx, "avatars/42", bytes.NewReader(png))
+//	r, info, err := blobs.Get(ctx, "avatars/42")
+
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned for keys with no blob.
+var ErrNotFound = errors.New("blob: not found")
+
+// ErrInvalidKey is returned for keys that aren't valid.
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// Info describes a stored blob.
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Store stores blobs by key. Keys are slash-separated paths such as
+// "avatars/42", without "." or ".." elements or a leading slash.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Put stores the contents of r under key, replacing any blob there.
+	// Readers see the old blob or the new one, never part of it.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Get returns the blob under key, which the caller must close, or
+	// ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, Info, error)
+
+	// Delete removes the blob under key. Missing blobs are no error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,69 @@
+// Description: This file contains tests for the filesystem blob store.
+
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileStore tests storing, replacing, reading, and deleting a blob.
+func TestFileStore(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Execute
+	if err := s.Put(ctx, "avatars/1", strings.NewReader("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "avatars/1", strings.NewReader("new!")); err != nil {
+		t.Fatal(err)
+	}
+	r, info, err := s.Get(ctx, "avatars/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+
+	// 3. Assert
+	if string(data) != "new!" || info.Size != 4 || info.ModTime.IsZero() {
+		t.Errorf("got %q, %+v", data, info)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "blobs", "avatars")); len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %v", entries)
+	}
+	if err := s.Delete(ctx, "avatars/1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(ctx, "avatars/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+	if err := s.Delete(ctx, "avatars/1"); err != nil {
+		t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+	}
+	if _, _, err := s.Get(ctx, "avatars"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a directory, got %v", err)
+	}
+}
+
+// TestFileStore_InvalidKeys tests that keys can't reach outside the
+// directory.
+func TestFileStore_InvalidKeys(t *testing.T) {
+	s, _ := NewFileStore(t.TempDir())
+	for _, key := range []string{"", ".", "../secret", "/etc/passwd", "a/../../b", "a//b"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
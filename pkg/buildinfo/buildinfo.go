@@ -0,0 +1,76 @@
+// Description: This package tells what build is running: its version,
+// commit, and build date, for the /version endpoint and the startup logs.
+// Release builds set them with the linker:
+//
+//	go build -ldflags "-X github.com/hanzalaareeb/HTTPGolang/pkg/buildinfo.Version=v1.4.0 \
+//		-X github.com/hanzalaareeb/HTTPGolang/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X github.com/hanzalaareeb/HTTPGolang/pkg/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+//
+// Whatever isn't set comes from the information the go command embeds in
+// every binary: the module version for "go install ...@v1.4.0", and the
+// commit and its time for builds inside a git checkout.
+
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X ...". Empty values fall back to the embedded build
+// information.
+var (
+	Version string // e.g. "v1.4.0"
+	Commit  string // the full commit hash
+	Date    string // when the binary was built, in RFC 3339
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a checkout with uncommitted changes
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the running build's information. A version nothing sets is
+// "(devel)", like the go command reports it.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fill(&info, bi)
+	}
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	return info
+}
+
+// fill sets the fields info lacks from bi.
+func fill(info *Info, bi *debug.BuildInfo) {
+	if info.Version == "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	fromVCS := info.Commit == ""
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && fromVCS:
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.Date == "":
+			// Not the build date, but the closest thing the go
+			// command records.
+			info.Date = s.Value
+		case s.Key == "vcs.modified" && fromVCS:
+			info.Modified = s.Value == "true"
+		}
+	}
+}
+
+// Short returns the commit's first 12 characters, enough to identify it.
+func (i Info) Short() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
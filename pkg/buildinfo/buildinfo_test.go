@@ -0,0 +1,54 @@
+// Description: This file contains tests for the build information.
+
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// TestFill tests which values come from the embedded build information.
+func TestFill(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	tests := []struct {
+		name string
+		info Info
+		want Info
+	}{
+		{"nothing set", Info{}, Info{Version: "v1.4.0", Commit: "0123456789abcdef0123", Date: "2024-05-01T12:00:00Z", Modified: true}},
+		{"ldflags win", Info{Version: "v2.0.0", Commit: "fedcba", Date: "2024-06-01T00:00:00Z"}, Info{Version: "v2.0.0", Commit: "fedcba", Date: "2024-06-01T00:00:00Z"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			info := tc.info
+
+			// 2. Execute
+			fill(&info, bi)
+
+			// 3. Assert
+			if info != tc.want {
+				t.Errorf("got %+v, want %+v", info, tc.want)
+			}
+		})
+	}
+}
+
+// TestGet tests a development build.
+func TestGet(t *testing.T) {
+	info := Get()
+	if info.Version == "" || info.GoVersion != runtime.Version() {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if got := (Info{Commit: "0123456789abcdef0123"}).Short(); got != "0123456789ab" {
+		t.Errorf("unexpected short commit %q", got)
+	}
+}
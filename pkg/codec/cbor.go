@@ -0,0 +1,309 @@
+// Description: This file implements the CBOR (RFC 8949) encoder and decoder. The
+// encoder always emits definite lengths and the shortest integer forms; the
+// decoder additionally accepts indefinite-length items and skips semantic tags.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// CBOR major types, stored in the top three bits of the initial byte.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+// cborBreak terminates an indefinite-length item.
+const cborBreak = 0xff
+
+// MarshalCBOR returns the CBOR encoding of v.
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	tree, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeCBOR(&buf, tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes CBOR data into out (a pointer).
+func UnmarshalCBOR(data []byte, out interface{}) error {
+	r := bytes.NewReader(data)
+	tree, err := readCBOR(r, 0)
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+	if tree == errBreak {
+		return errors.New("cbor: unexpected break")
+	}
+	if r.Len() != 0 {
+		return errors.New("cbor: trailing data after value")
+	}
+	return fromGeneric(tree, out)
+}
+
+// writeCBORHead writes an initial byte with its argument in the shortest form.
+func writeCBORHead(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(arg))
+	case arg <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		binary.Write(buf, binary.BigEndian, uint16(arg))
+	case arg <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		binary.Write(buf, binary.BigEndian, uint32(arg))
+	default:
+		buf.WriteByte(major | 27)
+		binary.Write(buf, binary.BigEndian, arg)
+	}
+}
+
+// writeCBORInt writes a signed integer using major type 0 or 1.
+func writeCBORInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 {
+		writeCBORHead(buf, cborUint, uint64(i))
+		return
+	}
+	// Negative integers are stored as -1 - n.
+	writeCBORHead(buf, cborNegInt, uint64(-1-i))
+}
+
+// writeCBOR encodes one generic value.
+func writeCBOR(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int64:
+		writeCBORInt(buf, v)
+	case uint64:
+		writeCBORHead(buf, cborUint, v)
+	case float64:
+		if isIntegral(v) {
+			writeCBORInt(buf, int64(v))
+		} else {
+			buf.WriteByte(0xfb)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case string:
+		writeCBORHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		writeCBORHead(buf, cborBytes, uint64(len(v)))
+		buf.Write(v)
+	case []interface{}:
+		writeCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := writeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeCBORHead(buf, cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			writeCBOR(buf, k)
+			if err := writeCBOR(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+	return nil
+}
+
+// errBreak is returned as a value when the break byte is read, so the callers
+// reading indefinite-length items know to stop.
+var errBreak = &struct{ name string }{"break"}
+
+// readCBORArg reads the argument that follows an initial byte. indefinite is
+// true for additional-info value 31.
+func readCBORArg(r *bytes.Reader, info byte) (arg uint64, indefinite bool, err error) {
+	switch {
+	case info < 24:
+		return uint64(info), false, nil
+	case info <= 27:
+		arg, err = readUint(r, 1<<(info-24))
+		return arg, false, err
+	case info == 31:
+		return 0, true, nil
+	}
+	return 0, false, fmt.Errorf("invalid additional information %d", info)
+}
+
+// readCBOR decodes one value into a generic tree.
+func readCBOR(r *bytes.Reader, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("value nested too deeply")
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if b == cborBreak {
+		return errBreak, nil
+	}
+	major, info := b&0xe0, b&0x1f
+
+	// Floats and simple values use the argument bits differently.
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23: // null, undefined
+			return nil, nil
+		case 25:
+			u, err := readUint(r, 2)
+			return float16ToFloat64(uint16(u)), err
+		case 26:
+			u, err := readUint(r, 4)
+			return float64(math.Float32frombits(uint32(u))), err
+		case 27:
+			u, err := readUint(r, 8)
+			return math.Float64frombits(u), err
+		}
+		return nil, fmt.Errorf("unsupported simple value %d", info)
+	}
+
+	arg, indefinite, err := readCBORArg(r, info)
+	if err != nil {
+		return nil, err
+	}
+	// Every byte, element, or pair takes at least a byte, so a definite
+	// length beyond the remaining input is a lie. Checking it as a uint64
+	// keeps lengths above MaxInt64 from turning negative as an int.
+	if !indefinite && major >= cborBytes && major <= cborMap && arg > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	switch major {
+	case cborUint:
+		if arg <= math.MaxInt64 {
+			return int64(arg), nil
+		}
+		return arg, nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("negative integer out of range")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		var data []byte
+		if indefinite {
+			// An indefinite string is a sequence of definite chunks.
+			for {
+				chunk, err := readCBOR(r, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				if chunk == errBreak {
+					break
+				}
+				switch c := chunk.(type) {
+				case []byte:
+					data = append(data, c...)
+				case string:
+					data = append(data, c...)
+				default:
+					return nil, errors.New("invalid chunk in indefinite-length string")
+				}
+			}
+		} else if data, err = readBytes(r, int(arg)); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if major == cborText {
+			return string(data), nil
+		}
+		return data, nil
+	case cborArray:
+		arr := make([]interface{}, 0, min(int(arg), maxCollection))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			item, err := readCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if item == errBreak {
+				if !indefinite {
+					return nil, errors.New("unexpected break")
+				}
+				break
+			}
+			arr = append(arr, item)
+		}
+		return arr, nil
+	case cborMap:
+		m := make(map[string]interface{}, min(int(arg), maxCollection))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			k, err := readCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if k == errBreak {
+				if !indefinite {
+					return nil, errors.New("unexpected break")
+				}
+				break
+			}
+			v, err := readCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if v == errBreak {
+				return nil, errors.New("unexpected break")
+			}
+			m[mapKey(k)] = v
+		}
+		return m, nil
+	case cborTag:
+		// Semantic tags (dates, bignums, ...) annotate the next item; we
+		// keep the item and drop the annotation.
+		return readCBOR(r, depth+1)
+	}
+	return nil, fmt.Errorf("unsupported major type %d", major>>5)
+}
+
+// float16ToFloat64 converts an IEEE 754 half-precision float.
+func float16ToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	frac := float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 31:
+		if frac == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
@@ -0,0 +1,109 @@
+// Description: This package implements compact binary serialization formats,
+// MessagePack (https://msgpack.org) and CBOR (RFC 8949), using only the standard
+// library. They are meant for IoT and mobile clients that prefer small payloads.
+//
+// To keep both formats consistent with our JSON API, Go values are mapped the
+// same way encoding/json maps them: struct fields use their `json` tags, and
+// decoding into a struct goes through the same rules. Internally, values are
+// first converted into a generic tree (maps, slices, strings, numbers, bools,
+// nil), which each format then knows how to write and read.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// toGeneric converts any JSON-encodable value into a generic tree. Numbers keep
+// their integer-ness, so 42 is encoded as an integer rather than a float.
+func toGeneric(v interface{}) (interface{}, error) {
+	// []byte maps to the formats' native binary type rather than base64.
+	if b, ok := v.([]byte); ok {
+		return b, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(tree), nil
+}
+
+// normalizeNumbers replaces json.Number values with int64, uint64, or float64.
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = normalizeNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeNumbers(val)
+		}
+	}
+	return v
+}
+
+// fromGeneric stores a decoded generic tree into out (a pointer), using the same
+// rules as json.Unmarshal.
+func fromGeneric(tree interface{}, out interface{}) error {
+	// Decoding into a []byte target directly keeps binary data intact.
+	if b, ok := out.(*[]byte); ok {
+		if raw, ok := tree.([]byte); ok {
+			*b = append((*b)[:0], raw...)
+			return nil
+		}
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// sortedKeys returns the keys of a generic map in sorted order, so encoding a
+// value always produces the same bytes.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapKey turns a decoded map key into the string key used by the generic tree.
+func mapKey(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprint(k)
+}
+
+// maxCollection caps the element count a decoder will preallocate for, so a
+// tiny malicious payload can't claim to contain billions of elements.
+const maxCollection = 1 << 16
+
+// isIntegral reports whether a float can be encoded as an integer losslessly.
+func isIntegral(f float64) bool {
+	return f == math.Trunc(f) && math.Abs(f) < 1<<63
+}
@@ -0,0 +1,154 @@
+// Description: This file contains tests for the MessagePack and CBOR codecs.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// sample is a struct we round-trip through both formats.
+type sample struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Score   float64           `json:"score"`
+	Neg     int64             `json:"neg"`
+	Active  bool              `json:"active"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Missing *string           `json:"missing"`
+}
+
+// TestRoundTrip encodes and decodes a struct with both codecs.
+func TestRoundTrip(t *testing.T) {
+	in := sample{
+		Name:   "sensor-1",
+		Age:    300,
+		Score:  1.5,
+		Neg:    -70000,
+		Active: true,
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"room": "kitchen"},
+	}
+
+	codecs := []struct {
+		name      string
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"msgpack", MarshalMsgPack, UnmarshalMsgPack},
+		{"cbor", MarshalCBOR, UnmarshalCBOR},
+	}
+	for _, tc := range codecs {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.marshal(in)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var out sample
+			if err := tc.unmarshal(data, &out); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+			}
+		})
+	}
+}
+
+// TestKnownEncodings checks the output against encodings from the specs.
+func TestKnownEncodings(t *testing.T) {
+	tests := []struct {
+		name    string
+		marshal func(interface{}) ([]byte, error)
+		in      interface{}
+		want    string // hex
+	}{
+		{"msgpack fixint", MarshalMsgPack, 5, "05"},
+		{"msgpack negative fixint", MarshalMsgPack, -1, "ff"},
+		{"msgpack uint16", MarshalMsgPack, 1000, "d103e8"},
+		{"msgpack fixstr", MarshalMsgPack, "hi", "a26869"},
+		{"msgpack map", MarshalMsgPack, map[string]int{"a": 1}, "81a16101"},
+		{"msgpack bin", MarshalMsgPack, []byte{1, 2}, "c4020102"},
+		{"cbor small uint", MarshalCBOR, 10, "0a"},
+		{"cbor uint16", MarshalCBOR, 1000, "1903e8"},
+		{"cbor negative", MarshalCBOR, -100, "3863"},
+		{"cbor text", MarshalCBOR, "IETF", "6449455446"},
+		{"cbor array", MarshalCBOR, []int{1, 2, 3}, "83010203"},
+		{"cbor float", MarshalCBOR, 1.1, "fb3ff199999999999a"},
+		{"cbor null", MarshalCBOR, nil, "f6"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.marshal(tc.in)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if got := hex.EncodeToString(data); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestUnmarshalCBOR_Indefinite decodes indefinite-length items and tags, which
+// our encoder never produces but other clients may.
+func TestUnmarshalCBOR_Indefinite(t *testing.T) {
+	// {_ "a": [_ 1, 2], "s": (_ "ab", "c")} followed by tag 1 (epoch time) on an int.
+	data, _ := hex.DecodeString("bf6161" + "9f0102ff" + "6173" + "7f626162" + "6163ff" + "ff")
+	var out struct {
+		A []int  `json:"a"`
+		S string `json:"s"`
+	}
+	if err := UnmarshalCBOR(data, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out.A, []int{1, 2}) || out.S != "abc" {
+		t.Errorf("unexpected result: %+v", out)
+	}
+
+	var ts int
+	tagged, _ := hex.DecodeString("c11a514b67b0")
+	if err := UnmarshalCBOR(tagged, &ts); err != nil || ts != 1363896240 {
+		t.Errorf("tagged value: got %d, %v", ts, err)
+	}
+}
+
+// TestUnmarshal_Errors checks that truncated and hostile inputs are rejected.
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		unmarshal func([]byte, interface{}) error
+		data      string // hex
+	}{
+		{"msgpack truncated string", UnmarshalMsgPack, "a568"},
+		{"msgpack huge array", UnmarshalMsgPack, "ddffffffff"},
+		{"msgpack trailing data", UnmarshalMsgPack, "0101"},
+		{"msgpack unsupported", UnmarshalMsgPack, "c1"},
+		{"cbor truncated", UnmarshalCBOR, "1903"},
+		{"cbor huge bytes", UnmarshalCBOR, "5bffffffffffffffff"},
+		{"cbor huge array", UnmarshalCBOR, "9bffffffffffffffff"},
+		{"cbor huge map", UnmarshalCBOR, "bbffffffffffffffff"},
+		{"cbor array longer than input", UnmarshalCBOR, "9a0001000001"},
+		{"cbor lone break", UnmarshalCBOR, "ff"},
+		{"cbor empty", UnmarshalCBOR, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tc.data)
+			var out interface{}
+			if err := tc.unmarshal(data, &out); err == nil {
+				t.Errorf("expected an error, got value %v", out)
+			}
+		})
+	}
+
+	// Deep nesting must fail cleanly rather than overflow the stack.
+	deep := bytes.Repeat([]byte{0x91}, 10000)
+	var out interface{}
+	if err := UnmarshalMsgPack(deep, &out); err == nil {
+		t.Error("expected an error for deeply nested input")
+	}
+}
@@ -0,0 +1,299 @@
+// Description: This file implements the MessagePack encoder and decoder.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MarshalMsgPack returns the MessagePack encoding of v.
+func MarshalMsgPack(v interface{}) ([]byte, error) {
+	tree, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeMsgPack(&buf, tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes MessagePack data into out (a pointer).
+func UnmarshalMsgPack(data []byte, out interface{}) error {
+	r := bytes.NewReader(data)
+	tree, err := readMsgPack(r, 0)
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	if r.Len() != 0 {
+		return errors.New("msgpack: trailing data after value")
+	}
+	return fromGeneric(tree, out)
+}
+
+// writeMsgPack encodes one generic value.
+func writeMsgPack(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int64:
+		writeMsgPackInt(buf, v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			writeMsgPackInt(buf, int64(v))
+		} else {
+			buf.WriteByte(0xcf)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case float64:
+		if isIntegral(v) {
+			writeMsgPackInt(buf, int64(v))
+		} else {
+			buf.WriteByte(0xcb)
+			binary.Write(buf, binary.BigEndian, v)
+		}
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			buf.WriteByte(0xd9)
+			buf.WriteByte(byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xda)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdb)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.WriteString(v)
+	case []byte:
+		n := len(v)
+		switch {
+		case n <= math.MaxUint8:
+			buf.WriteByte(0xc4)
+			buf.WriteByte(byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xc5)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xc6)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.Write(v)
+	case []interface{}:
+		writeMsgPackHeader(buf, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeMsgPackHeader(buf, len(v), 0x80, 0xde, 0xdf)
+		for _, k := range sortedKeys(v) {
+			writeMsgPack(buf, k)
+			if err := writeMsgPack(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+// writeMsgPackInt writes an integer in the smallest MessagePack form.
+func writeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 127:
+		buf.WriteByte(byte(i)) // positive fixint
+	case i >= -32 && i < 0:
+		buf.WriteByte(byte(int8(i))) // negative fixint
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// writeMsgPackHeader writes the length header of an array or map.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fix, b16, b32 byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(b16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(b32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// maxDepth limits nesting so a malicious payload can't exhaust the stack.
+const maxDepth = 100
+
+// readMsgPack decodes one value into a generic tree.
+func readMsgPack(r *bytes.Reader, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("value nested too deeply")
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xe0 == 0xa0:
+		return readString(r, int(b&0x1f))
+	case b&0xf0 == 0x90:
+		return readMsgPackArray(r, int(b&0x0f), depth)
+	case b&0xf0 == 0x80:
+		return readMsgPackMap(r, int(b&0x0f), depth)
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := readUint(r, 1<<(b-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		u, err := readUint(r, size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the encoded width.
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, nil
+	case 0xca:
+		u, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := readUint(r, 8)
+		return math.Float64frombits(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readUint(r, 1<<(b-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readString(r, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readUint(r, 1<<(b-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readBytes(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := readUint(r, 2<<(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readUint(r, 2<<(b-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackMap(r, int(n), depth)
+	}
+	return nil, fmt.Errorf("unsupported type byte 0x%02x", b)
+}
+
+// readMsgPackArray decodes n array elements.
+func readMsgPackArray(r *bytes.Reader, n int, depth int) (interface{}, error) {
+	arr := make([]interface{}, 0, min(n, maxCollection))
+	for i := 0; i < n; i++ {
+		item, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, item)
+	}
+	return arr, nil
+}
+
+// readMsgPackMap decodes n key/value pairs.
+func readMsgPackMap(r *bytes.Reader, n int, depth int) (interface{}, error) {
+	m := make(map[string]interface{}, min(n, maxCollection))
+	for i := 0; i < n; i++ {
+		k, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		m[mapKey(k)] = v
+	}
+	return m, nil
+}
+
+// readUint reads a big-endian unsigned integer of the given byte size.
+func readUint(r *bytes.Reader, size int) (uint64, error) {
+	var u uint64
+	for i := 0; i < size; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+// readBytes reads exactly n bytes, refusing lengths longer than the input.
+func readBytes(r *bytes.Reader, n int) ([]byte, error) {
+	if n < 0 || n > r.Len() {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	io.ReadFull(r, b)
+	return b, nil
+}
+
+// readString reads exactly n bytes as a string.
+func readString(r *bytes.Reader, n int) (interface{}, error) {
+	b, err := readBytes(r, n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
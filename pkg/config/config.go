@@ -0,0 +1,461 @@
+// Description: This package loads the server's settings. They start from
+// Default, are read from a JSON, YAML, or TOML file, and can then be
+// overridden by environment variables, which is how containers usually get
+// their configuration:
+//
+//	# server.yaml
+//	server:
+//	  addr: ":8443"
+//	  shutdown_timeout: 30s
+//	tls:
+//	  cert: /etc/ssl/server.pem
+//	  key: /etc/ssl/server.key
+//	log:
+//	  level: debug
+//	features:
+//	  beta_users: true
+//
+//	HTTPGOLANG_SERVER_ADDR=:9443 HTTPGOLANG_FEATURES_BETA_USERS=false ./server -config server.yaml
+//
+// Every setting's variable is EnvPrefix followed by its path in the file, in
+// upper case with underscores. The result is validated before it's returned.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the names of the environment variables overriding the file.
+const EnvPrefix = "HTTPGOLANG_"
+
+// Config holds the server's settings.
+type Config struct {
+	Server ServerConfig `json:"server"`
+	TLS    TLSConfig    `json:"tls"`
+	ACME   ACMEConfig   `json:"acme"`
+	Log    LogConfig    `json:"log"`
+	Admin  AdminConfig  `json:"admin"`
+	DB     DBConfig     `json:"database"`
+	Auth   AuthConfig   `json:"auth"`
+	Blobs  BlobConfig   `json:"blobs"`
+
+	// Features switches optional behavior on or off by name. Names are
+	// lower case; a variable like HTTPGOLANG_FEATURES_BETA_USERS=true sets
+	// "beta_users".
+	Features map[string]bool `json:"features"`
+}
+
+// ServerConfig holds the listener settings.
+type ServerConfig struct {
+	// Addr is the address to listen on, e.g. ":8080".
+	Addr string `json:"addr"`
+
+	// The http.Server timeouts, see server.WithTimeouts. Zero means none.
+	ReadTimeout  Duration `json:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+
+	// ShutdownTimeout is how long to wait for in-flight requests when
+	// shutting down.
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	// BindRetry is how long to keep trying to listen on an address that's
+	// still in use, see server.WithBindRetry. Zero fails right away.
+	BindRetry Duration `json:"bind_retry"`
+
+	// MaxConnections caps the open connections, see server.WithConnLimit.
+	// Zero means no limit.
+	MaxConnections int `json:"max_connections"`
+
+	// H2C also accepts HTTP/2 without TLS, for trusted networks.
+	H2C bool `json:"h2c"`
+}
+
+// TLSConfig holds the certificate files and handshake policy for serving
+// HTTPS.
+type TLSConfig struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+
+	// Policy is the TLS preset: "modern" accepts only TLS 1.3,
+	// "intermediate" also TLS 1.2 with forward-secret ciphers. See
+	// server.TLSPolicy.
+	Policy string `json:"policy"`
+
+	// RedirectAddr, if set, also listens for plain HTTP there and
+	// redirects to HTTPS, e.g. ":80". It applies to ACME too.
+	RedirectAddr string `json:"redirect_addr"`
+}
+
+// ACMEConfig holds the settings for certificates from Let's Encrypt.
+type ACMEConfig struct {
+	// Hosts are the names to get certificates for; none disables ACME.
+	Hosts []string `json:"hosts"`
+	Email string   `json:"email"`
+	Cache string   `json:"cache"`
+}
+
+// AdminConfig holds the settings of the internal admin server.
+type AdminConfig struct {
+	// Addr is where to serve the admin endpoints, e.g. "127.0.0.1:9090".
+	// Empty disables the admin server.
+	Addr string `json:"addr"`
+
+	// DebugToken, if set, also serves the debugging endpoints (pprof and
+	// runtime statistics) on the public port under /debug, to requests
+	// sending it in the X-Debug-Token header. It's a secret: prefer
+	// setting it through the environment.
+	DebugToken string `json:"debug_token"`
+}
+
+// DBConfig holds the database settings, see store.OpenSQL. Without a
+// driver, the data is kept in memory.
+type DBConfig struct {
+	// Driver is the database/sql driver: postgres or pgx for PostgreSQL,
+	// sqlite or sqlite3 for SQLite. The binary must be built with it.
+	Driver string `json:"driver"`
+
+	// DSN locates the database, e.g. postgres://app@db/app or app.db. It
+	// may hold a password: prefer setting it through the environment.
+	DSN string `json:"dsn"`
+
+	// The connection pool settings of database/sql. Zero keeps its default.
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+}
+
+// AuthConfig holds the settings of password logins, see
+// handlers.RegisterAuthRoutes. Without a secret, the /auth endpoints are
+// off.
+type AuthConfig struct {
+	// Secret signs the access tokens, and must be the same on every
+	// instance. It's a secret: prefer setting it through the environment.
+	Secret string `json:"secret"`
+
+	// AccessTTL and RefreshTTL are how long the tokens last. Zero keeps
+	// the defaults, 15m and 720h.
+	AccessTTL  Duration `json:"access_ttl"`
+	RefreshTTL Duration `json:"refresh_ttl"`
+
+	// AdminIDs are the IDs of the users who are admins, who may create and
+	// delete users. They must be of existing users: registering never makes
+	// an admin.
+	AdminIDs []int `json:"admin_ids"`
+}
+
+// BlobConfig holds the settings of the uploaded files' storage, see the
+// blob package.
+type BlobConfig struct {
+	// Dir is the directory the files, such as avatars, are kept in. Empty
+	// turns uploads off.
+	Dir string `json:"dir"`
+}
+
+// LogConfig holds the logging settings.
+type LogConfig struct {
+	// Level is the least severe level logged: debug, info, warn, or error.
+	Level string `json:"level"`
+
+	// Format is "text" (key=value pairs) or "json", one object per line.
+	Format string `json:"format"`
+
+	// Access is the access log, separate from the application's logs.
+	Access AccessLogConfig `json:"access"`
+
+	// Sample picks requests whose debug lines are logged whatever Level
+	// is, see middleware.Sample.
+	Sample SampleLogConfig `json:"sample"`
+}
+
+// SampleLogConfig holds the settings of request sampling for verbose logs.
+type SampleLogConfig struct {
+	// Rate is the fraction of requests sampled, e.g. 0.01 for 1%.
+	Rate float64 `json:"rate"`
+
+	// Token, if set, samples the requests sending it in the X-Debug-Log
+	// header. It's a secret: prefer setting it through the environment.
+	Token string `json:"token"`
+}
+
+// AccessLogConfig holds the settings of the access log file, see the
+// logfile package.
+type AccessLogConfig struct {
+	// Path is the file to write one line per request to. Empty disables
+	// the access log.
+	Path string `json:"path"`
+
+	// Format is "text" or "json".
+	Format string `json:"format"`
+
+	// MaxSizeMB rotates the file once it reaches that many megabytes, and
+	// RotateEvery once a period of that length (e.g. 24h) begins. Zero
+	// disables either.
+	MaxSizeMB   int      `json:"max_size_mb"`
+	RotateEvery Duration `json:"rotate_every"`
+
+	// MaxBackups is how many rotated files to keep; zero keeps all.
+	MaxBackups int `json:"max_backups"`
+
+	// Compress gzips the rotated files.
+	Compress bool `json:"compress"`
+}
+
+// logLevels are the valid values of LogConfig.Level.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// logFormats are the valid values of LogConfig.Format and
+// AccessLogConfig.Format.
+var logFormats = []string{"text", "json"}
+
+// dbDrivers are the valid values of DBConfig.Driver.
+var dbDrivers = []string{"postgres", "pgx", "sqlite", "sqlite3"}
+
+// tlsPolicies are the valid values of TLSConfig.Policy.
+var tlsPolicies = []string{"modern", "intermediate"}
+
+// minTokenLength is the shortest secret token accepted, so it can't be
+// guessed.
+const minTokenLength = 16
+
+// minSecretLength is the shortest auth.secret accepted, that of
+// auth.MinSecretLength.
+const minSecretLength = 32
+
+// Duration is a time.Duration written like "15s" or "1m30s" in the file.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler, which encoding/json
+// uses for string values.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, so the duration is written
+// back as "15s" by encoding/json and our YAML encoder.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the settings used when nothing else is configured.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     Duration(5 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			IdleTimeout:     Duration(120 * time.Second),
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		TLS:  TLSConfig{Policy: "intermediate"},
+		ACME: ACMEConfig{Cache: "certs"},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+			Access: AccessLogConfig{Format: "text", MaxSizeMB: 100, MaxBackups: 10},
+		},
+		Features: map[string]bool{},
+	}
+}
+
+// Load returns the settings from the file at path, if path isn't empty, with
+// the environment's overrides applied, and validates them. The file's format
+// follows its extension: .json, .yaml or .yml, or .toml.
+func Load(path string) (*Config, error) {
+	return LoadWith(path, nil)
+}
+
+// LoadWith is Load with override, if not nil, called after the environment is
+// applied and before validation. cmd/server passes its command-line flags that
+// way, so they win over both the file and the environment.
+func LoadWith(path string, override func(*Config)) (*Config, error) {
+	return load(path, os.Environ(), override)
+}
+
+// load is LoadWith with the environment given as "KEY=value" pairs.
+func load(path string, environ []string, override func(*Config)) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if err := cfg.decode(data, filepath.Ext(path)); err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(environ); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if override != nil {
+		override(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decode reads the file contents over the settings. YAML and TOML are parsed
+// into the same maps JSON would be, so one set of struct tags serves all
+// three, and a misspelled key is an error in each.
+func (c *Config) decode(data []byte, ext string) error {
+	switch strings.ToLower(ext) {
+	case ".json":
+	case ".yaml", ".yml":
+		doc, err := parseYAML(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	case ".toml":
+		doc, err := parseTOML(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q, want .json, .yaml, .yml, or .toml", ext)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(c)
+}
+
+// Validate reports every invalid setting, each prefixed with its path.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("config: %s: "+format, append([]any{key}, args...)...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr", "%v", err)
+	}
+	for _, d := range []struct {
+		key   string
+		value Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.bind_retry", c.Server.BindRetry},
+		{"log.access.rotate_every", c.Log.Access.RotateEvery},
+		{"database.conn_max_lifetime", c.DB.ConnMaxLifetime},
+		{"database.conn_max_idle_time", c.DB.ConnMaxIdleTime},
+		{"auth.access_ttl", c.Auth.AccessTTL},
+		{"auth.refresh_ttl", c.Auth.RefreshTTL},
+	} {
+		if d.value < 0 {
+			invalid(d.key, "must not be negative")
+		}
+	}
+	if c.Server.MaxConnections < 0 {
+		invalid("server.max_connections", "must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("server.shutdown_timeout", "must be positive")
+	}
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		invalid("tls", "cert and key must be set together")
+	}
+	if c.TLS.Cert != "" && len(c.ACME.Hosts) > 0 {
+		invalid("tls", "cert and acme.hosts are mutually exclusive")
+	}
+	if c.TLS.RedirectAddr != "" {
+		if _, _, err := net.SplitHostPort(c.TLS.RedirectAddr); err != nil {
+			invalid("tls.redirect_addr", "%v", err)
+		} else if !c.HTTPS() {
+			invalid("tls.redirect_addr", "needs tls.cert or acme.hosts")
+		}
+	}
+	if !slices.Contains(tlsPolicies, c.TLS.Policy) {
+		invalid("tls.policy", "%q isn't one of %s", c.TLS.Policy, strings.Join(tlsPolicies, ", "))
+	}
+	for _, h := range c.ACME.Hosts {
+		if h == "" || strings.ContainsAny(h, "/: ") {
+			invalid("acme.hosts", "invalid host name %q", h)
+		}
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		invalid("log.format", "%q isn't one of %s", c.Log.Format, strings.Join(logFormats, ", "))
+	}
+	if !slices.Contains(logFormats, c.Log.Access.Format) {
+		invalid("log.access.format", "%q isn't one of %s", c.Log.Access.Format, strings.Join(logFormats, ", "))
+	}
+	for _, n := range []struct {
+		key   string
+		value int
+	}{
+		{"log.access.max_size_mb", c.Log.Access.MaxSizeMB},
+		{"log.access.max_backups", c.Log.Access.MaxBackups},
+		{"database.max_open_conns", c.DB.MaxOpenConns},
+		{"database.max_idle_conns", c.DB.MaxIdleConns},
+	} {
+		if n.value < 0 {
+			invalid(n.key, "must not be negative")
+		}
+	}
+	if c.DB.Driver != "" && !slices.Contains(dbDrivers, c.DB.Driver) {
+		invalid("database.driver", "%q isn't one of %s", c.DB.Driver, strings.Join(dbDrivers, ", "))
+	}
+	if (c.DB.Driver == "") != (c.DB.DSN == "") {
+		invalid("database", "driver and dsn must be set together")
+	}
+	if c.Admin.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Admin.Addr); err != nil {
+			invalid("admin.addr", "%v", err)
+		}
+	}
+	if c.Admin.DebugToken != "" && len(c.Admin.DebugToken) < minTokenLength {
+		invalid("admin.debug_token", "must be at least %d characters", minTokenLength)
+	}
+	if c.Auth.Secret != "" && len(c.Auth.Secret) < minSecretLength {
+		invalid("auth.secret", "must be at least %d characters", minSecretLength)
+	}
+	for _, id := range c.Auth.AdminIDs {
+		if id <= 0 {
+			invalid("auth.admin_ids", "must be user IDs, not %d", id)
+		}
+	}
+	if c.Log.Sample.Rate < 0 || c.Log.Sample.Rate > 1 {
+		invalid("log.sample.rate", "must be between 0 and 1")
+	}
+	if c.Log.Sample.Token != "" && len(c.Log.Sample.Token) < minTokenLength {
+		invalid("log.sample.token", "must be at least %d characters", minTokenLength)
+	}
+	if !slices.Contains(logLevels, c.Log.Level) {
+		invalid("log.level", "%q isn't one of %s", c.Log.Level, strings.Join(logLevels, ", "))
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPS reports whether the server serves HTTPS, from files or ACME.
+func (c *Config) HTTPS() bool {
+	return c.TLS.Cert != "" || len(c.ACME.Hosts) > 0
+}
+
+// Feature reports whether the named feature flag is on.
+func (c *Config) Feature(name string) bool {
+	return c.Features[strings.ToLower(name)]
+}
@@ -0,0 +1,288 @@
+// Description: This file contains tests for loading the configuration.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a config file into a temporary directory.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoad_Formats tests that the same settings read the same in each format.
+func TestLoad_Formats(t *testing.T) {
+	want := Default()
+	want.Server.Addr = ":8443"
+	want.Server.ShutdownTimeout = Duration(30 * time.Second)
+	want.Server.H2C = true
+	want.TLS = TLSConfig{Cert: "/etc/ssl/a #1.pem", Key: "/etc/ssl/a.key", RedirectAddr: ":80", Policy: "modern"}
+	want.ACME.Email = "ops@example.com"
+	want.Log.Level = "debug"
+	want.Log.Access.Path = "/var/log/app/access.log"
+	want.Log.Access.RotateEvery = Duration(24 * time.Hour)
+	want.Log.Access.Compress = true
+	want.Log.Sample.Rate = 0.01
+	want.Features = map[string]bool{"beta_users": true, "dark_mode": false}
+
+	tests := []struct {
+		name, file string
+	}{
+		{name: "server.json", file: `{
+  "server": {"addr": ":8443", "shutdown_timeout": "30s", "h2c": true},
+  "tls": {"cert": "/etc/ssl/a #1.pem", "key": "/etc/ssl/a.key", "redirect_addr": ":80", "policy": "modern"},
+  "acme": {"email": "ops@example.com"},
+  "log": {"level": "debug", "access": {"path": "/var/log/app/access.log", "rotate_every": "24h", "compress": true}, "sample": {"rate": 0.01}},
+  "features": {"beta_users": true, "dark_mode": false}
+}`},
+		{name: "server.yaml", file: `# Production settings.
+---
+server:
+  addr: ":8443"
+  shutdown_timeout: 30s   # drain for longer
+  h2c: true
+tls:
+  cert: "/etc/ssl/a #1.pem"
+  key: '/etc/ssl/a.key'
+  redirect_addr: ":80"
+  policy: modern
+acme:
+  email: ops@example.com
+log:
+  level: debug
+  access:
+    path: /var/log/app/access.log
+    rotate_every: 24h
+    compress: true
+  sample:
+    rate: 0.01
+features:
+  beta_users: true
+  dark_mode: false
+`},
+		{name: "server.toml", file: `# Production settings.
+log.level = "debug"
+
+[server]
+addr = ":8443"
+shutdown_timeout = "30s" # drain for longer
+h2c = true
+
+[tls]
+cert = "/etc/ssl/a #1.pem"
+key = '/etc/ssl/a.key'
+redirect_addr = ":80"
+policy = "modern"
+
+[acme]
+email = "ops@example.com"
+
+[log.access]
+path = "/var/log/app/access.log"
+rotate_every = "24h"
+compress = true
+
+[log.sample]
+rate = 0.01
+
+[features]
+beta_users = true
+dark_mode = false
+`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			path := writeFile(t, tc.name, tc.file)
+
+			// 2. Execute
+			cfg, err := load(path, nil, nil)
+
+			// 3. Assert
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("got %+v\nwant %+v", cfg, want)
+			}
+		})
+	}
+}
+
+// TestLoad_Lists tests the list syntaxes.
+func TestLoad_Lists(t *testing.T) {
+	want := []string{"example.com", "www.example.com"}
+	tests := []struct {
+		name, file string
+	}{
+		{name: "block.yaml", file: "acme:\n  hosts:\n    - example.com\n    - www.example.com\n"},
+		{name: "unindented.yaml", file: "acme:\n  hosts:\n  - example.com\n  - www.example.com\n"},
+		{name: "flow.yaml", file: "acme:\n  hosts: [example.com, \"www.example.com\"]\n"},
+		{name: "multiline.toml", file: "[acme]\nhosts = [\n  \"example.com\", # apex\n  \"www.example.com\",\n]\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := load(writeFile(t, tc.name, tc.file), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.ACME.Hosts, want) {
+				t.Errorf("got %q, want %q", cfg.ACME.Hosts, want)
+			}
+		})
+	}
+}
+
+// TestLoad_Env tests the environment variable overrides.
+func TestLoad_Env(t *testing.T) {
+	// 1. Setup
+	path := writeFile(t, "server.yaml", "server:\n  addr: \":8443\"\nfeatures:\n  beta_users: true\n")
+	environ := []string{
+		"HTTPGOLANG_SERVER_ADDR=:9443",
+		"HTTPGOLANG_SERVER_READ_TIMEOUT=2s",
+		"HTTPGOLANG_SERVER_H2C=true",
+		"HTTPGOLANG_SERVER_MAX_CONNECTIONS=512",
+		"HTTPGOLANG_LOG_SAMPLE_RATE=0.05",
+		"HTTPGOLANG_DATABASE_DRIVER=pgx",
+		"HTTPGOLANG_DATABASE_DSN=postgres://app:secret@db/app",
+		"HTTPGOLANG_ACME_HOSTS=example.com, www.example.com",
+		"HTTPGOLANG_AUTH_ADMIN_IDS=1, 7",
+		"HTTPGOLANG_FEATURES_BETA_USERS=false",
+		"HTTPGOLANG_FEATURES_NEW_CHECKOUT=1",
+		"PATH=/usr/bin",
+	}
+
+	// 2. Execute
+	cfg, err := load(path, environ, nil)
+
+	// 3. Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":9443" || cfg.Server.ReadTimeout != Duration(2*time.Second) || !cfg.Server.H2C || cfg.Server.MaxConnections != 512 {
+		t.Errorf("server settings not overridden: %+v", cfg.Server)
+	}
+	if cfg.DB.Driver != "pgx" || cfg.DB.DSN != "postgres://app:secret@db/app" {
+		t.Errorf("got database settings %+v", cfg.DB)
+	}
+	if cfg.Log.Sample.Rate != 0.05 {
+		t.Errorf("got sample rate %v", cfg.Log.Sample.Rate)
+	}
+	if cfg.DB.Driver != "pgx" || cfg.DB.DSN != "postgres://app:secret@db/app" {
+		t.Errorf("got database settings %+v", cfg.DB)
+	}
+	if cfg.Log.Sample.Rate != 0.05 {
+		t.Errorf("got sample rate %v", cfg.Log.Sample.Rate)
+	}
+	if !reflect.DeepEqual(cfg.ACME.Hosts, []string{"example.com", "www.example.com"}) {
+		t.Errorf("got hosts %q", cfg.ACME.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.Auth.AdminIDs, []int{1, 7}) {
+		t.Errorf("got admin IDs %v", cfg.Auth.AdminIDs)
+	}
+	if cfg.Feature("beta_users") || !cfg.Feature("NEW_CHECKOUT") {
+		t.Errorf("got features %v", cfg.Features)
+	}
+	// An override, as from a command-line flag, wins.
+	cfg, err = load(path, environ, func(c *Config) { c.Server.Addr = ":10443" })
+	if err != nil || cfg.Server.Addr != ":10443" {
+		t.Errorf("override not applied: %v, %v", cfg, err)
+	}
+	if _, err := load("", []string{"HTTPGOLANG_SERVER_H2C=maybe"}, nil); err == nil || !strings.Contains(err.Error(), "HTTPGOLANG_SERVER_H2C") {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+}
+
+// TestLoad_Errors tests files that can't be loaded.
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name, file, wantErr string
+	}{
+		{name: "unknown.json", file: `{"server": {"adress": ":80"}}`, wantErr: `unknown field "adress"`},
+		{name: "type.json", file: `{"server": {"h2c": "yes"}}`, wantErr: "h2c"},
+		{name: "duration.yaml", file: "server:\n  read_timeout: soon\n", wantErr: "soon"},
+		{name: "indent.yaml", file: "server:\n    addr: \":80\"\n  h2c: true\n", wantErr: "line 3"},
+		{name: "tabs.yaml", file: "server:\n\taddr: \":80\"\n", wantErr: "line 2: tabs"},
+		{name: "duplicate.yaml", file: "log:\n  level: info\n  level: debug\n", wantErr: `line 3: duplicate key "level"`},
+		{name: "anchor.yaml", file: "log: &x\n  level: info\n", wantErr: "line 1: unsupported"},
+		{name: "list.yaml", file: "- a\n", wantErr: "must be a mapping"},
+		{name: "value.toml", file: "[log]\nlevel = info\n", wantErr: "line 2: unsupported value"},
+		{name: "duplicate.toml", file: "[log]\nlevel = \"info\"\nlevel = \"debug\"\n", wantErr: "line 3: duplicate"},
+		{name: "server.ini", file: "", wantErr: "unknown format"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(writeFile(t, tc.name, tc.file), nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if _, err := load(filepath.Join(t.TempDir(), "missing.json"), nil, nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+// TestValidate tests the checks on the settings.
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{name: "bad addr", modify: func(c *Config) { c.Server.Addr = "8080" }, wantErr: "server.addr"},
+		{name: "negative timeout", modify: func(c *Config) { c.Server.IdleTimeout = -1 }, wantErr: "server.idle_timeout"},
+		{name: "negative bind retry", modify: func(c *Config) { c.Server.BindRetry = -1 }, wantErr: "server.bind_retry"},
+		{name: "negative connection limit", modify: func(c *Config) { c.Server.MaxConnections = -1 }, wantErr: "server.max_connections"},
+		{name: "no shutdown timeout", modify: func(c *Config) { c.Server.ShutdownTimeout = 0 }, wantErr: "server.shutdown_timeout"},
+		{name: "cert without key", modify: func(c *Config) { c.TLS.Cert = "a.pem" }, wantErr: "set together"},
+		{name: "cert and acme", modify: func(c *Config) {
+			c.TLS.Cert, c.TLS.Key, c.ACME.Hosts = "a.pem", "a.key", []string{"example.com"}
+		}, wantErr: "mutually exclusive"},
+		{name: "redirect without https", modify: func(c *Config) { c.TLS.RedirectAddr = ":80" }, wantErr: "tls.redirect_addr"},
+		{name: "bad tls policy", modify: func(c *Config) { c.TLS.Policy = "old" }, wantErr: "tls.policy"},
+		{name: "bad host", modify: func(c *Config) { c.ACME.Hosts = []string{"example.com:443"} }, wantErr: "acme.hosts"},
+		{name: "bad admin addr", modify: func(c *Config) { c.Admin.Addr = "9090" }, wantErr: "admin.addr"},
+		{name: "short debug token", modify: func(c *Config) { c.Admin.DebugToken = "hunter2" }, wantErr: "admin.debug_token"},
+		{name: "bad access log format", modify: func(c *Config) { c.Log.Access.Format = "apache" }, wantErr: "log.access.format"},
+		{name: "negative access log backups", modify: func(c *Config) { c.Log.Access.MaxBackups = -1 }, wantErr: "log.access.max_backups"},
+		{name: "sample rate above 1", modify: func(c *Config) { c.Log.Sample.Rate = 1.5 }, wantErr: "log.sample.rate"},
+		{name: "short sample token", modify: func(c *Config) { c.Log.Sample.Token = "hunter2" }, wantErr: "log.sample.token"},
+		{name: "bad database driver", modify: func(c *Config) { c.DB.Driver, c.DB.DSN = "oracle", "db" }, wantErr: "database.driver"},
+		{name: "database driver without dsn", modify: func(c *Config) { c.DB.Driver = "sqlite" }, wantErr: "set together"},
+		{name: "negative pool size", modify: func(c *Config) { c.DB.MaxOpenConns = -1 }, wantErr: "database.max_open_conns"},
+		{name: "short auth secret", modify: func(c *Config) { c.Auth.Secret = "hunter2" }, wantErr: "auth.secret"},
+		{name: "bad admin ID", modify: func(c *Config) { c.Auth.AdminIDs = []int{0} }, wantErr: "auth.admin_ids"},
+		{name: "negative access ttl", modify: func(c *Config) { c.Auth.AccessTTL = -1 }, wantErr: "auth.access_ttl"},
+		{name: "bad log format", modify: func(c *Config) { c.Log.Format = "xml" }, wantErr: "log.format"},
+		{name: "bad level", modify: func(c *Config) { c.Log.Level = "verbose" }, wantErr: "log.level"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default()
+			tc.modify(cfg)
+			err := cfg.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
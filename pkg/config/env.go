@@ -0,0 +1,160 @@
+// Description: This file applies the environment variable overrides. Each
+// setting's variable is EnvPrefix plus its path in the file, so server.addr
+// is HTTPGOLANG_SERVER_ADDR. Lists are comma-separated, and every
+// HTTPGOLANG_FEATURES_<NAME> variable sets a feature flag.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is a configurable value and its path in the file.
+type setting struct {
+	key   string
+	value any // a pointer into the Config
+}
+
+// settings returns every setting but the feature flags.
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.addr", &c.Server.Addr},
+		{"server.read_timeout", &c.Server.ReadTimeout},
+		{"server.write_timeout", &c.Server.WriteTimeout},
+		{"server.idle_timeout", &c.Server.IdleTimeout},
+		{"server.shutdown_timeout", &c.Server.ShutdownTimeout},
+		{"server.bind_retry", &c.Server.BindRetry},
+		{"server.max_connections", &c.Server.MaxConnections},
+		{"server.h2c", &c.Server.H2C},
+		{"tls.cert", &c.TLS.Cert},
+		{"tls.key", &c.TLS.Key},
+		{"tls.redirect_addr", &c.TLS.RedirectAddr},
+		{"tls.policy", &c.TLS.Policy},
+		{"acme.hosts", &c.ACME.Hosts},
+		{"acme.email", &c.ACME.Email},
+		{"acme.cache", &c.ACME.Cache},
+		{"log.level", &c.Log.Level},
+		{"log.format", &c.Log.Format},
+		{"log.access.path", &c.Log.Access.Path},
+		{"log.access.format", &c.Log.Access.Format},
+		{"log.access.max_size_mb", &c.Log.Access.MaxSizeMB},
+		{"log.access.rotate_every", &c.Log.Access.RotateEvery},
+		{"log.access.max_backups", &c.Log.Access.MaxBackups},
+		{"log.access.compress", &c.Log.Access.Compress},
+		{"log.sample.rate", &c.Log.Sample.Rate},
+		{"log.sample.token", &c.Log.Sample.Token},
+		{"admin.addr", &c.Admin.Addr},
+		{"admin.debug_token", &c.Admin.DebugToken},
+		{"database.driver", &c.DB.Driver},
+		{"database.dsn", &c.DB.DSN},
+		{"database.max_open_conns", &c.DB.MaxOpenConns},
+		{"database.max_idle_conns", &c.DB.MaxIdleConns},
+		{"database.conn_max_lifetime", &c.DB.ConnMaxLifetime},
+		{"database.conn_max_idle_time", &c.DB.ConnMaxIdleTime},
+		{"auth.secret", &c.Auth.Secret},
+		{"auth.access_ttl", &c.Auth.AccessTTL},
+		{"auth.refresh_ttl", &c.Auth.RefreshTTL},
+		{"auth.admin_ids", &c.Auth.AdminIDs},
+		{"blobs.dir", &c.Blobs.Dir},
+	}
+}
+
+// EnvName returns the environment variable overriding the setting at key,
+// e.g. "server.addr".
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnv sets the settings named by the "KEY=value" pairs in environ.
+func (c *Config) applyEnv(environ []string) error {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, EnvPrefix) {
+			env[k] = v
+		}
+	}
+
+	for _, s := range c.settings() {
+		v, ok := env[EnvName(s.key)]
+		if !ok {
+			continue
+		}
+		if err := set(s.value, v); err != nil {
+			return fmt.Errorf("%s: %w", EnvName(s.key), err)
+		}
+	}
+
+	featurePrefix := EnvName("features") + "_"
+	for k, v := range env {
+		name, ok := strings.CutPrefix(k, featurePrefix)
+		if !ok || name == "" {
+			continue
+		}
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		if c.Features == nil {
+			c.Features = map[string]bool{}
+		}
+		c.Features[strings.ToLower(name)] = on
+	}
+	return nil
+}
+
+// set parses v into the setting p points to.
+func set(p any, v string) error {
+	switch p := p.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = Duration(d)
+	case *[]string:
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+	case *[]int:
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			n, err := strconv.Atoi(item)
+			if err != nil {
+				return err
+			}
+			*p = append(*p, n)
+		}
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %T", p))
+	}
+	return nil
+}
@@ -0,0 +1,452 @@
+// Description: This file parses YAML and TOML configuration files, using only
+// the standard library. Both are turned into the maps and slices JSON would
+// decode to, and covered only as far as configuration files need:
+//
+//   - YAML: block mappings and lists nested by indentation, flow lists like
+//     [a, b], plain and quoted scalars, and comments. Anchors, tags, flow
+//     mappings, and multi-line strings are rejected.
+//   - TOML: [tables], key = value pairs with dotted keys, strings, numbers,
+//     booleans, and arrays, which may span lines. Arrays of tables, inline
+//     tables, and dates are rejected.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of a YAML document with content.
+type yamlLine struct {
+	num    int // 1-based, for errors
+	indent int
+	text   string // without the indentation and any comment
+}
+
+// yamlParser reads the block structure from the lines.
+type yamlParser struct {
+	lines []yamlLine
+	i     int // the next line to read
+}
+
+// parseYAML parses a YAML document whose top level is a mapping.
+func parseYAML(data []byte) (map[string]any, error) {
+	var lines []yamlLine
+	for n, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(stripComment(strings.TrimSuffix(raw, "\r")), " \t")
+		text := strings.TrimLeft(raw, " \t")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.Contains(raw[:len(raw)-len(text)], "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't be used for indentation", n+1)
+		}
+		lines = append(lines, yamlLine{num: n + 1, indent: len(raw) - len(text), text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	if isListItem(lines[0].text) {
+		return nil, fmt.Errorf("line %d: the document must be a mapping", lines[0].num)
+	}
+	doc, err := p.mapping(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.i].num)
+	}
+	return doc, nil
+}
+
+// block parses the mapping or list starting at the current line.
+func (p *yamlParser) block() (any, error) {
+	l := p.lines[p.i]
+	if isListItem(l.text) {
+		return p.list(l.indent)
+	}
+	return p.mapping(l.indent)
+}
+
+// mapping parses "key: value" lines at indent.
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent {
+		l := p.lines[p.i]
+		if isListItem(l.text) {
+			return nil, fmt.Errorf("line %d: expected a key, got a list item", l.num)
+		}
+		key, rest, ok := cutKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", l.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.num, key)
+		}
+		p.i++
+
+		var (
+			v   any
+			err error
+		)
+		switch {
+		case rest != "":
+			if v, err = yamlScalar(rest); err != nil {
+				err = fmt.Errorf("line %d: %w", l.num, err)
+			}
+		case p.i < len(p.lines) && p.lines[p.i].indent > indent:
+			v, err = p.block()
+		case p.i < len(p.lines) && p.lines[p.i].indent == indent && isListItem(p.lines[p.i].text):
+			// A list may sit at its key's indentation.
+			v, err = p.list(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// list parses "- item" lines at indent.
+func (p *yamlParser) list(indent int) ([]any, error) {
+	items := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isListItem(p.lines[p.i].text) {
+		l := p.lines[p.i]
+		rest := strings.TrimLeft(l.text[1:], " ")
+		var (
+			v   any
+			err error
+		)
+		switch {
+		case rest == "":
+			// A bare "-" has its item on the lines below, if any.
+			p.i++
+			if p.i < len(p.lines) && p.lines[p.i].indent > indent {
+				v, err = p.block()
+			}
+		case startsMapping(rest):
+			// "- name: a" starts a mapping item, whose other keys are
+			// indented to line up with name.
+			p.lines[p.i] = yamlLine{num: l.num, indent: indent + len(l.text) - len(rest), text: rest}
+			v, err = p.mapping(p.lines[p.i].indent)
+		default:
+			p.i++
+			if v, err = yamlScalar(rest); err != nil {
+				err = fmt.Errorf("line %d: %w", l.num, err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+// isListItem reports whether text starts a list item.
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// startsMapping reports whether a list item's text, "name: a", starts a
+// mapping. Quoted and flow values are scalars even if they hold ": ".
+func startsMapping(text string) bool {
+	_, _, isKey := cutKey(text)
+	return isKey && !strings.ContainsAny(text[:1], `"'[`)
+}
+
+// cutKey splits "key: value" (or "key:") into the key and the value text.
+func cutKey(text string) (key, rest string, ok bool) {
+	if text == "" {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+			return "", "", false
+		}
+		key, rest = text[1:end+1], text[end+3:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest), true
+	}
+	if strings.HasSuffix(text, ":") {
+		return text[:len(text)-1], "", !strings.Contains(text[:len(text)-1], ": ")
+	}
+	key, rest, ok = strings.Cut(text, ": ")
+	return key, strings.TrimSpace(rest), ok
+}
+
+// yamlScalar parses a value written on one line.
+func yamlScalar(s string) (any, error) {
+	switch {
+	case s[0] == '"':
+		return strconv.Unquote(s)
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, errors.New("unterminated string")
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[':
+		items, err := splitFlow(s)
+		if err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for _, item := range items {
+			v, err := yamlScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case strings.ContainsAny(s[:1], "{&*!|>%@`"):
+		return nil, fmt.Errorf("unsupported YAML syntax %q", s)
+	}
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// parseTOML parses a TOML document.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(stripComment(strings.TrimSuffix(lines[i], "\r")))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "[["):
+			return nil, fmt.Errorf("line %d: arrays of tables aren't supported", num)
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", num)
+			}
+			path, err := splitKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			if table, err = subtable(root, path); err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", num)
+		}
+		v = strings.TrimSpace(v)
+		// An array may continue over the following lines.
+		for strings.HasPrefix(v, "[") && !balanced(v) && i+1 < len(lines) {
+			i++
+			v += " " + strings.TrimSpace(stripComment(strings.TrimSuffix(lines[i], "\r")))
+		}
+		path, err := splitKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		value, err := tomlValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		parent, err := subtable(table, path[:len(path)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		key := path[len(path)-1]
+		if _, dup := parent[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", num, key)
+		}
+		parent[key] = value
+	}
+	return root, nil
+}
+
+// subtable returns the table at path under t, creating missing ones.
+func subtable(t map[string]any, path []string) (map[string]any, error) {
+	for _, key := range path {
+		switch next := t[key].(type) {
+		case nil:
+			sub := map[string]any{}
+			t[key] = sub
+			t = sub
+		case map[string]any:
+			t = next
+		default:
+			return nil, fmt.Errorf("key %q is already a value", key)
+		}
+	}
+	return t, nil
+}
+
+// splitKey splits a dotted TOML key like a.b or "a.b".c into its parts.
+func splitKey(s string) ([]string, error) {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSpace(s), ".") {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') && part[len(part)-1] == part[0] {
+			part = part[1 : len(part)-1]
+		} else if part == "" || strings.ContainsAny(part, " \"'") {
+			return nil, fmt.Errorf("invalid key %q", s)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// tomlValue parses a TOML value.
+func tomlValue(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, errors.New("missing value")
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return nil, errors.New("multi-line strings aren't supported")
+	case s[0] == '"':
+		return strconv.Unquote(s)
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, errors.New("unterminated string")
+		}
+		return s[1 : len(s)-1], nil
+	case s[0] == '[':
+		items, err := splitFlow(s)
+		if err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for _, item := range items {
+			v, err := tomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case s[0] == '{':
+		return nil, errors.New("inline tables aren't supported")
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	}
+	n := strings.ReplaceAll(s, "_", "")
+	if i, err := strconv.ParseInt(n, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(n, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %q", s)
+}
+
+// stripComment removes a # comment, unless the # is inside quotes. In YAML a
+// comment must follow a space, which the TOML files we support also do.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// balanced reports whether the brackets outside quotes in s are closed.
+func balanced(s string) bool {
+	depth, quote := 0, byte(0)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// splitFlow splits a bracketed list like [a, "b, c", [d]] into its item
+// texts. A trailing comma is allowed.
+func splitFlow(s string) ([]string, error) {
+	if !strings.HasSuffix(s, "]") || !balanced(s) {
+		return nil, errors.New("unterminated list")
+	}
+	inner := s[1 : len(s)-1]
+	var items []string
+	var item bytes.Buffer
+	depth, quote := 0, byte(0)
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(inner) {
+				item.WriteByte(c)
+				i++
+				c = inner[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			if text := strings.TrimSpace(item.String()); text != "" {
+				items = append(items, text)
+			} else {
+				return nil, errors.New("empty list item")
+			}
+			item.Reset()
+			continue
+		}
+		item.WriteByte(c)
+	}
+	if text := strings.TrimSpace(item.String()); text != "" {
+		items = append(items, text)
+	}
+	return items, nil
+}
@@ -0,0 +1,57 @@
+// Description: This file contains tests for the configuration file parsers.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseYAML_BareListItems tests "-" items whose value is on the lines
+// below, or missing.
+func TestParseYAML_BareListItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "nested mapping", file: "hosts:\n  -\n    name: a\n",
+			want: map[string]any{"hosts": []any{map[string]any{"name": "a"}}}},
+		{name: "empty item", file: "hosts:\n  -\n  - b\n",
+			want: map[string]any{"hosts": []any{nil, "b"}}},
+		{name: "last line", file: "hosts:\n  -\n",
+			want: map[string]any{"hosts": []any{nil}}},
+		{name: "bare scalar below", file: "auth:\n  admins:\n    -\n      x\n", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tc.file))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v (%v), want %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+// FuzzParseYAML checks that no file, however malformed, crashes the parser.
+func FuzzParseYAML(f *testing.F) {
+	for _, seed := range []string{
+		"server:\n  addr: \":8080\"\n",
+		"acme:\n  hosts:\n    - example.com\n    - 'www.example.com'\n",
+		"auth:\n  admins:\n    -\n      x\n",
+		"-\n- - a\n- \"k\": v\n",
+		"list: [a, \"b, c\"]\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, file string) {
+		parseYAML([]byte(file))
+	})
+}
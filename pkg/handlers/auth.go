@@ -0,0 +1,309 @@
+// Description: This file contains the handlers of password logins:
+//
+//	POST /auth/register  {name, email, password}  creates a user
+//	POST /auth/login     {email, password}        returns a token pair
+//	POST /auth/refresh   {refresh_token}          returns a new pair
+//	POST /auth/logout    {refresh_token}          revokes the login
+//
+// A token pair is a short-lived access token, for the Authorization
+// header (see auth.Tokens.RequireAccess), and a refresh token. Refreshing
+// rotates the refresh token: the old one is spent and the new one joins
+// the login's family. Presenting a spent token means two clients hold it,
+// one of them a thief, so the whole family is revoked and both have to
+// log in again.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/auth"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// RegisterAuthRoutes registers the /auth endpoints. The users are created
+// in users, their credentials and refresh tokens kept in accounts. For
+// deleted users to lose their logins, users should be
+// store.WithAccounts(users, accounts), here and for RegisterRoutes.
+func RegisterAuthRoutes(r *router.Router, users store.UserStore, accounts store.AuthStore, tokens *auth.Tokens) {
+	a := NewAuthHandlers(users, accounts, tokens)
+	r.POST("/auth/register", a.Register)
+	r.POST("/auth/login", a.Login)
+	r.POST("/auth/refresh", a.Refresh)
+	r.POST("/auth/logout", a.Logout)
+}
+
+// AuthHandlers handles the /auth endpoints.
+type AuthHandlers struct {
+	users    store.UserStore
+	accounts store.AuthStore
+	tokens   *auth.Tokens
+}
+
+// NewAuthHandlers returns the handlers of the logins of the users in users.
+func NewAuthHandlers(users store.UserStore, accounts store.AuthStore, tokens *auth.Tokens) *AuthHandlers {
+	return &AuthHandlers{users: users, accounts: accounts, tokens: tokens}
+}
+
+// Authenticate returns middleware that records the caller of requests with
+// a valid access token as a middleware.Principal, for middleware.RBAC. The
+// roles are looked up on every request, so a change applies at once rather
+// than when the token expires. The users with the IDs in admins are admins
+// whatever their stored role. Other requests carry on anonymously.
+//
+// admins is how the first admins are made, since registering never makes
+// one: an email isn't proof of who registered it. The IDs must be of
+// existing users (see CheckAdmins), or the next to register would get one.
+func Authenticate(users store.UserStore, tokens *auth.Tokens, admins []int) router.Middleware {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(c *httpcontext.Context) {
+			token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok {
+				next(c)
+				return
+			}
+			claims, err := tokens.VerifyAccess(strings.TrimSpace(token))
+			if err != nil {
+				next(c)
+				return
+			}
+			id, err := strconv.Atoi(claims.Subject)
+			if err != nil {
+				next(c)
+				return
+			}
+			u, err := users.Get(c.Request.Context(), id)
+			if err != nil {
+				// A deleted user's tokens are worth nothing.
+				next(c)
+				return
+			}
+			p := &middleware.Principal{ID: claims.Subject, Permissions: []string{PermUpdateUsers, PermSetAvatar}}
+			switch {
+			case slices.Contains(admins, id):
+				p.Roles = []string{store.RoleAdmin}
+			case u.Role != "":
+				p.Roles = []string{u.Role}
+			}
+			middleware.SetPrincipal(c, p)
+			next(c)
+		}
+	}
+}
+
+// CheckAdmins returns an error unless every ID in admins, the admins for
+// Authenticate, is of an existing user. It's meant to be called at startup.
+func CheckAdmins(ctx context.Context, users store.UserStore, admins []int) error {
+	for _, id := range admins {
+		if _, err := users.Get(ctx, id); err != nil {
+			return fmt.Errorf("admin user %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// registerRequest is the body of POST /auth/register.
+type registerRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,max=1024"`
+}
+
+// loginRequest is the body of POST /auth/login.
+type loginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// refreshRequest is the body of POST /auth/refresh and /auth/logout.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// tokenPair is the data of a successful login or refresh, shaped like an
+// OAuth 2.0 token response (RFC 6749, section 5.1).
+type tokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
+	RefreshToken string `json:"refresh_token"`
+}
+
+var (
+	errEmailTaken         = errors.New("email already registered")
+	errBadLogin           = errors.New("invalid email or password")
+	errBadRefreshToken    = errors.New("invalid or expired refresh token")
+	errReusedRefreshToken = errors.New("refresh token already used; log in again")
+)
+
+// dummyHash is checked against on logins with an unknown email, so they
+// take as long as those with a wrong password and don't reveal which
+// emails are registered.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("not a password")
+	return hash
+})
+
+// Register handles requests to create a user with a password. It answers
+// 201 Created with the new user, or 409 Conflict if the email is taken.
+// Emails are compared in lower case. New users have no role.
+func (h *AuthHandlers) Register(c *httpcontext.Context) {
+	var req registerRequest
+	if err := c.BindJSON(&req); err != nil {
+		failUser(c, err)
+		return
+	}
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	email := strings.ToLower(req.Email)
+	ctx := c.Request.Context()
+	u, err := h.users.Create(ctx, User{Name: req.Name})
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	err = h.accounts.CreateCredentials(ctx, store.Credentials{UserID: u.ID, Email: email, PasswordHash: hash})
+	if err != nil {
+		// The user is only created to get its ID; take it back.
+		if err := h.users.Delete(ctx, u.ID); err != nil {
+			c.Logger().Error("Removing the user of a failed registration failed", "user", u.ID, "err", err)
+		}
+		if errors.Is(err, store.ErrExists) {
+			c.Fail(http.StatusConflict, errEmailTaken)
+			return
+		}
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	c.SetHeader("Location", "/users/"+strconv.Itoa(u.ID))
+	c.Respond(http.StatusCreated, u, nil)
+}
+
+// Login handles requests to log in with an email and password. It answers
+// with a token pair, or 401 Unauthorized, whether the email or the password
+// is wrong.
+func (h *AuthHandlers) Login(c *httpcontext.Context) {
+	var req loginRequest
+	if err := c.BindJSON(&req); err != nil {
+		failUser(c, err)
+		return
+	}
+	creds, err := h.accounts.CredentialsByEmail(c.Request.Context(), strings.ToLower(req.Email))
+	if errors.Is(err, store.ErrNotFound) {
+		auth.CheckPassword(dummyHash(), req.Password)
+		c.Fail(http.StatusUnauthorized, errBadLogin)
+		return
+	}
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	if !auth.CheckPassword(creds.PasswordHash, req.Password) {
+		c.Fail(http.StatusUnauthorized, errBadLogin)
+		return
+	}
+	h.issue(c, creds.UserID, "")
+}
+
+// Refresh handles requests to trade a refresh token for a new token pair.
+// Each refresh token works once; using one again revokes its login.
+func (h *AuthHandlers) Refresh(c *httpcontext.Context) {
+	var req refreshRequest
+	if err := c.BindJSON(&req); err != nil {
+		failUser(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	t, err := h.accounts.UseRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+	switch {
+	case errors.Is(err, store.ErrTokenUsed):
+		c.Logger().Warn("Refresh token reused; revoking its login", "user", t.UserID)
+		if err := h.accounts.RevokeRefreshTokens(ctx, t.Family); err != nil {
+			c.Fail(http.StatusInternalServerError, err)
+			return
+		}
+		c.Fail(http.StatusUnauthorized, errReusedRefreshToken)
+		return
+	case errors.Is(err, store.ErrNotFound):
+		c.Fail(http.StatusUnauthorized, errBadRefreshToken)
+		return
+	case err != nil:
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	case !time.Now().Before(t.Expires):
+		c.Fail(http.StatusUnauthorized, errBadRefreshToken)
+		return
+	}
+	h.issue(c, t.UserID, t.Family)
+}
+
+// Logout handles requests to end a login: its refresh tokens are revoked,
+// and its access tokens last until they expire. It answers 204 No Content,
+// even for unknown tokens, since the login is ended either way.
+func (h *AuthHandlers) Logout(c *httpcontext.Context) {
+	var req refreshRequest
+	if err := c.BindJSON(&req); err != nil {
+		failUser(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	t, err := h.accounts.UseRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+	if err == nil || errors.Is(err, store.ErrTokenUsed) {
+		err = h.accounts.RevokeRefreshTokens(ctx, t.Family)
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// issue answers with a new token pair for the user. The refresh token
+// joins family, or starts one, named by its hash, if family is empty.
+func (h *AuthHandlers) issue(c *httpcontext.Context, userID int, family string) {
+	access, expires, err := h.tokens.IssueAccess(strconv.Itoa(userID))
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	if family == "" {
+		family = hash
+	}
+	err = h.accounts.SaveRefreshToken(c.Request.Context(), store.RefreshToken{
+		Hash:    hash,
+		UserID:  userID,
+		Family:  family,
+		Expires: time.Now().Add(h.tokens.RefreshTTL()),
+	})
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	// Token responses must not be cached (RFC 6749, section 5.1).
+	c.SetHeader("Cache-Control", "no-store")
+	c.OK(tokenPair{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expires).Round(time.Second).Seconds()),
+		RefreshToken: refresh,
+	})
+}
@@ -0,0 +1,327 @@
+// Description: This file contains tests for the password login handlers.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/auth"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// newAuthRouter returns a router with the /auth endpoints, and Ann
+// registered with the password "correct horse".
+func newAuthRouter(t *testing.T) *router.Router {
+	t.Helper()
+	// Cheap hashes keep the tests fast.
+	n := auth.PasswordIterations
+	t.Cleanup(func() { auth.PasswordIterations = n })
+	auth.PasswordIterations = 1000
+
+	tokens, err := auth.NewTokens(auth.TokenOptions{Secret: []byte(strings.Repeat("s", auth.MinSecretLength))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := router.New()
+	RegisterAuthRoutes(r, store.NewMemoryUserStore(), store.NewMemoryAuthStore(), tokens)
+	if rr := post(r, "/auth/register", `{"name":"Ann","email":"Ann@Example.com","password":"correct horse"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("registering: %d %s", rr.Code, rr.Body)
+	}
+	return r
+}
+
+// post sends a JSON body to path.
+func post(r *router.Router, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// login logs Ann in and returns the token pair.
+func login(t *testing.T, r *router.Router) tokenPair {
+	t.Helper()
+	rr := post(r, "/auth/login", `{"email":"ann@example.com","password":"correct horse"}`)
+	var resp struct{ Data tokenPair }
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("logging in: %d %s", rr.Code, rr.Body)
+	}
+	return resp.Data
+}
+
+// TestRegisterHandler tests creating accounts.
+func TestRegisterHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "register", body: `{"name":"Bob","email":"bob@example.com","password":"12345678"}`,
+			wantStatus: http.StatusCreated, wantBody: `{"data":{"id":2,"name":"Bob","version":1}}`},
+		{name: "email taken", body: `{"name":"Ann 2","email":"ANN@example.com","password":"12345678"}`,
+			wantStatus: http.StatusConflict, wantBody: "email already registered"},
+		{name: "short password", body: `{"name":"Bob","email":"bob@example.com","password":"1234567"}`,
+			wantStatus: http.StatusUnprocessableEntity, wantBody: `"field":"password","rule":"min"`},
+		{name: "bad email", body: `{"name":"Bob","email":"bob","password":"12345678"}`,
+			wantStatus: http.StatusUnprocessableEntity, wantBody: `"field":"email","rule":"email"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			r := newAuthRouter(t)
+
+			// 2. Execute
+			rr := post(r, "/auth/register", tc.body)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+// TestLoginHandler tests logging in with good and bad credentials.
+func TestLoginHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "login", body: `{"email":"ANN@example.com","password":"correct horse"}`,
+			wantStatus: http.StatusOK, wantBody: `"token_type":"Bearer","expires_in":900,"refresh_token":"`},
+		{name: "wrong password", body: `{"email":"ann@example.com","password":"correct horsE"}`,
+			wantStatus: http.StatusUnauthorized, wantBody: "invalid email or password"},
+		{name: "unknown email", body: `{"email":"bob@example.com","password":"correct horse"}`,
+			wantStatus: http.StatusUnauthorized, wantBody: "invalid email or password"},
+		{name: "no password", body: `{"email":"ann@example.com"}`,
+			wantStatus: http.StatusUnprocessableEntity, wantBody: `"field":"password"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			r := newAuthRouter(t)
+
+			// 2. Execute
+			rr := post(r, "/auth/login", tc.body)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+// TestRefreshHandler tests that refresh tokens rotate, and that reusing
+// one revokes its login.
+func TestRefreshHandler(t *testing.T) {
+	// 1. Setup
+	r := newAuthRouter(t)
+	first := login(t, r)
+	other := login(t, r)
+
+	// 2. Execute
+	rotated := post(r, "/auth/refresh", `{"refresh_token":"`+first.RefreshToken+`"}`)
+	var resp struct{ Data tokenPair }
+	json.Unmarshal(rotated.Body.Bytes(), &resp)
+	reused := post(r, "/auth/refresh", `{"refresh_token":"`+first.RefreshToken+`"}`)
+	afterReuse := post(r, "/auth/refresh", `{"refresh_token":"`+resp.Data.RefreshToken+`"}`)
+	otherLogin := post(r, "/auth/refresh", `{"refresh_token":"`+other.RefreshToken+`"}`)
+	unknown := post(r, "/auth/refresh", `{"refresh_token":"nonsense"}`)
+
+	// 3. Assert
+	if rotated.Code != http.StatusOK || resp.Data.RefreshToken == "" || resp.Data.RefreshToken == first.RefreshToken {
+		t.Errorf("expected a new refresh token, got %d %s", rotated.Code, rotated.Body)
+	}
+	if rotated.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected tokens not to be cached")
+	}
+	if reused.Code != http.StatusUnauthorized || !strings.Contains(reused.Body.String(), "already used") {
+		t.Errorf("expected 401 reusing a token, got %d %s", reused.Code, reused.Body)
+	}
+	if afterReuse.Code != http.StatusUnauthorized {
+		t.Errorf("expected the rotated token revoked with its family, got %d", afterReuse.Code)
+	}
+	if otherLogin.Code != http.StatusOK {
+		t.Errorf("expected another login unaffected, got %d %s", otherLogin.Code, otherLogin.Body)
+	}
+	if unknown.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown token, got %d", unknown.Code)
+	}
+}
+
+// TestLogoutHandler tests that logging out revokes the refresh token.
+func TestLogoutHandler(t *testing.T) {
+	// 1. Setup
+	r := newAuthRouter(t)
+	pair := login(t, r)
+
+	// 2. Execute
+	logout := post(r, "/auth/logout", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	again := post(r, "/auth/logout", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	refresh := post(r, "/auth/refresh", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+
+	// 3. Assert
+	if logout.Code != http.StatusNoContent || again.Code != http.StatusNoContent {
+		t.Errorf("expected 204 twice, got %d and %d", logout.Code, again.Code)
+	}
+	if refresh.Code != http.StatusUnauthorized {
+		t.Errorf("expected the token revoked, got %d %s", refresh.Code, refresh.Body)
+	}
+}
+
+// TestAuthenticate tests that only admins may create and delete users once
+// RBAC is installed.
+func TestAuthenticate(t *testing.T) {
+	// 1. Setup: Ann, user 1, is an admin, Bob isn't.
+	n := auth.PasswordIterations
+	t.Cleanup(func() { auth.PasswordIterations = n })
+	auth.PasswordIterations = 1000
+	tokens, _ := auth.NewTokens(auth.TokenOptions{Secret: []byte(strings.Repeat("s", auth.MinSecretLength))})
+	users := store.NewMemoryUserStore()
+	r := router.New()
+	RegisterRoutes(r, users)
+	RegisterAuthRoutes(r, users, store.NewMemoryAuthStore(), tokens)
+	r.Use(Authenticate(users, tokens, []int{1}), middleware.RBAC(middleware.RBACOptions{Roles: UserRoles}))
+	post(r, "/auth/register", `{"name":"Ann","email":"ann@example.com","password":"correct horse"}`)
+	post(r, "/auth/register", `{"name":"Bob","email":"bob@example.com","password":"battery staple"}`)
+	ann := login(t, r)
+	var bob struct{ Data tokenPair }
+	json.Unmarshal(post(r, "/auth/login", `{"email":"bob@example.com","password":"battery staple"}`).Body.Bytes(), &bob)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+		body       string // `{"name":"Cy"}` if empty
+	}{
+		{"admin creates", "POST", "/users", ann.AccessToken, http.StatusCreated, `"name":"Cy"`, ""},
+		{"user creates", "POST", "/users", bob.Data.AccessToken, http.StatusForbidden, "missing permission users:create", ""},
+		{"anonymous creates", "POST", "/users", "", http.StatusUnauthorized, "authentication required", ""},
+		{"bad token", "POST", "/users", ann.AccessToken + "x", http.StatusUnauthorized, "authentication required", ""},
+		{"user deletes", "DELETE", "/users/1", bob.Data.AccessToken, http.StatusForbidden, "users:delete", ""},
+		{"user reads", "GET", "/users/1", bob.Data.AccessToken, http.StatusOK, `"name":"Ann"`, ""},
+		{"anonymous reads", "GET", "/users", "", http.StatusOK, `"name":"Bob"`, ""},
+		{"anonymous replaces", "PUT", "/users/2", "", http.StatusUnauthorized, "authentication required", `{"name":"Eve","version":1}`},
+		{"anonymous patches", "PATCH", "/users/2", "", http.StatusUnauthorized, "authentication required", `{"name":"Eve","version":1}`},
+		{"user replaces another", "PUT", "/users/1", bob.Data.AccessToken, http.StatusForbidden, "only admins may change other users", `{"name":"Eve","version":1}`},
+		{"user patches another", "PATCH", "/users/1", bob.Data.AccessToken, http.StatusForbidden, "only admins may change other users", `{"name":"Eve","version":1}`},
+		{"user patches self", "PATCH", "/users/2", bob.Data.AccessToken, http.StatusOK, `"name":"Bobby"`, `{"name":"Bobby","version":1}`},
+		{"admin replaces another", "PUT", "/users/2", ann.AccessToken, http.StatusOK, `"name":"Robert"`, `{"name":"Robert","version":2}`},
+		{"admin deletes", "DELETE", "/users/2", ann.AccessToken, http.StatusNoContent, "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := tc.body
+			if body == "" {
+				body = `{"name":"Cy"}`
+			}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+// TestDeleteUser_DeletesAccount tests that a deleted user can't log in or
+// refresh any more, and that their email can be registered again.
+func TestDeleteUser_DeletesAccount(t *testing.T) {
+	// 1. Setup: Ann has logged in.
+	n := auth.PasswordIterations
+	t.Cleanup(func() { auth.PasswordIterations = n })
+	auth.PasswordIterations = 1000
+	tokens, _ := auth.NewTokens(auth.TokenOptions{Secret: []byte(strings.Repeat("s", auth.MinSecretLength))})
+	accounts := store.NewMemoryAuthStore()
+	users := store.WithAccounts(store.NewMemoryUserStore(), accounts)
+	r := router.New()
+	RegisterRoutes(r, users)
+	RegisterAuthRoutes(r, users, accounts, tokens)
+	post(r, "/auth/register", `{"name":"Ann","email":"ann@example.com","password":"correct horse"}`)
+	pair := login(t, r)
+
+	// 2. Execute: Delete her.
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/users/1", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("deleting: %d %s", rr.Code, rr.Body)
+	}
+
+	// 3. Assert: Her login is gone, and her email free.
+	if rr := post(r, "/auth/login", `{"email":"ann@example.com","password":"correct horse"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("login: got %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := post(r, "/auth/refresh", `{"refresh_token":"`+pair.RefreshToken+`"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("refresh: got %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := post(r, "/auth/register", `{"name":"Ann","email":"ann@example.com","password":"correct horse"}`); rr.Code != http.StatusCreated {
+		t.Errorf("register: got %d %s, want %d", rr.Code, rr.Body, http.StatusCreated)
+	}
+}
+
+// TestRegisterHandler_NoAdmin tests that registering never makes an admin,
+// whatever the email, and that CheckAdmins refuses IDs of no user.
+func TestRegisterHandler_NoAdmin(t *testing.T) {
+	// 1. Setup: User 2 is to be an admin, once it exists.
+	n := auth.PasswordIterations
+	t.Cleanup(func() { auth.PasswordIterations = n })
+	auth.PasswordIterations = 1000
+	tokens, _ := auth.NewTokens(auth.TokenOptions{Secret: []byte(strings.Repeat("s", auth.MinSecretLength))})
+	users := store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"})
+	admins := []int{2}
+	r := router.New()
+	RegisterRoutes(r, users)
+	RegisterAuthRoutes(r, users, store.NewMemoryAuthStore(), tokens)
+	r.Use(Authenticate(users, tokens, []int{1}), middleware.RBAC(middleware.RBACOptions{Roles: UserRoles}))
+
+	// 2. Execute: Refuse the admin that doesn't exist yet, then register
+	// with an admin-looking email.
+	errMissing := CheckAdmins(context.Background(), users, admins)
+	reg := post(r, "/auth/register", `{"name":"Mallory","email":"admin@example.com","password":"correct horse"}`)
+	var pair struct{ Data tokenPair }
+	json.Unmarshal(post(r, "/auth/login", `{"email":"admin@example.com","password":"correct horse"}`).Body.Bytes(), &pair)
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"Cy"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+pair.Data.AccessToken)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	// 3. Assert
+	if !errors.Is(errMissing, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an admin of no user, got %v", errMissing)
+	}
+	if err := CheckAdmins(context.Background(), users, []int{1}); err != nil {
+		t.Errorf("expected an existing admin to pass, got %v", err)
+	}
+	if reg.Code != http.StatusCreated || strings.Contains(reg.Body.String(), "role") {
+		t.Errorf("expected a user without a role, got %d %s", reg.Code, reg.Body)
+	}
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected the new user to be refused creating users, got %d %s", rr.Code, rr.Body)
+	}
+}
@@ -0,0 +1,150 @@
+// Description: This file contains the avatar handlers of the users
+// resource. Avatars are kept in a blob.Store under "avatars/<id>", apart
+// from the users themselves, and are only accepted if they decode as a PNG,
+// JPEG, or GIF image of a sensible size: whatever is uploaded is later
+// served to other clients, so it must be what it claims to be.
+
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // registers GIF with image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/blob"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// maxAvatarBytes caps the size of an avatar.
+const maxAvatarBytes = 2 << 20
+
+// maxAvatarSide caps the width and height of an avatar, in pixels, so a
+// small file can't decode into a huge image in a client.
+const maxAvatarSide = 4096
+
+// avatarTypes are the media types of the accepted avatars, as sniffed by
+// http.DetectContentType.
+var avatarTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+// avatarCacheControl lets clients and proxies keep an avatar for an hour,
+// and revalidate it with its ETag afterwards.
+const avatarCacheControl = "public, max-age=3600"
+
+var errNoAvatar = errors.New("the user has no avatar")
+
+// RegisterAvatarRoutes registers the /users/:id/avatar endpoints, which
+// keep the avatars of the users in users in blobs. Setting one requires
+// PermSetAvatar where middleware.RBAC is installed.
+func RegisterAvatarRoutes(r *router.Router, users store.UserStore, blobs blob.Store) {
+	a := NewAvatarHandlers(users, blobs)
+	r.GET("/users/:id/avatar", a.Get)
+	r.PUT("/users/:id/avatar", a.Put, middleware.RequirePermissions(PermSetAvatar))
+}
+
+// AvatarHandlers handles the /users/:id/avatar endpoints.
+type AvatarHandlers struct {
+	users store.UserStore
+	blobs blob.Store
+}
+
+// NewAvatarHandlers returns the handlers of the avatars of the users in
+// users, stored in blobs.
+func NewAvatarHandlers(users store.UserStore, blobs blob.Store) *AvatarHandlers {
+	return &AvatarHandlers{users: users, blobs: blobs}
+}
+
+// avatarKey returns the blob key of a user's avatar.
+func avatarKey(id int) string {
+	return "avatars/" + strconv.Itoa(id)
+}
+
+// Put handles requests to set a user's avatar, sent as the request body.
+// It answers 204 No Content, 413 for files over 2 MB, 415 for files that
+// aren't PNG, JPEG, or GIF, and 422 for corrupt or oversized images. A
+// logged-in caller may only set their own avatar, unless they're an admin.
+func (h *AvatarHandlers) Put(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok || !selfOrAdmin(c, id, "only admins may set other users' avatars") {
+		return
+	}
+	ctx := c.Request.Context()
+	if _, err := h.users.Get(ctx, id); err != nil {
+		failUser(c, err)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarBytes))
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		c.Fail(http.StatusRequestEntityTooLarge, fmt.Errorf("avatars must not be larger than %d bytes", maxErr.Limit))
+		return
+	}
+	if err != nil {
+		c.Fail(http.StatusBadRequest, fmt.Errorf("reading the avatar: %w", err))
+		return
+	}
+	if len(data) == 0 {
+		c.Fail(http.StatusBadRequest, errors.New("send the image as the request body"))
+		return
+	}
+	// The type is taken from the content, not from the Content-Type the
+	// client claims.
+	if t := http.DetectContentType(data); !slices.Contains(avatarTypes, t) {
+		c.Fail(http.StatusUnsupportedMediaType, fmt.Errorf("avatars must be PNG, JPEG, or GIF images, not %s", t))
+		return
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		c.Fail(http.StatusUnprocessableEntity, errors.New("the image is corrupt"))
+		return
+	}
+	if cfg.Width > maxAvatarSide || cfg.Height > maxAvatarSide {
+		c.Fail(http.StatusUnprocessableEntity, fmt.Errorf("avatars must be at most %dx%d pixels", maxAvatarSide, maxAvatarSide))
+		return
+	}
+	if err := h.blobs.Put(ctx, avatarKey(id), bytes.NewReader(data)); err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Get handles requests to download a user's avatar, with caching headers.
+// Conditional and range requests are answered by http.ServeContent.
+func (h *AvatarHandlers) Get(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if _, err := h.users.Get(ctx, id); err != nil {
+		failUser(c, err)
+		return
+	}
+	r, info, err := h.blobs.Get(ctx, avatarKey(id))
+	if errors.Is(err, blob.ErrNotFound) {
+		c.Fail(http.StatusNotFound, errNoAvatar)
+		return
+	}
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err)
+		return
+	}
+	defer r.Close()
+	c.SetHeader("Cache-Control", avatarCacheControl)
+	c.SetHeader("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime.UnixNano(), info.Size))
+	c.SetHeader("X-Content-Type-Options", "nosniff")
+	// ServeContent sets the Content-Type from the first bytes, which Put
+	// checked are those of an image.
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime, r)
+}
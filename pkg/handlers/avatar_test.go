@@ -0,0 +1,174 @@
+// Description: This file contains tests for the avatar handlers.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/auth"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/blob"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// pngOf returns a blank PNG image of the given size.
+func pngOf(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newAvatarRouter returns a router with the avatar endpoints, for user 1,
+// keeping the avatars in a temporary directory.
+func newAvatarRouter(t *testing.T) *router.Router {
+	t.Helper()
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := router.New()
+	RegisterAvatarRoutes(r, store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}), blobs)
+	return r
+}
+
+// TestPutAvatarHandler tests the uploads that are accepted and refused.
+func TestPutAvatarHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       []byte
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "png", path: "/users/1/avatar", body: pngOf(t, 64, 64), wantStatus: http.StatusNoContent},
+		{name: "unknown user", path: "/users/9/avatar", body: pngOf(t, 64, 64), wantStatus: http.StatusNotFound, wantBody: "user not found"},
+		{name: "empty", path: "/users/1/avatar", wantStatus: http.StatusBadRequest, wantBody: "request body"},
+		{name: "not an image", path: "/users/1/avatar", body: []byte("<svg onload=alert(1)>"), wantStatus: http.StatusUnsupportedMediaType,
+			wantBody: "not text/plain"},
+		{name: "corrupt", path: "/users/1/avatar", body: pngOf(t, 64, 64)[:20], wantStatus: http.StatusUnprocessableEntity, wantBody: "corrupt"},
+		{name: "too wide", path: "/users/1/avatar", body: pngOf(t, maxAvatarSide+1, 1), wantStatus: http.StatusUnprocessableEntity,
+			wantBody: "at most 4096x4096"},
+		{name: "too large", path: "/users/1/avatar", body: append(pngOf(t, 1, 1), make([]byte, maxAvatarBytes)...),
+			wantStatus: http.StatusRequestEntityTooLarge, wantBody: "larger than"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			r := newAvatarRouter(t)
+			req := httptest.NewRequest("PUT", tc.path, bytes.NewReader(tc.body))
+			req.Header.Set("Content-Type", "image/png")
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+// TestGetAvatarHandler tests serving an avatar, and revalidating it.
+func TestGetAvatarHandler(t *testing.T) {
+	// 1. Setup
+	r := newAvatarRouter(t)
+	avatar := pngOf(t, 64, 64)
+	missing := httptest.NewRecorder()
+	r.ServeHTTP(missing, httptest.NewRequest("GET", "/users/1/avatar", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/users/1/avatar", bytes.NewReader(avatar)))
+
+	// 2. Execute
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/users/1/avatar", nil))
+	req := httptest.NewRequest("GET", "/users/1/avatar", nil)
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	revalidated := httptest.NewRecorder()
+	r.ServeHTTP(revalidated, req)
+
+	// 3. Assert
+	if missing.Code != http.StatusNotFound || !strings.Contains(missing.Body.String(), "no avatar") {
+		t.Errorf("expected 404 before the upload, got %d %s", missing.Code, missing.Body)
+	}
+	if rr.Code != http.StatusOK || !bytes.Equal(rr.Body.Bytes(), avatar) {
+		t.Errorf("expected the avatar, got %d (%d bytes)", rr.Code, rr.Body.Len())
+	}
+	for header, want := range map[string]string{
+		"Content-Type":           "image/png",
+		"Cache-Control":          avatarCacheControl,
+		"X-Content-Type-Options": "nosniff",
+	} {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("got %s %q, want %q", header, got, want)
+		}
+	}
+	if rr.Header().Get("ETag") == "" || rr.Header().Get("Last-Modified") == "" {
+		t.Errorf("expected validators, got %v", rr.Header())
+	}
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", revalidated.Code)
+	}
+}
+
+// TestPutAvatarHandler_Permissions tests that with logins, users may only
+// set their own avatar, and admins anyone's.
+func TestPutAvatarHandler_Permissions(t *testing.T) {
+	// 1. Setup: Ann (user 1) is an admin, Bob (user 2) isn't.
+	n := auth.PasswordIterations
+	t.Cleanup(func() { auth.PasswordIterations = n })
+	auth.PasswordIterations = 1000
+	tokens, _ := auth.NewTokens(auth.TokenOptions{Secret: []byte(strings.Repeat("s", auth.MinSecretLength))})
+	users := store.NewMemoryUserStore()
+	blobs, _ := blob.NewFileStore(t.TempDir())
+	r := router.New()
+	RegisterAvatarRoutes(r, users, blobs)
+	RegisterAuthRoutes(r, users, store.NewMemoryAuthStore(), tokens)
+	r.Use(Authenticate(users, tokens, []int{1}), middleware.RBAC(middleware.RBACOptions{Roles: UserRoles}))
+	post(r, "/auth/register", `{"name":"Ann","email":"ann@example.com","password":"correct horse"}`)
+	post(r, "/auth/register", `{"name":"Bob","email":"bob@example.com","password":"battery staple"}`)
+	ann := login(t, r)
+	var bob struct{ Data tokenPair }
+	json.Unmarshal(post(r, "/auth/login", `{"email":"bob@example.com","password":"battery staple"}`).Body.Bytes(), &bob)
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"anonymous", "/users/2/avatar", "", http.StatusUnauthorized, "authentication required"},
+		{"own avatar", "/users/2/avatar", bob.Data.AccessToken, http.StatusNoContent, ""},
+		{"another user's", "/users/1/avatar", bob.Data.AccessToken, http.StatusForbidden, "only admins"},
+		{"admin", "/users/2/avatar", ann.AccessToken, http.StatusNoContent, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tc.path, bytes.NewReader(pngOf(t, 8, 8)))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
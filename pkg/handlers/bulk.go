@@ -0,0 +1,139 @@
+// Description: This file contains the bulk handlers of the users resource,
+// which create or delete many users in one request. A batch is all or
+// nothing: if one user is invalid or missing, no user is changed, so a
+// client never has to work out which half of its batch went through.
+// Clients accepting application/x-ndjson get the created users streamed one
+// per line instead of in one envelope, which suits large batches.
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// maxBulkUsers caps the users of one bulk request, so a single request
+// can't hold the store (or a database transaction) for long.
+const maxBulkUsers = 1000
+
+// bulkMaxBytes caps the body of a bulk request, which is larger than the
+// usual limit allows.
+const bulkMaxBytes = 8 << 20
+
+// ndjsonMediaType is the media type of newline-delimited JSON.
+const ndjsonMediaType = "application/x-ndjson"
+
+// CreateBulk handles requests to create several users. The body is a JSON
+// array of users, validated like Create's; the errors of the invalid ones
+// are listed with their index, e.g. field "3.name". It answers 201 Created
+// with the new users, in the order sent.
+func (h *UserHandlers) CreateBulk(c *httpcontext.Context) {
+	var users []User
+	if err := bindBulk(c, &users); err != nil {
+		failUser(c, err)
+		return
+	}
+	if err := validateUsers(users, nil); err != nil {
+		failUser(c, err)
+		return
+	}
+	created, err := h.store.CreateMany(c.Request.Context(), users)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	if strings.Contains(c.Request.Header.Get("Accept"), ndjsonMediaType) {
+		streamUsers(c, http.StatusCreated, created)
+		return
+	}
+	c.Respond(http.StatusCreated, created, nil)
+}
+
+// DeleteBulk handles requests to delete several users. The body is a JSON
+// array of IDs. If one of them doesn't exist, it answers 404 Not Found
+// naming it and deletes nothing; otherwise it answers 204 No Content.
+func (h *UserHandlers) DeleteBulk(c *httpcontext.Context) {
+	var ids []int
+	if err := bindBulk(c, &ids); err != nil {
+		failUser(c, err)
+		return
+	}
+	err := h.store.DeleteMany(c.Request.Context(), ids)
+	var notFound *store.NotFoundError
+	if errors.As(err, &notFound) {
+		c.Fail(http.StatusNotFound, fmt.Errorf("user %d not found; no user was deleted", notFound.ID))
+		return
+	}
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindBulk decodes the JSON array of a bulk request into v, a pointer to a
+// slice, which must have between 1 and maxBulkUsers elements.
+func bindBulk[T any](c *httpcontext.Context, v *[]T) error {
+	opts := httpcontext.DefaultBindOptions
+	opts.MaxBytes = bulkMaxBytes
+	if err := c.BindJSONWith(v, opts); err != nil {
+		return err
+	}
+	if len(*v) == 0 || len(*v) > maxBulkUsers {
+		return &httpcontext.BindError{
+			Status:  http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("send between 1 and %d users", maxBulkUsers),
+		}
+	}
+	return nil
+}
+
+// validateUsers validates each user, returning one 422 *BindError listing
+// every failure, with the user's number before the field name: numbers[i],
+// or its index if numbers is nil.
+func validateUsers(users []User, numbers []int) error {
+	var fields []httpcontext.FieldError
+	for i, u := range users {
+		var bindErr *httpcontext.BindError
+		if errors.As(httpcontext.Validate(u), &bindErr) {
+			n := i
+			if numbers != nil {
+				n = numbers[i]
+			}
+			for _, f := range bindErr.Fields {
+				f.Field = strconv.Itoa(n) + "." + f.Field
+				fields = append(fields, f)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &httpcontext.BindError{Status: http.StatusUnprocessableEntity, Message: "validation failed", Fields: fields}
+}
+
+// streamUsers writes users as newline-delimited JSON, flushing every
+// hundred lines, so the client can start reading before the end.
+func streamUsers(c *httpcontext.Context, status int, users []User) {
+	c.SetHeader("Content-Type", ndjsonMediaType)
+	c.Writer.WriteHeader(status)
+	rc := http.NewResponseController(c.Writer)
+	enc := json.NewEncoder(c.Writer)
+	for i, u := range users {
+		if err := enc.Encode(u); err != nil {
+			// The client went away; the users were created all the same.
+			c.Logger().Warn("Streaming users failed", "written", i, "err", err)
+			return
+		}
+		if (i+1)%100 == 0 {
+			rc.Flush()
+		}
+	}
+}
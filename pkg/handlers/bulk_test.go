@@ -0,0 +1,82 @@
+// Description: This file contains tests for the bulk users handlers.
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// TestBulkHandlers tests creating and deleting users in bulk.
+func TestBulkHandlers(t *testing.T) {
+	seeded := []User{{ID: 1, Name: "Hanzala", Version: 1}, {ID: 2, Name: "Areeb", Version: 1}}
+	tests := []struct {
+		name       string
+		method     string
+		accept     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantType   string
+		wantUsers  []User // the store afterwards
+	}{
+		{name: "create", method: "POST", body: `[{"name":"Ann"},{"name":"Bob"}]`, wantStatus: http.StatusCreated,
+			wantBody:  `{"data":[{"id":3,"name":"Ann","version":1},{"id":4,"name":"Bob","version":1}]}`,
+			wantUsers: append(seeded[:2:2], User{ID: 3, Name: "Ann", Version: 1}, User{ID: 4, Name: "Bob", Version: 1})},
+		{name: "create streamed", method: "POST", accept: "application/x-ndjson", body: `[{"name":"Ann"},{"name":"Bob"}]`,
+			wantStatus: http.StatusCreated, wantType: "application/x-ndjson",
+			wantBody:  "{\"id\":3,\"name\":\"Ann\",\"version\":1}\n{\"id\":4,\"name\":\"Bob\",\"version\":1}\n",
+			wantUsers: append(seeded[:2:2], User{ID: 3, Name: "Ann", Version: 1}, User{ID: 4, Name: "Bob", Version: 1})},
+		{name: "create with an invalid user", method: "POST", body: `[{"name":"Ann"},{"name":""}]`,
+			wantStatus: http.StatusUnprocessableEntity, wantBody: `"field":"1.name","rule":"required"`},
+		{name: "create nothing", method: "POST", body: `[]`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"message":"send between 1 and 1000 users"`},
+		{name: "create from an object", method: "POST", body: `{"name":"Ann"}`, wantStatus: http.StatusBadRequest,
+			wantBody: `"status":400`},
+		{name: "delete", method: "DELETE", body: `[2,1]`, wantStatus: http.StatusNoContent, wantUsers: []User{}},
+		{name: "delete a user twice", method: "DELETE", body: `[1,1]`, wantStatus: http.StatusNoContent,
+			wantUsers: seeded[1:]},
+		{name: "delete a missing user", method: "DELETE", body: `[1,9]`, wantStatus: http.StatusNotFound,
+			wantBody: `"message":"user 9 not found; no user was deleted"`},
+		{name: "delete too many", method: "DELETE", body: "[" + strings.Repeat("1,", 1000) + "1]",
+			wantStatus: http.StatusUnprocessableEntity, wantBody: `"status":422`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			users := store.NewMemoryUserStore(seeded...)
+			r := router.New()
+			RegisterRoutes(r, users)
+			req := httptest.NewRequest(tc.method, "/users/bulk", strings.NewReader(tc.body))
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if tc.wantType != "" && rr.Header().Get("Content-Type") != tc.wantType {
+				t.Errorf("got Content-Type %q, want %q", rr.Header().Get("Content-Type"), tc.wantType)
+			}
+			want := tc.wantUsers
+			if want == nil {
+				want = seeded
+			}
+			if got, _, _ := users.List(context.Background(), store.ListOptions{}); !reflect.DeepEqual(got, want) {
+				t.Errorf("store holds %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Description: This file contains the export and import handlers of the
+// users resource. Exports are streamed page by page straight from the store,
+// so exporting many users doesn't hold them all in memory; imports are
+// all or nothing, like the bulk handlers (see bulk.go).
+
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// exportPageSize is the number of users read from the store at a time.
+const exportPageSize = 500
+
+// maxImportUsers caps the rows of one import.
+const maxImportUsers = 10000
+
+// importMaxBytes caps the upload of an import.
+const importMaxBytes = 16 << 20
+
+// csvHeader is the first line of an export, naming the columns.
+var csvHeader = []string{"id", "name", "version"}
+
+// Export handles requests to download every user: as CSV by default, or as
+// JSON Lines with ?format=jsonl. It's sent as an attachment (users.csv or
+// users.jsonl). The users are read a page at a time, so ones changed while
+// the export runs may or may not be in it.
+func (h *UserHandlers) Export(c *httpcontext.Context) {
+	var (
+		start       func() error // writes what comes before the users
+		write       func(User) error
+		flush       func() error
+		contentType string
+		filename    string
+	)
+	switch format := c.DefaultQuery("format", "csv"); format {
+	case "csv":
+		w := csv.NewWriter(c.Writer)
+		start = func() error { return w.Write(csvHeader) }
+		write = func(u User) error {
+			return w.Write([]string{strconv.Itoa(u.ID), csvCell(u.Name), strconv.Itoa(u.Version)})
+		}
+		flush = func() error { w.Flush(); return w.Error() }
+		contentType, filename = "text/csv; charset=utf-8", "users.csv"
+	case "jsonl":
+		enc := json.NewEncoder(c.Writer)
+		start = func() error { return nil }
+		write = func(u User) error { return enc.Encode(u) }
+		flush = func() error { return nil }
+		contentType, filename = ndjsonMediaType, "users.jsonl"
+	default:
+		c.Fail(http.StatusBadRequest, fmt.Errorf("unknown format %q, use csv or jsonl", format))
+		return
+	}
+
+	// Read the first page before answering, so a failing store still gets
+	// a proper error response.
+	opts := store.ListOptions{Limit: exportPageSize}
+	page, total, err := h.store.List(c.Request.Context(), opts)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.SetHeader("Content-Type", contentType)
+	c.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Writer.WriteHeader(http.StatusOK)
+	if err := start(); err != nil {
+		c.Logger().Warn("Export failed", "err", err)
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	for {
+		for _, u := range page {
+			if err := write(u); err != nil {
+				c.Logger().Warn("Export failed", "err", err)
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			c.Logger().Warn("Export failed", "err", err)
+			return
+		}
+		rc.Flush()
+		opts.Offset += exportPageSize
+		if len(page) < exportPageSize || opts.Offset >= total {
+			return
+		}
+		if page, _, err = h.store.List(c.Request.Context(), opts); err != nil {
+			// The response has started; all we can do is cut it short.
+			c.Logger().Error("Export failed", "offset", opts.Offset, "err", err)
+			return
+		}
+	}
+}
+
+// csvCell returns s as a cell that spreadsheets show as text. Those read a
+// cell starting with "=", "+", "-", "@", a tab, or a carriage return as a
+// formula, which a user's name could use to run commands on whoever opens
+// the export; a leading "'" marks it as text instead. fromCSVCell undoes it,
+// so names that look quoted already get another.
+func csvCell(s string) string {
+	if isFormulaStart(s) || isQuoted(s) {
+		return "'" + s
+	}
+	return s
+}
+
+// fromCSVCell returns the value of a cell written by csvCell.
+func fromCSVCell(s string) string {
+	if isQuoted(s) {
+		return s[1:]
+	}
+	return s
+}
+
+// isQuoted reports whether s is a "'" followed by a formula or by another
+// quoted value.
+func isQuoted(s string) bool {
+	rest, ok := strings.CutPrefix(s, "'")
+	return ok && (isFormulaStart(rest) || isQuoted(rest))
+}
+
+// isFormulaStart reports whether spreadsheets read s as a formula.
+func isFormulaStart(s string) bool {
+	return s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0]))
+}
+
+// Import handles requests to create users from a CSV file, uploaded as the
+// "file" field of a multipart form. The first line names the columns; only
+// "name" is needed, and others (like an export's id and version) are
+// ignored, the users getting new IDs. Names quoted as text by Export lose
+// the quote again. If any row is invalid, none is
+// imported, and the 422 response lists the failures by line, e.g. field
+// "3.name". Otherwise it answers 201 Created with the number imported.
+func (h *UserHandlers) Import(c *httpcontext.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBytes)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.Fail(http.StatusRequestEntityTooLarge, fmt.Errorf("upload must not be larger than %d bytes", maxErr.Limit))
+			return
+		}
+		c.Fail(http.StatusBadRequest, errors.New(`send the CSV file as the "file" field of a multipart form`))
+		return
+	}
+	defer file.Close()
+
+	users, lines, err := readUsersCSV(file)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	if err := validateUsers(users, lines); err != nil {
+		failUser(c, err)
+		return
+	}
+	created, err := h.store.CreateMany(c.Request.Context(), users)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.Respond(http.StatusCreated, map[string]int{"imported": len(created)}, nil)
+}
+
+// readUsersCSV reads the users of an import and the line each one is on
+// (blank lines are skipped, and quoted values may span lines). It returns a
+// 400 or 422 *BindError for files it can't use.
+func readUsersCSV(r io.Reader) ([]User, []int, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, &httpcontext.BindError{Status: http.StatusUnprocessableEntity, Message: "the file is empty"}
+	}
+	if err != nil {
+		return nil, nil, &httpcontext.BindError{Status: http.StatusBadRequest, Message: "malformed CSV: " + err.Error(), Err: err}
+	}
+	nameCol := slices.IndexFunc(header, func(col string) bool {
+		// Spreadsheets often start the file with a byte order mark.
+		return strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")), "name")
+	})
+	if nameCol < 0 {
+		return nil, nil, &httpcontext.BindError{Status: http.StatusUnprocessableEntity, Message: `the first line must name the columns, including "name"`}
+	}
+
+	var (
+		users []User
+		lines []int
+	)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, &httpcontext.BindError{Status: http.StatusBadRequest, Message: "malformed CSV: " + err.Error(), Err: err}
+		}
+		if len(users) == maxImportUsers {
+			return nil, nil, &httpcontext.BindError{
+				Status:  http.StatusUnprocessableEntity,
+				Message: fmt.Sprintf("import at most %d users at a time", maxImportUsers),
+			}
+		}
+		line, _ := cr.FieldPos(nameCol)
+		users = append(users, User{Name: fromCSVCell(record[nameCol])})
+		lines = append(lines, line)
+	}
+	if len(users) == 0 {
+		return nil, nil, &httpcontext.BindError{Status: http.StatusUnprocessableEntity, Message: "the file has no users"}
+	}
+	return users, lines, nil
+}
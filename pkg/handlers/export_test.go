@@ -0,0 +1,206 @@
+// Description: This file contains tests for the export and import handlers.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// TestExportHandler tests downloading the users in each format.
+func TestExportHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantStatus      int
+		wantType        string
+		wantDisposition string
+		wantBody        string
+	}{
+		{name: "csv", wantStatus: http.StatusOK, wantType: "text/csv; charset=utf-8",
+			wantDisposition: "attachment; filename=users.csv",
+			wantBody:        "id,name,version\n1,Hanzala,1\n2,\"Areeb, A.\",1\n"},
+		{name: "jsonl", query: "?format=jsonl", wantStatus: http.StatusOK, wantType: "application/x-ndjson",
+			wantDisposition: "attachment; filename=users.jsonl",
+			wantBody:        "{\"id\":1,\"name\":\"Hanzala\",\"version\":1}\n{\"id\":2,\"name\":\"Areeb, A.\",\"version\":1}\n"},
+		{name: "unknown format", query: "?format=xlsx", wantStatus: http.StatusBadRequest,
+			wantType: "application/json", wantBody: `"status":400`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			r := router.New()
+			RegisterRoutes(r, store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}, User{ID: 2, Name: "Areeb, A."}))
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, httptest.NewRequest("GET", "/users/export"+tc.query, nil))
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %q, want %d containing %q", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, tc.wantType) {
+				t.Errorf("got Content-Type %q, want %q", got, tc.wantType)
+			}
+			if got := rr.Header().Get("Content-Disposition"); got != tc.wantDisposition {
+				t.Errorf("got Content-Disposition %q, want %q", got, tc.wantDisposition)
+			}
+		})
+	}
+}
+
+// TestExportHandler_Pages tests that exports read past the first page.
+func TestExportHandler_Pages(t *testing.T) {
+	// 1. Setup
+	users := store.NewMemoryUserStore()
+	for i := 0; i < exportPageSize*2+1; i++ {
+		users.Create(context.Background(), User{Name: fmt.Sprint("user", i)})
+	}
+	r := router.New()
+	RegisterRoutes(r, users)
+	rr := httptest.NewRecorder()
+
+	// 2. Execute
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/users/export", nil))
+
+	// 3. Assert: the header, then every user.
+	if lines := strings.Count(rr.Body.String(), "\n"); lines != exportPageSize*2+2 {
+		t.Errorf("expected %d lines, got %d", exportPageSize*2+2, lines)
+	}
+}
+
+// TestExportHandler_Formulas tests that names spreadsheets would run as
+// formulas are exported as text.
+func TestExportHandler_Formulas(t *testing.T) {
+	// 1. Setup
+	r := router.New()
+	RegisterRoutes(r, store.NewMemoryUserStore(
+		User{ID: 1, Name: "=HYPERLINK(\"http://evil\")"}, User{ID: 2, Name: "+1"}, User{ID: 3, Name: "-1"},
+		User{ID: 4, Name: "@SUM(A1)"}, User{ID: 5, Name: "\tx"}, User{ID: 6, Name: "\rx"}, User{ID: 7, Name: "a=b"},
+	))
+	rr := httptest.NewRecorder()
+
+	// 2. Execute
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/users/export", nil))
+
+	// 3. Assert: Each formula starts with a quote; other names are as is.
+	want := "id,name,version\n" +
+		"1,\"'=HYPERLINK(\"\"http://evil\"\")\",1\n" +
+		"2,'+1,1\n3,'-1,1\n4,'@SUM(A1),1\n5,'\tx,1\n6,\"'\rx\",1\n7,a=b,1\n"
+	if rr.Body.String() != want {
+		t.Errorf("got %q, want %q", rr.Body, want)
+	}
+}
+
+// TestExportImport_RoundTrip tests that importing an export gives back the
+// same names, including those exported as text.
+func TestExportImport_RoundTrip(t *testing.T) {
+	// 1. Setup
+	names := []string{"Ann", "-foo", "=1+1", "@home", "+1", "'-quoted", "''=x", "'plain", "a=b"}
+	from := store.NewMemoryUserStore()
+	for _, name := range names {
+		from.Create(context.Background(), User{Name: name})
+	}
+	to := store.NewMemoryUserStore()
+	r, r2 := router.New(), router.New()
+	RegisterRoutes(r, from)
+	RegisterRoutes(r2, to)
+
+	// 2. Execute: Export from one store, and import into the other.
+	exported := httptest.NewRecorder()
+	r.ServeHTTP(exported, httptest.NewRequest("GET", "/users/export", nil))
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "users.csv")
+	fw.Write(exported.Body.Bytes())
+	mw.Close()
+	req := httptest.NewRequest("POST", "/users/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	r2.ServeHTTP(rr, req)
+
+	// 3. Assert
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("importing: %d %s", rr.Code, rr.Body)
+	}
+	imported, _, _ := to.List(context.Background(), store.ListOptions{})
+	var got []string
+	for _, u := range imported {
+		got = append(got, u.Name)
+	}
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", names) {
+		t.Errorf("got names %q, want %q", got, names)
+	}
+}
+
+// TestImportHandler tests creating users from an uploaded CSV file.
+func TestImportHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      string // the form field of the file
+		file       string
+		wantStatus int
+		wantBody   string
+		wantTotal  int // the users in the store afterwards
+	}{
+		{name: "import", file: "name\nAnn\n\"Bob, B.\"\n", wantStatus: http.StatusCreated,
+			wantBody: `{"data":{"imported":2}}`, wantTotal: 3},
+		{name: "export columns", file: "\ufeffid,Name,version\n7,Ann,3\n", wantStatus: http.StatusCreated,
+			wantBody: `{"data":{"imported":1}}`, wantTotal: 2},
+		{name: "invalid rows", file: "name\nAnn\n\n\"\"\n" + strings.Repeat("x", 101) + "\n", wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"fields":[{"field":"4.name","rule":"required","message":"is required"},{"field":"5.name","rule":"max"`, wantTotal: 1},
+		{name: "too long", file: "name\n" + strings.Repeat("x", 101) + "\n", wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"field":"2.name","rule":"max"`, wantTotal: 1},
+		{name: "no name column", file: "id\n1\n", wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `including \"name\"`, wantTotal: 1},
+		{name: "no users", file: "name\n", wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"the file has no users"`, wantTotal: 1},
+		{name: "empty", file: "", wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"the file is empty"`, wantTotal: 1},
+		{name: "malformed", file: "name,id\nAnn\n", wantStatus: http.StatusBadRequest,
+			wantBody: `malformed CSV`, wantTotal: 1},
+		{name: "wrong field", field: "upload", file: "name\nAnn\n", wantStatus: http.StatusBadRequest,
+			wantBody: `"file\" field`, wantTotal: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			users := store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"})
+			r := router.New()
+			RegisterRoutes(r, users)
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			field := tc.field
+			if field == "" {
+				field = "file"
+			}
+			fw, _ := mw.CreateFormFile(field, "users.csv")
+			fw.Write([]byte(tc.file))
+			mw.Close()
+			req := httptest.NewRequest("POST", "/users/import", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if _, total, _ := users.List(context.Background(), store.ListOptions{}); total != tc.wantTotal {
+				t.Errorf("store holds %d users, want %d", total, tc.wantTotal)
+			}
+		})
+	}
+}
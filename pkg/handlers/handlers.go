@@ -1,68 +1,71 @@
 // Description: This package contains the application's request handlers.
 // Each handler is responsible for the business logic of a specific API endpoint.
+// All of them answer with the standard response envelope ({"data": ...} or
+// {"error": ...}), see httpcontext/envelope.go.
 
 package handlers
 
 import (
-	"net/http" // Provides HTTP status constants like http.StatusOK.
-
 	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
 	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
 )
 
-// User represents a user in our system.
-type User struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
-
 // RegisterRoutes is a function that registers all the application's routes
 // with the provided router. This keeps the route setup organized and separate
-// from the main application startup logic.
-func RegisterRoutes(r *router.Router) {
+// from the main application startup logic. The handlers keep their data in
+// users, so the same routes can run against memory in tests and a database
+// in production.
+//
+// The routes creating and deleting users require PermCreateUsers and
+// PermDeleteUsers, which only admins have (see UserRoles), and those
+// changing one PermUpdateUsers, which logged-in users have for themselves.
+// That's enforced by middleware.RBAC, where it's installed: without logins,
+// everyone may.
+func RegisterRoutes(r *router.Router, users store.UserStore) {
 	r.GET("/health", HealthCheckHandler)
-	r.GET("/users", GetUsersHandler)
-	r.POST("/users", CreateUserHandler)
+
+	u := NewUserHandlers(users)
+	create := middleware.RequirePermissions(PermCreateUsers)
+	remove := middleware.RequirePermissions(PermDeleteUsers)
+	update := middleware.RequirePermissions(PermUpdateUsers)
+	r.GET("/users", u.List)
+	r.POST("/users", u.Create, create)
+	r.POST("/users/bulk", u.CreateBulk, create)
+	r.DELETE("/users/bulk", u.DeleteBulk, remove)
+	r.GET("/users/export", u.Export)
+	r.POST("/users/import", u.Import, create)
+	r.GET("/users/:id", u.Get)
+	r.PUT("/users/:id", u.Update, update)
+	r.PATCH("/users/:id", u.Patch, update)
+	r.DELETE("/users/:id", u.Delete, remove)
+}
+
+// The permissions of the users routes. Every logged-in user has
+// PermUpdateUsers and PermSetAvatar, for themselves and their own avatar
+// (see UserHandlers.Update and AvatarHandlers.Put).
+const (
+	PermCreateUsers = "users:create"
+	PermDeleteUsers = "users:delete"
+	PermUpdateUsers = "users:update"
+	PermSetAvatar   = "users:avatar"
+)
+
+// UserRoles are the permissions of each role of store.User, for
+// middleware.RBACOptions. Regular users have no role, and none of them.
+var UserRoles = map[string][]string{
+	store.RoleAdmin: {"*"},
 }
 
 // HealthCheckHandler handles the /health endpoint.
 // It's a simple handler to check if the service is running.
 func HealthCheckHandler(c *httpcontext.Context) {
-	// Use the JSON helper from our custom context to send a response.
-	// The `map[string]string` will be automatically encoded into a JSON object.
-	c.JSON(http.StatusOK, map[string]string{
+	// Use the OK helper from our custom context to send a response.
+	// The `map[string]string` will be automatically encoded into a JSON object
+	// under the envelope's "data" key.
+	c.OK(map[string]string{
 		"status":  "ok",
 		"service": "HTTPGolang_Server",
 	})
 }
-
-// GetUsersHandler handles requests to retrieve a list of users.
-func GetUsersHandler(c *httpcontext.Context) {
-	// In a real application, you would fetch this data from a database.
-	// Here, we're just using a static list for demonstration.
-	users := []User{
-		{ID: 1, Name: "Hanzala"},
-		{ID: 2, Name: "Areeb"},
-	}
-
-	// Send the list of users as a JSON array.
-	c.JSON(http.StatusOK, users)
-}
-
-// CreateUserHandler handles requests to create a new user.
-func CreateUserHandler(c *httpcontext.Context) {
-	// For a POST request, you would typically decode the request body.
-	// For example:
-	// var newUser User
-	// if err := json.NewDecoder(c.Request.Body).Decode(&newUser); err != nil {
-	//     c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-	//     return
-	// }
-	//
-	// log.Printf("Created new user: %v", newUser)
-
-	// For this example, we'll just return a success message.
-	c.JSON(http.StatusCreated, map[string]string{
-		"status": "user created successfully",
-	})
-}
@@ -11,7 +11,6 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
 	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
 )
 
@@ -45,85 +44,21 @@ func TestHealthCheckHandler(t *testing.T) {
 	}
 
 	// 6. Check the response body.
-	// We expect a specific JSON response.
+	// We expect a specific JSON response inside the envelope's "data" key.
 	expected := map[string]string{
 		"status":  "ok",
 		"service": "HTTPGolang_Server",
 	}
-	var actual map[string]string
-	// Unmarshal the JSON from the response body into our `actual` map.
-	if err := json.Unmarshal(rr.Body.Bytes(), &actual); err != nil {
-		t.Fatalf("could not unmarshal response body: %v", err)
-	}
-
-	// Use reflect.DeepEqual for a robust comparison of maps/structs.
-	if !reflect.DeepEqual(expected, actual) {
-		t.Errorf("handler returned unexpected body: got %v want %v",
-			actual, expected)
-	}
-}
-
-// TestGetUsersHandler tests the /users endpoint for GET requests.
-func TestGetUsersHandler(t *testing.T) {
-	req, err := http.NewRequest("GET", "/users", nil)
-	if err != nil {
-		t.Fatalf("could not create request: %v", err)
-	}
-
-	rr := httptest.NewRecorder()
-	handlerCtx := &httpcontext.Context{Writer: rr, Request: req}
-
-	// We can call the handler directly with a mocked context.
-	GetUsersHandler(handlerCtx)
-
-	// Check status code
-	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusOK)
-	}
-
-	// Check the response body
-	expected := []User{
-		{ID: 1, Name: "Hanzala"},
-		{ID: 2, Name: "Areeb"},
+	var envelope struct {
+		Data map[string]string `json:"data"`
 	}
-	var actual []User
-	if err := json.Unmarshal(rr.Body.Bytes(), &actual); err != nil {
-		t.Fatalf("could not unmarshal response body: %v", err)
-	}
-
-	if !reflect.DeepEqual(expected, actual) {
-		t.Errorf("handler returned unexpected body: got %v want %v",
-			actual, expected)
-	}
-}
-
-// TestCreateUserHandler tests the /users endpoint for POST requests.
-func TestCreateUserHandler(t *testing.T) {
-	req, err := http.NewRequest("POST", "/users", nil) // Body is nil for this simple case.
-	if err != nil {
-		t.Fatalf("could not create request: %v", err)
-	}
-
-	rr := httptest.NewRecorder()
-	r := router.New()
-	r.POST("/users", CreateUserHandler)
-
-	r.ServeHTTP(rr, req)
-
-	// Check status code
-	if status := rr.Code; status != http.StatusCreated {
-		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusCreated)
-	}
-
-	// Check response body
-	expected := map[string]string{"status": "user created successfully"}
-	var actual map[string]string
-	if err := json.Unmarshal(rr.Body.Bytes(), &actual); err != nil {
+	// Unmarshal the JSON from the response body into our envelope.
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
 		t.Fatalf("could not unmarshal response body: %v", err)
 	}
+	actual := envelope.Data
 
+	// Use reflect.DeepEqual for a robust comparison of maps/structs.
 	if !reflect.DeepEqual(expected, actual) {
 		t.Errorf("handler returned unexpected body: got %v want %v",
 			actual, expected)
@@ -0,0 +1,282 @@
+// Description: This file contains the handlers of the users resource. They
+// read and write users through a store.UserStore, which is handed to
+// NewUserHandlers instead of being hard-coded, so tests can give them a
+// store of their own.
+
+package handlers
+
+import (
+	"errors"
+	"net/http" // Provides HTTP status constants like http.StatusOK.
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/middleware"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// User represents a user in our system.
+type User = store.User
+
+// userPatch is the body of a PATCH request: only the fields present are
+// changed.
+type userPatch struct {
+	Name    *string `json:"name"`
+	Version int     `json:"version"`
+}
+
+// UserHandlers handles the /users endpoints.
+type UserHandlers struct {
+	store store.UserStore
+}
+
+// NewUserHandlers returns the handlers of the users in s.
+func NewUserHandlers(s store.UserStore) *UserHandlers {
+	return &UserHandlers{store: s}
+}
+
+// listMeta is the meta object of a page of users.
+type listMeta struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// List handles requests to retrieve a list of users, one page at a time:
+// ?page=2&per_page=50 (20 by default, at most 100), sorted with e.g.
+// ?sort=-name,id, and filtered with ?name=ann, which matches names
+// containing it in any case. The links to the other pages are in the Link
+// header, and the totals in the meta object and X-Total-Count.
+func (h *UserHandlers) List(c *httpcontext.Context) {
+	p, err := c.Pagination(httpcontext.PaginationDefaults{
+		PerPage:      20,
+		MaxPerPage:   100,
+		AllowedSorts: store.UserSortFields,
+	})
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	opts := store.ListOptions{NameContains: c.Query("name"), Offset: p.Offset, Limit: p.PerPage}
+	for _, f := range p.Sort {
+		opts.Sort = append(opts.Sort, store.SortField{Field: f.Field, Desc: f.Desc})
+	}
+	users, total, err := h.store.List(c.Request.Context(), opts)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.SetPaginationHeaders(p, total)
+	// Send the page of users as a JSON array in the envelope.
+	c.Respond(http.StatusOK, users, listMeta{
+		Page:       p.Page,
+		PerPage:    p.PerPage,
+		Total:      total,
+		TotalPages: (total + p.PerPage - 1) / p.PerPage,
+	})
+}
+
+// Create handles requests to create a new user. The body is the user
+// without an ID, which the store assigns. Malformed JSON is answered with 400
+// Bad Request, and a user breaking User's validation rules with 422
+// Unprocessable Entity listing the fields. On success, it answers 201
+// Created with the new user, and its URL in the Location header.
+func (h *UserHandlers) Create(c *httpcontext.Context) {
+	var u User
+	if err := c.BindJSON(&u); err != nil {
+		failUser(c, err)
+		return
+	}
+	u, err := h.store.Create(c.Request.Context(), u)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.SetHeader("Location", "/users/"+strconv.Itoa(u.ID))
+	c.SetHeader("ETag", userETag(u))
+	c.Respond(http.StatusCreated, u, nil)
+}
+
+// Get handles requests to retrieve one user by ID. The ETag header carries
+// its version, for the If-Match header of a later update.
+func (h *UserHandlers) Get(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	u, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.SetHeader("ETag", userETag(u))
+	c.OK(u)
+}
+
+// Update handles requests to replace a user. The body is the whole user; its
+// ID, if any, is ignored in favor of the path's. The client must say which
+// version it is replacing, see checkVersion. A logged-in caller may only
+// change themselves, unless they're an admin.
+func (h *UserHandlers) Update(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok || !selfOrAdmin(c, id, "only admins may change other users") {
+		return
+	}
+	var u User
+	if err := c.BindJSON(&u); err != nil {
+		failUser(c, err)
+		return
+	}
+	current, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	if !checkVersion(c, current, u.Version) {
+		return
+	}
+	u.ID, u.Version = id, current.Version
+	h.save(c, u)
+}
+
+// Patch handles requests to change some of a user's fields; the ones missing
+// from the body keep their values. Like Update, it needs the version, and
+// only lets callers change themselves unless they're admins.
+func (h *UserHandlers) Patch(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok || !selfOrAdmin(c, id, "only admins may change other users") {
+		return
+	}
+	var patch userPatch
+	if err := c.BindJSON(&patch); err != nil {
+		failUser(c, err)
+		return
+	}
+	u, err := h.store.Get(c.Request.Context(), id)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	if !checkVersion(c, u, patch.Version) {
+		return
+	}
+	if patch.Name != nil {
+		u.Name = *patch.Name
+	}
+	// The result must be as valid as a full replacement.
+	if err := httpcontext.Validate(u); err != nil {
+		failUser(c, err)
+		return
+	}
+	h.save(c, u)
+}
+
+// save stores u, which is at the version the client checked, and answers
+// with its new version. If it changed since it was read, the store refuses
+// with ErrConflict, answered with 409 Conflict.
+func (h *UserHandlers) save(c *httpcontext.Context, u User) {
+	u, err := h.store.Update(c.Request.Context(), u)
+	if err != nil {
+		failUser(c, err)
+		return
+	}
+	c.SetHeader("ETag", userETag(u))
+	c.OK(u)
+}
+
+// Delete handles requests to delete a user. It answers 204 No Content.
+func (h *UserHandlers) Delete(c *httpcontext.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+		failUser(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// The client-facing messages of failed users requests.
+var (
+	errUserNotFound    = errors.New("user not found")
+	errVersionConflict = errors.New("the user was changed by someone else; get it again and retry")
+	errVersionRequired = errors.New("send the If-Match header, or the version, of the user being changed")
+)
+
+// userETag returns the entity tag of u's version, e.g. "3".
+func userETag(u User) string {
+	return `"` + strconv.Itoa(u.Version) + `"`
+}
+
+// checkVersion tells whether a change to current may go ahead: the client
+// must show it has seen the latest version, so that of two editors, the
+// second doesn't silently overwrite the first's change. It either sends the
+// ETag GET returned in If-Match (or If-Match: * to change it regardless),
+// answered with 412 Precondition Failed if it's outdated, or the version in
+// the body, answered with 409 Conflict. Without either, it answers 428
+// Precondition Required. It returns false if it answered.
+func checkVersion(c *httpcontext.Context, current User, version int) bool {
+	if header := c.Request.Header.Get("If-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			if tag = strings.TrimSpace(tag); tag == "*" || tag == userETag(current) {
+				return true
+			}
+		}
+		c.Fail(http.StatusPreconditionFailed, errVersionConflict)
+		return false
+	}
+	switch version {
+	case current.Version:
+		return true
+	case 0:
+		c.Fail(http.StatusPreconditionRequired, errVersionRequired)
+	default:
+		c.Fail(http.StatusConflict, errVersionConflict)
+	}
+	return false
+}
+
+// selfOrAdmin reports whether the caller may act on the user with the given
+// ID: callers without a login, where logins are off, and the user
+// themselves or an admin, where they're on. Otherwise it answers 403
+// Forbidden with detail and returns false.
+func selfOrAdmin(c *httpcontext.Context, id int, detail string) bool {
+	p, ok := middleware.PrincipalFrom(c)
+	if !ok || p.ID == strconv.Itoa(id) || slices.Contains(p.Roles, store.RoleAdmin) {
+		return true
+	}
+	c.Problem(httpcontext.Problem{Status: http.StatusForbidden, Detail: detail})
+	return false
+}
+
+// userID returns the :id path parameter. If it isn't a number, it answers
+// 400 Bad Request and returns false.
+func userID(c *httpcontext.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Fail(http.StatusBadRequest, errors.New("invalid user ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// failUser answers with the error of a users request: 404 for unknown users,
+// 409 for conflicting updates, a bind error's status for invalid bodies, and
+// 500 otherwise.
+func failUser(c *httpcontext.Context, err error) {
+	var bindErr *httpcontext.BindError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.Fail(http.StatusNotFound, errUserNotFound)
+	case errors.Is(err, store.ErrConflict):
+		c.Fail(http.StatusConflict, errVersionConflict)
+	case errors.As(err, &bindErr):
+		c.Fail(bindErr.Status, bindErr)
+	default:
+		c.Fail(http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,259 @@
+// Description: This file contains tests for the users handlers.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/store"
+)
+
+// TestGetUsersHandler tests the /users endpoint for GET requests.
+func TestGetUsersHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/users", nil)
+	if err != nil {
+		t.Fatalf("could not create request: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlerCtx := &httpcontext.Context{Writer: rr, Request: req}
+	h := NewUserHandlers(store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}, User{ID: 2, Name: "Areeb"}))
+
+	// We can call the handler directly with a mocked context.
+	h.List(handlerCtx)
+
+	// Check status code
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	// Check the response body
+	expected := []User{
+		{ID: 1, Name: "Hanzala", Version: 1},
+		{ID: 2, Name: "Areeb", Version: 1},
+	}
+	var envelope struct {
+		Data []User `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("could not unmarshal response body: %v", err)
+	}
+	actual := envelope.Data
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("handler returned unexpected body: got %v want %v",
+			actual, expected)
+	}
+}
+
+// TestGetUsersHandler_Pagination tests paging, sorting, and filtering the
+// user list.
+func TestGetUsersHandler_Pagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+		wantLink   string
+	}{
+		{name: "first page", query: "?per_page=2", wantStatus: http.StatusOK,
+			wantBody: `{"data":[{"id":1,"name":"Hanzala","version":1},{"id":2,"name":"Areeb","version":1}],"meta":{"page":1,"per_page":2,"total":3,"total_pages":2}}`,
+			wantLink: `</users?page=2&per_page=2>; rel="next"`},
+		{name: "last page", query: "?page=2&per_page=2", wantStatus: http.StatusOK,
+			wantBody: `{"data":[{"id":3,"name":"Ann","version":1}],"meta":{"page":2,"per_page":2,"total":3,"total_pages":2}}`,
+			wantLink: `</users?page=1&per_page=2>; rel="prev"`},
+		{name: "sorted", query: "?sort=name", wantStatus: http.StatusOK,
+			wantBody: `[{"id":3,"name":"Ann","version":1},{"id":2,"name":"Areeb","version":1},{"id":1,"name":"Hanzala","version":1}]`},
+		{name: "filtered", query: "?name=AN", wantStatus: http.StatusOK,
+			wantBody: `{"data":[{"id":1,"name":"Hanzala","version":1},{"id":3,"name":"Ann","version":1}],"meta":{"page":1,"per_page":20,"total":2,"total_pages":1}}`},
+		{name: "no match", query: "?name=zed", wantStatus: http.StatusOK,
+			wantBody: `{"data":[],"meta":{"page":1,"per_page":20,"total":0,"total_pages":0}}`},
+		{name: "unknown sort", query: "?sort=password", wantStatus: http.StatusBadRequest,
+			wantBody: `"status":400`},
+		{name: "invalid page", query: "?page=0", wantStatus: http.StatusBadRequest,
+			wantBody: `"status":400`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			r := router.New()
+			RegisterRoutes(r, store.NewMemoryUserStore(
+				User{ID: 1, Name: "Hanzala"}, User{ID: 2, Name: "Areeb"}, User{ID: 3, Name: "Ann"},
+			))
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, httptest.NewRequest("GET", "/users"+tc.query, nil))
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if link := rr.Header().Get("Link"); !strings.Contains(link, tc.wantLink) {
+				t.Errorf("got Link %q, want it to contain %q", link, tc.wantLink)
+			}
+		})
+	}
+}
+
+// TestCreateUserHandler tests the /users endpoint for POST requests.
+func TestCreateUserHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{name: "created", body: `{"name":"Ann"}`, wantStatus: http.StatusCreated,
+			wantBody: `{"data":{"id":3,"name":"Ann","version":1}}`, wantLocation: "/users/3"},
+		{name: "id ignored", body: `{"id":1,"name":"Ann"}`, wantStatus: http.StatusCreated,
+			wantBody: `{"data":{"id":3,"name":"Ann","version":1}}`, wantLocation: "/users/3"},
+		{name: "no body", wantStatus: http.StatusBadRequest, wantBody: `"message":"request body is empty"`},
+		{name: "malformed", body: `{"name":}`, wantStatus: http.StatusBadRequest, wantBody: `"status":400`},
+		{name: "wrong type", body: `{"name":7}`, wantStatus: http.StatusBadRequest, wantBody: `"field":"name"`},
+		{name: "missing name", body: `{}`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"fields":[{"field":"name","rule":"required"`},
+		{name: "name too long", body: `{"name":"` + strings.Repeat("a", 101) + `"}`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"rule":"max"`},
+		{name: "admin", body: `{"name":"Ann","role":"admin"}`, wantStatus: http.StatusCreated,
+			wantBody: `{"data":{"id":3,"name":"Ann","version":1,"role":"admin"}}`, wantLocation: "/users/3"},
+		{name: "unknown role", body: `{"name":"Ann","role":"owner"}`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"field":"role","rule":"oneof"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			users := store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}, User{ID: 2, Name: "Areeb"})
+			r := router.New()
+			r.POST("/users", NewUserHandlers(users).Create)
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, httptest.NewRequest("POST", "/users", body))
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if got := rr.Header().Get("Location"); got != tc.wantLocation {
+				t.Errorf("got Location %q, want %q", got, tc.wantLocation)
+			}
+			list, _, _ := users.List(context.Background(), store.ListOptions{})
+			if created := tc.wantStatus == http.StatusCreated; created != (len(list) == 3) {
+				t.Errorf("store holds %v", list)
+			}
+		})
+	}
+}
+
+// TestUserHandlers tests reading, replacing, patching, and deleting a user.
+func TestUserHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		ifMatch    string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantETag   string
+		wantUsers  []User // the store afterwards
+	}{
+		{name: "get", method: "GET", path: "/users/1", wantStatus: http.StatusOK,
+			wantBody: `{"data":{"id":1,"name":"Hanzala","version":1}}`, wantETag: `"1"`},
+		{name: "get unknown", method: "GET", path: "/users/9", wantStatus: http.StatusNotFound,
+			wantBody: `{"error":{"status":404,"message":"user not found"}}`},
+		{name: "get invalid id", method: "GET", path: "/users/abc", wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":{"status":400,"message":"invalid user ID"}}`},
+		{name: "put", method: "PUT", path: "/users/1", body: `{"id":7,"name":"Hanzala Areeb","version":1}`, wantStatus: http.StatusOK,
+			wantBody: `{"data":{"id":1,"name":"Hanzala Areeb","version":2}}`, wantETag: `"2"`,
+			wantUsers: []User{{ID: 1, Name: "Hanzala Areeb", Version: 2}, {ID: 2, Name: "Areeb", Version: 1}}},
+		{name: "put if-match", method: "PUT", path: "/users/1", ifMatch: `"7", "1"`, body: `{"name":"Hanzala Areeb"}`, wantStatus: http.StatusOK,
+			wantBody:  `{"data":{"id":1,"name":"Hanzala Areeb","version":2}}`,
+			wantUsers: []User{{ID: 1, Name: "Hanzala Areeb", Version: 2}, {ID: 2, Name: "Areeb", Version: 1}}},
+		{name: "put if-match any", method: "PUT", path: "/users/1", ifMatch: "*", body: `{"name":"Hanzala Areeb"}`, wantStatus: http.StatusOK,
+			wantUsers: []User{{ID: 1, Name: "Hanzala Areeb", Version: 2}, {ID: 2, Name: "Areeb", Version: 1}}},
+		{name: "put outdated if-match", method: "PUT", path: "/users/1", ifMatch: `"0"`, body: `{"name":"Hanzala Areeb","version":1}`,
+			wantStatus: http.StatusPreconditionFailed, wantBody: `"status":412`},
+		{name: "put outdated version", method: "PUT", path: "/users/1", body: `{"name":"Hanzala Areeb","version":3}`,
+			wantStatus: http.StatusConflict, wantBody: `"status":409`},
+		{name: "put without version", method: "PUT", path: "/users/1", body: `{"name":"Hanzala Areeb"}`,
+			wantStatus: http.StatusPreconditionRequired, wantBody: `"status":428`},
+		{name: "put without name", method: "PUT", path: "/users/1", body: `{"version":1}`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"field":"name","rule":"required"`},
+		{name: "put unknown", method: "PUT", path: "/users/9", body: `{"name":"Nobody"}`, wantStatus: http.StatusNotFound,
+			wantBody: `"user not found"`},
+		{name: "put malformed", method: "PUT", path: "/users/1", body: `{"name":`, wantStatus: http.StatusBadRequest,
+			wantBody: `"status":400`},
+		{name: "patch", method: "PATCH", path: "/users/2", body: `{"name":"A. Areeb","version":1}`, wantStatus: http.StatusOK,
+			wantBody: `{"data":{"id":2,"name":"A. Areeb","version":2}}`, wantETag: `"2"`,
+			wantUsers: []User{{ID: 1, Name: "Hanzala", Version: 1}, {ID: 2, Name: "A. Areeb", Version: 2}}},
+		{name: "patch if-match", method: "PATCH", path: "/users/2", ifMatch: `"1"`, body: `{"name":"A. Areeb"}`, wantStatus: http.StatusOK,
+			wantUsers: []User{{ID: 1, Name: "Hanzala", Version: 1}, {ID: 2, Name: "A. Areeb", Version: 2}}},
+		{name: "patch outdated if-match", method: "PATCH", path: "/users/2", ifMatch: `W/"1"`, body: `{"name":"A. Areeb"}`,
+			wantStatus: http.StatusPreconditionFailed, wantBody: `"status":412`},
+		{name: "patch outdated version", method: "PATCH", path: "/users/2", body: `{"name":"A. Areeb","version":2}`,
+			wantStatus: http.StatusConflict, wantBody: `"status":409`},
+		{name: "patch without version", method: "PATCH", path: "/users/2", body: `{"name":"A. Areeb"}`,
+			wantStatus: http.StatusPreconditionRequired, wantBody: `"status":428`},
+		{name: "patch nothing", method: "PATCH", path: "/users/2", body: `{"version":1}`, wantStatus: http.StatusOK,
+			wantBody:  `{"data":{"id":2,"name":"Areeb","version":2}}`,
+			wantUsers: []User{{ID: 1, Name: "Hanzala", Version: 1}, {ID: 2, Name: "Areeb", Version: 2}}},
+		{name: "patch empty name", method: "PATCH", path: "/users/2", body: `{"name":"","version":1}`, wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `"rule":"required"`},
+		{name: "delete", method: "DELETE", path: "/users/1", wantStatus: http.StatusNoContent,
+			wantUsers: []User{{ID: 2, Name: "Areeb", Version: 1}}},
+		{name: "delete unknown", method: "DELETE", path: "/users/9", wantStatus: http.StatusNotFound,
+			wantBody: `"user not found"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			users := store.NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}, User{ID: 2, Name: "Areeb"})
+			r := router.New()
+			RegisterRoutes(r, users)
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req := httptest.NewRequest(tc.method, tc.path, body)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rr.Code, rr.Body, tc.wantStatus, tc.wantBody)
+			}
+			if tc.wantETag != "" && rr.Header().Get("ETag") != tc.wantETag {
+				t.Errorf("got ETag %q, want %q", rr.Header().Get("ETag"), tc.wantETag)
+			}
+			want := tc.wantUsers
+			if want == nil {
+				want = []User{{ID: 1, Name: "Hanzala", Version: 1}, {ID: 2, Name: "Areeb", Version: 1}}
+			}
+			if got, _, _ := users.List(context.Background(), store.ListOptions{}); !reflect.DeepEqual(got, want) {
+				t.Errorf("store holds %v, want %v", got, want)
+			}
+		})
+	}
+}
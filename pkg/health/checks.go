@@ -0,0 +1,60 @@
+// Description: This file contains ready-made checks for common dependencies.
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Pinger is anything that can check its connection, like *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck checks a database, or anything else with PingContext.
+func PingCheck(p Pinger) Check {
+	return p.PingContext
+}
+
+// HTTPCheck checks a downstream service by fetching url, which must answer
+// with a 2xx or 3xx status. A nil client means http.DefaultClient.
+func HTTPCheck(client *http.Client, url string) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		// Drain a little, so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// DiskSpaceCheck fails when the file system holding path has fewer than
+// minFree bytes available to the server's user.
+func DiskSpaceCheck(path string, minFree uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%s: %d bytes free, want at least %d", path, free, minFree)
+		}
+		return nil
+	}
+}
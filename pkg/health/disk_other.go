@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || openbsd)
+
+// Description: This file stands in for reading the free disk space on
+// systems without statfs.
+
+package health
+
+import "errors"
+
+// freeSpace reports that free space can't be read here.
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("health: disk space checks aren't supported on this system")
+}
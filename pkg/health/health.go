@@ -0,0 +1,207 @@
+// Description: This package contains the health checks behind the liveness
+// and readiness probes. Components register named checks when they're set up:
+//
+//	checks := health.New(health.Options{})
+//	checks.Register("db", health.PingCheck(db))
+//	checks.Register("disk", health.DiskSpaceCheck("/var/lib/app", 1<<30))
+//	checks.Register("billing", health.HTTPCheck(nil, "http://billing.internal/healthz"))
+//	a := admin.New(admin.Options{Health: checks})
+//
+// Readiness asks whether the process should get traffic, and fails while a
+// dependency is down, so the load balancer routes around it. Liveness asks
+// whether it should be restarted, and must not depend on downstream services:
+// a database outage would otherwise restart every replica at once. Checks
+// registered with RegisterLiveness count for both.
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Check reports a component's health: nil if it's healthy. It should give up
+// once ctx is done.
+type Check func(ctx context.Context) error
+
+// Options configures a Registry.
+type Options struct {
+	// Timeout bounds each check; one still running then fails. The default
+	// is 2 seconds, so a probe answers within its usual timeout.
+	Timeout time.Duration
+}
+
+// Registry holds the named checks. It's safe for concurrent use.
+type Registry struct {
+	opts Options
+
+	mu        sync.RWMutex
+	readiness map[string]Check
+	liveness  map[string]Check
+}
+
+// Status is the outcome of a check, or of all of them: "ok" or "fail".
+type Status string
+
+// The statuses.
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of a probe: StatusOK if every check passed.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// New creates an empty registry.
+func New(opts Options) *Registry {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	return &Registry{
+		opts:      opts,
+		readiness: make(map[string]Check),
+		liveness:  make(map[string]Check),
+	}
+}
+
+// Register adds a readiness check. Names must be unique.
+func (r *Registry) Register(name string, check Check) {
+	r.add(r.readiness, name, check)
+}
+
+// RegisterLiveness adds a check for both liveness and readiness, for
+// problems only a restart fixes, like a deadlocked worker.
+func (r *Registry) RegisterLiveness(name string, check Check) {
+	r.add(r.liveness, name, check)
+}
+
+// add registers check under name in checks.
+func (r *Registry) add(checks map[string]Check, name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, dupReady := r.readiness[name]
+	_, dupLive := r.liveness[name]
+	if dupReady || dupLive {
+		panic(fmt.Errorf("health: check %q registered twice", name))
+	}
+	checks[name] = check
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := copyChecks(r.liveness)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Ready runs the readiness and liveness checks.
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := copyChecks(r.liveness, r.readiness)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// copyChecks merges the maps, so the checks run without holding the lock.
+func copyChecks(maps ...map[string]Check) map[string]Check {
+	all := make(map[string]Check)
+	for _, m := range maps {
+		for name, check := range m {
+			all[name] = check
+		}
+	}
+	return all
+}
+
+// run runs the checks concurrently, each with the timeout.
+func (r *Registry) run(ctx context.Context, checks map[string]Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := r.runCheck(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// runCheck runs one check. A check that ignores its context is abandoned at
+// the timeout, so a hung dependency can't hang the probe.
+func (r *Registry) runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %v", r.opts.Timeout)
+	}
+	res := Result{Status: StatusOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
+
+// LivenessHandler answers a liveness probe with the report, as 200 OK when
+// the checks pass and 503 Service Unavailable otherwise.
+func (r *Registry) LivenessHandler() func(c *httpcontext.Context) {
+	return func(c *httpcontext.Context) {
+		respond(c, r.Live(c.Request.Context()))
+	}
+}
+
+// ReadinessHandler answers a readiness probe like LivenessHandler.
+func (r *Registry) ReadinessHandler() func(c *httpcontext.Context) {
+	return func(c *httpcontext.Context) {
+		respond(c, r.Ready(c.Request.Context()))
+	}
+}
+
+// respond writes the report with the status code probes look at. The
+// errors are included: the probes are served on the internal admin port.
+func respond(c *httpcontext.Context, report Report) {
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.SetHeader("Cache-Control", "no-store")
+	c.Respond(status, report, nil)
+}
@@ -0,0 +1,148 @@
+// Description: This file contains tests for the health checks.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// ok is a check that always passes.
+func ok(ctx context.Context) error { return nil }
+
+// TestRegistry tests running the checks for each probe.
+func TestRegistry(t *testing.T) {
+	// 1. Setup
+	r := New(Options{Timeout: 20 * time.Millisecond})
+	r.RegisterLiveness("worker", ok)
+	r.Register("db", func(ctx context.Context) error { return errors.New("connection refused") })
+	r.Register("cache", ok)
+	r.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second) // ignores ctx
+		return nil
+	})
+	r.Register("buggy", func(ctx context.Context) error { panic("nil map") })
+
+	// 2. Execute
+	start := time.Now()
+	live := r.Live(context.Background())
+	ready := r.Ready(context.Background())
+
+	// 3. Assert
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("probes took %v; the slow check should have been abandoned", elapsed)
+	}
+	if live.Status != StatusOK || len(live.Checks) != 1 || live.Checks["worker"].Status != StatusOK {
+		t.Errorf("liveness must only run the liveness checks, got %+v", live)
+	}
+	if ready.Status != StatusFail || len(ready.Checks) != 5 {
+		t.Fatalf("got readiness %+v", ready)
+	}
+	wantErrors := map[string]string{
+		"worker": "",
+		"cache":  "",
+		"db":     "connection refused",
+		"slow":   "timed out after 20ms",
+		"buggy":  "panic: nil map",
+	}
+	for name, want := range wantErrors {
+		if got := ready.Checks[name]; got.Error != want || (want == "") != (got.Status == StatusOK) {
+			t.Errorf("%s: got %+v, want error %q", name, got, want)
+		}
+	}
+}
+
+// TestRegistry_Duplicate tests that check names are unique across probes.
+func TestRegistry_Duplicate(t *testing.T) {
+	r := New(Options{})
+	r.Register("db", ok)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	r.RegisterLiveness("db", ok)
+}
+
+// TestReadinessHandler tests the probe responses.
+func TestReadinessHandler(t *testing.T) {
+	var failing error
+	r := New(Options{})
+	r.Register("db", func(ctx context.Context) error { return failing })
+
+	for _, tc := range []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "healthy", wantStatus: http.StatusOK},
+		{name: "unhealthy", err: errors.New("down"), wantStatus: http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			failing = tc.err
+			rr := httptest.NewRecorder()
+			c := new(httpcontext.Context)
+			c.Reset(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+			// 2. Execute
+			r.ReadinessHandler()(c)
+
+			// 3. Assert
+			var body struct{ Data Report }
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rr.Code != tc.wantStatus || body.Data.Checks["db"].Status == "" {
+				t.Errorf("got %d %s", rr.Code, rr.Body)
+			}
+			if rr.Header().Get("Cache-Control") != "no-store" {
+				t.Error("probe response may be cached")
+			}
+		})
+	}
+}
+
+// TestChecks tests the ready-made checks.
+func TestChecks(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+
+	tests := []struct {
+		name    string
+		check   Check
+		wantErr bool
+	}{
+		{name: "http up", check: HTTPCheck(nil, up.URL)},
+		{name: "http error status", check: HTTPCheck(nil, down.URL), wantErr: true},
+		{name: "http unreachable", check: HTTPCheck(nil, "http://127.0.0.1:1/"), wantErr: true},
+		{name: "disk space", check: DiskSpaceCheck(t.TempDir(), 1)},
+		{name: "disk full", check: DiskSpaceCheck(t.TempDir(), 1<<62), wantErr: true},
+		{name: "ping", check: PingCheck(pinger{})},
+		{name: "ping failure", check: PingCheck(pinger{errors.New("bad connection")}), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.check(context.Background()); (err != nil) != tc.wantErr {
+				t.Errorf("got %v, want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// pinger is a Pinger returning a fixed error.
+type pinger struct{ err error }
+
+// PingContext implements Pinger.
+func (p pinger) PingContext(ctx context.Context) error { return p.err }
@@ -0,0 +1,77 @@
+// Description: This file adds compact binary formats, MessagePack and CBOR, for
+// IoT and mobile clients. Handlers can render them directly (c.MsgPack, c.CBOR),
+// or call c.Negotiate (see render.go) to let the client's Accept header pick
+// between JSON and the binary formats. Bind picks the matching decoder from the Content-Type.
+
+package httpcontext
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/codec"
+)
+
+// Media types for the binary formats. application/vnd.msgpack is the
+// registered type; the x- variants are still common in the wild and accepted
+// when binding.
+const (
+	MIMEMsgPack = "application/vnd.msgpack"
+	MIMECBOR    = "application/cbor"
+)
+
+// isMsgPack reports whether mt is one of the media types used for MessagePack.
+func isMsgPack(mt string) bool {
+	return mt == MIMEMsgPack || mt == "application/msgpack" || mt == "application/x-msgpack"
+}
+
+// MsgPack is a helper method to send a MessagePack response.
+func (c *Context) MsgPack(statusCode int, data interface{}) {
+	c.Render(statusCode, MsgPackRenderer{Data: data})
+}
+
+// CBOR is a helper method to send a CBOR response.
+func (c *Context) CBOR(statusCode int, data interface{}) {
+	c.Render(statusCode, CBORRenderer{Data: data})
+}
+
+// BindMsgPack decodes the MessagePack request body into v (a pointer) using
+// DefaultBindOptions.MaxBytes, then validates it. On failure it returns a *BindError.
+func (c *Context) BindMsgPack(v interface{}) error {
+	return c.bindBinary(v, "MessagePack", codec.UnmarshalMsgPack)
+}
+
+// BindCBOR decodes the CBOR request body into v (a pointer) using
+// DefaultBindOptions.MaxBytes, then validates it. On failure it returns a *BindError.
+func (c *Context) BindCBOR(v interface{}) error {
+	return c.bindBinary(v, "CBOR", codec.UnmarshalCBOR)
+}
+
+// bindBinary reads the whole body and decodes it with unmarshal. Unlike JSON,
+// these decoders work on a complete buffer, so the size limit is applied while
+// reading.
+func (c *Context) bindBinary(v interface{}, format string, unmarshal func([]byte, interface{}) error) error {
+	body := c.Request.Body
+	if body == nil || body == http.NoBody {
+		return &BindError{Status: http.StatusBadRequest, Message: "request body is empty"}
+	}
+	if DefaultBindOptions.MaxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, DefaultBindOptions.MaxBytes)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return jsonBindError(err)
+		}
+		return &BindError{Status: http.StatusBadRequest, Message: "error reading request body", Err: err}
+	}
+	if len(data) == 0 {
+		return &BindError{Status: http.StatusBadRequest, Message: "request body is empty"}
+	}
+	if err := unmarshal(data, v); err != nil {
+		return &BindError{Status: http.StatusBadRequest, Message: "malformed " + format + " body", Err: err}
+	}
+	return Validate(v)
+}
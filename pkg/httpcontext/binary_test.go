@@ -0,0 +1,94 @@
+// Description: This file contains tests for the MessagePack and CBOR helpers.
+
+package httpcontext
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/codec"
+)
+
+// TestContext_Negotiate checks that the Accept header selects the format.
+func TestContext_Negotiate(t *testing.T) {
+	tests := []struct {
+		accept   string
+		wantType string
+	}{
+		{"", "application/json"},
+		{"application/json", "application/json"},
+		{"application/msgpack", MIMEMsgPack},
+		{"application/cbor", MIMECBOR},
+		{"application/json;q=0.5, application/cbor", MIMECBOR},
+		{"application/cbor;q=0.1, */*", "application/json"},
+		{"text/html", "application/json"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.accept, func(t *testing.T) {
+			// 1. Setup
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tc.accept)
+			c := &Context{Writer: rr, Request: req}
+
+			// 2. Execute
+			c.Negotiate(http.StatusOK, bindTarget{Name: "ann", Age: 30})
+
+			// 3. Assert
+			if got := rr.Header().Get("Content-Type"); got != tc.wantType {
+				t.Errorf("Content-Type: got %q, want %q", got, tc.wantType)
+			}
+			if rr.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", rr.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+// TestContext_BindBinary checks that Bind decodes MessagePack and CBOR bodies
+// from their Content-Type.
+func TestContext_BindBinary(t *testing.T) {
+	tests := []struct {
+		contentType string
+		marshal     func(interface{}) ([]byte, error)
+	}{
+		{"application/msgpack", codec.MarshalMsgPack},
+		{"application/x-msgpack", codec.MarshalMsgPack},
+		{MIMEMsgPack, codec.MarshalMsgPack},
+		{MIMECBOR, codec.MarshalCBOR},
+	}
+	for _, tc := range tests {
+		t.Run(tc.contentType, func(t *testing.T) {
+			body, err := tc.marshal(bindTarget{Name: "ann", Age: 30})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("POST", "/users", bytes.NewReader(body))
+			req.Header.Set("Content-Type", tc.contentType)
+			c := &Context{Writer: httptest.NewRecorder(), Request: req}
+
+			var v bindTarget
+			if err := c.Bind(&v); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.Name != "ann" || v.Age != 30 {
+				t.Errorf("unexpected result: %+v", v)
+			}
+		})
+	}
+}
+
+// TestContext_BindCBOR_Malformed checks that garbage yields a 400 BindError.
+func TestContext_BindCBOR_Malformed(t *testing.T) {
+	c := newBodyContext("\x1b\x01")
+
+	var v bindTarget
+	err := c.BindCBOR(&v)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Status != http.StatusBadRequest {
+		t.Fatalf("expected a 400 BindError, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+// Description: This file contains helpers for decoding ("binding") request bodies
+// into Go values. Binding errors are returned as *BindError, which carries the
+// HTTP status a handler should answer with and a client-friendly message.
+
+package httpcontext
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// BindOptions controls how request bodies are decoded.
+type BindOptions struct {
+	// MaxBytes caps the size of the body. Zero or less means no limit.
+	MaxBytes int64
+
+	// DisallowUnknownFields makes decoding fail when the body contains a
+	// field that doesn't exist on the target struct, instead of ignoring it.
+	DisallowUnknownFields bool
+}
+
+// DefaultBindOptions are used by BindJSON. They can be changed at startup to set
+// application-wide defaults; individual handlers can use BindJSONWith instead.
+var DefaultBindOptions = BindOptions{
+	MaxBytes: 1 << 20, // 1MB is plenty for a typical JSON API payload.
+}
+
+// BindError describes why a request body couldn't be bound. It is designed to be
+// sent back to the client as-is: c.JSON(err.Status, err).
+type BindError struct {
+	// Status is the HTTP status code to respond with: 400 for malformed
+	// input, 413 when the body is too large.
+	Status int `json:"-"`
+
+	// Field is the offending field, when the error can be traced to one.
+	Field string `json:"field,omitempty"`
+
+	// Message is a human-readable description of the problem.
+	Message string `json:"error"`
+
+	// Fields lists every failed validation rule when Status is 422.
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// Err is the underlying decoding error, kept for logging.
+	Err error `json:"-"`
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s (field %q)", e.Message, e.Field)
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindJSON decodes the JSON request body into v (a pointer) using
+// DefaultBindOptions, then validates it (see validate.go). On failure it returns
+// a *BindError:
+//
+//	var u User
+//	if err := c.BindJSON(&u); err != nil {
+//		var bindErr *httpcontext.BindError
+//		errors.As(err, &bindErr)
+//		c.JSON(bindErr.Status, bindErr)
+//		return
+//	}
+func (c *Context) BindJSON(v interface{}) error {
+	return c.BindJSONWith(v, DefaultBindOptions)
+}
+
+// BindJSONWith is like BindJSON but with explicit options.
+func (c *Context) BindJSONWith(v interface{}, opts BindOptions) error {
+	body := c.Request.Body
+	if body == nil || body == http.NoBody {
+		return &BindError{Status: http.StatusBadRequest, Message: "request body is empty"}
+	}
+	if opts.MaxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, opts.MaxBytes)
+	}
+
+	dec := json.NewDecoder(body)
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return jsonBindError(err)
+	}
+
+	// The body must contain exactly one JSON value; "{}{}" or "{} junk" is
+	// almost certainly a client bug.
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if err == nil {
+			return &BindError{Status: http.StatusBadRequest, Message: "request body must contain a single JSON value"}
+		}
+		return jsonBindError(err)
+	}
+	return Validate(v)
+}
+
+// jsonBindError translates the errors produced by encoding/json (and by
+// http.MaxBytesReader) into a BindError.
+func jsonBindError(err error) *BindError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &maxErr):
+		return &BindError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not be larger than %d bytes", maxErr.Limit),
+			Err:     err,
+		}
+	case errors.As(err, &syntaxErr):
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("malformed JSON at position %d", syntaxErr.Offset),
+			Err:     err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{Status: http.StatusBadRequest, Message: "malformed JSON: unexpected end of body", Err: err}
+	case errors.Is(err, io.EOF):
+		return &BindError{Status: http.StatusBadRequest, Message: "request body is empty", Err: err}
+	case errors.As(err, &typeErr):
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("invalid value: expected %s", typeErr.Type),
+			Err:     err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no dedicated error type for unknown fields,
+		// only this message format.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BindError{Status: http.StatusBadRequest, Field: field, Message: "unknown field", Err: err}
+	default:
+		return &BindError{Status: http.StatusBadRequest, Message: "invalid request body", Err: err}
+	}
+}
+
+// BindXML decodes the XML request body into v (a pointer) using
+// DefaultBindOptions.MaxBytes, then validates it. On failure it returns a *BindError.
+func (c *Context) BindXML(v interface{}) error {
+	body := c.Request.Body
+	if body == nil || body == http.NoBody {
+		return &BindError{Status: http.StatusBadRequest, Message: "request body is empty"}
+	}
+	if DefaultBindOptions.MaxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, DefaultBindOptions.MaxBytes)
+	}
+	if err := xml.NewDecoder(body).Decode(v); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return jsonBindError(err)
+		}
+		if errors.Is(err, io.EOF) {
+			return &BindError{Status: http.StatusBadRequest, Message: "request body is empty", Err: err}
+		}
+		return &BindError{Status: http.StatusBadRequest, Message: "malformed XML", Err: err}
+	}
+	return Validate(v)
+}
+
+// BindQuery fills the struct pointed to by v from the URL query string, using
+// `query:"name"` struct tags.
+func (c *Context) BindQuery(v interface{}) error {
+	if err := bindValues(v, c.queryValues(), "query", nil); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// BindForm fills the struct pointed to by v from an
+// application/x-www-form-urlencoded or multipart/form-data body (plus the URL
+// query string), using `form:"name"` struct tags.
+func (c *Context) BindForm(v interface{}) error {
+	if DefaultBindOptions.MaxBytes > 0 && c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, DefaultBindOptions.MaxBytes)
+	}
+
+	// ParseMultipartForm also handles urlencoded bodies, but complains when
+	// the body isn't multipart, so we pick the right parser ourselves.
+	var err error
+	if mediaType(c.Request) == "multipart/form-data" {
+		err = c.Request.ParseMultipartForm(32 << 20) // Files above 32MB are spooled to disk.
+	} else {
+		err = c.Request.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return jsonBindError(err)
+		}
+		return &BindError{Status: http.StatusBadRequest, Message: "malformed form body", Err: err}
+	}
+	if err := bindValues(v, c.Request.Form, "form", nil); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// BindHeader fills the struct pointed to by v from the request headers, using
+// `header:"X-Name"` struct tags. Header names are matched case-insensitively.
+func (c *Context) BindHeader(v interface{}) error {
+	if err := bindValues(v, c.Request.Header, "header", http.CanonicalHeaderKey); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// Bind picks a decoder based on the request's Content-Type: JSON, XML, form,
+// MessagePack, or CBOR bodies are decoded accordingly, and requests without a body (such as most GETs)
+// are bound from the query string. Other content types yield a 415 BindError.
+func (c *Context) Bind(v interface{}) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+		return c.BindQuery(v)
+	}
+
+	switch mt := mediaType(c.Request); {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		return c.BindJSON(v)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return c.BindXML(v)
+	case mt == "application/x-www-form-urlencoded" || mt == "multipart/form-data":
+		return c.BindForm(v)
+	case isMsgPack(mt):
+		return c.BindMsgPack(v)
+	case mt == MIMECBOR:
+		return c.BindCBOR(v)
+	default:
+		return &BindError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("unsupported content type %q", c.Request.Header.Get("Content-Type")),
+		}
+	}
+}
+
+// mediaType returns the request's media type without parameters, lowercased,
+// or "" if the Content-Type header is missing or malformed.
+func mediaType(req *http.Request) string {
+	mt, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
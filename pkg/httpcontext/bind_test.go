@@ -0,0 +1,236 @@
+// Description: This file contains tests for the request body binding helpers.
+
+package httpcontext
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bindTarget is the struct our binding tests decode into.
+type bindTarget struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// newBodyContext builds a POST Context carrying body as its request body.
+func newBodyContext(body string) *Context {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	return &Context{Writer: rr, Request: req}
+}
+
+// TestContext_BindJSON tests successful decoding.
+func TestContext_BindJSON(t *testing.T) {
+	c := newBodyContext(`{"name":"ann","age":30,"extra":true}`)
+
+	var v bindTarget
+	if err := c.BindJSON(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Name != "ann" || v.Age != 30 {
+		t.Errorf("unexpected result: %+v", v)
+	}
+}
+
+// TestContext_BindJSON_Errors tests that decoding failures become BindErrors
+// with the right status and field.
+func TestContext_BindJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		opts       BindOptions
+		wantStatus int
+		wantField  string
+	}{
+		{"empty", ``, BindOptions{}, http.StatusBadRequest, ""},
+		{"syntax", `{"name":`, BindOptions{}, http.StatusBadRequest, ""},
+		{"type", `{"age":"old"}`, BindOptions{}, http.StatusBadRequest, "age"},
+		{"unknown field", `{"nick":"a"}`, BindOptions{DisallowUnknownFields: true}, http.StatusBadRequest, "nick"},
+		{"trailing data", `{} {}`, BindOptions{}, http.StatusBadRequest, ""},
+		{"too large", `{"name":"` + strings.Repeat("a", 100) + `"}`, BindOptions{MaxBytes: 16}, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		c := newBodyContext(tt.body)
+
+		var v bindTarget
+		err := c.BindJSONWith(&v, tt.opts)
+
+		var bindErr *BindError
+		if !errors.As(err, &bindErr) {
+			t.Errorf("%s: expected a *BindError, but got %v", tt.name, err)
+			continue
+		}
+		if bindErr.Status != tt.wantStatus {
+			t.Errorf("%s: expected status %d, but got %d", tt.name, tt.wantStatus, bindErr.Status)
+		}
+		if bindErr.Field != tt.wantField {
+			t.Errorf("%s: expected field %q, but got %q", tt.name, tt.wantField, bindErr.Field)
+		}
+	}
+}
+
+// multiTarget carries tags for every binding source.
+type multiTarget struct {
+	Name    string   `json:"name" xml:"name" form:"name" query:"name"`
+	Age     int      `json:"age" xml:"age" form:"age" query:"age"`
+	Tags    []string `form:"tag" query:"tag"`
+	Active  *bool    `query:"active"`
+	Ignored string   `query:"-"`
+}
+
+// TestContext_Bind tests that Bind picks the decoder from the Content-Type.
+func TestContext_Bind(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+	}{
+		{"json", "POST", "/users", "application/json", `{"name":"ann","age":30}`},
+		{"xml", "POST", "/users", "application/xml", `<user><name>ann</name><age>30</age></user>`},
+		{"form", "POST", "/users", "application/x-www-form-urlencoded", `name=ann&age=30`},
+		{"query", "GET", "/users?name=ann&age=30", "", ``},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		c := &Context{Writer: httptest.NewRecorder(), Request: req}
+
+		var v multiTarget
+		if err := c.Bind(&v); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if v.Name != "ann" || v.Age != 30 {
+			t.Errorf("%s: unexpected result: %+v", tt.name, v)
+		}
+	}
+}
+
+// TestContext_BindQuery tests slices, pointers, skipped fields, and errors.
+func TestContext_BindQuery(t *testing.T) {
+	c, _ := newTestContext("GET", "/users?tag=a&tag=b&active=true&Ignored=x")
+
+	var v multiTarget
+	if err := c.BindQuery(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Tags) != 2 || v.Tags[0] != "a" || v.Tags[1] != "b" {
+		t.Errorf("expected tags [a b], but got %v", v.Tags)
+	}
+	if v.Active == nil || !*v.Active {
+		t.Errorf("expected active to be set to true, but got %v", v.Active)
+	}
+	if v.Ignored != "" {
+		t.Errorf("expected the \"-\" field to be skipped, but got %q", v.Ignored)
+	}
+
+	// A value of the wrong type names the field.
+	c, _ = newTestContext("GET", "/users?age=old")
+	err := c.BindQuery(&v)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Field != "age" || bindErr.Status != http.StatusBadRequest {
+		t.Errorf("expected a 400 BindError for field age, but got %v", err)
+	}
+}
+
+// TestContext_BindHeader tests case-insensitive header binding.
+func TestContext_BindHeader(t *testing.T) {
+	var v struct {
+		RequestID string `header:"x-request-id"`
+		Retries   int    `header:"X-Retries"`
+	}
+	c, _ := newTestContext("GET", "/")
+	c.Request.Header.Set("X-Request-Id", "abc")
+	c.Request.Header.Set("X-Retries", "3")
+
+	if err := c.BindHeader(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.RequestID != "abc" || v.Retries != 3 {
+		t.Errorf("unexpected result: %+v", v)
+	}
+}
+
+// TestContext_Bind_UnsupportedType tests that unknown content types are rejected.
+func TestContext_Bind_UnsupportedType(t *testing.T) {
+	c := newBodyContext("hello")
+	c.Request.Header.Set("Content-Type", "text/plain")
+
+	var v multiTarget
+	var bindErr *BindError
+	if err := c.Bind(&v); !errors.As(err, &bindErr) || bindErr.Status != http.StatusUnsupportedMediaType {
+		t.Errorf("expected a 415 BindError, but got %v", err)
+	}
+}
+
+// level is a domain enum bound through a registered decoder.
+type level int
+
+// hexID is a domain type that decodes itself via encoding.TextUnmarshaler,
+// the way most UUID types do.
+type hexID [2]byte
+
+func (h *hexID) UnmarshalText(text []byte) error {
+	if len(text) != 4 {
+		return fmt.Errorf("expected 4 hex digits")
+	}
+	_, err := hex.Decode(h[:], text)
+	return err
+}
+
+// TestBind_CustomDecoders tests registered decoders, TextUnmarshaler types,
+// and the default time decoders.
+func TestBind_CustomDecoders(t *testing.T) {
+	RegisterDecoder(func(s string) (level, error) {
+		switch s {
+		case "low":
+			return 1, nil
+		case "high":
+			return 2, nil
+		}
+		return 0, fmt.Errorf("unknown level")
+	})
+
+	var v struct {
+		Since time.Time     `query:"since"`
+		Every time.Duration `query:"every"`
+		Level level         `query:"level"`
+		ID    hexID         `query:"id"`
+		IDs   []hexID       `query:"ids"`
+	}
+	c, _ := newTestContext("GET", "/?since=2024-06-07T12:00:00Z&every=1m30s&level=high&id=beef&ids=0001&ids=ffff")
+	if err := c.BindQuery(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !v.Since.Equal(time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time: %v", v.Since)
+	}
+	if v.Every != 90*time.Second {
+		t.Errorf("unexpected duration: %v", v.Every)
+	}
+	if v.Level != 2 {
+		t.Errorf("unexpected level: %v", v.Level)
+	}
+	if v.ID != (hexID{0xbe, 0xef}) || len(v.IDs) != 2 || v.IDs[1] != (hexID{0xff, 0xff}) {
+		t.Errorf("unexpected IDs: %v %v", v.ID, v.IDs)
+	}
+
+	// A value a decoder rejects becomes a 400 naming the field.
+	c, _ = newTestContext("GET", "/?level=medium")
+	var bindErr *BindError
+	if err := c.BindQuery(&v); !errors.As(err, &bindErr) || bindErr.Field != "level" {
+		t.Errorf("expected a BindError for field level, but got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Description: This file implements binding of string key/value data (query
+// strings, form bodies, and headers) into structs using struct tags, e.g.
+//
+//	type Filter struct {
+//		Name  string   `query:"name"`
+//		Page  int      `query:"page"`
+//		Tags  []string `query:"tag"`
+//	}
+//
+// Fields without the tag are looked up by their Go name, and a tag of "-"
+// skips the field. Embedded structs are bound as if their fields were inlined.
+
+package httpcontext
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// decoders is the registry of custom string decoders, keyed by target type.
+// It's read on every bind and written rarely (at startup), hence the RWMutex.
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[reflect.Type]func(string) (interface{}, error){}
+)
+
+// RegisterDecoder teaches query, form, and header binding how to turn a string
+// into a T, for domain types that aren't plain strings or numbers:
+//
+//	httpcontext.RegisterDecoder(func(s string) (Status, error) { return ParseStatus(s) })
+//
+// Types implementing encoding.TextUnmarshaler (like most UUID packages) work
+// without registration. time.Time (RFC 3339) and time.Duration ("1m30s") are
+// registered by default. Registering a type again replaces its decoder.
+func RegisterDecoder[T any](decode func(string) (T, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[typ] = func(s string) (interface{}, error) {
+		return decode(s)
+	}
+}
+
+// init registers the decoders for common standard library types.
+func init() {
+	RegisterDecoder(func(s string) (time.Time, error) {
+		return time.Parse(time.RFC3339, s)
+	})
+	RegisterDecoder(time.ParseDuration)
+}
+
+// lookupDecoder returns the registered decoder for a type, if any.
+func lookupDecoder(typ reflect.Type) (func(string) (interface{}, error), bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decode, ok := decoders[typ]
+	return decode, ok
+}
+
+// textUnmarshalerType is used to detect types that can decode themselves.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// bindValues fills the struct pointed to by v from values, using the struct tag
+// named tag to map fields to keys. canonical, if non-nil, normalizes keys before
+// lookup (used for case-insensitive header names).
+func bindValues(v interface{}, values map[string][]string, tag string, canonical func(string) string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		// Passing a non-pointer is a programming error, not a client one,
+		// but we still report it through the normal error path.
+		return &BindError{Status: http.StatusInternalServerError, Message: "bind target must be a non-nil pointer to a struct"}
+	}
+	return bindStruct(rv.Elem(), values, tag, canonical)
+}
+
+// bindStruct binds every exported field of the struct value sv.
+func bindStruct(sv reflect.Value, values map[string][]string, tag string, canonical func(string) string) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		fv := sv.Field(i)
+
+		// Embedded structs are flattened into their parent.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(fv, values, tag, canonical); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get(tag)
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		if canonical != nil {
+			key = canonical(key)
+		}
+
+		raw, ok := values[key]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			return &BindError{Status: http.StatusBadRequest, Field: key, Message: err.Error(), Err: err}
+		}
+	}
+	return nil
+}
+
+// setField converts the raw string values into the field's type.
+func setField(fv reflect.Value, raw []string) error {
+	// Slices take every value; all other types take the first one. Slice
+	// types that know how to decode themselves (e.g. net.IP) are scalars here.
+	if fv.Kind() == reflect.Slice && !decodesItself(fv) {
+		slice := reflect.MakeSlice(fv.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setScalar(fv, raw[0])
+}
+
+// decodesItself reports whether a value's type has a registered decoder or
+// implements encoding.TextUnmarshaler.
+func decodesItself(fv reflect.Value) bool {
+	if _, ok := lookupDecoder(fv.Type()); ok {
+		return true
+	}
+	return reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType)
+}
+
+// setScalar converts a single string into the value's type.
+func setScalar(fv reflect.Value, s string) error {
+	// Pointers are allocated on demand, so an absent key leaves them nil.
+	if fv.Kind() == reflect.Ptr {
+		elem := reflect.New(fv.Type().Elem())
+		if err := setScalar(elem.Elem(), s); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+
+	// Registered decoders and self-decoding types take priority over the
+	// built-in conversions, so e.g. time.Duration isn't parsed as an int64.
+	if decode, ok := lookupDecoder(fv.Type()); ok {
+		value, err := decode(s)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: %v", s, err)
+		}
+		fv.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if fv.CanAddr() && reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+		if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid value %q: %v", s, err)
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: expected a boolean", s)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid value %q: expected an integer", s)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid value %q: expected a non-negative integer", s)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid value %q: expected a number", s)
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Description: This file contains Body, which buffers the request body so it
+// can be read more than once. A request body is normally a one-shot stream: if a
+// signature-checking middleware reads it, the handler's JSON decoder finds it
+// empty. Body reads it into memory once and puts a fresh reader back in its
+// place every time it's called.
+
+package httpcontext
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Body returns the complete request body, reading and buffering it on the first
+// call. Afterwards c.Request.Body is replaced with a reader over the buffer, so
+// the Bind helpers and anything else reading the request still see the whole
+// body. The size is capped at DefaultBindOptions.MaxBytes; a larger body yields
+// a 413 *BindError. A request without a body returns nil.
+func (c *Context) Body() ([]byte, error) {
+	if !c.bodyRead {
+		data, err := c.readBody()
+		if err != nil {
+			return nil, err
+		}
+		c.body, c.bodyRead = data, true
+	}
+	if c.body != nil {
+		// Rewind for the next reader.
+		c.Request.Body = io.NopCloser(bytes.NewReader(c.body))
+	}
+	return c.body, nil
+}
+
+// readBody reads the whole request body within the configured limit.
+func (c *Context) readBody() ([]byte, error) {
+	body := c.Request.Body
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	if DefaultBindOptions.MaxBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, DefaultBindOptions.MaxBytes)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, jsonBindError(err)
+		}
+		return nil, &BindError{Status: http.StatusBadRequest, Message: "error reading request body", Err: err}
+	}
+	return data, nil
+}
@@ -0,0 +1,51 @@
+// Description: This file contains tests for the rewindable request body.
+
+package httpcontext
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestContext_Body checks that the body can be read by several consumers.
+func TestContext_Body(t *testing.T) {
+	// 1. Setup
+	c := newBodyContext(`{"name":"ann","age":30}`)
+
+	// 2. Execute: A middleware reads the raw bytes, then the handler binds.
+	raw, err := c.Body()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var v bindTarget
+	if err := c.BindJSON(&v); err != nil {
+		t.Fatalf("bind after Body failed: %v", err)
+	}
+
+	// 3. Assert: Both saw the whole body, and it can be read yet again.
+	if string(raw) != `{"name":"ann","age":30}` || v.Name != "ann" {
+		t.Errorf("unexpected results: %q %+v", raw, v)
+	}
+	again, _ := c.Body()
+	rest, _ := io.ReadAll(c.Request.Body)
+	if string(again) != string(raw) || string(rest) != string(raw) {
+		t.Errorf("body not rewound: %q %q", again, rest)
+	}
+}
+
+// TestContext_Body_TooLarge checks that the size limit is enforced.
+func TestContext_Body_TooLarge(t *testing.T) {
+	old := DefaultBindOptions
+	defer func() { DefaultBindOptions = old }()
+	DefaultBindOptions.MaxBytes = 8
+
+	c := newBodyContext(strings.Repeat("x", 100))
+	_, err := c.Body()
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected a 413 BindError, got %v", err)
+	}
+}
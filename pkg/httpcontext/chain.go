@@ -0,0 +1,52 @@
+// Description: This file contains the helpers middleware uses to control the
+// handler chain. Abort stops the request from going any further: once a
+// middleware aborts (say, because authentication failed), the router won't
+// run later middleware or the route's handler, even if next is called.
+
+package httpcontext
+
+// Abort prevents the remaining middleware and the handler from running. It
+// doesn't stop the current function, so callers usually return right after it.
+// Middleware that already ran keeps running its code after next returns.
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+// IsAborted reports whether Abort was called for this request.
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// AbortWithStatus aborts the chain and sends a response with only a status code.
+func (c *Context) AbortWithStatus(statusCode int) {
+	c.Abort()
+	c.Status(statusCode)
+}
+
+// AbortWithStatusJSON aborts the chain and sends a JSON response, e.g.
+// c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "login required"}).
+func (c *Context) AbortWithStatusJSON(statusCode int, data interface{}) {
+	c.Abort()
+	c.JSON(statusCode, data)
+}
+
+// Next runs the rest of the chain (the following middleware and the handler)
+// from inside a middleware written as a plain handler, see
+// router.MiddlewareFunc. It lets the middleware run code both before and after
+// the handler. Next does nothing if the chain was aborted or already ran.
+func (c *Context) Next() {
+	next := c.next
+	if next == nil {
+		return
+	}
+	c.next = nil
+	if !c.aborted {
+		next(c)
+	}
+}
+
+// SetNext records the rest of the chain for Next. Like SetParams, it is meant
+// to be called by the router; handlers normally don't need it.
+func (c *Context) SetNext(next func(*Context)) {
+	c.next = next
+}
@@ -0,0 +1,144 @@
+// Description: This file contains ClientIP, which finds the address of the real
+// client behind reverse proxies and load balancers. Forwarding headers are
+// trivially forged, so they're only believed when the request actually came
+// from a proxy we trust; by default no proxy is trusted and ClientIP simply
+// returns the address of the TCP peer.
+
+package httpcontext
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// trustedProxies holds the server-wide list of proxy networks whose forwarding
+// headers are believed.
+var (
+	trustedProxiesMu sync.RWMutex
+	trustedProxies   []netip.Prefix
+)
+
+// SetTrustedProxies configures the proxies whose forwarding headers ClientIP
+// believes, as CIDRs ("10.0.0.0/8") or single addresses ("192.0.2.1"). Call it
+// once at startup; calling it with no arguments trusts no proxy again.
+func SetTrustedProxies(cidrs ...string) error {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return fmt.Errorf("httpcontext: invalid trusted proxy %q: %w", cidr, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("httpcontext: invalid trusted proxy %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	trustedProxiesMu.Lock()
+	defer trustedProxiesMu.Unlock()
+	trustedProxies = prefixes
+	return nil
+}
+
+// isTrustedProxy reports whether addr belongs to a trusted proxy network.
+func isTrustedProxy(addr netip.Addr) bool {
+	trustedProxiesMu.RLock()
+	defer trustedProxiesMu.RUnlock()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client that made the request. When the
+// request came from a trusted proxy, the forwarding headers are consulted in
+// order of preference: RFC 7239 Forwarded, X-Forwarded-For, then X-Real-IP.
+// Address chains are walked from the right, skipping trusted proxies, so a
+// client can't spoof its address by sending its own header ahead of ours.
+func (c *Context) ClientIP() string {
+	remote, ok := parseIP(c.Request.RemoteAddr)
+	if !ok {
+		// Not an IP (e.g. a Unix socket): nothing better to report.
+		return c.Request.RemoteAddr
+	}
+	if !isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	header := c.Request.Header
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		return walkChain(remote, forwardedFor(values)).String()
+	}
+	if values := header.Values("X-Forwarded-For"); len(values) > 0 {
+		return walkChain(remote, strings.Split(strings.Join(values, ","), ",")).String()
+	}
+	if ip, ok := parseIP(header.Get("X-Real-IP")); ok {
+		return ip.String()
+	}
+	return remote.String()
+}
+
+// walkChain returns the client address from a chain of hops (client first, the
+// proxy closest to us last). It starts from the right and stops at the first
+// address that isn't a trusted proxy. An unparseable entry ends the walk at the
+// last address we could trust it came from.
+func walkChain(remote netip.Addr, hops []string) netip.Addr {
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(hops[i])
+		if !ok {
+			break
+		}
+		client = ip
+		if !isTrustedProxy(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// forwardedFor extracts the "for" parameters of Forwarded header values, e.g.
+// `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(val, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseIP parses an address that may carry a port, e.g. "192.0.2.1",
+// "192.0.2.1:80", "2001:db8::1", or "[2001:db8::1]:443". IPv4-mapped IPv6
+// addresses are reported as plain IPv4.
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(strings.Trim(s, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,58 @@
+// Description: This file contains tests for ClientIP and the trusted proxy list.
+
+package httpcontext
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContext_ClientIP walks through the forwarding header scenarios.
+func TestContext_ClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.0/8", "2001:db8::1"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies()
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"no proxy", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer headers ignored", "203.0.113.5:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.5"},
+		{"X-Forwarded-For", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "198.51.100.7"}, "198.51.100.7"},
+		{"spoofed entry left of real client", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.7, 10.1.1.1"}, "198.51.100.7"},
+		{"all hops trusted", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "10.2.2.2, 10.1.1.1"}, "10.2.2.2"},
+		{"garbage hop", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "198.51.100.7, nonsense"}, "10.0.0.1"},
+		{"X-Real-IP", "10.0.0.1:80", map[string]string{"X-Real-IP": "198.51.100.8"}, "198.51.100.8"},
+		{"Forwarded", "10.0.0.1:80", map[string]string{"Forwarded": `for=198.51.100.9;proto=https, for="10.3.3.3:8080"`}, "198.51.100.9"},
+		{"Forwarded IPv6", "[2001:db8::1]:443", map[string]string{"Forwarded": `For="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"Forwarded wins over X-Forwarded-For", "10.0.0.1:80", map[string]string{"Forwarded": "for=198.51.100.9", "X-Forwarded-For": "198.51.100.7"}, "198.51.100.9"},
+		{"Forwarded obfuscated", "10.0.0.1:80", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			c := &Context{Writer: httptest.NewRecorder(), Request: req}
+
+			if got := c.ClientIP(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestSetTrustedProxies_Invalid checks that bad entries are reported.
+func TestSetTrustedProxies_Invalid(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", ""} {
+		if err := SetTrustedProxies(cidr); err == nil {
+			t.Errorf("expected an error for %q", cidr)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Description: This file contains helpers for cache-friendly endpoints. A
+// handler describes the current version of a resource with an ETag or a
+// modification time; if the client's cached copy is still current, the helpers
+// answer 304 Not Modified and the handler skips building the body:
+//
+//	etag := fmt.Sprintf("%d-%d", user.ID, user.Version)
+//	if c.IfNoneMatch(etag) {
+//		return // 304 already sent
+//	}
+//	c.JSON(http.StatusOK, user)
+
+package httpcontext
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetETag sets the response's ETag header. The tag is quoted if needed, so both
+// "v1" and `"v1"` work; weak tags (`W/"v1"`) are passed through unchanged.
+func (c *Context) SetETag(tag string) {
+	c.SetHeader("ETag", quoteETag(tag))
+}
+
+// SetLastModified sets the response's Last-Modified header.
+func (c *Context) SetLastModified(t time.Time) {
+	c.SetHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// IfNoneMatch sets the ETag header to tag and evaluates the request's
+// If-None-Match header against it. When the client already holds this version,
+// it sends 304 Not Modified (for GET and HEAD) or 412 Precondition Failed (for
+// other methods, which protects against lost updates) and returns true; the
+// handler should then return without writing anything.
+func (c *Context) IfNoneMatch(tag string) bool {
+	tag = quoteETag(tag)
+	c.SetHeader("ETag", tag)
+
+	header := c.Request.Header.Get("If-None-Match")
+	if header == "" || !etagListMatches(header, tag) {
+		return false
+	}
+	if m := c.Request.Method; m == http.MethodGet || m == http.MethodHead {
+		c.Status(http.StatusNotModified)
+	} else {
+		c.Status(http.StatusPreconditionFailed)
+	}
+	return true
+}
+
+// IfModifiedSince sets the Last-Modified header to modified and evaluates the
+// request's If-Modified-Since header. If the resource hasn't changed since the
+// client's copy, it sends 304 Not Modified and returns true. As RFC 9110
+// requires, the header is ignored for methods other than GET and HEAD, and when
+// the request also has If-None-Match (ETags are more precise).
+func (c *Context) IfModifiedSince(modified time.Time) bool {
+	c.SetLastModified(modified)
+
+	m := c.Request.Method
+	if m != http.MethodGet && m != http.MethodHead || c.Request.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have one-second resolution.
+	if modified.Truncate(time.Second).After(since) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// quoteETag adds the quotes an entity tag needs, unless it already has them.
+func quoteETag(tag string) string {
+	if strings.HasPrefix(tag, `"`) || strings.HasPrefix(tag, `W/"`) {
+		return tag
+	}
+	return `"` + tag + `"`
+}
+
+// etagListMatches reports whether an If-None-Match list contains tag. It uses
+// the weak comparison RFC 9110 prescribes for If-None-Match: W/"x" matches "x".
+func etagListMatches(list, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
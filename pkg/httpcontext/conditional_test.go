@@ -0,0 +1,89 @@
+// Description: This file contains tests for the conditional request helpers.
+
+package httpcontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestContext_IfNoneMatch checks ETag matching for reads and writes.
+func TestContext_IfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		want        bool
+		wantStatus  int
+	}{
+		{"no header", "GET", "", false, http.StatusOK},
+		{"match", "GET", `"v1"`, true, http.StatusNotModified},
+		{"weak match in list", "GET", `"v0", W/"v1"`, true, http.StatusNotModified},
+		{"mismatch", "GET", `"v2"`, false, http.StatusOK},
+		{"star on write", "PUT", "*", true, http.StatusPreconditionFailed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			req := httptest.NewRequest(tc.method, "/users/1", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+			c := new(Context)
+			c.Reset(rr, req)
+
+			// 2. Execute
+			got := c.IfNoneMatch("v1")
+
+			// 3. Assert
+			if got != tc.want || c.ResponseStatus() != tc.wantStatus {
+				t.Errorf("got %v/%d, want %v/%d", got, c.ResponseStatus(), tc.want, tc.wantStatus)
+			}
+			if etag := rr.Header().Get("ETag"); etag != `"v1"` {
+				t.Errorf("expected ETag \"v1\", got %q", etag)
+			}
+		})
+	}
+}
+
+// TestContext_IfModifiedSince checks date comparison and precedence rules.
+func TestContext_IfModifiedSince(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{"no header", "GET", nil, false},
+		{"not modified", "GET", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"}, true},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 11:59:59 GMT"}, false},
+		{"ignored for POST", "POST", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"}, false},
+		{"ignored with If-None-Match", "GET", map[string]string{
+			"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT",
+			"If-None-Match":     `"other"`,
+		}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			c := new(Context)
+			c.Reset(rr, req)
+
+			if got := c.IfModifiedSince(modified); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if lm := rr.Header().Get("Last-Modified"); lm != "Wed, 01 May 2024 12:00:00 GMT" {
+				t.Errorf("unexpected Last-Modified %q", lm)
+			}
+		})
+	}
+}
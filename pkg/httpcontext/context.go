@@ -0,0 +1,45 @@
+// Description: This file makes Context satisfy the standard context.Context
+// interface by delegating to the request's context. A handler can then pass c
+// straight to database calls and outgoing requests, and they inherit the
+// request's deadline and are cancelled when the client goes away.
+
+package httpcontext
+
+import (
+	"context"
+	"time"
+)
+
+// Compile-time check that Context implements context.Context.
+var _ context.Context = (*Context)(nil)
+
+// requestContext returns the request's context, or a background context when
+// there is no request (e.g. a Context built by hand in a test).
+func (c *Context) requestContext() context.Context {
+	if c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
+
+// Deadline returns the time when the request's work should be cancelled, if any.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done returns a channel that's closed when the request is cancelled, for
+// example because the client disconnected or a timeout expired.
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err returns why Done was closed (context.Canceled or
+// context.DeadlineExceeded), or nil while the request is still live.
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value returns the value stored in the request's context for key.
+func (c *Context) Value(key interface{}) interface{} {
+	return c.requestContext().Value(key)
+}
@@ -0,0 +1,58 @@
+// Description: This file contains tests for Context's context.Context methods.
+
+package httpcontext
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ctxKey is a private key type for values stored in a test context.
+type ctxKey struct{}
+
+// TestContext_ContextContext checks that deadlines, cancellation, and values
+// come from the request's context.
+func TestContext_ContextContext(t *testing.T) {
+	// 1. Setup
+	base := context.WithValue(context.Background(), ctxKey{}, "value")
+	deadline := time.Now().Add(time.Hour)
+	reqCtx, cancel := context.WithDeadline(base, deadline)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+	c := &Context{Writer: httptest.NewRecorder(), Request: req}
+
+	// 2. A derived context sees the same values and deadline.
+	derived, stop := context.WithCancel(c)
+	defer stop()
+	if got := derived.Value(ctxKey{}); got != "value" {
+		t.Errorf("Value: got %v, want %q", got, "value")
+	}
+	if d, ok := c.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("Deadline: got %v, %v", d, ok)
+	}
+	if c.Err() != nil {
+		t.Errorf("Err before cancel: %v", c.Err())
+	}
+
+	// 3. Cancelling the request cancels everything derived from c.
+	cancel()
+	select {
+	case <-derived.Done():
+	case <-time.After(time.Second):
+		t.Fatal("derived context was not cancelled")
+	}
+	if !errors.Is(c.Err(), context.Canceled) {
+		t.Errorf("Err after cancel: got %v", c.Err())
+	}
+}
+
+// TestContext_ContextContext_NoRequest checks that a bare Context behaves like
+// context.Background instead of panicking.
+func TestContext_ContextContext_NoRequest(t *testing.T) {
+	c := &Context{}
+	if c.Done() != nil || c.Err() != nil || c.Value(ctxKey{}) != nil {
+		t.Error("expected background context behaviour")
+	}
+}
@@ -0,0 +1,74 @@
+// Description: This file contains tests for the cookie helpers.
+
+package httpcontext
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// roundTrip sends the cookies set on rr back in a new request's context.
+func roundTrip(rr *httptest.ResponseRecorder) *Context {
+	req := httptest.NewRequest("GET", "/", nil)
+	for _, cookie := range rr.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return &Context{Writer: httptest.NewRecorder(), Request: req}
+}
+
+// TestContext_Cookie tests plain cookies and their defaults.
+func TestContext_Cookie(t *testing.T) {
+	c, rr := newTestContext("GET", "/")
+	c.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Path != "/" || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookies: %+v", cookies)
+	}
+
+	if value, err := roundTrip(rr).Cookie("theme"); err != nil || value != "dark" {
+		t.Errorf("expected dark, nil but got %q, %v", value, err)
+	}
+	if _, err := roundTrip(rr).Cookie("missing"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("expected http.ErrNoCookie, but got %v", err)
+	}
+}
+
+// TestContext_SignedCookie tests signing, verification, tampering, and key rotation.
+func TestContext_SignedCookie(t *testing.T) {
+	oldKey := []byte("old-key-old-key-old-key-old-key!")
+	newKey := []byte("new-key-new-key-new-key-new-key!")
+	SetCookieSigningKeys(oldKey)
+	defer SetCookieSigningKeys()
+
+	// 1. Sign a cookie with the old key and read it back.
+	c, rr := newTestContext("GET", "/")
+	if err := c.SetSignedCookie(&http.Cookie{Name: "session", Value: "user=42; admin=false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, err := roundTrip(rr).SignedCookie("session"); err != nil || value != "user=42; admin=false" {
+		t.Errorf("expected the original value, but got %q, %v", value, err)
+	}
+
+	// 2. After rotating, cookies signed with the old key are still accepted.
+	SetCookieSigningKeys(newKey, oldKey)
+	if _, err := roundTrip(rr).SignedCookie("session"); err != nil {
+		t.Errorf("expected the old cookie to remain valid after rotation, but got %v", err)
+	}
+
+	// 3. A tampered cookie is rejected.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: rr.Result().Cookies()[0].Value + "x"})
+	tampered := &Context{Writer: httptest.NewRecorder(), Request: req}
+	if _, err := tampered.SignedCookie("session"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, but got %v", err)
+	}
+
+	// 4. Once the old key is dropped, its cookies stop being accepted.
+	SetCookieSigningKeys(newKey)
+	if _, err := roundTrip(rr).SignedCookie("session"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature after dropping the old key, but got %v", err)
+	}
+}
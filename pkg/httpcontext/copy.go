@@ -0,0 +1,78 @@
+// Description: This file contains Copy, for handing request data to goroutines
+// that outlive the request. The router recycles a Context as soon as the handler
+// returns, so a background goroutine holding on to c would suddenly see another
+// request's data. It must work on a copy instead:
+//
+//	cp := c.Copy()
+//	go func() {
+//		sendWelcomeEmail(cp, cp.Param("id"))
+//	}()
+
+package httpcontext
+
+import (
+	"context"
+	"maps"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// Copy returns a snapshot of the context that is safe to use after the handler
+// returns. The copy has its own route info, parameters, query values, and
+// recorded errors. It can't respond to the client: its writer discards
+// everything and logs the attempt. Its context.Context keeps the request's
+// values but is not cancelled when the request ends, so background work isn't
+// cut short when the client disconnects.
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		params:  append(Params(nil), c.params...),
+		query:   maps.Clone(c.query),
+		aborted: c.aborted,
+		errors:  append([]*Error(nil), c.errors...),
+
+		// The buffered body is never modified, so it can be shared.
+		body:     c.body,
+		bodyRead: c.bodyRead,
+
+		requestID:   c.requestID,
+		sampled:     c.sampled,
+		jsonOptions: c.jsonOptions,
+	}
+	if c.route != nil {
+		info := *c.route
+		cp.route = &info
+	}
+	if c.Request != nil {
+		cp.Request = c.Request.WithContext(context.WithoutCancel(c.Request.Context()))
+	}
+
+	// Keep what was sent so far, so Written and ResponseStatus still answer
+	// truthfully, but write nowhere.
+	cp.resp = c.resp
+	cp.resp.ResponseWriter = &detachedWriter{header: make(http.Header)}
+	cp.Writer = &cp.resp
+	return cp
+}
+
+// detachedWriter is the writer of a copied context. Writing to it is a bug
+// (the real response belongs to the original request), so it only logs.
+type detachedWriter struct {
+	header http.Header
+}
+
+// Header returns a private header map, so changes don't race with the real response.
+func (w *detachedWriter) Header() http.Header {
+	return w.header
+}
+
+// Write discards b.
+func (w *detachedWriter) Write(b []byte) (int, error) {
+	logger.Warn("Write on a copied context discarded", "bytes", len(b))
+	return len(b), nil
+}
+
+// WriteHeader discards the status.
+func (w *detachedWriter) WriteHeader(code int) {
+	logger.Warn("WriteHeader on a copied context discarded", "status", code)
+}
@@ -0,0 +1,43 @@
+// Description: This file contains tests for Context.Copy.
+
+package httpcontext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContext_Copy checks that the copy survives the original being recycled.
+func TestContext_Copy(t *testing.T) {
+	// 1. Setup: A context as the router prepares it, with a cancellable request.
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/users/42?tab=posts", nil).WithContext(reqCtx)
+	rr := httptest.NewRecorder()
+	c := new(Context)
+	c.Reset(rr, req)
+	c.SetRouteInfo(&RouteInfo{Method: "GET", Path: "/users/:id"})
+	c.SetParams(Params{{Key: "id", Value: "42"}})
+	c.Query("tab") // Populate the query cache.
+
+	// 2. Execute: Copy, then end and recycle the original.
+	cp := c.Copy()
+	cancel()
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/other", nil))
+	c.SetParams(append(c.Params(), Param{Key: "id", Value: "7"}))
+
+	// 3. Assert: The copy still sees the first request.
+	if cp.Param("id") != "42" || cp.FullPath() != "/users/:id" || cp.Query("tab") != "posts" {
+		t.Errorf("copy lost its data: id=%q path=%q tab=%q", cp.Param("id"), cp.FullPath(), cp.Query("tab"))
+	}
+	if cp.Err() != nil {
+		t.Errorf("copy should not be cancelled with the request, got %v", cp.Err())
+	}
+
+	// Writing to the copy never reaches the client.
+	cp.JSON(http.StatusOK, map[string]string{"late": "yes"})
+	if rr.Body.Len() != 0 || rr.Header().Get("Content-Type") != "" {
+		t.Errorf("copy wrote to the real response: %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+// Description: This file contains the response envelope, a consistent shape for
+// every JSON API response:
+//
+//	{"data": {...}, "meta": {...}}              on success
+//	{"error": {"status": 404, "message": "..."}} on failure
+//
+// Handlers send it with c.OK / c.Respond and c.Fail. Clients can then check for
+// "error" the same way on every endpoint. The key names are configurable
+// through DefaultEnvelope.
+
+package httpcontext
+
+import (
+	"errors"
+	"net/http"
+)
+
+// EnvelopeOptions names the top-level keys of the response envelope.
+type EnvelopeOptions struct {
+	DataKey  string
+	ErrorKey string
+	MetaKey  string
+}
+
+// DefaultEnvelope holds the envelope keys used by OK, Respond, and Fail. It can
+// be changed at startup, e.g. to {"result", "error", "meta"} to match an
+// existing API.
+var DefaultEnvelope = EnvelopeOptions{
+	DataKey:  "data",
+	ErrorKey: "error",
+	MetaKey:  "meta",
+}
+
+// EnvelopeError is the error object inside a failed response's envelope.
+type EnvelopeError struct {
+	// Status repeats the HTTP status code, for clients that only see the body.
+	Status int `json:"status"`
+
+	// Message is a human-readable description of the problem.
+	Message string `json:"message"`
+
+	// Field is the offending field of a bind error, when known.
+	Field string `json:"field,omitempty"`
+
+	// Fields lists every failed validation rule of a 422 bind error.
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// RequestID is the request's ID, which clients can quote when
+	// reporting the problem. See requestid.go.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// OK sends data in the envelope with status 200 OK.
+func (c *Context) OK(data interface{}) {
+	c.Respond(http.StatusOK, data, nil)
+}
+
+// Respond sends data, and optional metadata such as pagination details, in the
+// envelope with the given status code. A nil meta is left out.
+func (c *Context) Respond(statusCode int, data interface{}, meta interface{}) {
+	body := map[string]interface{}{DefaultEnvelope.DataKey: data}
+	if meta != nil {
+		body[DefaultEnvelope.MetaKey] = meta
+	}
+	c.JSON(statusCode, body)
+}
+
+// Fail sends err in the envelope with the given status code. Bind errors keep
+// their field details. For server errors (5xx) the message is replaced by the
+// status text and err is logged instead, so internals never reach the client.
+func (c *Context) Fail(statusCode int, err error) {
+	e := EnvelopeError{Status: statusCode, Message: http.StatusText(statusCode), RequestID: c.requestID}
+	var bindErr *BindError
+	switch {
+	case statusCode >= 500:
+		c.Logger().Error("Error handling request", "method", c.Request.Method, "path", c.Request.URL.Path, "err", err)
+	case errors.As(err, &bindErr):
+		e.Message, e.Field, e.Fields = bindErr.Message, bindErr.Field, bindErr.Fields
+	case err != nil:
+		e.Message = err.Error()
+	}
+	c.JSON(statusCode, map[string]interface{}{DefaultEnvelope.ErrorKey: e})
+}
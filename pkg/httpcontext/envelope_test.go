@@ -0,0 +1,80 @@
+// Description: This file contains tests for the response envelope helpers.
+
+package httpcontext
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestContext_Envelope checks the success and failure shapes.
+func TestContext_Envelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		respond    func(c *Context)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "OK",
+			respond:    func(c *Context) { c.OK(map[string]int{"id": 1}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"id":1}}`,
+		},
+		{
+			name:       "Respond with meta",
+			respond:    func(c *Context) { c.Respond(http.StatusOK, []int{1}, map[string]int{"total": 9}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":[1],"meta":{"total":9}}`,
+		},
+		{
+			name:       "client error",
+			respond:    func(c *Context) { c.Fail(http.StatusNotFound, errors.New("user 7 not found")) },
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":{"status":404,"message":"user 7 not found"}}`,
+		},
+		{
+			name: "bind error",
+			respond: func(c *Context) {
+				c.Fail(http.StatusUnprocessableEntity, &BindError{
+					Status: http.StatusUnprocessableEntity, Message: "validation failed",
+					Fields: []FieldError{{Field: "email", Rule: "email", Message: "must be a valid email address"}},
+				})
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"error":{"status":422,"message":"validation failed","fields":[{"field":"email","rule":"email","message":"must be a valid email address"}]}}`,
+		},
+		{
+			name: "server error hides details",
+			respond: func(c *Context) {
+				c.Fail(http.StatusInternalServerError, errors.New("pq: password authentication failed"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":{"status":500,"message":"Internal Server Error"}}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, rr := newTestContext("GET", "/users")
+			tc.respond(c)
+			if rr.Code != tc.wantStatus || strings.TrimSpace(rr.Body.String()) != tc.wantBody {
+				t.Errorf("got %d %s, want %d %s", rr.Code, rr.Body.String(), tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+// TestDefaultEnvelope checks that the keys can be renamed.
+func TestDefaultEnvelope(t *testing.T) {
+	old := DefaultEnvelope
+	defer func() { DefaultEnvelope = old }()
+	DefaultEnvelope.DataKey = "result"
+
+	c, rr := newTestContext("GET", "/")
+	c.OK("pong")
+	if got := strings.TrimSpace(rr.Body.String()); got != `{"result":"pong"}` {
+		t.Errorf("got %s", got)
+	}
+}
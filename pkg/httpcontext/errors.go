@@ -0,0 +1,148 @@
+// Description: This file lets handlers record errors on the context instead of
+// turning each one into a response themselves. A handler calls c.Error(err) and
+// returns; a final error-handling stage (router.HandleErrors) then logs what was
+// recorded and answers the client with a consistent JSON error.
+
+package httpcontext
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// ErrorType classifies a recorded error, which decides what the client sees.
+type ErrorType uint8
+
+const (
+	// ErrorTypePrivate errors are logged, but the client only sees the
+	// generic status text. This is the default, so internal details such as
+	// SQL errors never leak by accident.
+	ErrorTypePrivate ErrorType = iota
+
+	// ErrorTypePublic errors have a message that is safe to send to the client.
+	ErrorTypePublic
+
+	// ErrorTypeBind errors are *BindError values from the Bind helpers. They're
+	// sent back as-is, including their field details.
+	ErrorTypeBind
+)
+
+// String returns the name of the error type, for logging.
+func (t ErrorType) String() string {
+	switch t {
+	case ErrorTypePublic:
+		return "public"
+	case ErrorTypeBind:
+		return "bind"
+	default:
+		return "private"
+	}
+}
+
+// Error is an error recorded with c.Error, plus what the error handler needs
+// to know about it.
+type Error struct {
+	Err  error
+	Type ErrorType
+
+	// Status is the HTTP status to respond with. Zero means the one implied
+	// by Err (a BindError's status) or 500 Internal Server Error.
+	Status int
+
+	// Meta is arbitrary extra data for logging, e.g. the ID of a failed record.
+	Meta interface{}
+
+	// callers is where c.Error recorded the error, for error reports.
+	callers []uintptr
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the recorded error, so errors.Is and errors.As see through it.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SetType sets the error's type and returns the error, for chaining:
+// c.Error(err).SetType(httpcontext.ErrorTypePublic).SetStatus(http.StatusConflict).
+func (e *Error) SetType(t ErrorType) *Error {
+	e.Type = t
+	return e
+}
+
+// SetStatus sets the HTTP status to respond with and returns the error.
+func (e *Error) SetStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+// SetMeta attaches extra data for logging and returns the error.
+func (e *Error) SetMeta(meta interface{}) *Error {
+	e.Meta = meta
+	return e
+}
+
+// Stack returns the stack trace of where the error was recorded with c.Error,
+// formatted like runtime/debug.Stack's frames, or nil if it wasn't.
+func (e *Error) Stack() []byte {
+	if len(e.callers) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.callers)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s(...)\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return []byte(b.String())
+}
+
+// StatusCode returns the HTTP status to respond with for this error.
+func (e *Error) StatusCode() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	var bindErr *BindError
+	if errors.As(e.Err, &bindErr) {
+		return bindErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error records err on the context and returns it wrapped in an *Error, which
+// can be refined with SetType, SetStatus, and SetMeta. Bind errors are
+// recognized automatically. Recording a nil error does nothing and returns nil.
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var recorded *Error
+	if !errors.As(err, &recorded) {
+		recorded = &Error{Err: err}
+		var bindErr *BindError
+		if errors.As(err, &bindErr) {
+			recorded.Type = ErrorTypeBind
+		}
+	}
+	if recorded.callers == nil {
+		var pcs [32]uintptr
+		recorded.callers = pcs[:runtime.Callers(2, pcs[:])]
+	}
+	c.errors = append(c.errors, recorded)
+	return recorded
+}
+
+// Errors returns the errors recorded on the context, in the order they were
+// recorded.
+func (c *Context) Errors() []*Error {
+	return c.errors
+}
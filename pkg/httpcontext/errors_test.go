@@ -0,0 +1,46 @@
+// Description: This file contains tests for error accumulation on Context.
+
+package httpcontext
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestContext_Error checks recording, classification, and chaining.
+func TestContext_Error(t *testing.T) {
+	c, _ := newTestContext("GET", "/")
+	sentinel := errors.New("db down")
+
+	if c.Error(nil) != nil || len(c.Errors()) != 0 {
+		t.Fatal("recording nil should do nothing")
+	}
+
+	c.Error(sentinel).SetMeta("user 42")
+	c.Error(&BindError{Status: http.StatusUnprocessableEntity, Message: "invalid"})
+	c.Error(errors.New("taken")).SetType(ErrorTypePublic).SetStatus(http.StatusConflict)
+
+	errs := c.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d", len(errs))
+	}
+	if !errors.Is(errs[0], sentinel) || errs[0].Type != ErrorTypePrivate || errs[0].Meta != "user 42" {
+		t.Errorf("unexpected first error: %+v", errs[0])
+	}
+	if errs[0].StatusCode() != http.StatusInternalServerError {
+		t.Errorf("expected default status 500, got %d", errs[0].StatusCode())
+	}
+	if errs[1].Type != ErrorTypeBind || errs[1].StatusCode() != http.StatusUnprocessableEntity {
+		t.Errorf("unexpected bind error: %+v", errs[1])
+	}
+	if errs[2].Type != ErrorTypePublic || errs[2].StatusCode() != http.StatusConflict {
+		t.Errorf("unexpected public error: %+v", errs[2])
+	}
+
+	// Reset clears the errors of the previous request.
+	c.Reset(c.Writer, c.Request)
+	if len(c.Errors()) != 0 {
+		t.Error("expected Reset to clear errors")
+	}
+}
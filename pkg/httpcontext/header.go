@@ -0,0 +1,57 @@
+// Description: This file contains helpers for reading request headers and
+// setting response headers, so handlers rarely need c.Writer.Header() directly.
+// The setters also catch a common mistake: changing headers after the response
+// was started, which net/http silently ignores.
+
+package httpcontext
+
+// GetHeader returns the first value of the named request header, or "".
+// The name is case-insensitive.
+func (c *Context) GetHeader(name string) string {
+	return c.Request.Header.Get(name)
+}
+
+// SetHeader sets a response header, replacing any existing values. An empty
+// value deletes the header instead.
+func (c *Context) SetHeader(name, value string) {
+	if !c.canSetHeader(name) {
+		return
+	}
+	if value == "" {
+		c.Writer.Header().Del(name)
+		return
+	}
+	c.Writer.Header().Set(name, value)
+}
+
+// AddHeader adds a value to a response header, keeping existing values. It's
+// the right choice for list headers such as Vary or Link.
+func (c *Context) AddHeader(name, value string) {
+	if !c.canSetHeader(name) {
+		return
+	}
+	c.Writer.Header().Add(name, value)
+}
+
+// ContentType returns the media type of the request body without parameters,
+// lowercased (e.g. "application/json"), or "" if none was sent.
+func (c *Context) ContentType() string {
+	return mediaType(c.Request)
+}
+
+// NoSniff tells browsers to trust the response's Content-Type instead of
+// guessing it from the body. Without it, a user-uploaded "image" containing
+// HTML could be run as a page on our origin.
+func (c *Context) NoSniff() {
+	c.SetHeader("X-Content-Type-Options", "nosniff")
+}
+
+// canSetHeader reports whether response headers can still be changed, logging
+// the attempt if they can't.
+func (c *Context) canSetHeader(name string) bool {
+	if c.Written() {
+		c.Logger().Warn("Header set after the response was started; ignored", "header", name)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+// Description: This file contains tests for the header helpers.
+
+package httpcontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContext_Headers tests reading request headers and setting response ones.
+func TestContext_Headers(t *testing.T) {
+	// 1. Setup
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("x-request-id", "abc")
+	req.Header.Set("Content-Type", "Application/JSON; charset=utf-8")
+	rr := httptest.NewRecorder()
+	c := new(Context)
+	c.Reset(rr, req)
+
+	// 2. Execute & 3. Assert: Request side.
+	if got := c.GetHeader("X-Request-ID"); got != "abc" {
+		t.Errorf("GetHeader: got %q", got)
+	}
+	if got := c.ContentType(); got != "application/json" {
+		t.Errorf("ContentType: got %q", got)
+	}
+
+	// Response side.
+	c.SetHeader("Cache-Control", "no-store")
+	c.AddHeader("Vary", "Accept")
+	c.AddHeader("Vary", "Accept-Encoding")
+	c.SetHeader("X-Temp", "1")
+	c.SetHeader("X-Temp", "")
+	c.NoSniff()
+	c.Status(http.StatusNoContent)
+
+	// Changes after the response started are ignored.
+	c.SetHeader("X-Late", "1")
+
+	h := rr.Header()
+	if h.Get("Cache-Control") != "no-store" || len(h.Values("Vary")) != 2 {
+		t.Errorf("unexpected headers: %v", h)
+	}
+	if _, ok := h["X-Temp"]; ok {
+		t.Error("expected X-Temp to be deleted")
+	}
+	if h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("expected X-Content-Type-Options: nosniff")
+	}
+	if c.Writer.Header().Get("X-Late") != "" {
+		t.Error("expected the late header to be ignored")
+	}
+}
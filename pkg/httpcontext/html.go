@@ -0,0 +1,136 @@
+// Description: This file implements HTML template rendering. Templates are loaded
+// once at startup into a Templates set, then handlers render pages with
+// c.HTML(status, "users.html", data). The expected layout of the template
+// directory is:
+//
+//	templates/
+//	├── layouts/    # Page skeletons, e.g. base.html with {{block "content" .}}{{end}}
+//	├── partials/   # Reusable fragments, e.g. nav.html with {{define "nav"}}...{{end}}
+//	└── users.html  # Pages, e.g. {{define "content"}}...{{end}}
+//
+// Every page is parsed together with all layouts and partials, so pages can
+// fill in layout blocks and pull in any partial.
+
+package httpcontext
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// TemplateOptions configures how a Templates set is loaded.
+type TemplateOptions struct {
+	// Layout is the name of the template that renders the page skeleton,
+	// e.g. "base.html". When empty, each page is executed on its own.
+	Layout string
+
+	// Funcs are extra functions made available to every template.
+	Funcs template.FuncMap
+
+	// Reload re-parses the templates on every render, so edits show up
+	// without restarting the server. Meant for development only.
+	Reload bool
+}
+
+// Templates is a set of parsed HTML pages.
+type Templates struct {
+	fsys fs.FS
+	opts TemplateOptions
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// LoadTemplates parses every page in fsys (typically os.DirFS("templates") or an
+// embed.FS) together with its layouts and partials. Parse errors are reported
+// here, at startup, rather than on the first request.
+func LoadTemplates(fsys fs.FS, opts TemplateOptions) (*Templates, error) {
+	t := &Templates{fsys: fsys, opts: opts}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// load (re)parses every page and swaps in the result.
+func (t *Templates) load() error {
+	// Layouts and partials are shared by every page. It's fine for either
+	// directory to be missing.
+	var shared []string
+	for _, dir := range []string{"layouts", "partials"} {
+		matches, err := fs.Glob(t.fsys, path.Join(dir, "*.html"))
+		if err != nil {
+			return err
+		}
+		shared = append(shared, matches...)
+	}
+
+	pageFiles, err := fs.Glob(t.fsys, "*.html")
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]*template.Template, len(pageFiles))
+	for _, file := range pageFiles {
+		// Each page gets its own template set, so two pages can both
+		// define "content" without clashing.
+		tmpl := template.New(file).Funcs(t.opts.Funcs)
+		tmpl, err := tmpl.ParseFS(t.fsys, append([]string{file}, shared...)...)
+		if err != nil {
+			return fmt.Errorf("httpcontext: parsing template %s: %w", file, err)
+		}
+		pages[file] = tmpl
+	}
+
+	t.mu.Lock()
+	t.pages = pages
+	t.mu.Unlock()
+	return nil
+}
+
+// Render executes the named page with data and writes the result to buf.
+func (t *Templates) Render(buf *bytes.Buffer, name string, data interface{}) error {
+	if t.opts.Reload {
+		if err := t.load(); err != nil {
+			return err
+		}
+	}
+
+	t.mu.RLock()
+	tmpl, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("httpcontext: template %q not found", name)
+	}
+
+	entry := name
+	if t.opts.Layout != "" {
+		entry = t.opts.Layout
+	}
+	return tmpl.ExecuteTemplate(buf, entry, data)
+}
+
+// htmlTemplates is the server-wide template set used by c.HTML.
+var (
+	htmlTemplatesMu sync.RWMutex
+	htmlTemplates   *Templates
+)
+
+// SetTemplates installs the template set that c.HTML renders from. Call it once
+// at startup, after LoadTemplates.
+func SetTemplates(t *Templates) {
+	htmlTemplatesMu.Lock()
+	defer htmlTemplatesMu.Unlock()
+	htmlTemplates = t
+}
+
+// HTML renders the named page with data and sends it with the given status code.
+// The page is rendered into a buffer first, so a template error produces a clean
+// 500 response instead of a half-written page with a 200 status.
+func (c *Context) HTML(statusCode int, name string, data interface{}) {
+	c.Render(statusCode, HTMLRenderer{Name: name, Data: data})
+}
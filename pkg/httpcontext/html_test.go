@@ -0,0 +1,82 @@
+// Description: This file contains tests for HTML template rendering.
+
+package httpcontext
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testTemplates is a small template directory with a layout, a partial, and two pages.
+var testTemplates = fstest.MapFS{
+	"layouts/base.html": {Data: []byte(`<html>{{template "nav" .}}<main>{{block "content" .}}{{end}}</main></html>`)},
+	"partials/nav.html": {Data: []byte(`{{define "nav"}}<nav>{{.Title}}</nav>{{end}}`)},
+	"users.html":        {Data: []byte(`{{define "content"}}<p>{{.Name}}</p>{{end}}`)},
+	"broken-exec.html":  {Data: []byte(`{{define "content"}}{{index .Title 99}}{{end}}`)},
+}
+
+// TestContext_HTML tests rendering a page inside its layout.
+func TestContext_HTML(t *testing.T) {
+	tmpl, err := LoadTemplates(testTemplates, TemplateOptions{Layout: "base.html"})
+	if err != nil {
+		t.Fatalf("could not load templates: %v", err)
+	}
+	SetTemplates(tmpl)
+	defer SetTemplates(nil)
+
+	c, rr := newTestContext("GET", "/")
+	c.HTML(http.StatusOK, "users.html", map[string]string{"Title": "Users", "Name": "<ann>"})
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected an HTML content type, but got %q", ct)
+	}
+	// The data is HTML-escaped, and the partial and page are in the layout.
+	want := `<html><nav>Users</nav><main><p>&lt;ann&gt;</p></main></html>`
+	if body := rr.Body.String(); body != want {
+		t.Errorf("expected body %q, but got %q", want, body)
+	}
+}
+
+// TestContext_HTML_Errors tests that rendering failures produce a clean 500.
+func TestContext_HTML_Errors(t *testing.T) {
+	tmpl, err := LoadTemplates(testTemplates, TemplateOptions{Layout: "base.html"})
+	if err != nil {
+		t.Fatalf("could not load templates: %v", err)
+	}
+	SetTemplates(tmpl)
+	defer SetTemplates(nil)
+
+	for _, name := range []string{"missing.html", "broken-exec.html"} {
+		c, rr := newTestContext("GET", "/")
+		c.HTML(http.StatusOK, name, map[string]string{"Title": "x"})
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status code %d, but got %d", name, http.StatusInternalServerError, rr.Code)
+		}
+		if strings.Contains(rr.Body.String(), "<html>") {
+			t.Errorf("%s: expected no partial page in the body, but got %q", name, rr.Body.String())
+		}
+	}
+}
+
+// TestTemplates_Reload tests that Reload picks up template changes.
+func TestTemplates_Reload(t *testing.T) {
+	fsys := fstest.MapFS{"page.html": {Data: []byte(`v1`)}}
+	tmpl, err := LoadTemplates(fsys, TemplateOptions{Reload: true})
+	if err != nil {
+		t.Fatalf("could not load templates: %v", err)
+	}
+	SetTemplates(tmpl)
+	defer SetTemplates(nil)
+
+	fsys["page.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+	c, rr := newTestContext("GET", "/")
+	c.HTML(http.StatusOK, "page.html", nil)
+	if rr.Body.String() != "v2" {
+		t.Errorf("expected the reloaded template, but got %q", rr.Body.String())
+	}
+}
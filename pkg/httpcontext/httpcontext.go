@@ -5,47 +5,224 @@
 package httpcontext
 
 import (
-	"encoding/json" // For encoding data into JSON format.
-	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Context wraps the standard http.ResponseWriter and *http.Request.
 type Context struct {
 	Writer  http.ResponseWriter
 	Request *http.Request
+
+	// route describes the route that matched this request. It is set by the
+	// router and read through RouteInfo().
+	route *RouteInfo
+
+	// params holds the values of the path parameters (e.g. ":id") extracted
+	// by the router. It is read through Param().
+	params Params
+
+	// query caches the parsed URL query string, so calling several Query
+	// helpers doesn't re-parse it each time. See query.go.
+	query url.Values
+
+	// aborted is set by Abort; the router skips the rest of the chain once
+	// it's true. next is the rest of the chain for Next. See chain.go.
+	aborted bool
+	next    func(*Context)
+
+	// errors collects the errors recorded with c.Error. See errors.go.
+	errors []*Error
+
+	// body holds the buffered request body once Body was called; bodyRead
+	// tells an empty body apart from one that wasn't read yet. See body.go.
+	body     []byte
+	bodyRead bool
+
+	// requestID identifies the request in logs and error responses. See
+	// requestid.go.
+	requestID string
+
+	// sampled marks requests picked for verbose logging. See sampling.go.
+	sampled bool
+
+	// jsonOptions, if set, replace DefaultJSONOptions for this request.
+	// See SetJSONOptions.
+	jsonOptions *JSONOptions
+
+	// resp tracks the status and size of the response. Reset points Writer
+	// at it; middleware may wrap Writer further, but writes still end up
+	// here. See response.go.
+	resp responseWriter
+}
+
+// Param is a single path parameter, e.g. Key "id" and Value "42".
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is the list of path parameters for a request, in pattern order.
+type Params []Param
+
+// Get returns the value of the named parameter and whether it was present.
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// RouteInfo describes a registered route: where it lives and any metadata that
+// was attached to it at registration time (description, tags, auth requirements...).
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Description string
+	Tags        []string
+	Metadata    map[string]interface{}
+}
+
+// Reset prepares a (possibly recycled) context for a new request. The router
+// pools contexts to avoid an allocation per request, so every field must be
+// cleared here; the params slice keeps its capacity for reuse.
+func (c *Context) Reset(w http.ResponseWriter, req *http.Request) {
+	*c = Context{
+		Request: req,
+		params:  c.params[:0],
+	}
+	c.resp.reset(w)
+	c.Writer = &c.resp
 }
 
+// RouteInfo returns the metadata of the route that matched the current request.
+// It returns the zero value if the context wasn't created by the router.
+// The returned Tags and Metadata are shared with the router and must not be modified.
+func (c *Context) RouteInfo() RouteInfo {
+	if c.route == nil {
+		return RouteInfo{}
+	}
+	return *c.route
+}
+
+// FullPath returns the pattern of the matched route (e.g. "/users/:id"), not the
+// concrete request path ("/users/42"). Logging and metrics should use it to
+// group requests by route without one series per ID. It returns "" when no route matched.
+func (c *Context) FullPath() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.Path
+}
+
+// Params returns all path parameters of the request, in pattern order.
+func (c *Context) Params() Params {
+	return c.params
+}
+
+// Param returns the value of a path parameter, e.g. c.Param("id") for a route
+// registered as "/users/:id". It returns "" if the parameter doesn't exist.
+func (c *Context) Param(name string) string {
+	value, _ := c.params.Get(name)
+	return value
+}
+
+// SetParams records the path parameters extracted by the router.
+// Like SetRouteInfo, it is meant to be called by the router.
+func (c *Context) SetParams(params Params) {
+	c.params = params
+}
+
+// SetRouteInfo records which route matched the request. It is called by the
+// router before invoking the handler; handlers normally don't need it.
+func (c *Context) SetRouteInfo(info *RouteInfo) {
+	c.route = info
+}
+
+// JSONOptions controls how JSON responses are encoded.
+type JSONOptions struct {
+	// Indent, when non-empty, pretty-prints the output using it as the
+	// indentation for each level, e.g. "  ".
+	Indent string
+
+	// DisableHTMLEscape stops the encoder from escaping <, >, and & as
+	// \u003c etc. Escaping is the safe default for JSON that may end up
+	// embedded in HTML; APIs returning URLs or markup may prefer it raw.
+	DisableHTMLEscape bool
+
+	// Prefix is written before the JSON body. SecureJSON uses it to prevent
+	// JSON hijacking of array responses in old browsers.
+	Prefix string
+}
+
+// DefaultJSONOptions are used by JSON. They can be changed at startup to set
+// application-wide defaults (e.g. indented output in development); a router
+// can set its own with SetJSONOptions, and individual handlers can use
+// JSONWith instead.
+var DefaultJSONOptions = JSONOptions{}
+
+// SetJSONOptions makes JSON encode this request's responses with opts
+// instead of DefaultJSONOptions. The router calls it for routers configured
+// with their own options.
+func (c *Context) SetJSONOptions(opts JSONOptions) {
+	c.jsonOptions = &opts
+}
+
+// JSONOptions returns the options JSON encodes with: those set with
+// SetJSONOptions, or else DefaultJSONOptions.
+func (c *Context) JSONOptions() JSONOptions {
+	if c.jsonOptions != nil {
+		return *c.jsonOptions
+	}
+	return DefaultJSONOptions
+}
+
+// SecureJSONPrefix is the prefix SecureJSON writes before the body. Clients
+// must strip it before parsing.
+var SecureJSONPrefix = "while(1);"
+
 // JSON is a helper method to send a JSON response.
 // It takes a status code and a data payload (which can be any Go struct or map).
 func (c *Context) JSON(statusCode int, data interface{}) {
-	// Set the Content-Type header to indicate that the response body is JSON.
-	c.Writer.Header().Set("Content-Type", "application/json")
+	c.JSONWith(statusCode, data, c.JSONOptions())
+}
 
-	// Write the HTTP status code to the response header.
-	// This must be done before writing the body.
-	c.Writer.WriteHeader(statusCode)
+// IndentedJSON sends a pretty-printed JSON response, indented with two spaces.
+// It's meant for humans reading responses in a browser or terminal.
+func (c *Context) IndentedJSON(statusCode int, data interface{}) {
+	opts := c.JSONOptions()
+	opts.Indent = "  "
+	c.JSONWith(statusCode, data, opts)
+}
 
-	// Encode the data payload into JSON and write it to the response body.
-	// json.NewEncoder is efficient as it writes directly to the writer's output stream.
-	if err := json.NewEncoder(c.Writer).Encode(data); err != nil {
-		// If an error occurs during JSON encoding, log it.
-		// In a real app, you might have more robust error handling here.
-		http.Error(c.Writer, "Error encoding JSON response", http.StatusInternalServerError)
-	}
+// SecureJSON sends a JSON response prefixed with SecureJSONPrefix, so that the
+// body can't be executed as a script by a malicious page including it.
+func (c *Context) SecureJSON(statusCode int, data interface{}) {
+	opts := c.JSONOptions()
+	opts.Prefix = SecureJSONPrefix
+	c.JSONWith(statusCode, data, opts)
+}
+
+// JSONWith sends a JSON response encoded with explicit options, overriding
+// c.JSONOptions() for this call.
+func (c *Context) JSONWith(statusCode int, data interface{}, opts JSONOptions) {
+	c.Render(statusCode, JSONRenderer{Data: data, Options: opts})
 }
 
 // String is a helper method to send a plain text response.
 func (c *Context) String(statusCode int, format string, values ...interface{}) {
-	// Set the Content-Type header to plain text.
-	c.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	// Write the HTTP status code.
-	c.Writer.WriteHeader(statusCode)
-	// Write the formatted string to the response body.
-	c.Writer.Write([]byte(fmt.Sprintf(format, values...)))
+	c.Render(statusCode, TextRenderer{Format: format, Args: values})
 }
 
 // Status is a helper method to send a response with only a status code and no body.
 func (c *Context) Status(statusCode int) {
 	c.Writer.WriteHeader(statusCode)
 }
+
+// YAML is a helper method to send a YAML response, handy for ops-facing
+// endpoints such as configuration dumps.
+func (c *Context) YAML(statusCode int, data interface{}) {
+	c.Render(statusCode, YAMLRenderer{Data: data})
+}
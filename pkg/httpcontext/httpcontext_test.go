@@ -0,0 +1,133 @@
+// Description: This file contains tests for the response helpers in httpcontext.go.
+
+package httpcontext
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestContext_YAML tests the YAML response helper.
+func TestContext_YAML(t *testing.T) {
+	c, rr := newTestContext("GET", "/config")
+	c.YAML(http.StatusOK, map[string]interface{}{"port": 8080, "hosts": []string{"a", "b"}})
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("expected Content-Type application/yaml, but got %q", ct)
+	}
+	want := "hosts:\n  - a\n  - b\nport: 8080\n"
+	if rr.Body.String() != want {
+		t.Errorf("expected body %q, but got %q", want, rr.Body.String())
+	}
+}
+
+// TestContext_JSONOptions tests the JSON rendering variants.
+func TestContext_JSONOptions(t *testing.T) {
+	data := map[string]interface{}{"url": "/a?b=1&c=<d>", "n": []int{1}}
+
+	tests := []struct {
+		name   string
+		render func(c *Context)
+		want   string
+	}{
+		{
+			name:   "JSON escapes HTML by default",
+			render: func(c *Context) { c.JSON(http.StatusOK, data) },
+			want:   `{"n":[1],"url":"/a?b=1\u0026c=\u003cd\u003e"}` + "\n",
+		},
+		{
+			name:   "IndentedJSON",
+			render: func(c *Context) { c.IndentedJSON(http.StatusOK, map[string]int{"a": 1}) },
+			want:   "{\n  \"a\": 1\n}\n",
+		},
+		{
+			name:   "SecureJSON",
+			render: func(c *Context) { c.SecureJSON(http.StatusOK, []int{1, 2}) },
+			want:   "while(1);[1,2]\n",
+		},
+		{
+			name: "JSONWith disabled escaping",
+			render: func(c *Context) {
+				c.JSONWith(http.StatusOK, data, JSONOptions{DisableHTMLEscape: true})
+			},
+			want: `{"n":[1],"url":"/a?b=1&c=<d>"}` + "\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, rr := newTestContext("GET", "/")
+			tc.render(c)
+			if rr.Body.String() != tc.want {
+				t.Errorf("expected body %q, but got %q", tc.want, rr.Body.String())
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected Content-Type application/json, but got %q", ct)
+			}
+		})
+	}
+}
+
+// TestContext_JSON_EncodeError checks that an unencodable value yields a 500
+// rather than a success status with a truncated body.
+func TestContext_JSON_EncodeError(t *testing.T) {
+	c, rr := newTestContext("GET", "/")
+	c.JSON(http.StatusOK, map[string]interface{}{"f": func() {}})
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, but got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+// TestDefaultJSONOptions checks that the global defaults apply to JSON.
+func TestDefaultJSONOptions(t *testing.T) {
+	old := DefaultJSONOptions
+	defer func() { DefaultJSONOptions = old }()
+	DefaultJSONOptions.Indent = "\t"
+
+	c, rr := newTestContext("GET", "/")
+	c.JSON(http.StatusOK, map[string]int{"a": 1})
+	if want := "{\n\t\"a\": 1\n}\n"; rr.Body.String() != want {
+		t.Errorf("expected body %q, but got %q", want, rr.Body.String())
+	}
+}
+
+// TestContext_SetJSONOptions checks that a context's own options replace the
+// defaults, in negotiated responses too, and that JSONWith still wins.
+func TestContext_SetJSONOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(c *Context)
+		want   string
+	}{
+		{"JSON", func(c *Context) { c.JSON(http.StatusOK, map[string]int{"a": 1}) }, "{\n\t\"a\": 1\n}\n"},
+		{"Negotiate", func(c *Context) { c.Negotiate(http.StatusOK, map[string]int{"a": 1}) }, "{\n\t\"a\": 1\n}\n"},
+		{"SecureJSON", func(c *Context) { c.SecureJSON(http.StatusOK, []int{1}) }, "while(1);[\n\t1\n]\n"},
+		{"JSONWith", func(c *Context) { c.JSONWith(http.StatusOK, map[string]int{"a": 1}, JSONOptions{}) }, `{"a":1}` + "\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			c, rr := newTestContext("GET", "/")
+			c.SetJSONOptions(JSONOptions{Indent: "\t"})
+
+			// 2. Execute
+			tc.render(c)
+
+			// 3. Assert
+			if rr.Body.String() != tc.want {
+				t.Errorf("expected body %q, but got %q", tc.want, rr.Body.String())
+			}
+		})
+	}
+
+	// A reset context is back to the defaults.
+	c, rr := newTestContext("GET", "/")
+	c.SetJSONOptions(JSONOptions{Indent: "\t"})
+	c.Reset(rr, c.Request)
+	if got := c.JSONOptions(); got != DefaultJSONOptions {
+		t.Errorf("expected the defaults after Reset, but got %+v", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Description: This file contains language negotiation. AcceptedLanguages reads
+// the client's Accept-Language header, Language picks the best match among the
+// languages we support, and T translates a message through the application's
+// translator, which is registered once at startup with SetTranslator.
+
+package httpcontext
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Translator returns the message for key in lang, formatted with args. Plug in
+// any i18n library (or a simple map lookup) by adapting it to this signature.
+type Translator func(lang, key string, args ...interface{}) string
+
+// translator holds the server-wide translator and the languages it supports,
+// in order of preference; the first one is the fallback.
+var (
+	translatorMu       sync.RWMutex
+	translator         Translator
+	supportedLanguages []string
+)
+
+// SetTranslator registers the translator used by c.T, along with the languages
+// it supports (e.g. "en", "de", "pt-BR"). The first language is the fallback
+// when the client accepts none of them.
+func SetTranslator(t Translator, languages ...string) {
+	translatorMu.Lock()
+	defer translatorMu.Unlock()
+	translator = t
+	supportedLanguages = languages
+}
+
+// AcceptedLanguages returns the language tags of the Accept-Language header,
+// most preferred first, e.g. ["de-CH", "de", "en"] for
+// "de-CH, de;q=0.9, en;q=0.5". Languages with q=0 are left out.
+func (c *Context) AcceptedLanguages() []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(c.Request.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if qs, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weighted{tag, q})
+		}
+	}
+	// Stable, so equally weighted languages keep the client's order.
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
+
+// Language returns the best of the supported languages for this request, or
+// the first supported language if the client accepts none of them. Without
+// arguments, the languages given to SetTranslator are used. Matching is
+// case-insensitive, and a regional tag falls back to its base language: a
+// client asking for "en-GB" gets "en" if that's all we have.
+func (c *Context) Language(supported ...string) string {
+	if len(supported) == 0 {
+		translatorMu.RLock()
+		supported = supportedLanguages
+		translatorMu.RUnlock()
+	}
+	if len(supported) == 0 {
+		return ""
+	}
+
+	for _, accepted := range c.AcceptedLanguages() {
+		if accepted == "*" {
+			return supported[0]
+		}
+		for _, lang := range supported {
+			if strings.EqualFold(accepted, lang) {
+				return lang
+			}
+		}
+		base, _, _ := strings.Cut(accepted, "-")
+		for _, lang := range supported {
+			if strings.EqualFold(base, lang) {
+				return lang
+			}
+		}
+	}
+	return supported[0]
+}
+
+// T translates key into the request's language using the registered
+// translator. Without a translator, key is used as a format string, so
+// c.T("user %s not found", id) still produces a sensible message.
+func (c *Context) T(key string, args ...interface{}) string {
+	translatorMu.RLock()
+	t := translator
+	translatorMu.RUnlock()
+	if t == nil {
+		return fmt.Sprintf(key, args...)
+	}
+	return t(c.Language(), key, args...)
+}
@@ -0,0 +1,62 @@
+// Description: This file contains tests for language negotiation.
+
+package httpcontext
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestContext_AcceptedLanguages checks ordering by quality.
+func TestContext_AcceptedLanguages(t *testing.T) {
+	c, _ := newTestContext("GET", "/")
+	c.Request.Header.Set("Accept-Language", "fr;q=0.5, de-CH, de;q=0.9, xx;q=0, en;q=0.5")
+
+	want := []string{"de-CH", "de", "fr", "en"}
+	if got := c.AcceptedLanguages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestContext_Language checks matching against the supported languages.
+func TestContext_Language(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"DE-at, fr;q=0.8", "de"},
+		{"pt-br", "pt-BR"},
+		{"ja, *;q=0.1", "en"},
+		{"ja", "en"},
+	}
+	for _, tc := range tests {
+		c, _ := newTestContext("GET", "/")
+		c.Request.Header.Set("Accept-Language", tc.header)
+		if got := c.Language("en", "de", "pt-BR"); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestContext_T checks the translator hook.
+func TestContext_T(t *testing.T) {
+	c, _ := newTestContext("GET", "/")
+	c.Request.Header.Set("Accept-Language", "de")
+
+	if got := c.T("user %s not found", "ann"); got != "user ann not found" {
+		t.Errorf("without translator: got %q", got)
+	}
+
+	messages := map[string]string{"de:user %s not found": "Benutzer %s nicht gefunden"}
+	SetTranslator(func(lang, key string, args ...interface{}) string {
+		return fmt.Sprintf(messages[lang+":"+key], args...)
+	}, "en", "de")
+	defer SetTranslator(nil)
+
+	if got := c.T("user %s not found", "ann"); got != "Benutzer ann nicht gefunden" {
+		t.Errorf("with translator: got %q", got)
+	}
+}
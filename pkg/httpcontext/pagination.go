@@ -0,0 +1,175 @@
+// Description: This file contains helpers for paginated list endpoints. Pagination
+// reads the usual query parameters (page, per_page or limit, offset, sort) with
+// bounds checking, and SetPaginationHeaders tells the client how to get the
+// other pages via the Link and X-Total-Count headers:
+//
+//	p, err := c.Pagination(httpcontext.PaginationDefaults{PerPage: 20, MaxPerPage: 100})
+//	if err != nil {
+//		c.Error(err)
+//		return
+//	}
+//	users, total := store.List(p.Offset, p.PerPage)
+//	c.SetPaginationHeaders(p, total)
+//	c.JSON(http.StatusOK, users)
+
+package httpcontext
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PaginationDefaults configures Pagination for one endpoint.
+type PaginationDefaults struct {
+	// PerPage is the page size when the client doesn't ask for one.
+	// Zero means 20.
+	PerPage int
+
+	// MaxPerPage caps the page size a client may request; larger values are
+	// lowered to it. Zero means 100.
+	MaxPerPage int
+
+	// Sort is used when the client doesn't send a sort parameter, e.g. "-created_at".
+	Sort string
+
+	// AllowedSorts lists the fields clients may sort by. Sorting by anything
+	// else is a 400 error, which keeps arbitrary columns out of ORDER BY
+	// clauses. Empty means no sorting is accepted besides the default.
+	AllowedSorts []string
+}
+
+// SortField is one field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Page is the pagination requested by the client.
+type Page struct {
+	// Page is the 1-based page number.
+	Page int
+
+	// PerPage is the number of items per page.
+	PerPage int
+
+	// Offset is the number of items to skip, ready for a LIMIT/OFFSET query.
+	Offset int
+
+	// Sort lists the sort fields in priority order, e.g. sort=name,-age.
+	Sort []SortField
+}
+
+// Pagination parses the pagination query parameters:
+//
+//   - page: the 1-based page number;
+//   - per_page, or its alias limit: the page size;
+//   - offset: items to skip, instead of page (Page is then derived from it);
+//   - sort: comma-separated fields, "-" prefix for descending.
+//
+// Malformed or out-of-range values yield a 400 *BindError naming the parameter.
+func (c *Context) Pagination(defaults PaginationDefaults) (Page, error) {
+	if defaults.PerPage <= 0 {
+		defaults.PerPage = 20
+	}
+	if defaults.MaxPerPage <= 0 {
+		defaults.MaxPerPage = 100
+	}
+
+	p := Page{Page: 1, PerPage: defaults.PerPage}
+	var err error
+
+	if _, ok := c.GetQuery("per_page"); ok {
+		p.PerPage, err = c.positiveQuery("per_page", 1)
+	} else if _, ok := c.GetQuery("limit"); ok {
+		p.PerPage, err = c.positiveQuery("limit", 1)
+	}
+	if err != nil {
+		return Page{}, err
+	}
+	p.PerPage = min(p.PerPage, defaults.MaxPerPage)
+
+	if _, ok := c.GetQuery("offset"); ok {
+		if p.Offset, err = c.positiveQuery("offset", 0); err != nil {
+			return Page{}, err
+		}
+		p.Page = p.Offset/p.PerPage + 1
+	} else {
+		if p.Page, err = c.positiveQuery("page", 1); err != nil {
+			return Page{}, err
+		}
+		p.Offset = (p.Page - 1) * p.PerPage
+	}
+
+	sort := defaults.Sort
+	if s, ok := c.GetQuery("sort"); ok && s != "" {
+		sort = s
+	}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sf := SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if sort != defaults.Sort && !slices.Contains(defaults.AllowedSorts, sf.Field) {
+			return Page{}, &BindError{
+				Status:  http.StatusBadRequest,
+				Field:   "sort",
+				Message: fmt.Sprintf("cannot sort by %q", sf.Field),
+			}
+		}
+		p.Sort = append(p.Sort, sf)
+	}
+	return p, nil
+}
+
+// positiveQuery parses an integer query parameter that must be at least minimum.
+// A missing or empty parameter yields minimum.
+func (c *Context) positiveQuery(name string, minimum int) (int, error) {
+	n, err := c.QueryInt(name, minimum)
+	if err == nil && n < minimum {
+		err = fmt.Errorf("query parameter %q must be at least %d, got %d", name, minimum, n)
+	}
+	// Guard against page*per_page overflowing into a negative offset.
+	if err == nil && n > 1<<31 {
+		err = fmt.Errorf("query parameter %q is too large", name)
+	}
+	if err != nil {
+		return 0, &BindError{Status: http.StatusBadRequest, Field: name, Message: err.Error()}
+	}
+	return n, nil
+}
+
+// SetPaginationHeaders sets X-Total-Count to total and a Link header (RFC 8288)
+// with the first, prev, next, and last pages, keeping the request's other query
+// parameters such as filters.
+func (c *Context) SetPaginationHeaders(p Page, total int) {
+	c.SetHeader("X-Total-Count", strconv.Itoa(total))
+
+	lastPage := max(1, (total+p.PerPage-1)/p.PerPage)
+	var links []string
+	link := func(page int, rel string) {
+		u := *c.Request.URL
+		q := u.Query()
+		q.Del("offset")
+		q.Del("limit")
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+		u.RawQuery = q.Encode()
+		// Links are relative to the host, which works behind proxies too.
+		u.Scheme, u.Host = "", ""
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u.String(), rel))
+	}
+
+	link(1, "first")
+	if p.Page > 1 {
+		link(min(p.Page-1, lastPage), "prev")
+	}
+	if p.Page < lastPage {
+		link(p.Page+1, "next")
+	}
+	link(lastPage, "last")
+	c.SetHeader("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,69 @@
+// Description: This file contains tests for the pagination helpers.
+
+package httpcontext
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestContext_Pagination checks parsing, defaults, and bounds.
+func TestContext_Pagination(t *testing.T) {
+	defaults := PaginationDefaults{PerPage: 10, MaxPerPage: 50, Sort: "-id", AllowedSorts: []string{"name", "age"}}
+
+	tests := []struct {
+		query   string
+		want    Page
+		wantErr string // the offending parameter
+	}{
+		{"", Page{Page: 1, PerPage: 10, Offset: 0, Sort: []SortField{{"id", true}}}, ""},
+		{"page=3&per_page=5", Page{Page: 3, PerPage: 5, Offset: 10, Sort: []SortField{{"id", true}}}, ""},
+		{"limit=1000", Page{Page: 1, PerPage: 50, Offset: 0, Sort: []SortField{{"id", true}}}, ""},
+		{"offset=25&limit=10&sort=name,-age", Page{Page: 3, PerPage: 10, Offset: 25, Sort: []SortField{{"name", false}, {"age", true}}}, ""},
+		{"page=0", Page{}, "page"},
+		{"per_page=abc", Page{}, "per_page"},
+		{"offset=-1", Page{}, "offset"},
+		{"sort=password", Page{}, "sort"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			c, _ := newTestContext("GET", "/users?"+tc.query)
+			got, err := c.Pagination(defaults)
+			if tc.wantErr != "" {
+				var bindErr *BindError
+				if !errors.As(err, &bindErr) || bindErr.Field != tc.wantErr {
+					t.Fatalf("expected a BindError for %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestContext_SetPaginationHeaders checks the Link and X-Total-Count headers.
+func TestContext_SetPaginationHeaders(t *testing.T) {
+	c, rr := newTestContext("GET", "/users?role=admin&limit=10&offset=10")
+	p, err := c.Pagination(PaginationDefaults{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPaginationHeaders(p, 35)
+
+	if got := rr.Header().Get("X-Total-Count"); got != "35" {
+		t.Errorf("X-Total-Count: got %q", got)
+	}
+	want := `</users?page=1&per_page=10&role=admin>; rel="first", ` +
+		`</users?page=1&per_page=10&role=admin>; rel="prev", ` +
+		`</users?page=3&per_page=10&role=admin>; rel="next", ` +
+		`</users?page=4&per_page=10&role=admin>; rel="last"`
+	if got := rr.Header().Get("Link"); got != want {
+		t.Errorf("Link:\n got %s\nwant %s", got, want)
+	}
+}
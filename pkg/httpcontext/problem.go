@@ -0,0 +1,59 @@
+// Description: This file contains problem details (RFC 9457), the standard
+// application/problem+json error format. The envelope in envelope.go stays the
+// default for this API; Problem is for responses that clients, gateways, or
+// generic tooling expect in the standard format, such as authorization failures.
+
+package httpcontext
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// ProblemContentType is the media type of problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details object. Only Status is required; an empty Type
+// means "about:blank", i.e. the status code says it all, and an empty Title
+// defaults to the status text.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// RequestID is an extension member carrying the request's ID, like the
+	// envelope's request_id.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ProblemRenderer renders a Problem as application/problem+json.
+type ProblemRenderer struct {
+	Problem Problem
+}
+
+// ContentType implements Renderer.
+func (r ProblemRenderer) ContentType() string { return ProblemContentType }
+
+// Render implements Renderer.
+func (r ProblemRenderer) Render(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Problem)
+}
+
+// Problem sends p as the response, with p.Status as the status code. The title
+// and request ID are filled in when missing, and the instance defaults to the
+// request path.
+func (c *Context) Problem(p Problem) {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = c.requestID
+	}
+	c.Render(p.Status, ProblemRenderer{Problem: p})
+}
@@ -0,0 +1,35 @@
+// Description: This file contains tests for the problem details helper.
+
+package httpcontext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContext_Problem checks the body and defaults of a problem response.
+func TestContext_Problem(t *testing.T) {
+	// 1. Setup
+	rr := httptest.NewRecorder()
+	c := new(Context)
+	c.Reset(rr, httptest.NewRequest("DELETE", "/users/7", nil))
+	c.SetRequestID("abc")
+
+	// 2. Execute
+	c.Problem(Problem{Status: http.StatusForbidden, Detail: "missing permission users:delete"})
+
+	// 3. Assert
+	if rr.Code != http.StatusForbidden || rr.Header().Get("Content-Type") != ProblemContentType {
+		t.Fatalf("expected 403 %s, got %d %s", ProblemContentType, rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var got Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{Title: "Forbidden", Status: 403, Detail: "missing permission users:delete", Instance: "/users/7", RequestID: "abc"}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,81 @@
+// Description: This file contains helpers for reading URL query parameters, so
+// handlers don't have to call c.Request.URL.Query() and convert strings to other
+// types by hand.
+
+package httpcontext
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// queryValues parses the query string once per request and caches the result.
+func (c *Context) queryValues() url.Values {
+	if c.query == nil {
+		c.query = c.Request.URL.Query()
+	}
+	return c.query
+}
+
+// GetQuery returns the first value of a query parameter and whether the
+// parameter was present at all ("?name=" is present but empty).
+func (c *Context) GetQuery(name string) (string, bool) {
+	values, ok := c.queryValues()[name]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// Query returns the first value of a query parameter, or "" if it's missing.
+// For "/users?name=ann", c.Query("name") returns "ann".
+func (c *Context) Query(name string) string {
+	value, _ := c.GetQuery(name)
+	return value
+}
+
+// DefaultQuery returns the first value of a query parameter, or def if the
+// parameter is missing.
+func (c *Context) DefaultQuery(name, def string) string {
+	if value, ok := c.GetQuery(name); ok {
+		return value
+	}
+	return def
+}
+
+// QueryArray returns every value of a repeated query parameter. For
+// "/users?id=1&id=2", c.QueryArray("id") returns ["1", "2"].
+func (c *Context) QueryArray(name string) []string {
+	return c.queryValues()[name]
+}
+
+// QueryInt returns a query parameter converted to an int. A missing or empty
+// parameter yields def; a value that isn't a valid integer yields an error
+// naming the parameter, suitable for a 400 Bad Request response.
+func (c *Context) QueryInt(name string, def int) (int, error) {
+	value, ok := c.GetQuery(name)
+	if !ok || value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, fmt.Errorf("query parameter %q must be an integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// QueryBool returns a query parameter converted to a bool. It accepts the forms
+// understood by strconv.ParseBool ("1", "t", "true", "0", "f", "false", ...).
+// A missing or empty parameter yields def.
+func (c *Context) QueryBool(name string, def bool) (bool, error) {
+	value, ok := c.GetQuery(name)
+	if !ok || value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("query parameter %q must be a boolean, got %q", name, value)
+	}
+	return b, nil
+}
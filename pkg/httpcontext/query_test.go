@@ -0,0 +1,61 @@
+// Description: This file contains tests for the query parameter helpers.
+
+package httpcontext
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newTestContext builds a Context around a recorder and a request for target.
+func newTestContext(method, target string) (*Context, *httptest.ResponseRecorder) {
+	rr := httptest.NewRecorder()
+	return &Context{Writer: rr, Request: httptest.NewRequest(method, target, nil)}, rr
+}
+
+// TestContext_Query tests the string query helpers.
+func TestContext_Query(t *testing.T) {
+	c, _ := newTestContext("GET", "/users?name=ann&empty=&id=1&id=2")
+
+	if got := c.Query("name"); got != "ann" {
+		t.Errorf("Query(name): expected %q, but got %q", "ann", got)
+	}
+	if got := c.Query("missing"); got != "" {
+		t.Errorf("Query(missing): expected empty string, but got %q", got)
+	}
+	if got := c.DefaultQuery("missing", "def"); got != "def" {
+		t.Errorf("DefaultQuery(missing): expected %q, but got %q", "def", got)
+	}
+	// A present but empty parameter is not replaced by the default.
+	if got := c.DefaultQuery("empty", "def"); got != "" {
+		t.Errorf("DefaultQuery(empty): expected empty string, but got %q", got)
+	}
+	if _, ok := c.GetQuery("empty"); !ok {
+		t.Error("GetQuery(empty): expected the parameter to be present")
+	}
+	if got := c.QueryArray("id"); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("QueryArray(id): expected [1 2], but got %v", got)
+	}
+}
+
+// TestContext_QueryTyped tests the int and bool query helpers.
+func TestContext_QueryTyped(t *testing.T) {
+	c, _ := newTestContext("GET", "/users?page=3&bad=x&active=true")
+
+	if n, err := c.QueryInt("page", 1); err != nil || n != 3 {
+		t.Errorf("QueryInt(page): expected 3, nil but got %d, %v", n, err)
+	}
+	if n, err := c.QueryInt("missing", 1); err != nil || n != 1 {
+		t.Errorf("QueryInt(missing): expected the default 1, but got %d, %v", n, err)
+	}
+	if _, err := c.QueryInt("bad", 1); err == nil {
+		t.Error("QueryInt(bad): expected an error for a non-integer value")
+	}
+	if b, err := c.QueryBool("active", false); err != nil || !b {
+		t.Errorf("QueryBool(active): expected true, nil but got %v, %v", b, err)
+	}
+	if _, err := c.QueryBool("bad", false); err == nil {
+		t.Error("QueryBool(bad): expected an error for a non-boolean value")
+	}
+}
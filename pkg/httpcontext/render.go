@@ -0,0 +1,261 @@
+// Description: This file contains the Renderer interface, which every response
+// helper (JSON, String, YAML, HTML, MsgPack, CBOR) is built on. Applications can
+// add their own formats (CSV, Excel, PDF, custom envelopes) by implementing it
+// and calling c.Render(status, renderer). Registering a renderer for a media
+// type also makes it available to content negotiation with c.Negotiate.
+
+package httpcontext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/codec"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/yaml"
+)
+
+// Renderer writes a response body in some format.
+type Renderer interface {
+	// ContentType returns the value for the Content-Type header.
+	ContentType() string
+
+	// Render writes the body to w.
+	Render(w io.Writer) error
+}
+
+// Render sends a response produced by r with the given status code. The body is
+// rendered into a buffer first, so a rendering error produces a clean 500
+// response instead of a half-written body behind a success status.
+func (c *Context) Render(statusCode int, r Renderer) {
+	var buf bytes.Buffer
+	if err := r.Render(&buf); err != nil {
+		c.Logger().Error("Error rendering response", "content_type", r.ContentType(), "err", err)
+		http.Error(c.Writer, "Error rendering response", http.StatusInternalServerError)
+		return
+	}
+	c.SetHeader("Content-Type", r.ContentType())
+	c.Writer.WriteHeader(statusCode)
+	c.Writer.Write(buf.Bytes())
+}
+
+// JSONRenderer renders Data as JSON.
+type JSONRenderer struct {
+	Data    interface{}
+	Options JSONOptions
+}
+
+// ContentType implements Renderer.
+func (r JSONRenderer) ContentType() string { return "application/json" }
+
+// Render implements Renderer.
+func (r JSONRenderer) Render(w io.Writer) error {
+	io.WriteString(w, r.Options.Prefix)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(!r.Options.DisableHTMLEscape)
+	if r.Options.Indent != "" {
+		enc.SetIndent("", r.Options.Indent)
+	}
+	return enc.Encode(r.Data)
+}
+
+// TextRenderer renders a plain text message, formatted like fmt.Sprintf.
+type TextRenderer struct {
+	Format string
+	Args   []interface{}
+}
+
+// ContentType implements Renderer.
+func (r TextRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Render implements Renderer.
+func (r TextRenderer) Render(w io.Writer) error {
+	_, err := fmt.Fprintf(w, r.Format, r.Args...)
+	return err
+}
+
+// YAMLRenderer renders Data as YAML.
+type YAMLRenderer struct {
+	Data interface{}
+}
+
+// ContentType implements Renderer. application/yaml is the registered media
+// type (RFC 9512).
+func (r YAMLRenderer) ContentType() string { return "application/yaml" }
+
+// Render implements Renderer.
+func (r YAMLRenderer) Render(w io.Writer) error {
+	return marshalTo(w, r.Data, yaml.Marshal)
+}
+
+// MsgPackRenderer renders Data as MessagePack.
+type MsgPackRenderer struct {
+	Data interface{}
+}
+
+// ContentType implements Renderer.
+func (r MsgPackRenderer) ContentType() string { return MIMEMsgPack }
+
+// Render implements Renderer.
+func (r MsgPackRenderer) Render(w io.Writer) error {
+	return marshalTo(w, r.Data, codec.MarshalMsgPack)
+}
+
+// CBORRenderer renders Data as CBOR.
+type CBORRenderer struct {
+	Data interface{}
+}
+
+// ContentType implements Renderer.
+func (r CBORRenderer) ContentType() string { return MIMECBOR }
+
+// Render implements Renderer.
+func (r CBORRenderer) Render(w io.Writer) error {
+	return marshalTo(w, r.Data, codec.MarshalCBOR)
+}
+
+// HTMLRenderer renders the named page of a template set. A nil Templates uses
+// the set installed with SetTemplates.
+type HTMLRenderer struct {
+	Templates *Templates
+	Name      string
+	Data      interface{}
+}
+
+// ContentType implements Renderer.
+func (r HTMLRenderer) ContentType() string { return "text/html; charset=utf-8" }
+
+// Render implements Renderer.
+func (r HTMLRenderer) Render(w io.Writer) error {
+	t := r.Templates
+	if t == nil {
+		htmlTemplatesMu.RLock()
+		t = htmlTemplates
+		htmlTemplatesMu.RUnlock()
+	}
+	if t == nil {
+		return fmt.Errorf("httpcontext: no templates configured; call SetTemplates at startup")
+	}
+	var buf bytes.Buffer
+	if err := t.Render(&buf, r.Name, r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// marshalTo writes the output of a Marshal-style function to w.
+func marshalTo(w io.Writer, data interface{}, marshal func(interface{}) ([]byte, error)) error {
+	out, err := marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// negotiable is a media type c.Negotiate can answer with.
+type negotiable struct {
+	mediaType string
+
+	// factory is nil for the built-in JSON, which is encoded with the
+	// context's JSONOptions.
+	factory func(data interface{}) Renderer
+}
+
+// negotiables holds the server-wide list of formats for c.Negotiate, in order
+// of preference; the first one is the default.
+var (
+	negotiablesMu sync.RWMutex
+	negotiables   = []negotiable{
+		{"application/json", nil},
+		{MIMEMsgPack, func(data interface{}) Renderer { return MsgPackRenderer{Data: data} }},
+		{MIMECBOR, func(data interface{}) Renderer { return CBORRenderer{Data: data} }},
+	}
+)
+
+// RegisterRenderer makes c.Negotiate answer requests accepting mediaType using
+// the renderer built by factory, e.g.
+//
+//	httpcontext.RegisterRenderer("text/csv", func(data interface{}) httpcontext.Renderer {
+//		return CSVRenderer{Rows: data}
+//	})
+//
+// Registering a media type again replaces its factory. Call it at startup.
+func RegisterRenderer(mediaType string, factory func(data interface{}) Renderer) {
+	negotiablesMu.Lock()
+	defer negotiablesMu.Unlock()
+	mediaType = strings.ToLower(mediaType)
+	for i, n := range negotiables {
+		if n.mediaType == mediaType {
+			negotiables[i].factory = factory
+			return
+		}
+	}
+	negotiables = append(negotiables, negotiable{mediaType, factory})
+}
+
+// Negotiate sends data in the registered format the client's Accept header
+// prefers: JSON, MessagePack, and CBOR out of the box. JSON is the default when
+// the header is missing or names none of them.
+func (c *Context) Negotiate(statusCode int, data interface{}) {
+	// The response depends on Accept, so caches must key on it too.
+	c.AddHeader("Vary", "Accept")
+
+	negotiablesMu.RLock()
+	best := negotiate(c.Request.Header.Get("Accept"), negotiables)
+	negotiablesMu.RUnlock()
+	if best.factory == nil {
+		c.JSON(statusCode, data)
+		return
+	}
+	c.Render(statusCode, best.factory(data))
+}
+
+// negotiate returns the format with the highest quality in an Accept header.
+// Ties go to the entry listed first, wildcards select the most preferred
+// matching format, and the first format is the default.
+func negotiate(accept string, formats []negotiable) negotiable {
+	best, bestQ := formats[0], 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if isMsgPack(mt) {
+			mt = MIMEMsgPack // Accept the common aliases.
+		}
+
+		for _, f := range formats {
+			if mediaTypeMatches(mt, f.mediaType) {
+				if q > bestQ {
+					best, bestQ = f, q
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+// mediaTypeMatches reports whether a media range from an Accept header
+// ("*/*", "application/*", or a full type) covers mediaType.
+func mediaTypeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
@@ -0,0 +1,78 @@
+// Description: This file contains tests for the Renderer interface and for
+// registering custom formats with content negotiation.
+
+package httpcontext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// csvRenderer is a custom renderer, as an application would write one.
+type csvRenderer struct {
+	rows [][]string
+	err  error
+}
+
+func (r csvRenderer) ContentType() string { return "text/csv" }
+
+func (r csvRenderer) Render(w io.Writer) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, row := range r.rows {
+		fmt.Fprintf(w, "%s,%s\n", row[0], row[1])
+	}
+	return nil
+}
+
+// TestContext_Render checks custom renderers and error handling.
+func TestContext_Render(t *testing.T) {
+	c, rr := newTestContext("GET", "/export")
+	c.Render(http.StatusOK, csvRenderer{rows: [][]string{{"ann", "30"}}})
+	if rr.Body.String() != "ann,30\n" || rr.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("unexpected response: %q %q", rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+
+	c, rr = newTestContext("GET", "/export")
+	c.Render(http.StatusOK, csvRenderer{err: errors.New("disk full")})
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 on render error, got %d", rr.Code)
+	}
+}
+
+// TestRegisterRenderer checks that registered formats take part in Negotiate.
+func TestRegisterRenderer(t *testing.T) {
+	negotiablesMu.RLock()
+	saved := append([]negotiable(nil), negotiables...)
+	negotiablesMu.RUnlock()
+	defer func() {
+		negotiablesMu.Lock()
+		negotiables = saved
+		negotiablesMu.Unlock()
+	}()
+
+	RegisterRenderer("text/csv", func(data interface{}) Renderer {
+		return csvRenderer{rows: data.([][]string)}
+	})
+
+	tests := []struct {
+		accept   string
+		wantType string
+	}{
+		{"text/csv", "text/csv"},
+		{"text/*", "text/csv"},
+		{"text/html, application/json;q=0.9", "application/json"},
+	}
+	for _, tc := range tests {
+		c, rr := newTestContext("GET", "/export")
+		c.Request.Header.Set("Accept", tc.accept)
+		c.Negotiate(http.StatusOK, [][]string{{"ann", "30"}})
+		if got := rr.Header().Get("Content-Type"); got != tc.wantType {
+			t.Errorf("%q: got %q, want %q", tc.accept, got, tc.wantType)
+		}
+	}
+}
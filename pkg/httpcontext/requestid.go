@@ -0,0 +1,47 @@
+// Description: This file contains the request ID, a short string identifying
+// one request across log lines, error responses, and the services it calls.
+// It's assigned by the middleware.RequestID middleware; handlers read it with
+// c.RequestID and log with c.Logger (or c.Logf), which tags each line with it.
+
+package httpcontext
+
+import (
+	"fmt"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// RequestIDHeader is the header that carries request IDs, both from clients
+// and proxies to us and from us back in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns the request's ID, or "" if none was assigned.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// SetRequestID assigns the request's ID.
+func (c *Context) SetRequestID(id string) {
+	c.requestID = id
+}
+
+// Logger returns a logger for the current request: the default logger with
+// the request ID added to every line, as request_id=4f2a... Grepping the logs
+// for an ID a client reported then finds everything that happened to that
+// request. For sampled requests, it writes debug lines too (see Sampled).
+func (c *Context) Logger() logger.Interface {
+	l := logger.Default()
+	if c.sampled {
+		l = logger.Verbose(l)
+	}
+	if c.requestID == "" {
+		return l
+	}
+	return logger.With(l, "request_id", c.requestID)
+}
+
+// Logf logs a formatted message about the current request at the info level,
+// see Logger.
+func (c *Context) Logf(format string, args ...interface{}) {
+	c.Logger().Info(fmt.Sprintf(format, args...))
+}
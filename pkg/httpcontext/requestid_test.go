@@ -0,0 +1,40 @@
+// Description: This file contains tests for request IDs and request logging.
+
+package httpcontext
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLogf tests that log lines are tagged with the request ID.
+func TestLogf(t *testing.T) {
+	// 1. Setup: Capture the standard logger's output.
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	c := new(Context)
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	// 2. Execute
+	c.Logf("before %d", 1)
+	c.SetRequestID("abc")
+	c.Logf("after %d", 2)
+
+	// 3. Assert
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "request_id") || !strings.HasSuffix(lines[1], "INFO after 2 request_id=abc") {
+		t.Errorf("unexpected log output %q", buf.String())
+	}
+	if c.Copy().RequestID() != "abc" {
+		t.Error("expected copies to keep the request ID")
+	}
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if c.RequestID() != "" {
+		t.Error("expected Reset to clear the request ID")
+	}
+}
@@ -0,0 +1,105 @@
+// Description: This file contains the response writer the router installs on
+// every Context. It passes everything through to the real http.ResponseWriter
+// while remembering the status code, the number of body bytes, and whether the
+// response was started. That lets middleware log what was sent, and turns a
+// second WriteHeader (e.g. a handler calling c.JSON twice) into a logged no-op.
+
+package httpcontext
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// responseWriter tracks what was written to the wrapped http.ResponseWriter.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+// reset points the writer at a new response, discarding the previous state.
+func (w *responseWriter) reset(rw http.ResponseWriter) {
+	*w = responseWriter{ResponseWriter: rw}
+}
+
+// WriteHeader sends the status line once. Later calls are ignored and logged,
+// since they would be ignored by net/http anyway and usually indicate a bug.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		logger.Warn("Superfluous WriteHeader ignored", "status", code, "sent", w.status)
+		return
+	}
+	// 1xx informational responses (e.g. 103 Early Hints) may precede the
+	// real status, so they don't count as starting the response.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends body bytes, implicitly sending a 200 status first if needed.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, e.g. for WebSockets. A
+// hijacked response counts as written with status 101 Switching Protocols, so
+// access logs show the upgrade.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer, so http.ResponseController can reach
+// features (deadlines, full duplex) we don't forward ourselves.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written reports whether the response was started, i.e. the status line was
+// sent. Once it's true, headers can no longer be changed.
+func (c *Context) Written() bool {
+	return c.resp.wroteHeader
+}
+
+// ResponseStatus returns the status code sent to the client, or 200 if the
+// response wasn't started yet (which is what net/http sends when a handler
+// returns without writing anything).
+func (c *Context) ResponseStatus() int {
+	if !c.resp.wroteHeader {
+		return http.StatusOK
+	}
+	return c.resp.status
+}
+
+// ResponseSize returns the number of body bytes written so far.
+func (c *Context) ResponseSize() int64 {
+	return c.resp.size
+}
@@ -0,0 +1,52 @@
+// Description: This file contains tests for the tracking response writer.
+
+package httpcontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContext_ResponseTracking checks status, size, and Written.
+func TestContext_ResponseTracking(t *testing.T) {
+	// 1. Setup: A context prepared the way the router does it.
+	rr := httptest.NewRecorder()
+	c := new(Context)
+	c.Reset(rr, httptest.NewRequest("GET", "/", nil))
+
+	if c.Written() || c.ResponseStatus() != http.StatusOK || c.ResponseSize() != 0 {
+		t.Fatalf("unexpected initial state: %v %d %d", c.Written(), c.ResponseStatus(), c.ResponseSize())
+	}
+
+	// 2. Execute: A handler that mistakenly answers twice.
+	c.JSON(http.StatusCreated, map[string]int{"id": 1})
+	c.JSON(http.StatusInternalServerError, map[string]string{"error": "oops"})
+
+	// 3. Assert: The first status stuck, and both bodies were counted.
+	if !c.Written() {
+		t.Error("expected Written to be true")
+	}
+	if c.ResponseStatus() != http.StatusCreated || rr.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d (recorder %d)", c.ResponseStatus(), rr.Code)
+	}
+	if c.ResponseSize() != int64(rr.Body.Len()) {
+		t.Errorf("expected size %d, got %d", rr.Body.Len(), c.ResponseSize())
+	}
+}
+
+// TestContext_ResponseTracking_ImplicitStatus checks that a bare Write counts
+// as a 200.
+func TestContext_ResponseTracking_ImplicitStatus(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c := new(Context)
+	c.Reset(rr, httptest.NewRequest("GET", "/", nil))
+
+	c.Writer.Write([]byte("hello"))
+	if !c.Written() || c.ResponseStatus() != http.StatusOK || c.ResponseSize() != 5 {
+		t.Errorf("unexpected state: %v %d %d", c.Written(), c.ResponseStatus(), c.ResponseSize())
+	}
+	if _, ok := c.Writer.(http.Flusher); !ok {
+		t.Error("expected the writer to support http.Flusher")
+	}
+}
@@ -0,0 +1,18 @@
+// Description: This file contains the request's sampling mark. The
+// middleware.Sample middleware marks some requests, say 1% of them, as
+// sampled; their c.Logger writes debug lines even when the log level is
+// higher, and expensive debug output like middleware.BodyDump can be limited
+// to them. That keeps the detail of a few requests without the log volume of
+// all.
+
+package httpcontext
+
+// Sampled reports whether the request was picked for verbose logging.
+func (c *Context) Sampled() bool {
+	return c.sampled
+}
+
+// SetSampled marks the request as picked for verbose logging, or not.
+func (c *Context) SetSampled(sampled bool) {
+	c.sampled = sampled
+}
@@ -0,0 +1,217 @@
+// Description: This file implements tag-based struct validation. Binding runs it
+// automatically after decoding, so handlers get clean data or a 422 response
+// listing every problem at once:
+//
+//	type User struct {
+//		Name  string `json:"name" validate:"required,min=3,max=50"`
+//		Email string `json:"email" validate:"required,email"`
+//		Role  string `json:"role" validate:"oneof=admin member"`
+//	}
+//
+// Supported rules:
+//
+//	required    the value must not be the zero value ("" / 0 / nil / empty slice)
+//	min=N       strings and slices: at least N long; numbers: at least N
+//	max=N       strings and slices: at most N long; numbers: at most N
+//	len=N       strings and slices: exactly N long
+//	oneof=a b   the value must be one of the space-separated options
+//	email       the value must look like an e-mail address
+//
+// Rules other than required are skipped for empty values, so an optional field
+// can still be constrained: `validate:"min=3"` accepts "" but not "ab".
+
+package httpcontext
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes one validation failure.
+type FieldError struct {
+	// Field is the client-facing name of the field (its JSON name when it
+	// has one), with dots for nested structs, e.g. "address.city".
+	Field string `json:"field"`
+
+	// Rule is the rule that failed, e.g. "min".
+	Rule string `json:"rule"`
+
+	// Message is a human-readable explanation.
+	Message string `json:"message"`
+}
+
+// Validate checks the struct (or pointer to struct) v against its `validate`
+// tags. It returns nil if everything is valid, or a *BindError with status 422
+// Unprocessable Entity whose Fields list every failure.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	validateStruct(rv, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BindError{
+		Status:  http.StatusUnprocessableEntity,
+		Message: "validation failed",
+		Fields:  errs,
+	}
+}
+
+// validateStruct validates every field of sv, appending failures to errs.
+func validateStruct(sv reflect.Value, prefix string, errs *[]FieldError) {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := sv.Field(i)
+		name := prefix + fieldName(field)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			for _, rule := range strings.Split(tag, ",") {
+				if msg := checkRule(fv, rule); msg != "" {
+					ruleName, _, _ := strings.Cut(rule, "=")
+					*errs = append(*errs, FieldError{Field: name, Rule: ruleName, Message: msg})
+				}
+			}
+		}
+
+		// Recurse into nested structs (and non-nil pointers to them).
+		inner := fv
+		if inner.Kind() == reflect.Ptr && !inner.IsNil() {
+			inner = inner.Elem()
+		}
+		if inner.Kind() == reflect.Struct {
+			nestedPrefix := name + "."
+			if field.Anonymous {
+				nestedPrefix = prefix
+			}
+			validateStruct(inner, nestedPrefix, errs)
+		}
+	}
+}
+
+// fieldName returns the name clients know a field by: its JSON name if it has
+// one, otherwise its Go name.
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// checkRule applies a single rule to a value and returns an error message, or ""
+// if the value passes.
+func checkRule(fv reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+	if name == "required" {
+		if isEmpty(fv) {
+			return "is required"
+		}
+		return ""
+	}
+	// Every other rule only constrains values that are actually present.
+	if isEmpty(fv) {
+		return ""
+	}
+	if fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+
+	switch name {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("has an invalid %q rule", rule)
+		}
+		size, isLength := measure(fv)
+		var failed bool
+		switch name {
+		case "min":
+			failed = size < limit
+		case "max":
+			failed = size > limit
+		case "len":
+			failed = size != limit
+		}
+		if !failed {
+			return ""
+		}
+		what := "be"
+		if isLength {
+			what = "have a length of"
+		}
+		switch name {
+		case "min":
+			return fmt.Sprintf("must %s at least %s", what, arg)
+		case "max":
+			return fmt.Sprintf("must %s at most %s", what, arg)
+		default:
+			return fmt.Sprintf("must %s exactly %s", what, arg)
+		}
+	case "oneof":
+		value := fmt.Sprint(fv.Interface())
+		for _, option := range strings.Fields(arg) {
+			if value == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(arg), ", "))
+	case "email":
+		addr, err := mail.ParseAddress(fv.String())
+		// ParseAddress also accepts "Name <a@b.c>"; we want a bare address.
+		if err != nil || addr.Address != fv.String() {
+			return "must be a valid e-mail address"
+		}
+		return ""
+	default:
+		return fmt.Sprintf("has an unknown validation rule %q", name)
+	}
+}
+
+// measure returns the number a min/max/len rule compares against: the length
+// for strings, slices, and maps, or the value itself for numbers.
+func measure(fv reflect.Value) (size float64, isLength bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(fv.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false
+	}
+	return 0, false
+}
+
+// isEmpty reports whether a value is its type's zero value (or an empty
+// slice/map), which is what "required" rejects.
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	}
+	return fv.IsZero()
+}
@@ -0,0 +1,81 @@
+// Description: This file contains tests for tag-based struct validation.
+
+package httpcontext
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// validated exercises every validation rule.
+type validated struct {
+	Name    string   `json:"name" validate:"required,min=3,max=10"`
+	Email   string   `json:"email" validate:"required,email"`
+	Role    string   `json:"role" validate:"oneof=admin member"`
+	Age     int      `json:"age" validate:"min=18"`
+	Code    string   `json:"code" validate:"len=4"`
+	Tags    []string `json:"tags" validate:"max=2"`
+	Address struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+}
+
+// TestValidate_Valid tests that a valid struct passes.
+func TestValidate_Valid(t *testing.T) {
+	v := validated{Name: "ann", Email: "ann@example.com", Role: "admin", Age: 30, Code: "abcd"}
+	v.Address.City = "Lahore"
+	if err := Validate(&v); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+}
+
+// TestValidate_Invalid tests that every failing rule is reported with the
+// client-facing field name.
+func TestValidate_Invalid(t *testing.T) {
+	v := validated{Name: "an", Email: "not-an-email", Role: "guest", Age: 12, Code: "abc", Tags: []string{"a", "b", "c"}}
+
+	err := Validate(&v)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected a *BindError, but got %v", err)
+	}
+	if bindErr.Status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, but got %d", http.StatusUnprocessableEntity, bindErr.Status)
+	}
+
+	want := map[string]string{
+		"name":         "min",
+		"email":        "email",
+		"role":         "oneof",
+		"age":          "min",
+		"code":         "len",
+		"tags":         "max",
+		"address.city": "required",
+	}
+	got := make(map[string]string)
+	for _, fe := range bindErr.Fields {
+		got[fe.Field] = fe.Rule
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("expected field %q to fail rule %q, but got %q", field, rule, got[field])
+		}
+	}
+	if len(bindErr.Fields) != len(want) {
+		t.Errorf("expected %d field errors, but got %d: %+v", len(want), len(bindErr.Fields), bindErr.Fields)
+	}
+}
+
+// TestBindJSON_Validates tests that binding runs validation.
+func TestBindJSON_Validates(t *testing.T) {
+	c := newBodyContext(`{"name":"x"}`)
+
+	var v struct {
+		Name string `json:"name" validate:"min=3"`
+	}
+	var bindErr *BindError
+	if err := c.BindJSON(&v); !errors.As(err, &bindErr) || bindErr.Status != http.StatusUnprocessableEntity {
+		t.Errorf("expected a 422 BindError, but got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+// Description: This file connects Context to the websocket package, so a route
+// handler can switch the connection over to the WebSocket protocol:
+//
+//	r.GET("/ws", func(c *httpcontext.Context) {
+//		conn, err := c.UpgradeWebSocket(websocket.Options{})
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		...
+//	})
+
+package httpcontext
+
+import "github.com/hanzalaareeb/HTTPGolang/pkg/websocket"
+
+// IsWebSocketUpgrade reports whether the client asks to switch to WebSocket.
+func (c *Context) IsWebSocketUpgrade() bool {
+	return websocket.IsUpgrade(c.Request)
+}
+
+// UpgradeWebSocket performs the WebSocket handshake and returns the connection.
+// On failure the client has already received an HTTP error, so the handler
+// should just return. The connection outlives the HTTP exchange: keep using it
+// in the handler (or a goroutine holding a c.Copy()), not c itself.
+func (c *Context) UpgradeWebSocket(opts websocket.Options) (*websocket.Conn, error) {
+	return websocket.Upgrade(c.Writer, c.Request, opts)
+}
@@ -0,0 +1,18 @@
+// Description: This file contains tests for the WebSocket helpers on Context.
+
+package httpcontext
+
+import "testing"
+
+// TestContext_IsWebSocketUpgrade checks detection of upgrade requests.
+func TestContext_IsWebSocketUpgrade(t *testing.T) {
+	c, _ := newTestContext("GET", "/ws")
+	if c.IsWebSocketUpgrade() {
+		t.Error("plain request detected as an upgrade")
+	}
+	c.Request.Header.Set("Connection", "keep-alive, Upgrade")
+	c.Request.Header.Set("Upgrade", "WebSocket")
+	if !c.IsWebSocketUpgrade() {
+		t.Error("upgrade request not detected")
+	}
+}
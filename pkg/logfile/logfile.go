@@ -0,0 +1,282 @@
+// Description: This package contains a log file that rotates itself, for
+// servers that write their logs to disk rather than to a collector. Once the
+// file grows past a size, or a new period (e.g. a day) begins, it's renamed
+// with a timestamp, optionally gzipped, and a new one is started; the oldest
+// backups are deleted. It's an io.Writer, so it can be given to the access
+// log middleware or a log.Logger:
+//
+//	f, err := logfile.Open(logfile.Options{
+//		Path:       "/var/log/app/access.log",
+//		MaxSize:    100 << 20,
+//		Every:      24 * time.Hour,
+//		MaxBackups: 14,
+//		Compress:   true,
+//	})
+//	r.Use(middleware.LoggerWith(middleware.LoggerOptions{Output: f}))
+//	defer f.Close()
+//
+// The backups sit next to the file: access-2024-05-01T00-00-00.000.log.gz.
+
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures a File.
+type Options struct {
+	// Path is the file written to. Its directory is created if needed.
+	Path string
+
+	// MaxSize is the size in bytes at which the file is rotated. Zero means
+	// no limit. A single write larger than MaxSize still goes to one file.
+	MaxSize int64
+
+	// Every rotates the file when a new period of that length begins.
+	// Periods are counted from the zero time in UTC, so 24h rotates at
+	// midnight UTC and 1h on the hour. Zero means no time-based rotation.
+	Every time.Duration
+
+	// MaxBackups is how many rotated files to keep. Zero keeps all.
+	MaxBackups int
+
+	// Compress gzips rotated files, in the background.
+	Compress bool
+}
+
+// backupLayout is the timestamp in backup names, sortable and free of
+// characters file systems dislike.
+const backupLayout = "2006-01-02T15-04-05.000"
+
+// File is a rotating log file. It's safe for concurrent use.
+type File struct {
+	opts Options
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time // start of the period the file belongs to
+
+	// backups serializes compressing and pruning the rotated files, which
+	// happen in the background, and wg waits for them in Close.
+	backups sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// Open opens the file at opts.Path for appending, creating it if needed.
+func Open(opts Options) (*File, error) {
+	return open(opts, time.Now)
+}
+
+// open is Open with a clock, for tests.
+func open(opts Options, now func() time.Time) (*File, error) {
+	switch {
+	case opts.Path == "":
+		return nil, errors.New("logfile: no path")
+	case opts.MaxSize < 0 || opts.Every < 0 || opts.MaxBackups < 0:
+		return nil, fmt.Errorf("logfile: negative limit in %+v", opts)
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("logfile: %w", err)
+	}
+	f := &File{opts: opts, now: now}
+	if err := f.openFile(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// openFile opens the current file. An existing one belongs to the period it
+// was last written in.
+func (f *File) openFile() error {
+	file, err := os.OpenFile(f.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logfile: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("logfile: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	f.period = f.periodOf(f.now())
+	if f.size > 0 {
+		f.period = f.periodOf(info.ModTime())
+	}
+	return nil
+}
+
+// periodOf returns the start of the period t falls in.
+func (f *File) periodOf(t time.Time) time.Time {
+	if f.opts.Every <= 0 {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(f.opts.Every)
+}
+
+// Write writes p to the file, rotating it first if p doesn't fit or a new
+// period has begun.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.full(len(p)) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			// Keep logging to the current file rather than lose lines.
+			log.Printf("[logfile] %v", err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// full reports whether the file must be rotated before writing n bytes.
+func (f *File) full(n int) bool {
+	if f.opts.MaxSize > 0 && f.size+int64(n) > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.Every > 0 && !f.periodOf(f.now()).Equal(f.period)
+}
+
+// Rotate starts a new file right away, e.g. on SIGHUP.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate renames the current file to a backup and opens a new one.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("logfile: %w", err)
+	}
+	f.file = nil
+	ext := filepath.Ext(f.opts.Path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.opts.Path, ext), f.now().UTC().Format(backupLayout), ext)
+	if err := os.Rename(f.opts.Path, backup); err != nil {
+		if openErr := f.openFile(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("logfile: %w", err)
+	}
+	if err := f.openFile(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.backups.Lock()
+		defer f.backups.Unlock()
+		if f.opts.Compress {
+			if err := compress(backup); err != nil {
+				log.Printf("[logfile] compressing %s: %v", backup, err)
+			}
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+// compress gzips the file at path next to it, then removes it.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+// prune deletes the oldest backups beyond MaxBackups.
+func (f *File) prune() {
+	if f.opts.MaxBackups == 0 {
+		return
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		log.Printf("[logfile] listing backups: %v", err)
+		return
+	}
+	for len(backups) > f.opts.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			log.Printf("[logfile] removing %s: %v", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// Backups returns the paths of the rotated files, oldest first.
+func (f *File) Backups() ([]string, error) {
+	dir := filepath.Dir(f.opts.Path)
+	ext := filepath.Ext(f.opts.Path)
+	prefix := strings.TrimSuffix(filepath.Base(f.opts.Path), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".gz")
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		if _, err := time.Parse(backupLayout, strings.TrimSuffix(stamp, ext)); err == nil {
+			backups = append(backups, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The timestamps sort like the times; ".gz" doesn't change the order.
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// Close closes the file and waits for the background compression.
+func (f *File) Close() error {
+	f.mu.Lock()
+	file := f.file
+	f.file = nil
+	f.mu.Unlock()
+	if file == nil {
+		return os.ErrClosed
+	}
+	err := file.Close()
+	f.wg.Wait()
+	return err
+}
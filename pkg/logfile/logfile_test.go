@@ -0,0 +1,186 @@
+// Description: This file contains tests for the rotating log file.
+
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source.
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// readAll returns the contents of a file, gunzipping backups.
+func readAll(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestFile_Rotation tests rotating by size and by period, compressing, and
+// keeping only the newest backups.
+func TestFile_Rotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		lines       []string
+		step        time.Duration // clock advance per line
+		wantCurrent string
+		wantBackups []string
+	}{
+		{
+			name:        "size",
+			opts:        Options{MaxSize: 10},
+			lines:       []string{"aaaa\n", "bbbb\n", "cccc\n"},
+			step:        time.Millisecond,
+			wantCurrent: "cccc\n",
+			wantBackups: []string{"aaaa\nbbbb\n"},
+		},
+		{
+			name:        "period",
+			opts:        Options{Every: time.Hour},
+			lines:       []string{"a\n", "b\n", "c\n", "d\n"},
+			step:        40 * time.Minute, // 00:00, 00:40, 01:20, 02:00
+			wantCurrent: "d\n",
+			wantBackups: []string{"a\nb\n", "c\n"},
+		},
+		{
+			name:        "compressed and pruned",
+			opts:        Options{MaxSize: 2, MaxBackups: 2, Compress: true},
+			lines:       []string{"1\n", "2\n", "3\n", "4\n"},
+			step:        time.Second,
+			wantCurrent: "4\n",
+			wantBackups: []string{"2\n", "3\n"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			c := &clock{t: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+			tc.opts.Path = filepath.Join(t.TempDir(), "logs", "access.log")
+			f, err := open(tc.opts, c.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 2. Execute
+			for _, line := range tc.lines {
+				if _, err := io.WriteString(f, line); err != nil {
+					t.Fatal(err)
+				}
+				c.advance(tc.step)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 3. Assert
+			if got := readAll(t, tc.opts.Path); got != tc.wantCurrent {
+				t.Errorf("current file: expected %q, got %q", tc.wantCurrent, got)
+			}
+			backups, err := f.Backups()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != len(tc.wantBackups) {
+				t.Fatalf("expected %d backups, got %q", len(tc.wantBackups), backups)
+			}
+			for i, path := range backups {
+				if tc.opts.Compress != strings.HasSuffix(path, ".gz") {
+					t.Errorf("backup %s: compression should be %v", path, tc.opts.Compress)
+				}
+				if got := readAll(t, path); got != tc.wantBackups[i] {
+					t.Errorf("backup %s: expected %q, got %q", path, tc.wantBackups[i], got)
+				}
+			}
+		})
+	}
+}
+
+// TestFile_Reopen tests that an existing file is appended to, and that its
+// period is the one it was last written in.
+func TestFile_Reopen(t *testing.T) {
+	// 1. Setup: The file was last written a day ago.
+	path := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	os.Chtimes(path, yesterday, yesterday)
+
+	// 2. Execute
+	f, err := Open(Options{Path: path, Every: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(f, "new\n")
+	f.Close()
+
+	// 3. Assert
+	backups, _ := f.Backups()
+	if len(backups) != 1 || readAll(t, backups[0]) != "old\n" || readAll(t, path) != "new\n" {
+		t.Errorf("expected yesterday's lines rotated out, got backups %q and %q", backups, readAll(t, path))
+	}
+}
+
+// TestFile_Closed tests writing after Close.
+func TestFile_Closed(t *testing.T) {
+	f, err := Open(Options{Path: filepath.Join(t.TempDir(), "access.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := f.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+	if err := f.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+}
+
+// TestOpen_Invalid tests rejecting bad options.
+func TestOpen_Invalid(t *testing.T) {
+	for _, opts := range []Options{{}, {Path: filepath.Join(t.TempDir(), "a.log"), MaxSize: -1}} {
+		if _, err := Open(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Description: This file contains the Interface the packages here log
+// through, so an application already using another logging library can send
+// our lines there too. It only takes the four leveled methods; adapting zap,
+// logrus, or anything else is a small type forwarding them:
+//
+//	type zapLogger struct{ l *zap.SugaredLogger }
+//
+//	func (z zapLogger) Debug(msg string, kv ...any) { z.l.Debugw(msg, kv...) }
+//	func (z zapLogger) Info(msg string, kv ...any)  { z.l.Infow(msg, kv...) }
+//	func (z zapLogger) Warn(msg string, kv ...any)  { z.l.Warnw(msg, kv...) }
+//	func (z zapLogger) Error(msg string, kv ...any) { z.l.Errorw(msg, kv...) }
+//
+//	logger.SetDefault(zapLogger{sugar})
+//
+// log/slog is supported out of the box with FromSlog.
+
+package logger
+
+// Interface is a leveled, structured logger: each method logs msg with
+// key/value pairs. *Logger implements it.
+type Interface interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+}
+
+// With returns a child of l adding the key/value pairs to every line, like
+// Logger.With, for any Interface.
+func With(l Interface, kv ...any) Interface {
+	if len(kv) == 0 {
+		return l
+	}
+	switch l := l.(type) {
+	case *Logger:
+		return l.With(kv...)
+	case slogLogger:
+		return slogLogger{l.l.With(kv...)}
+	case withFields:
+		return withFields{l.next, append(l.fields[:len(l.fields):len(l.fields)], kv...)}
+	}
+	return withFields{l, kv}
+}
+
+// Verbose returns a child of l writing every level, like Logger.Verbose.
+// Loggers of other libraries are returned as they are: their own
+// configuration decides which levels they write.
+func Verbose(l Interface) Interface {
+	switch l := l.(type) {
+	case *Logger:
+		return l.Verbose()
+	case withFields:
+		return withFields{Verbose(l.next), l.fields}
+	}
+	return l
+}
+
+// Enabled reports whether l logs at level. Loggers that can't tell are
+// assumed to log everything.
+func Enabled(l Interface, level Level) bool {
+	if e, ok := l.(interface{ Enabled(Level) bool }); ok {
+		return e.Enabled(level)
+	}
+	return true
+}
+
+// withFields adds fields to the lines of a logger that has no With of its
+// own.
+type withFields struct {
+	next   Interface
+	fields []any
+}
+
+func (w withFields) Debug(msg string, kv ...any) { w.next.Debug(msg, w.pairs(kv)...) }
+func (w withFields) Info(msg string, kv ...any)  { w.next.Info(msg, w.pairs(kv)...) }
+func (w withFields) Warn(msg string, kv ...any)  { w.next.Warn(msg, w.pairs(kv)...) }
+func (w withFields) Error(msg string, kv ...any) { w.next.Error(msg, w.pairs(kv)...) }
+
+// pairs returns the fields followed by kv.
+func (w withFields) pairs(kv []any) []any {
+	return append(w.fields[:len(w.fields):len(w.fields)], kv...)
+}
+
+// Enabled implements the optional method Enabled looks for.
+func (w withFields) Enabled(level Level) bool {
+	return Enabled(w.next, level)
+}
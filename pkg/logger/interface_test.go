@@ -0,0 +1,63 @@
+// Description: This file contains tests for plugging in other loggers.
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recorder is an Interface recording its lines, like an adapter for another
+// library would forward them.
+type recorder struct{ lines *[]string }
+
+func (r recorder) log(level, msg string, kv []any) {
+	*r.lines = append(*r.lines, strings.TrimSpace(fmt.Sprintln(append([]any{level, msg}, kv...)...)))
+}
+func (r recorder) Debug(msg string, kv ...any) { r.log("debug", msg, kv) }
+func (r recorder) Info(msg string, kv ...any)  { r.log("info", msg, kv) }
+func (r recorder) Warn(msg string, kv ...any)  { r.log("warn", msg, kv) }
+func (r recorder) Error(msg string, kv ...any) { r.log("error", msg, kv) }
+
+// TestWith tests child loggers of an Interface without a With method.
+func TestWith(t *testing.T) {
+	// 1. Setup
+	var lines []string
+	var l Interface = recorder{&lines}
+	prev := Default()
+	SetDefault(l)
+	defer SetDefault(prev)
+
+	// 2. Execute
+	child := With(With(l, "request_id", "abc"), "user", 7)
+	child.Warn("Slow", "took", "2s")
+	With(child).Info("Same")
+	Error("Through the default", "err", "boom")
+
+	// 3. Assert
+	want := []string{
+		"warn Slow request_id abc user 7 took 2s",
+		"info Same request_id abc user 7",
+		"error Through the default err boom",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, lines)
+	}
+	if !Enabled(child, LevelDebug) {
+		t.Error("expected a logger without Enabled to log everything")
+	}
+}
+
+// TestWith_Logger tests that With keeps a *Logger's own children.
+func TestWith_Logger(t *testing.T) {
+	l, buf := newTest(Options{Level: LevelWarn})
+	child := With(l, "k", "v")
+	if _, ok := child.(*Logger); !ok {
+		t.Fatalf("expected a *Logger, got %T", child)
+	}
+	child.Warn("hi")
+	if !strings.HasSuffix(buf.String(), "WARN hi k=v\n") || Enabled(child, LevelInfo) {
+		t.Errorf("unexpected output %q", buf)
+	}
+}
@@ -0,0 +1,325 @@
+// Description: This package contains our structured logger. Every line has a
+// level and a message, followed by key/value pairs that log pipelines can
+// filter on without parsing the message:
+//
+//	logger.Info("Registered route", "method", "GET", "path", "/users")
+//	// 2024-05-01T12:00:00.000+02:00 INFO Registered route method=GET path=/users
+//
+// The packages here log through Default, which the application replaces at
+// startup, e.g. with JSON lines at the configured level:
+//
+//	logger.SetDefault(logger.New(logger.Options{Level: logger.LevelDebug, Format: logger.FormatJSON}))
+//
+// With returns a child logger adding its pairs to every line; the request
+// context's c.Logger is one, carrying the request ID. Other logging libraries
+// can be plugged in through Interface.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// Level is a line's severity. The values match log/slog's.
+type Level int
+
+// The levels, least severe first.
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String returns the level's name, e.g. "INFO".
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ParseLevel returns the level named s: debug, info, warn, or error, in any
+// case.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("logger: unknown level %q", s)
+}
+
+// Format selects how lines are written.
+type Format int
+
+const (
+	// FormatText writes the time, level, and message, then key=value
+	// pairs, quoting values with spaces.
+	FormatText Format = iota
+
+	// FormatJSON writes one JSON object per line, with "time", "level",
+	// and "msg" keys before the pairs.
+	FormatJSON
+)
+
+// Options configures New.
+type Options struct {
+	// Level is the least severe level written. The default is LevelInfo.
+	Level Level
+
+	// Format is the line format; the default is FormatText.
+	Format Format
+
+	// Output receives the lines, one Write each. By default they go where
+	// the standard logger's do, so log.SetOutput redirects both.
+	Output io.Writer
+}
+
+// Logger writes leveled, structured lines. It's safe for concurrent use, and
+// the zero value isn't usable: create loggers with New or With.
+type Logger struct {
+	out     *output
+	fields  []any // key/value pairs added to every line
+	verbose bool  // write every level, see Verbose
+}
+
+// output is the destination shared by a logger and its children.
+type output struct {
+	format Format
+	level  atomic.Int64
+	w      io.Writer // nil means log.Writer()
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// New creates a logger.
+func New(opts Options) *Logger {
+	out := &output{format: opts.Format, w: opts.Output, now: time.Now}
+	out.level.Store(int64(opts.Level))
+	return &Logger{out: out}
+}
+
+// With returns a child logger adding the key/value pairs to every line. It
+// shares the parent's output and level.
+func (l *Logger) With(kv ...any) *Logger {
+	if len(kv) == 0 {
+		return l
+	}
+	fields := make([]any, 0, len(l.fields)+len(kv))
+	return &Logger{out: l.out, fields: append(append(fields, l.fields...), kv...), verbose: l.verbose}
+}
+
+// Verbose returns a child logger writing every level, whatever the level is
+// set to, e.g. for the few requests whose debug lines are wanted.
+func (l *Logger) Verbose() *Logger {
+	return &Logger{out: l.out, fields: l.fields, verbose: true}
+}
+
+// SetLevel changes the least severe level written, for the logger, its
+// parent, and its children alike.
+func (l *Logger) SetLevel(level Level) {
+	l.out.level.Store(int64(level))
+}
+
+// Enabled reports whether lines at level are written, so expensive values
+// are only computed when needed.
+func (l *Logger) Enabled(level Level) bool {
+	return l.verbose || int64(level) >= l.out.level.Load()
+}
+
+// Debug logs at LevelDebug.
+func (l *Logger) Debug(msg string, kv ...any) { l.Log(LevelDebug, msg, kv...) }
+
+// Info logs at LevelInfo.
+func (l *Logger) Info(msg string, kv ...any) { l.Log(LevelInfo, msg, kv...) }
+
+// Warn logs at LevelWarn.
+func (l *Logger) Warn(msg string, kv ...any) { l.Log(LevelWarn, msg, kv...) }
+
+// Error logs at LevelError.
+func (l *Logger) Error(msg string, kv ...any) { l.Log(LevelError, msg, kv...) }
+
+// Log writes a line at level with the message and key/value pairs. A key
+// without a value is logged under "!BADKEY".
+func (l *Logger) Log(level Level, msg string, kv ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	pairs := pairsOf(append(l.fields[:len(l.fields):len(l.fields)], kv...))
+	var buf bytes.Buffer
+	if l.out.format == FormatJSON {
+		writeJSON(&buf, l.out.now(), level, msg, pairs)
+	} else {
+		writeText(&buf, l.out.now(), level, msg, pairs)
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	w := l.out.w
+	if w == nil {
+		w = log.Writer()
+	}
+	w.Write(buf.Bytes())
+}
+
+// pair is one key and its value.
+type pair struct {
+	key   string
+	value any
+}
+
+// pairsOf groups kv into pairs.
+func pairsOf(kv []any) []pair {
+	pairs := make([]pair, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok || i+1 == len(kv) {
+			pairs = append(pairs, pair{"!BADKEY", kv[i]})
+			i--
+			continue
+		}
+		pairs = append(pairs, pair{key, kv[i+1]})
+	}
+	return pairs
+}
+
+// timeLayout is the timestamp of text lines: RFC 3339 with milliseconds.
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// writeText formats a text line.
+func writeText(buf *bytes.Buffer, t time.Time, level Level, msg string, pairs []pair) {
+	buf.WriteString(t.Format(timeLayout))
+	buf.WriteByte(' ')
+	buf.WriteString(level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	for _, p := range pairs {
+		buf.WriteByte(' ')
+		buf.WriteString(p.key)
+		buf.WriteByte('=')
+		buf.WriteString(quote(textValue(p.value)))
+	}
+	buf.WriteByte('\n')
+}
+
+// textValue formats v for a text line.
+func textValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// quote quotes s if it's empty or has spaces, quotes, '=', or unprintable
+// characters, so each pair stays one token.
+func quote(s string) string {
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// writeJSON formats a JSON line. Keys are written in order, so a repeated
+// key appears twice, like in log/slog.
+func writeJSON(buf *bytes.Buffer, t time.Time, level Level, msg string, pairs []pair) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, t.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	for _, p := range pairs {
+		buf.WriteByte(',')
+		writeJSONValue(buf, p.key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, jsonValue(p.value))
+	}
+	buf.WriteString("}\n")
+}
+
+// jsonValue returns v as it should be encoded: errors and Stringers (like
+// time.Duration) by their text, since they'd encode as {} or a number.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// writeJSONValue encodes v, falling back to its text for values JSON can't
+// encode, like channels.
+func writeJSONValue(buf *bytes.Buffer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// defaultLogger holds the logger Default returns, boxed because
+// atomic.Value needs one concrete type.
+var defaultLogger atomic.Value // of box
+
+type box struct{ Interface }
+
+func init() {
+	defaultLogger.Store(box{New(Options{})})
+}
+
+// Default returns the logger the packages here use: until SetDefault is
+// called, a text logger at LevelInfo writing where the standard logger does.
+func Default() Interface {
+	return defaultLogger.Load().(box).Interface
+}
+
+// SetDefault replaces the default logger, e.g. with FromSlog(slog.Default()).
+func SetDefault(l Interface) {
+	defaultLogger.Store(box{l})
+}
+
+// Debug logs at LevelDebug with the default logger.
+func Debug(msg string, kv ...any) { Default().Debug(msg, kv...) }
+
+// Info logs at LevelInfo with the default logger.
+func Info(msg string, kv ...any) { Default().Info(msg, kv...) }
+
+// Warn logs at LevelWarn with the default logger.
+func Warn(msg string, kv ...any) { Default().Warn(msg, kv...) }
+
+// Error logs at LevelError with the default logger.
+func Error(msg string, kv ...any) { Default().Error(msg, kv...) }
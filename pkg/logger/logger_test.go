@@ -0,0 +1,159 @@
+// Description: This file contains tests for the structured logger.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedTime is the clock of the test loggers.
+var fixedTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTest returns a logger writing to a buffer at a fixed time.
+func newTest(opts Options) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	opts.Output = &buf
+	l := New(opts)
+	l.out.now = func() time.Time { return fixedTime }
+	return l, &buf
+}
+
+// TestLogger_Text tests the text format.
+func TestLogger_Text(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *Logger)
+		want string
+	}{
+		{"message only", func(l *Logger) { l.Info("Server started") },
+			"2024-05-01T12:00:00.000Z INFO Server started\n"},
+		{"pairs", func(l *Logger) { l.Warn("Slow", "route", "/users/:id", "took", 1500*time.Millisecond, "status", 200) },
+			"2024-05-01T12:00:00.000Z WARN Slow route=/users/:id took=1.5s status=200\n"},
+		{"quoted", func(l *Logger) { l.Error("Failed", "err", errors.New("connection refused"), "empty", "", "eq", "a=b") },
+			"2024-05-01T12:00:00.000Z ERROR Failed err=\"connection refused\" empty=\"\" eq=\"a=b\"\n"},
+		{"bad key", func(l *Logger) { l.Info("Odd", "alone") },
+			"2024-05-01T12:00:00.000Z INFO Odd !BADKEY=alone\n"},
+		{"child", func(l *Logger) { l.With("request_id", "abc").With("user", 7).Info("Hi", "x", 1) },
+			"2024-05-01T12:00:00.000Z INFO Hi request_id=abc user=7 x=1\n"},
+		{"filtered", func(l *Logger) { l.Debug("Hidden") }, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			l, buf := newTest(Options{})
+
+			// 2. Execute
+			tc.log(l)
+
+			// 3. Assert
+			if buf.String() != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, buf.String())
+			}
+		})
+	}
+}
+
+// TestLogger_JSON tests the JSON format.
+func TestLogger_JSON(t *testing.T) {
+	// 1. Setup
+	l, buf := newTest(Options{Format: FormatJSON, Level: LevelDebug})
+
+	// 2. Execute
+	l.With("request_id", "abc").Debug("Lookup", "took", time.Second, "err", errors.New("boom"), "n", 3, "ch", make(chan int))
+
+	// 3. Assert
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf, err)
+	}
+	want := map[string]any{"time": "2024-05-01T12:00:00Z", "level": "DEBUG", "msg": "Lookup",
+		"request_id": "abc", "took": "1s", "err": "boom", "n": 3.0}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, line[k])
+		}
+	}
+	if _, ok := line["ch"].(string); !ok {
+		t.Errorf("expected an unencodable value as text, got %v", line["ch"])
+	}
+}
+
+// TestLogger_SetLevel tests that children follow level changes.
+func TestLogger_SetLevel(t *testing.T) {
+	l, buf := newTest(Options{Level: LevelWarn})
+	child := l.With("k", "v")
+	child.Info("hidden")
+	l.SetLevel(LevelDebug)
+	child.Debug("shown")
+	if !strings.Contains(buf.String(), "shown") || strings.Contains(buf.String(), "hidden") || !child.Enabled(LevelDebug) {
+		t.Errorf("unexpected output %q", buf)
+	}
+}
+
+// TestLogger_Verbose tests that a verbose child writes every level, and
+// only it does.
+func TestLogger_Verbose(t *testing.T) {
+	l, buf := newTest(Options{Level: LevelWarn})
+	verbose := l.Verbose().With("k", "v")
+	verbose.Debug("shown")
+	l.Debug("hidden")
+	if !strings.Contains(buf.String(), "DEBUG shown k=v") || strings.Contains(buf.String(), "hidden") || l.Enabled(LevelDebug) {
+		t.Errorf("unexpected output %q", buf)
+	}
+}
+
+// TestParseLevel tests the level names.
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{"error", LevelError, false},
+		{"loud", 0, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseLevel(tc.in)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("ParseLevel(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+	if LevelWarn.String() != "WARN" || Level(2).String() != "LEVEL(2)" {
+		t.Error("unexpected level names")
+	}
+}
+
+// TestDefault tests that the default logger writes where the standard logger
+// does, and can be replaced.
+func TestDefault(t *testing.T) {
+	// 1. Setup
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+	prev := Default()
+	defer SetDefault(prev)
+
+	// 2. Execute
+	Info("to the standard logger's output")
+	custom, buf := newTest(Options{})
+	SetDefault(custom)
+	Error("to the custom one")
+
+	// 3. Assert
+	if !strings.Contains(std.String(), "INFO to the standard logger's output") || strings.Contains(std.String(), "custom") {
+		t.Errorf("unexpected standard output %q", std.String())
+	}
+	if !strings.Contains(buf.String(), "ERROR to the custom one") {
+		t.Errorf("unexpected custom output %q", buf)
+	}
+}
@@ -0,0 +1,40 @@
+// Description: This file contains the log/slog adapter, for applications
+// that already configure a slog handler:
+//
+//	logger.SetDefault(logger.FromSlog(slog.Default()))
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// FromSlog returns an Interface logging through l. Our levels map to slog's
+// of the same name.
+func FromSlog(l *slog.Logger) Interface {
+	return slogLogger{l}
+}
+
+// slogLogger adapts a *slog.Logger.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, kv ...any) {
+	s.l.Log(context.Background(), slog.LevelDebug, msg, kv...)
+}
+func (s slogLogger) Info(msg string, kv ...any) {
+	s.l.Log(context.Background(), slog.LevelInfo, msg, kv...)
+}
+func (s slogLogger) Warn(msg string, kv ...any) {
+	s.l.Log(context.Background(), slog.LevelWarn, msg, kv...)
+}
+func (s slogLogger) Error(msg string, kv ...any) {
+	s.l.Log(context.Background(), slog.LevelError, msg, kv...)
+}
+
+// Enabled implements the optional method Enabled looks for.
+func (s slogLogger) Enabled(level Level) bool {
+	return s.l.Enabled(context.Background(), slog.Level(level))
+}
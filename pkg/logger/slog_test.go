@@ -0,0 +1,39 @@
+// Description: This file contains tests for the log/slog adapter.
+
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// TestFromSlog tests logging through a slog handler.
+func TestFromSlog(t *testing.T) {
+	// 1. Setup
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := FromSlog(slog.New(h))
+
+	// 2. Execute
+	l.Debug("hidden")
+	With(l, "request_id", "abc").Warn("Slow request", "status", 200)
+	l.Error("Failed")
+
+	// 3. Assert
+	want := "level=WARN msg=\"Slow request\" request_id=abc status=200\nlevel=ERROR msg=Failed\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+	if Enabled(l, LevelDebug) || !Enabled(l, LevelInfo) {
+		t.Error("expected Enabled to follow the handler's level")
+	}
+}
@@ -0,0 +1,170 @@
+// Description: This file contains authentication middleware: HTTP Basic auth
+// (RFC 7617) and API keys sent in a header or query parameter. Both compare
+// secrets in constant time, so response timing doesn't reveal how much of a
+// guess was right, and both record who was authenticated for AuthUser.
+//
+// To protect a group of routes, put them on a subrouter and mount it:
+//
+//	admin := router.New()
+//	admin.Use(middleware.BasicAuth(map[string]string{"ops": secret}))
+//	admin.GET("/stats", statsHandler)
+//	r.Mount("/admin", admin)
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// authUserKey is the request context key for the authenticated user.
+type authUserKey struct{}
+
+// AuthUser returns the user authenticated by BasicAuth or APIKey, or "" if
+// the request didn't go through either.
+func AuthUser(c *httpcontext.Context) string {
+	user, _ := c.Value(authUserKey{}).(string)
+	return user
+}
+
+// setAuthUser records the authenticated user in the request's context.
+func setAuthUser(c *httpcontext.Context, user string) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), authUserKey{}, user))
+}
+
+// secureEqual compares two secrets in constant time. Hashing them first
+// makes the comparison independent of their lengths, which
+// subtle.ConstantTimeCompare alone would leak.
+func secureEqual(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// BasicAuthOptions configures BasicAuthWith.
+type BasicAuthOptions struct {
+	// Realm is shown by browsers in the login prompt. The default is
+	// "Restricted".
+	Realm string
+
+	// Validate checks a user name and password, e.g. against a database.
+	// It should use constant-time comparisons (see BasicAuthAccounts).
+	Validate func(c *httpcontext.Context, user, password string) bool
+}
+
+// BasicAuth returns middleware requiring one of the given user name and
+// password pairs.
+func BasicAuth(accounts map[string]string) Middleware {
+	return BasicAuthWith(BasicAuthOptions{Validate: BasicAuthAccounts(accounts)})
+}
+
+// BasicAuthAccounts returns a Validate function for a fixed set of accounts.
+// Unknown users are compared against a dummy password, so they take as long
+// to reject as wrong passwords.
+func BasicAuthAccounts(accounts map[string]string) func(c *httpcontext.Context, user, password string) bool {
+	// Copy, so the caller changing the map later can't race with requests.
+	copied := make(map[string]string, len(accounts))
+	for user, password := range accounts {
+		copied[user] = password
+	}
+	return func(_ *httpcontext.Context, user, password string) bool {
+		want, ok := copied[user]
+		match := secureEqual(password, want)
+		return ok && match
+	}
+}
+
+// BasicAuthWith returns middleware requiring HTTP Basic credentials accepted
+// by opts.Validate. Other requests get 401 Unauthorized with a WWW-Authenticate
+// challenge, which makes browsers ask for a login.
+func BasicAuthWith(opts BasicAuthOptions) Middleware {
+	if opts.Realm == "" {
+		opts.Realm = "Restricted"
+	}
+	challenge := "Basic realm=" + strconv.Quote(opts.Realm) + `, charset="UTF-8"`
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			user, password, ok := c.Request.BasicAuth()
+			if !ok || opts.Validate == nil || !opts.Validate(c, user, password) {
+				c.SetHeader("WWW-Authenticate", challenge)
+				abortWithError(c, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			setAuthUser(c, user)
+			next(c)
+		}
+	}
+}
+
+// APIKeyOptions configures APIKey.
+type APIKeyOptions struct {
+	// Header is the request header carrying the key. The default is
+	// "X-API-Key".
+	Header string
+
+	// Query is a query parameter that may carry the key instead, e.g.
+	// "api_key". Empty disables it. Keys in URLs end up in access logs and
+	// browser histories, so prefer the header where clients allow it.
+	Query string
+
+	// Lookup checks a key and returns who it belongs to, which AuthUser
+	// reports. It should use constant-time comparisons (see APIKeys).
+	Lookup func(c *httpcontext.Context, key string) (owner string, ok bool)
+}
+
+// APIKeys returns a Lookup function for a fixed set of keys, mapping each key
+// to its owner. Every key is compared, so the time taken doesn't depend on
+// which one matched.
+func APIKeys(keys map[string]string) func(c *httpcontext.Context, key string) (string, bool) {
+	type entry struct{ key, owner string }
+	entries := make([]entry, 0, len(keys))
+	for key, owner := range keys {
+		entries = append(entries, entry{key, owner})
+	}
+	return func(_ *httpcontext.Context, key string) (string, bool) {
+		owner, found := "", false
+		for _, e := range entries {
+			if secureEqual(key, e.key) {
+				owner, found = e.owner, true
+			}
+		}
+		return owner, found
+	}
+}
+
+// APIKey returns middleware requiring an API key accepted by opts.Lookup.
+// Requests without a key or with an unknown one get 401 Unauthorized.
+func APIKey(opts APIKeyOptions) Middleware {
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			key := c.GetHeader(opts.Header)
+			if key == "" && opts.Query != "" {
+				key = c.Query(opts.Query)
+			}
+			if key == "" {
+				abortWithError(c, http.StatusUnauthorized, "missing API key")
+				return
+			}
+			owner, ok := "", false
+			if opts.Lookup != nil {
+				owner, ok = opts.Lookup(c, key)
+			}
+			if !ok {
+				abortWithError(c, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			setAuthUser(c, owner)
+			next(c)
+		}
+	}
+}
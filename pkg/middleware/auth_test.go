@@ -0,0 +1,114 @@
+// Description: This file contains tests for the authentication middleware.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// whoami is a handler answering with the authenticated user.
+func whoami(c *httpcontext.Context) {
+	c.String(http.StatusOK, "%s", AuthUser(c))
+}
+
+// TestBasicAuth tests credentials checks and the login challenge.
+func TestBasicAuth(t *testing.T) {
+	// 1. Setup: Only the mounted /admin routes are protected.
+	admin := router.New()
+	admin.Use(BasicAuthWith(BasicAuthOptions{
+		Realm:    "Admin",
+		Validate: BasicAuthAccounts(map[string]string{"ops": "s3cret"}),
+	}))
+	admin.GET("/me", whoami)
+	r := router.New()
+	r.Mount("/admin", admin)
+	r.GET("/public", whoami)
+
+	tests := []struct {
+		name           string
+		path           string
+		user, password string
+		wantStatus     int
+		wantBody       string
+	}{
+		{"valid", "/admin/me", "ops", "s3cret", http.StatusOK, "ops"},
+		{"wrong password", "/admin/me", "ops", "guess", http.StatusUnauthorized, ""},
+		{"unknown user", "/admin/me", "root", "s3cret", http.StatusUnauthorized, ""},
+		{"no credentials", "/admin/me", "", "", http.StatusUnauthorized, ""},
+		{"unprotected route", "/public", "", "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus == http.StatusOK && rr.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+			challenge := rr.Header().Get("WWW-Authenticate")
+			if tt.wantStatus == http.StatusUnauthorized && challenge != `Basic realm="Admin", charset="UTF-8"` {
+				t.Errorf("unexpected challenge %q", challenge)
+			}
+		})
+	}
+}
+
+// TestAPIKey tests keys in headers and query parameters.
+func TestAPIKey(t *testing.T) {
+	// 1. Setup
+	h := Compose(whoami, APIKey(APIKeyOptions{
+		Query:  "api_key",
+		Lookup: APIKeys(map[string]string{"k-123": "billing", "k-456": "reports"}),
+	}))
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"header", "/", "k-123", http.StatusOK, "billing"},
+		{"query", "/?api_key=k-456", "", http.StatusOK, "reports"},
+		{"header wins", "/?api_key=k-456", "k-123", http.StatusOK, "billing"},
+		{"unknown key", "/", "k-789", http.StatusUnauthorized, ""},
+		{"missing key", "/", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus == http.StatusOK && rr.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Description: This file contains the body dump middleware, a debugging aid
+// that logs each request and response in full: headers and (the start of) the
+// bodies. It's meant for development, or for briefly chasing down what a client
+// integration actually sends; it's slow and the logs contain user data, so
+// don't leave it on in production. Credentials are redacted from the headers.
+
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// DefaultRedactHeaders are the headers BodyDump masks unless told otherwise.
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+
+// BodyDumpOptions configures BodyDumpWith.
+type BodyDumpOptions struct {
+	// Output receives the dumps. The default is os.Stderr.
+	Output io.Writer
+
+	// MaxBytes is how much of each body is logged; the rest is only counted.
+	// The default is 4 KiB.
+	MaxBytes int
+
+	// RedactHeaders lists the headers whose values are replaced with
+	// "[REDACTED]". The default is DefaultRedactHeaders.
+	RedactHeaders []string
+
+	// Skip, if set, is called first; requests it returns true for aren't dumped.
+	Skip func(c *httpcontext.Context) bool
+
+	// OnlySampled dumps only the requests Sample picked, which makes
+	// dumping bearable in production.
+	OnlySampled bool
+}
+
+// BodyDump returns body dump middleware with the default options.
+func BodyDump() Middleware {
+	return BodyDumpWith(BodyDumpOptions{})
+}
+
+// BodyDumpWith returns body dump middleware configured by opts.
+func BodyDumpWith(opts BodyDumpOptions) Middleware {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	logger := log.New(out, "", log.LstdFlags)
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 4 << 10
+	}
+	redact := opts.RedactHeaders
+	if redact == nil {
+		redact = DefaultRedactHeaders
+	}
+	redact = slices.Clone(redact)
+	for i, name := range redact {
+		redact[i] = http.CanonicalHeaderKey(name)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if (opts.Skip != nil && opts.Skip(c)) || (opts.OnlySampled && !c.Sampled()) {
+				next(c)
+				return
+			}
+
+			// Capture the bodies as they're read and written, rather than
+			// reading the request up front: the handler's own limits and
+			// streaming behavior stay as they are.
+			reqHeader := c.Request.Header.Clone()
+			reqBody := &capture{limit: opts.MaxBytes}
+			if c.Request.Body != nil && c.Request.Body != http.NoBody {
+				c.Request.Body = &captureReader{ReadCloser: c.Request.Body, capture: reqBody}
+			}
+			w := &dumpWriter{ResponseWriter: c.Writer, capture: capture{limit: opts.MaxBytes}}
+			c.Writer = w
+
+			start := time.Now()
+			next(c)
+			latency := time.Since(start)
+			c.Writer = w.ResponseWriter
+
+			var b strings.Builder
+			if id := c.RequestID(); id != "" {
+				fmt.Fprintf(&b, "[req %s] ", id)
+			}
+			fmt.Fprintf(&b, "--> %s %s %s\n", c.Request.Method, c.Request.URL.RequestURI(), c.Request.Proto)
+			writeHeaders(&b, reqHeader, redact)
+			reqBody.writeTo(&b)
+			status := c.ResponseStatus()
+			fmt.Fprintf(&b, "<-- %d %s (%v)\n", status, http.StatusText(status), latency.Round(time.Microsecond))
+			writeHeaders(&b, c.Writer.Header(), redact)
+			w.capture.writeTo(&b)
+			logger.Print(b.String())
+		}
+	}
+}
+
+// writeHeaders writes the headers sorted by name, masking the redacted ones.
+func writeHeaders(b *strings.Builder, h http.Header, redact []string) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range h[name] {
+			if slices.Contains(redact, name) {
+				value = "[REDACTED]"
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, value)
+		}
+	}
+}
+
+// capture keeps the first limit bytes of a body and counts the rest.
+type capture struct {
+	limit int
+	buf   []byte
+	total int64
+}
+
+// add records the next part of the body.
+func (c *capture) add(p []byte) {
+	c.total += int64(len(p))
+	if room := c.limit - len(c.buf); room > 0 {
+		c.buf = append(c.buf, p[:min(room, len(p))]...)
+	}
+}
+
+// writeTo writes the captured body, or a note if it's binary.
+func (c *capture) writeTo(b *strings.Builder) {
+	if c.total == 0 {
+		return
+	}
+	b.WriteString("\n")
+	// A cut can fall in the middle of a UTF-8 sequence; only look at what
+	// comes before it.
+	text := c.buf
+	if len(text) < int(c.total) {
+		for i := 0; i < utf8.UTFMax && len(text) > 0 && !utf8.Valid(text); i++ {
+			text = text[:len(text)-1]
+		}
+	}
+	if !utf8.Valid(text) {
+		fmt.Fprintf(b, "[%d bytes of binary data]\n", c.total)
+		return
+	}
+	b.Write(text)
+	if rest := c.total - int64(len(text)); rest > 0 {
+		fmt.Fprintf(b, "\n... (%d more bytes)", rest)
+	}
+	b.WriteString("\n")
+}
+
+// captureReader captures a request body as the handler reads it.
+type captureReader struct {
+	io.ReadCloser
+	capture *capture
+}
+
+// Read reads from the body and captures what was read.
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture.add(p[:n])
+	return n, err
+}
+
+// dumpWriter captures a response body as it's written.
+type dumpWriter struct {
+	http.ResponseWriter
+	capture capture
+}
+
+// Write writes to the response and captures what was written.
+func (w *dumpWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.capture.add(b[:n])
+	return n, err
+}
+
+// Flush sends buffered data to the client.
+func (w *dumpWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over.
+func (w *dumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *dumpWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
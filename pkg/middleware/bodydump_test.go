@@ -0,0 +1,84 @@
+// Description: This file contains tests for the body dump middleware.
+
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestBodyDump tests what the dump contains.
+func TestBodyDump(t *testing.T) {
+	// 1. Setup
+	var out bytes.Buffer
+	h := Compose(func(c *httpcontext.Context) {
+		body, _ := c.Body()
+		c.SetCookie(&http.Cookie{Name: "session", Value: "s3cret"})
+		c.String(http.StatusCreated, "created %s", body)
+	}, BodyDumpWith(BodyDumpOptions{Output: &out}))
+	req := httptest.NewRequest("POST", "/users?dry=1", strings.NewReader(`{"name":"ann"}`))
+	req.Header.Set("Authorization", "Bearer t0ken")
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	// 2. Execute
+	h.ServeHTTP(rr, req)
+
+	// 3. Assert
+	dump := out.String()
+	for _, want := range []string{
+		"--> POST /users?dry=1 HTTP/1.1",
+		"Authorization: [REDACTED]",
+		"Content-Type: application/json",
+		`{"name":"ann"}`,
+		"<-- 201 Created",
+		"Set-Cookie: [REDACTED]",
+		`created {"name":"ann"}`,
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump missing %q:\n%s", want, dump)
+		}
+	}
+	for _, secret := range []string{"t0ken", "s3cret"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("dump leaks %q:\n%s", secret, dump)
+		}
+	}
+	if rr.Code != http.StatusCreated || rr.Body.String() != `created {"name":"ann"}` {
+		t.Errorf("response changed: %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+// TestBodyDump_Limits tests truncated and binary bodies.
+func TestBodyDump_Limits(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{name: "truncated", body: []byte(strings.Repeat("a", 30)), want: "aaaaaaaaaa\n... (20 more bytes)"},
+		{name: "cut inside a rune", body: []byte("aaaaaaaaaé and more"), want: "aaaaaaaaa\n... (11 more bytes)"},
+		{name: "binary", body: []byte{0xff, 0xfe, 0, 1}, want: "[4 bytes of binary data]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			var out bytes.Buffer
+			h := Compose(func(c *httpcontext.Context) { c.Writer.Write(tt.body) },
+				BodyDumpWith(BodyDumpOptions{Output: &out, MaxBytes: 10}))
+
+			// 2. Execute
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			// 3. Assert
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("expected %q in:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
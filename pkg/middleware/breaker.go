@@ -0,0 +1,273 @@
+// Description: This file contains a circuit breaker. It watches the failure
+// rate of the requests it guards; when too many fail, it "opens" and fails
+// further requests immediately with 503, instead of piling more load onto a
+// struggling downstream service. After a pause it lets a few probe requests
+// through ("half-open"): if they succeed it closes again, otherwise it stays
+// open for another pause.
+//
+//	closed --(failure rate over threshold)--> open --(OpenTimeout)--> half-open
+//	   ^                                        ^                        |
+//	   +-------------(probes succeed)-----------+-----(a probe fails)----+
+//
+// A CircuitBreaker can guard a route with Middleware, or any call to an
+// upstream service with Allow. CircuitBreakerPerRoute keeps one per route.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// The breaker states.
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+// String returns the state's name.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerOptions configures a CircuitBreaker.
+type BreakerOptions struct {
+	// FailureRatio opens the breaker when this share of the requests in a
+	// window fail. The default is 0.5.
+	FailureRatio float64
+
+	// MinRequests is how many requests a window needs before its failure
+	// ratio counts, so two failures at night don't open the breaker. The
+	// default is 20.
+	MinRequests int
+
+	// Window is the period over which failures are counted. The default is
+	// 10 seconds.
+	Window time.Duration
+
+	// OpenTimeout is how long the breaker stays open before probing. The
+	// default is 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probes let through, all of which
+	// must succeed to close the breaker. The default is 1.
+	HalfOpenRequests int
+
+	// IsFailure decides whether a request guarded by Middleware failed. The
+	// default counts server errors (5xx) and panics.
+	IsFailure func(c *httpcontext.Context) bool
+
+	// OnStateChange, if set, is called on every transition, e.g. to log or
+	// alert. It must not block.
+	OnStateChange func(name string, from, to BreakerState)
+}
+
+// CircuitBreaker guards a downstream dependency. It's safe for concurrent use.
+type CircuitBreaker struct {
+	name string
+	opts BreakerOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	windowEnd time.Time
+	requests  int
+	failures  int
+	openedAt  time.Time
+	probes    int // probes in flight or succeeded while half-open
+	successes int
+}
+
+// NewCircuitBreaker creates a closed breaker. The name shows up in
+// OnStateChange and in logs.
+func NewCircuitBreaker(name string, opts BreakerOptions) *CircuitBreaker {
+	if opts.FailureRatio <= 0 || opts.FailureRatio > 1 {
+		opts.FailureRatio = 0.5
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 20
+	}
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(c *httpcontext.Context) bool { return c.ResponseStatus() >= 500 }
+	}
+	return &CircuitBreaker{name: name, opts: opts, now: time.Now}
+}
+
+// State returns the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.now())
+	return b.state
+}
+
+// Allow asks to make one call. If ok, the caller must report the outcome with
+// done; otherwise retryAfter says when the breaker will probe again.
+//
+//	done, _, ok := breaker.Allow()
+//	if !ok {
+//		return errUnavailable
+//	}
+//	resp, err := client.Do(req)
+//	done(err != nil || resp.StatusCode >= 500)
+func (b *CircuitBreaker) Allow() (done func(failed bool), retryAfter time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.advance(now)
+
+	switch b.state {
+	case BreakerOpen:
+		return nil, b.openedAt.Add(b.opts.OpenTimeout).Sub(now), false
+	case BreakerHalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			// The probes are out; wait for their verdict.
+			return nil, time.Second, false
+		}
+		b.probes++
+	}
+
+	var once sync.Once
+	return func(failed bool) {
+		once.Do(func() { b.record(failed) })
+	}, 0, true
+}
+
+// advance moves an open breaker to half-open once its timeout has passed, and
+// starts a new counting window when the current one is over. The caller holds
+// b.mu.
+func (b *CircuitBreaker) advance(now time.Time) {
+	if b.state == BreakerOpen && !now.Before(b.openedAt.Add(b.opts.OpenTimeout)) {
+		b.setState(BreakerHalfOpen)
+		b.probes, b.successes = 0, 0
+	}
+	if b.state == BreakerClosed && !now.Before(b.windowEnd) {
+		b.windowEnd = now.Add(b.opts.Window)
+		b.requests, b.failures = 0, 0
+	}
+}
+
+// record counts the outcome of a call.
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.advance(now)
+
+	switch b.state {
+	case BreakerClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.opts.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.opts.FailureRatio {
+			b.open(now)
+		}
+	case BreakerHalfOpen:
+		if failed {
+			b.open(now)
+			return
+		}
+		if b.successes++; b.successes >= b.opts.HalfOpenRequests {
+			b.setState(BreakerClosed)
+			b.windowEnd = now.Add(b.opts.Window)
+			b.requests, b.failures = 0, 0
+		}
+	}
+	// Outcomes arriving while open belong to calls made before it opened;
+	// they change nothing.
+}
+
+// open trips the breaker. The caller holds b.mu.
+func (b *CircuitBreaker) open(now time.Time) {
+	b.setState(BreakerOpen)
+	b.openedAt = now
+}
+
+// setState changes the state and reports the transition. The caller holds b.mu.
+func (b *CircuitBreaker) setState(to BreakerState) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.name, from, to)
+	}
+}
+
+// Middleware returns middleware guarding the wrapped handler with the
+// breaker. While it's open, requests get 503 with Retry-After.
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			done, retryAfter, ok := b.Allow()
+			if !ok {
+				c.SetHeader("Retry-After", strconv.Itoa(max(1, ceilSeconds(retryAfter))))
+				abortWithError(c, http.StatusServiceUnavailable, fmt.Sprintf("%s is temporarily unavailable", b.name))
+				return
+			}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					done(true)
+					panic(recovered)
+				}
+			}()
+			next(c)
+			done(b.opts.IsFailure(c))
+		}
+	}
+}
+
+// CircuitBreakerPerRoute returns middleware giving every route its own
+// breaker, named after the route pattern, so one failing endpoint doesn't
+// take the others down with it. Requests that matched no route pass through.
+func CircuitBreakerPerRoute(opts BreakerOptions) Middleware {
+	var mu sync.Mutex
+	breakers := make(map[string]Middleware)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			route := c.FullPath()
+			if route == "" {
+				next(c)
+				return
+			}
+			key := c.Request.Method + " " + route
+			mu.Lock()
+			mw, ok := breakers[key]
+			if !ok {
+				mw = NewCircuitBreaker(key, opts).Middleware()
+				breakers[key] = mw
+			}
+			mu.Unlock()
+			mw(next)(c)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Description: This file contains tests for the circuit breaker.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestCircuitBreaker tests the closed, open, and half-open transitions.
+func TestCircuitBreaker(t *testing.T) {
+	// 1. Setup
+	now := time.Unix(1000, 0)
+	var transitions []string
+	b := NewCircuitBreaker("users-db", BreakerOptions{
+		MinRequests: 4, FailureRatio: 0.5, OpenTimeout: 10 * time.Second, HalfOpenRequests: 2,
+		OnStateChange: func(name string, from, to BreakerState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	b.now = func() time.Time { return now }
+	call := func(failed bool) bool {
+		done, _, ok := b.Allow()
+		if ok {
+			done(failed)
+		}
+		return ok
+	}
+
+	// 2. Execute & 3. Assert: Too few requests don't open the breaker,
+	// then the failure ratio does.
+	call(true)
+	call(true)
+	call(false)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected closed below MinRequests, got %s", b.State())
+	}
+	call(true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected open at 3/4 failures, got %s", b.State())
+	}
+	if _, retryAfter, ok := b.Allow(); ok || retryAfter != 10*time.Second {
+		t.Fatalf("expected a fast fail with retry in 10s, got ok=%v %v", ok, retryAfter)
+	}
+
+	// After the timeout, a failed probe reopens it.
+	now = now.Add(10 * time.Second)
+	if b.State() != BreakerHalfOpen || !call(true) || b.State() != BreakerOpen {
+		t.Fatalf("expected a failed probe to reopen, got %s", b.State())
+	}
+
+	// Successful probes close it; extra requests wait meanwhile.
+	now = now.Add(10 * time.Second)
+	done1, _, ok1 := b.Allow()
+	done2, _, ok2 := b.Allow()
+	if _, _, ok := b.Allow(); !ok1 || !ok2 || ok {
+		t.Fatalf("expected exactly 2 probes, got %v %v %v", ok1, ok2, ok)
+	}
+	done1(false)
+	done2(false)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected successful probes to close, got %s", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("expected transitions %v, got %v", want, transitions)
+			break
+		}
+	}
+}
+
+// TestCircuitBreakerPerRoute tests that routes trip independently.
+func TestCircuitBreakerPerRoute(t *testing.T) {
+	// 1. Setup
+	r := router.New()
+	r.Use(CircuitBreakerPerRoute(BreakerOptions{MinRequests: 2}))
+	r.GET("/failing", func(c *httpcontext.Context) { c.Status(http.StatusBadGateway) })
+	r.GET("/healthy", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	send := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// 2. Execute
+	send("/failing")
+	send("/failing")
+	rr := send("/failing")
+
+	// 3. Assert
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "30" {
+		t.Errorf("expected the failing route to be cut off, got %d %v", rr.Code, rr.Header())
+	}
+	if rr := send("/healthy"); rr.Code != http.StatusOK {
+		t.Errorf("expected the healthy route to pass, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,458 @@
+// Description: This file contains an in-process response cache for expensive
+// GET endpoints. The first response for a URL is recorded and replayed to later
+// requests until it expires. Like an HTTP cache, it honours the response's Vary
+// header, so a response negotiated on Accept or Accept-Encoding is only reused
+// for requests sending the same values, and Cache-Control, so handlers can opt
+// out with "no-store" or "private".
+//
+//	cache := middleware.NewResponseCache(middleware.CacheOptions{TTL: 30 * time.Second})
+//	r.GET("/users", listUsers, router.With(cache.Middleware()))
+//	r.POST("/users", createUser, router.With(cache.InvalidateAfter("/users")))
+
+package middleware
+
+import (
+	"bufio"
+	"container/list"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// CacheOptions configures a ResponseCache.
+type CacheOptions struct {
+	// TTL is how long responses are reused. The default is one minute. A
+	// shorter max-age in the response's Cache-Control wins.
+	TTL time.Duration
+
+	// MaxEntries caps the number of cached responses. The default is 1000;
+	// the least recently used entries are evicted first.
+	MaxEntries int
+
+	// MaxBytes caps the total size of cached bodies. The default is 64 MiB.
+	MaxBytes int64
+
+	// MaxEntrySize is the largest body that is cached. The default is 1 MiB;
+	// bigger responses are served but not recorded.
+	MaxEntrySize int
+}
+
+// ResponseCache is an LRU cache of responses. It's safe for concurrent use.
+type ResponseCache struct {
+	opts CacheOptions
+	now  func() time.Time
+
+	mu    sync.Mutex
+	lru   *list.List // of *cacheEntry, most recent first
+	items map[string]*list.Element
+	bytes int64
+
+	// vary records, per URL, the request headers its response varies on,
+	// which complete the key of its entries.
+	vary map[string]*cacheVary
+}
+
+// cacheVary is the Vary header names of a URL, and how many entries use them.
+type cacheVary struct {
+	names []string
+	n     int
+}
+
+// cacheEntry is one recorded response.
+type cacheEntry struct {
+	key     string
+	url     string
+	path    string
+	status  int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// NewResponseCache creates an empty cache.
+func NewResponseCache(opts CacheOptions) *ResponseCache {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 64 << 20
+	}
+	if opts.MaxEntrySize <= 0 {
+		opts.MaxEntrySize = 1 << 20
+	}
+	return &ResponseCache{
+		opts:  opts,
+		now:   time.Now,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+		vary:  make(map[string]*cacheVary),
+	}
+}
+
+// Middleware returns middleware serving GET and HEAD requests from the cache.
+// Hits carry "X-Cache: HIT" and an Age header; misses "X-Cache: MISS".
+//
+// Only 200 responses are stored, and never those setting cookies or marked
+// no-store or private. Requests with an Authorization or Cookie header
+// bypass the cache, neither served from it nor stored: they carry
+// credentials, so their responses are usually personal.
+func (rc *ResponseCache) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			req := c.Request
+			if (req.Method != http.MethodGet && req.Method != http.MethodHead) ||
+				req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "" ||
+				hasDirective(req.Header.Get("Cache-Control"), "no-store") {
+				next(c)
+				return
+			}
+
+			url := req.URL.RequestURI()
+			if !hasDirective(req.Header.Get("Cache-Control"), "no-cache") {
+				if e := rc.get(url, req.Header); e != nil {
+					rc.replay(c, e)
+					return
+				}
+			}
+
+			c.SetHeader("X-Cache", "MISS")
+			rec := &cacheRecorder{ResponseWriter: c.Writer, limit: rc.opts.MaxEntrySize, head: req.Method == http.MethodHead}
+			c.Writer = rec
+			defer func() { c.Writer = rec.ResponseWriter }()
+			next(c)
+
+			if rec.cacheable() {
+				rc.store(url, req, rec)
+			}
+		}
+	}
+}
+
+// InvalidateAfter returns middleware for routes that change data: once the
+// handler succeeds (status below 400), the cached responses for paths are
+// dropped. Paths may use the route's parameters, e.g. "/users/:id".
+func (rc *ResponseCache) InvalidateAfter(paths ...string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			next(c)
+			if c.ResponseStatus() >= 400 {
+				return
+			}
+			expanded := make([]string, len(paths))
+			for i, p := range paths {
+				expanded[i] = expandParams(p, c)
+			}
+			rc.Invalidate(expanded...)
+		}
+	}
+}
+
+// expandParams replaces ":name" segments of path with c's route parameters.
+func expandParams(path string, c *httpcontext.Context) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			segments[i] = c.Param(seg[1:])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Invalidate drops the cached responses for the given paths, with any query
+// string and any Vary values.
+func (rc *ResponseCache) Invalidate(paths ...string) {
+	rc.removeIf(func(e *cacheEntry) bool {
+		for _, p := range paths {
+			if e.path == p {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InvalidatePrefix drops the cached responses for every path starting with
+// prefix, e.g. "/users/".
+func (rc *ResponseCache) InvalidatePrefix(prefix string) {
+	rc.removeIf(func(e *cacheEntry) bool { return strings.HasPrefix(e.path, prefix) })
+}
+
+// Purge empties the cache.
+func (rc *ResponseCache) Purge() {
+	rc.removeIf(func(*cacheEntry) bool { return true })
+}
+
+// Len returns the number of cached responses.
+func (rc *ResponseCache) Len() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.lru.Len()
+}
+
+// removeIf drops every entry matching the predicate.
+func (rc *ResponseCache) removeIf(match func(*cacheEntry) bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for el := rc.lru.Front(); el != nil; {
+		next := el.Next()
+		if match(el.Value.(*cacheEntry)) {
+			rc.remove(el)
+		}
+		el = next
+	}
+}
+
+// cacheKey builds an entry's key from the URL and the values of the request
+// headers the response varies on.
+func cacheKey(url string, names []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(url)
+	for _, name := range names {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// get returns the fresh entry for a request, or nil.
+func (rc *ResponseCache) get(url string, header http.Header) *cacheEntry {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	v, ok := rc.vary[url]
+	if !ok {
+		return nil
+	}
+	el, ok := rc.items[cacheKey(url, v.names, header)]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	if !rc.now().Before(e.expires) {
+		rc.remove(el)
+		return nil
+	}
+	rc.lru.MoveToFront(el)
+	return e
+}
+
+// replay sends a cached response.
+func (rc *ResponseCache) replay(c *httpcontext.Context, e *cacheEntry) {
+	h := c.Writer.Header()
+	for k, v := range e.header {
+		h[k] = v
+	}
+	h.Set("X-Cache", "HIT")
+	h.Set("Age", strconv.Itoa(int(rc.now().Sub(e.stored).Seconds())))
+	c.Writer.WriteHeader(e.status)
+	if c.Request.Method != http.MethodHead {
+		c.Writer.Write(e.body)
+	}
+}
+
+// store records a response, evicting old entries to make room.
+func (rc *ResponseCache) store(url string, req *http.Request, rec *cacheRecorder) {
+	header := rec.Header().Clone()
+	header.Del("X-Cache")
+	names, ok := varyNames(header)
+	if !ok {
+		return
+	}
+	now := rc.now()
+	ttl := rc.opts.TTL
+	if maxAge, ok := maxAgeDirective(header.Get("Cache-Control")); ok && maxAge < ttl {
+		ttl = maxAge
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	key := cacheKey(url, names, req.Header)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if el, ok := rc.items[key]; ok {
+		rc.remove(el)
+	}
+	if v, ok := rc.vary[url]; ok && !equalStrings(v.names, names) {
+		// The URL's responses changed what they vary on: drop the old
+		// variants, their keys no longer line up.
+		for el := rc.lru.Front(); el != nil; {
+			next := el.Next()
+			if el.Value.(*cacheEntry).url == url {
+				rc.remove(el)
+			}
+			el = next
+		}
+	}
+	v, ok := rc.vary[url]
+	if !ok {
+		v = &cacheVary{names: names}
+		rc.vary[url] = v
+	}
+
+	e := &cacheEntry{
+		key: key, url: url, path: req.URL.Path,
+		status: rec.status, header: header, body: rec.body,
+		stored: now, expires: now.Add(ttl),
+	}
+	rc.items[key] = rc.lru.PushFront(e)
+	rc.bytes += int64(len(e.body))
+	v.n++
+	for rc.lru.Len() > rc.opts.MaxEntries || rc.bytes > rc.opts.MaxBytes {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// remove drops one entry. The caller holds rc.mu.
+func (rc *ResponseCache) remove(el *list.Element) {
+	e := rc.lru.Remove(el).(*cacheEntry)
+	delete(rc.items, e.key)
+	rc.bytes -= int64(len(e.body))
+	if v, ok := rc.vary[e.url]; ok {
+		if v.n--; v.n <= 0 {
+			delete(rc.vary, e.url)
+		}
+	}
+}
+
+// varyNames returns the sorted, canonical header names of a response's Vary
+// header, and false for "Vary: *", which means the response can't be reused.
+func varyNames(header http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	// Drop duplicates, e.g. two middleware both adding Accept-Encoding.
+	out := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			out = append(out, name)
+		}
+	}
+	return out, true
+}
+
+// equalStrings reports whether two string slices are equal.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasDirective reports whether a Cache-Control header contains a directive.
+func hasDirective(cacheControl, directive string) bool {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAgeDirective returns the max-age of a Cache-Control header.
+func maxAgeDirective(cacheControl string) (time.Duration, bool) {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, "max-age") {
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// cacheRecorder passes a response through while keeping a copy of it.
+type cacheRecorder struct {
+	http.ResponseWriter
+	limit int
+	head  bool
+
+	status   int
+	body     []byte
+	tooLarge bool
+	streamed bool
+}
+
+// WriteHeader records the status code.
+func (w *cacheRecorder) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records part of the body, up to the size limit.
+func (w *cacheRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.tooLarge {
+		if len(w.body)+len(b) > w.limit {
+			w.tooLarge, w.body = true, nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush marks the response as streamed, which isn't cached, and flushes it.
+func (w *cacheRecorder) Flush() {
+	w.streamed = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over; such responses aren't cached.
+func (w *cacheRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.streamed = true
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *cacheRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// cacheable reports whether the recorded response may be stored.
+func (w *cacheRecorder) cacheable() bool {
+	h := w.Header()
+	cacheControl := h.Get("Cache-Control")
+	return w.status == http.StatusOK && !w.head && !w.tooLarge && !w.streamed &&
+		h.Get("Set-Cookie") == "" &&
+		!hasDirective(cacheControl, "no-store") && !hasDirective(cacheControl, "private") &&
+		!hasDirective(cacheControl, "no-cache")
+}
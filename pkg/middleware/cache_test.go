@@ -0,0 +1,170 @@
+// Description: This file contains tests for the response cache.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// countingHandler answers with how often it has been called.
+func countingHandler(calls *int, setup func(c *httpcontext.Context)) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		*calls++
+		if setup != nil {
+			setup(c)
+		}
+		c.String(http.StatusOK, "call %d", *calls)
+	}
+}
+
+// get sends a GET request with optional header pairs.
+func get(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestResponseCache tests hits, misses, expiry, and what isn't cached.
+func TestResponseCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(c *httpcontext.Context)
+		header    []string
+		advance   time.Duration
+		wantCache string // X-Cache of the second request
+		wantBody  string // body of the second request
+	}{
+		{name: "hit", wantCache: "HIT", wantBody: "call 1"},
+		{name: "expired", advance: 2 * time.Minute, wantCache: "MISS", wantBody: "call 2"},
+		{name: "short max-age", setup: func(c *httpcontext.Context) { c.SetHeader("Cache-Control", "max-age=5") },
+			advance: 10 * time.Second, wantCache: "MISS", wantBody: "call 2"},
+		{name: "no-store", setup: func(c *httpcontext.Context) { c.SetHeader("Cache-Control", "no-store") },
+			wantCache: "MISS", wantBody: "call 2"},
+		{name: "sets cookie", setup: func(c *httpcontext.Context) { c.SetCookie(&http.Cookie{Name: "a", Value: "b"}) },
+			wantCache: "MISS", wantBody: "call 2"},
+		{name: "authorized", header: []string{"Authorization", "Bearer x"}, wantCache: "", wantBody: "call 2"},
+		{name: "with cookie", header: []string{"Cookie", "session=x"}, wantCache: "", wantBody: "call 2"},
+		{name: "client no-cache", header: []string{"Cache-Control", "no-cache"}, wantCache: "MISS", wantBody: "call 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			now := time.Unix(1000, 0)
+			cache := NewResponseCache(CacheOptions{})
+			cache.now = func() time.Time { return now }
+			calls := 0
+			h := Compose(countingHandler(&calls, tt.setup), cache.Middleware())
+
+			// 2. Execute
+			get(h, "/users?page=1", tt.header...)
+			now = now.Add(tt.advance)
+			rr := get(h, "/users?page=1", tt.header...)
+
+			// 3. Assert
+			if got := rr.Header().Get("X-Cache"); got != tt.wantCache {
+				t.Errorf("expected X-Cache %q, got %q", tt.wantCache, got)
+			}
+			if rr.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestResponseCache_Vary tests that responses are only reused for requests
+// with the same values of the headers they vary on.
+func TestResponseCache_Vary(t *testing.T) {
+	// 1. Setup
+	cache := NewResponseCache(CacheOptions{})
+	calls := 0
+	h := Compose(countingHandler(&calls, func(c *httpcontext.Context) {
+		c.AddHeader("Vary", "Accept-Language")
+	}), cache.Middleware())
+
+	// 2. Execute
+	en1 := get(h, "/", "Accept-Language", "en")
+	de1 := get(h, "/", "Accept-Language", "de")
+	en2 := get(h, "/", "Accept-Language", "en")
+
+	// 3. Assert
+	if en1.Body.String() != "call 1" || de1.Body.String() != "call 2" || en2.Body.String() != "call 1" {
+		t.Errorf("unexpected bodies: %q %q %q", en1.Body, de1.Body, en2.Body)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 variants, got %d", cache.Len())
+	}
+}
+
+// TestResponseCache_Limits tests LRU eviction and the size limits.
+func TestResponseCache_Limits(t *testing.T) {
+	// 1. Setup
+	cache := NewResponseCache(CacheOptions{MaxEntries: 2, MaxEntrySize: 10})
+	calls := 0
+	h := Compose(func(c *httpcontext.Context) {
+		calls++
+		c.String(http.StatusOK, "%s", strings.Repeat("x", len(c.Query("size"))))
+	}, cache.Middleware())
+
+	// 2. Execute
+	get(h, "/a?size=1")
+	get(h, "/b?size=1")
+	get(h, "/a?size=1") // a is now the most recently used
+	get(h, "/c?size=1") // evicts b
+	get(h, "/big?size="+strings.Repeat("1", 20))
+
+	// 3. Assert
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+	// In this order: the miss for b stores it again, evicting a.
+	for _, tc := range []struct{ target, want string }{{"/a?size=1", "HIT"}, {"/b?size=1", "MISS"}} {
+		if got := get(h, tc.target).Header().Get("X-Cache"); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.target, tc.want, got)
+		}
+	}
+}
+
+// TestResponseCache_Invalidate tests invalidation after writes.
+func TestResponseCache_Invalidate(t *testing.T) {
+	// 1. Setup
+	cache := NewResponseCache(CacheOptions{})
+	calls := 0
+	r := router.New()
+	r.GET("/users/:id", countingHandler(&calls, nil), router.With(cache.Middleware()))
+	r.Handle("PUT", "/users/:id", func(c *httpcontext.Context) { c.Status(http.StatusNoContent) },
+		router.With(cache.InvalidateAfter("/users/:id")))
+
+	// 2. Execute
+	get(r, "/users/1")
+	get(r, "/users/2")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("PUT", "/users/1", nil))
+
+	// 3. Assert
+	if got := get(r, "/users/1").Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("expected /users/1 to be invalidated, got %s", got)
+	}
+	if got := get(r, "/users/2").Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("expected /users/2 to stay cached, got %s", got)
+	}
+
+	cache.InvalidatePrefix("/users/")
+	if cache.Len() != 0 {
+		t.Errorf("expected an empty cache, got %d entries", cache.Len())
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 handler calls, got %d", calls)
+	}
+}
@@ -0,0 +1,83 @@
+// Description: This file contains client certificate authentication, the
+// application side of mutual TLS. The server verifies the certificate during
+// the handshake (see server.WithClientAuth); ClientCert requires a verified one
+// and records the client's identity for AuthUser, exactly like BasicAuth and
+// APIKey do for their credentials.
+//
+//	s := server.New(":8443", r, server.WithTLS(cert, key), server.WithClientAuth(server.ClientAuthOptions{CAs: pool}))
+//	r.Use(middleware.ClientCert())
+
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// ClientCertOptions configures ClientCertWith.
+type ClientCertOptions struct {
+	// Identity names the client from its certificate. The default is
+	// CertIdentity.
+	Identity func(cert *x509.Certificate) string
+
+	// Allow, if set, refuses verified clients it returns false for with
+	// 403 Forbidden, e.g. to let only the billing service call an endpoint.
+	Allow func(c *httpcontext.Context, cert *x509.Certificate) bool
+}
+
+// ClientCert returns middleware requiring a verified client certificate, with
+// the default options.
+func ClientCert() Middleware {
+	return ClientCertWith(ClientCertOptions{})
+}
+
+// ClientCertWith returns client certificate middleware configured by opts.
+// Requests without a verified certificate get 401 Unauthorized.
+func ClientCertWith(opts ClientCertOptions) Middleware {
+	if opts.Identity == nil {
+		opts.Identity = CertIdentity
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			cert := ClientCertificate(c)
+			if cert == nil {
+				abortWithError(c, http.StatusUnauthorized, "client certificate required")
+				return
+			}
+			if opts.Allow != nil && !opts.Allow(c, cert) {
+				abortWithError(c, http.StatusForbidden, "client not allowed")
+				return
+			}
+			setAuthUser(c, opts.Identity(cert))
+			next(c)
+		}
+	}
+}
+
+// ClientCertificate returns the client's verified certificate, or nil when the
+// request didn't come over TLS with one. Certificates the client sent but the
+// server didn't verify are ignored.
+func ClientCertificate(c *httpcontext.Context) *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// CertIdentity names a client by the first URI in its certificate, such as a
+// SPIFFE ID ("spiffe://example.org/billing"), by its first DNS name otherwise,
+// and by its subject's common name as a last resort.
+func CertIdentity(cert *x509.Certificate) string {
+	switch {
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	default:
+		return cert.Subject.CommonName
+	}
+}
@@ -0,0 +1,57 @@
+// Description: This file contains tests for client certificate authentication.
+
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestClientCert tests which requests get through and who they're from.
+func TestClientCert(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	billing := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}, URIs: []*url.URL{spiffe}}
+	reports := &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}, DNSNames: []string{"reports.internal"}}
+	legacy := &x509.Certificate{Subject: pkix.Name{CommonName: "legacy"}}
+
+	tests := []struct {
+		name       string
+		tls        *tls.ConnectionState
+		wantStatus int
+		wantBody   string
+	}{
+		{"plain HTTP", nil, http.StatusUnauthorized, ""},
+		{"unverified certificate", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{billing}}, http.StatusUnauthorized, ""},
+		{"SPIFFE ID", &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{billing}}}, http.StatusOK, "spiffe://example.org/billing"},
+		{"DNS name", &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{reports}}}, http.StatusOK, "reports.internal"},
+		{"not allowed", &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{legacy}}}, http.StatusForbidden, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			h := Compose(whoami, ClientCertWith(ClientCertOptions{
+				Allow: func(_ *httpcontext.Context, cert *x509.Certificate) bool {
+					return cert.Subject.CommonName != "legacy"
+				},
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.TLS = tc.tls
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus || (tc.wantBody != "" && rr.Body.String() != tc.wantBody) {
+				t.Errorf("expected %d %q, got %d %q", tc.wantStatus, tc.wantBody, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
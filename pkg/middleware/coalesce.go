@@ -0,0 +1,200 @@
+// Description: This file contains request coalescing ("singleflight") for hot
+// GET endpoints. When identical requests arrive while the first one is still
+// being handled, they wait for it instead of running the handler again, and
+// all get a copy of its response. A burst of clients asking for the same
+// expensive report right after a deploy or a cache expiry (a "thundering herd")
+// then costs one handler run instead of hundreds.
+//
+//	r.GET("/reports/daily", dailyReport, router.With(middleware.Coalesce()))
+//
+// Unlike ResponseCache, nothing is kept once the first request finishes; the
+// two work well together, with Coalesce protecting the cache misses.
+
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// CoalesceOptions configures CoalesceWith.
+type CoalesceOptions struct {
+	// Key identifies identical requests. The default is the method, the URL
+	// with its query, and the Authorization and Cookie headers, so users
+	// never get each other's responses.
+	Key func(c *httpcontext.Context) string
+
+	// MaxSize is the largest response body that is shared, in bytes. The
+	// default is 1 MiB; waiting requests run the handler themselves when the
+	// response turns out bigger.
+	MaxSize int
+}
+
+// Coalesce returns coalescing middleware with the default options.
+func Coalesce() Middleware {
+	return CoalesceWith(CoalesceOptions{})
+}
+
+// CoalesceWith returns coalescing middleware configured by opts. Only GET and
+// HEAD requests are coalesced. Responses setting cookies, streamed responses,
+// and the responses of panicking handlers aren't shared either; the waiting
+// requests are then handled one by one as usual.
+func CoalesceWith(opts CoalesceOptions) Middleware {
+	if opts.Key == nil {
+		opts.Key = coalesceKey
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 20
+	}
+	var mu sync.Mutex
+	flights := make(map[string]*flight)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				next(c)
+				return
+			}
+			key := opts.Key(c)
+
+			mu.Lock()
+			if f, ok := flights[key]; ok {
+				mu.Unlock()
+				select {
+				case <-f.done:
+				case <-c.Done():
+					// The client gave up waiting.
+					c.Abort()
+					return
+				}
+				if f.shared {
+					f.replay(c)
+					return
+				}
+				next(c)
+				return
+			}
+			f := &flight{done: make(chan struct{})}
+			flights[key] = f
+			mu.Unlock()
+
+			w := &flightRecorder{ResponseWriter: c.Writer, limit: opts.MaxSize}
+			c.Writer = w
+			defer func() {
+				c.Writer = w.ResponseWriter
+				// This also runs when the handler panics, leaving
+				// shared false: the waiting requests then run the
+				// handler themselves.
+				mu.Lock()
+				delete(flights, key)
+				mu.Unlock()
+				close(f.done)
+			}()
+			next(c)
+
+			if w.status != 0 && !w.tooLarge && !w.streamed && w.header.Get("Set-Cookie") == "" {
+				f.status, f.header, f.body, f.shared = w.status, w.header, w.body, true
+			}
+		}
+	}
+}
+
+// coalesceKey is the default CoalesceOptions.Key.
+func coalesceKey(c *httpcontext.Context) string {
+	return strings.Join([]string{
+		c.Request.Method,
+		c.Request.URL.RequestURI(),
+		c.Request.Header.Get("Authorization"),
+		strings.Join(c.Request.Header.Values("Cookie"), "; "),
+	}, "\x00")
+}
+
+// flight is a request being handled, which identical requests wait for. Its
+// result fields are written before done is closed and only read after.
+type flight struct {
+	done chan struct{}
+
+	shared bool
+	status int
+	header http.Header
+	body   []byte
+}
+
+// replay sends the shared response. Headers the waiting request's own
+// middleware already set, such as its request ID, are kept.
+func (f *flight) replay(c *httpcontext.Context) {
+	h := c.Writer.Header()
+	for k, v := range f.header {
+		if _, set := h[k]; !set {
+			h[k] = append([]string(nil), v...)
+		}
+	}
+	c.Writer.WriteHeader(f.status)
+	if c.Request.Method != http.MethodHead {
+		c.Writer.Write(f.body)
+	}
+}
+
+// flightRecorder sends a response while keeping a copy to share.
+type flightRecorder struct {
+	http.ResponseWriter
+	limit int
+
+	status   int
+	header   http.Header
+	body     []byte
+	tooLarge bool
+	streamed bool
+}
+
+// WriteHeader records the status code and a snapshot of the headers.
+func (w *flightRecorder) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records part of the body, up to the size limit.
+func (w *flightRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.tooLarge {
+		if len(w.body)+len(b) > w.limit {
+			w.tooLarge, w.body = true, nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush marks the response as streamed, which isn't shared, and flushes it.
+func (w *flightRecorder) Flush() {
+	w.streamed = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over; there's no response left to share.
+func (w *flightRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.streamed = true
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *flightRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
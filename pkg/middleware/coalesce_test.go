@@ -0,0 +1,80 @@
+// Description: This file contains tests for request coalescing.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestCoalesce tests that identical concurrent requests share one handler
+// run, unless the response can't be shared.
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		name      string
+		setCookie bool
+		wantCalls int32
+	}{
+		{name: "shared", wantCalls: 1},
+		{name: "response sets a cookie", setCookie: true, wantCalls: 6},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup: The first request blocks in the handler until the
+			// others have looked up their key, and so are waiting for it.
+			const waiting = 5
+			var calls atomic.Int32
+			started, release := make(chan struct{}), make(chan struct{})
+			keyed := make(chan struct{}, waiting+1)
+			h := Compose(func(c *httpcontext.Context) {
+				if calls.Add(1) == 1 {
+					close(started)
+					<-release
+				}
+				if tc.setCookie {
+					c.SetCookie(&http.Cookie{Name: "n", Value: "1"})
+				}
+				c.SetHeader("X-Report", "daily")
+				c.String(http.StatusOK, "report")
+			}, CoalesceWith(CoalesceOptions{Key: func(c *httpcontext.Context) string {
+				keyed <- struct{}{}
+				return coalesceKey(c)
+			}}))
+
+			// 2. Execute
+			recorders := make([]*httptest.ResponseRecorder, waiting+1)
+			var wg sync.WaitGroup
+			serve := func(i int) {
+				defer wg.Done()
+				recorders[i] = httptest.NewRecorder()
+				h.ServeHTTP(recorders[i], httptest.NewRequest("GET", "/reports/daily", nil))
+			}
+			wg.Add(1)
+			go serve(0)
+			<-started
+			<-keyed
+			for i := 1; i <= waiting; i++ {
+				wg.Add(1)
+				go serve(i)
+				<-keyed
+			}
+			close(release)
+			wg.Wait()
+
+			// 3. Assert
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("expected %d handler calls, got %d", tc.wantCalls, got)
+			}
+			for i, rr := range recorders {
+				if rr.Code != http.StatusOK || rr.Body.String() != "report" || rr.Header().Get("X-Report") != "daily" {
+					t.Errorf("request %d: got %d %q %v", i, rr.Code, rr.Body.String(), rr.Header())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Description: This file contains the response compression middleware. It picks
+// an encoding from the client's Accept-Encoding header, and compresses only
+// responses that are worth it: big enough, and not already compressed (images,
+// video, archives, ...). gzip and deflate are built in; other encodings, such
+// as Brotli, can be plugged in through CompressOptions.Encodings.
+
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Compressor is a compressing writer that can be reused for another response
+// with Reset, like *gzip.Writer and *zlib.Writer. Flush is optional; if the
+// compressor has it, flushing the response flushes the compressor first.
+type Compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// Encoding is a content coding the middleware can produce.
+type Encoding struct {
+	// Name is the Accept-Encoding token, e.g. "br".
+	Name string
+
+	// New creates a compressor; they are pooled and reused.
+	New func() Compressor
+}
+
+// CompressOptions configures CompressWith.
+type CompressOptions struct {
+	// Level is the gzip and deflate compression level, from
+	// gzip.BestSpeed to gzip.BestCompression. Zero means
+	// gzip.DefaultCompression.
+	Level int
+
+	// MinSize is the smallest response, in bytes, worth compressing.
+	// The default is 1024; below that, the overhead eats the gain.
+	MinSize int
+
+	// Encodings are offered in addition to gzip and deflate, and preferred
+	// over them, e.g. a Brotli encoder wrapping a third-party package.
+	Encodings []Encoding
+
+	// SkipTypes are media types that are never compressed, added to the
+	// defaults (images, audio, video, archives, fonts, ...). A trailing "/*"
+	// matches a whole type, e.g. "image/*".
+	SkipTypes []string
+}
+
+// incompressibleTypes are already-compressed formats; compressing them again
+// only costs CPU.
+var incompressibleTypes = []string{
+	"image/*", "audio/*", "video/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+	"application/octet-stream", "application/wasm",
+}
+
+// Compress returns compression middleware with the default options.
+func Compress() Middleware {
+	return CompressWith(CompressOptions{})
+}
+
+// CompressWith returns middleware compressing responses as configured.
+func CompressWith(opts CompressOptions) Middleware {
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	if opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression {
+		panic(fmt.Errorf("middleware: invalid compression level %d", opts.Level))
+	}
+	if opts.MinSize == 0 {
+		opts.MinSize = 1024
+	}
+	level := opts.Level
+	encodings := append(append([]Encoding(nil), opts.Encodings...),
+		Encoding{Name: "gzip", New: func() Compressor {
+			w, _ := gzip.NewWriterLevel(nil, level)
+			return w
+		}},
+		// HTTP's "deflate" is the zlib format (RFC 9110, section 8.4.1.2),
+		// not raw DEFLATE, whatever its name suggests.
+		Encoding{Name: "deflate", New: func() Compressor {
+			w, _ := zlib.NewWriterLevel(nil, level)
+			return w
+		}},
+	)
+	pools := make(map[string]*sync.Pool, len(encodings))
+	for _, enc := range encodings {
+		pools[enc.Name] = &sync.Pool{New: func() interface{} { return enc.New() }}
+	}
+	skip := append(append([]string(nil), incompressibleTypes...), opts.SkipTypes...)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			// The response depends on Accept-Encoding whether or not we end
+			// up compressing it, so caches must know.
+			c.AddHeader("Vary", "Accept-Encoding")
+			name := negotiateEncoding(c.GetHeader("Accept-Encoding"), encodings)
+			if name == "" || c.IsWebSocketUpgrade() {
+				next(c)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: c.Writer,
+				encoding:       name,
+				pool:           pools[name],
+				minSize:        opts.MinSize,
+				skip:           skip,
+				head:           c.Request.Method == http.MethodHead,
+			}
+			c.Writer = cw
+			defer func() {
+				c.Writer = cw.ResponseWriter
+				cw.finish()
+			}()
+			next(c)
+		}
+	}
+}
+
+// negotiateEncoding picks the first of our encodings the client accepts,
+// honouring q-values: "gzip;q=0" refuses gzip, "*" accepts anything not listed.
+func negotiateEncoding(header string, encodings []Encoding) string {
+	if header == "" {
+		return ""
+	}
+	accepted := map[string]bool{}
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(part, ";")
+		token = strings.ToLower(strings.TrimSpace(token))
+		ok := true
+		if _, q, found := strings.Cut(params, "q="); found {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(q), 64); err == nil && v == 0 {
+				ok = false
+			}
+		}
+		if token == "*" {
+			wildcard = ok
+			continue
+		}
+		accepted[token] = ok
+	}
+	for _, enc := range encodings {
+		if ok, listed := accepted[enc.Name]; (listed && ok) || (!listed && wildcard) {
+			return enc.Name
+		}
+	}
+	return ""
+}
+
+// compressWriter holds back the start of the response until it knows whether
+// compressing is worthwhile: the headers must say so before the first byte
+// goes out.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+	skip     []string
+	head     bool
+
+	status  int
+	buf     []byte
+	decided bool
+
+	// comp is the compressor in use, or nil if the response goes out as is.
+	comp     Compressor
+	hijacked bool
+}
+
+// WriteHeader records the status code; it's sent once we've decided.
+func (w *compressWriter) WriteHeader(code int) {
+	// Informational responses go out immediately and change nothing.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 && !w.decided {
+		w.status = code
+	}
+}
+
+// Write buffers the body until MinSize bytes have arrived, then decides.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.comp != nil {
+		return w.comp.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// shouldCompress inspects the status and headers of the response.
+func (w *compressWriter) shouldCompress() bool {
+	h := w.Header()
+	switch {
+	case w.head, w.status < 200, w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified, w.status == http.StatusPartialContent:
+		return false
+	case h.Get("Content-Encoding") != "", h.Get("Content-Range") != "":
+		return false
+	case len(w.buf) < w.minSize:
+		return false
+	}
+	// Decide on the real content type, which net/http would otherwise only
+	// sniff after we'd hidden the body from it.
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	mediaType, _, _ := strings.Cut(h.Get("Content-Type"), ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, pattern := range w.skip {
+		if pattern == mediaType || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// decide sends the headers, compressed or not, followed by the buffered body.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.shouldCompress() {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", w.encoding)
+		// A strong ETag identifies exact bytes, which have now changed.
+		if etag := w.Header().Get("ETag"); strings.HasPrefix(etag, `"`) {
+			w.Header().Set("ETag", "W/"+etag)
+		}
+		w.comp = w.pool.Get().(Compressor)
+		w.comp.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.comp != nil {
+		_, err = w.comp.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish completes the response after the handler returned.
+func (w *compressWriter) finish() {
+	if w.hijacked {
+		return
+	}
+	if !w.decided {
+		if w.status == 0 {
+			// Nothing was written; leave the response to whoever
+			// handles it next, as if we weren't here.
+			return
+		}
+		w.decide()
+	}
+	if w.comp != nil {
+		w.comp.Close()
+		w.comp.Reset(io.Discard)
+		w.pool.Put(w.comp)
+		w.comp = nil
+	}
+}
+
+// Flush sends what we have, compressing it if the response qualifies, so
+// streaming responses keep working.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		// A streaming response decides now, even below MinSize.
+		w.minSize = 0
+		w.decide()
+	}
+	if f, ok := w.comp.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, e.g. for protocols that upgrade.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.hijacked = true
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
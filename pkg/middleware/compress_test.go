@@ -0,0 +1,166 @@
+// Description: This file contains tests for the compression middleware.
+
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestCompress tests which responses get compressed, and how.
+func TestCompress(t *testing.T) {
+	big := strings.Repeat("hello, compression! ", 100)
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        HandlerFunc
+		wantEncoding   string
+	}{
+		{
+			name: "large text", acceptEncoding: "gzip, deflate",
+			handler:      func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", big) },
+			wantEncoding: "gzip",
+		},
+		{
+			name: "deflate preferred by q-value", acceptEncoding: "gzip;q=0, deflate",
+			handler:      func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", big) },
+			wantEncoding: "deflate",
+		},
+		{
+			name: "small response", acceptEncoding: "gzip",
+			handler: func(c *httpcontext.Context) { c.String(http.StatusOK, "tiny") },
+		},
+		{
+			name:    "client without compression",
+			handler: func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", big) },
+		},
+		{
+			name: "already compressed type", acceptEncoding: "gzip",
+			handler: func(c *httpcontext.Context) {
+				c.SetHeader("Content-Type", "image/png")
+				c.Writer.Write([]byte(big))
+			},
+		},
+		{
+			name: "already encoded", acceptEncoding: "gzip",
+			handler: func(c *httpcontext.Context) {
+				c.SetHeader("Content-Encoding", "br")
+				c.Writer.Write([]byte(big))
+			},
+			wantEncoding: "br",
+		},
+		{
+			name: "HEAD request", method: "HEAD", acceptEncoding: "gzip",
+			handler: func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", big) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			h := Compose(tt.handler, Compress())
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if got := rr.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+			if !strings.Contains(rr.Header().Get("Vary"), "Accept-Encoding") {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", rr.Header().Get("Vary"))
+			}
+			var zr io.Reader
+			var err error
+			switch tt.wantEncoding {
+			case "gzip":
+				zr, err = gzip.NewReader(rr.Body)
+			case "deflate":
+				zr, err = zlib.NewReader(rr.Body)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if zr != nil {
+				body, _ := io.ReadAll(zr)
+				if string(body) != big {
+					t.Errorf("decompressed body doesn't match")
+				}
+				if rr.Header().Get("Content-Length") != "" {
+					t.Errorf("expected no Content-Length on a compressed response")
+				}
+			}
+		})
+	}
+}
+
+// TestCompress_Streaming tests that flushing sends compressed data right away.
+func TestCompress_Streaming(t *testing.T) {
+	// 1. Setup: A handler that flushes a small first event.
+	flushed := make(chan []byte, 1)
+	rr := httptest.NewRecorder()
+	h := Compose(func(c *httpcontext.Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		c.Writer.Write([]byte("data: 1\n\n"))
+		http.NewResponseController(c.Writer).Flush()
+		flushed <- append([]byte(nil), rr.Body.Bytes()...)
+		c.Writer.Write([]byte("data: 2\n\n"))
+	}, Compress())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	// 2. Execute
+	h.ServeHTTP(rr, req)
+
+	// 3. Assert: Something was sent at the flush, and the whole stream is valid.
+	if len(<-flushed) == 0 || !rr.Flushed {
+		t.Fatal("expected data to be flushed")
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if string(body) != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("unexpected stream %q", body)
+	}
+}
+
+// TestNegotiateEncoding tests Accept-Encoding parsing.
+func TestNegotiateEncoding(t *testing.T) {
+	encodings := []Encoding{{Name: "br"}, {Name: "gzip"}, {Name: "deflate"}}
+	tests := map[string]string{
+		"":                  "",
+		"gzip":              "gzip",
+		"deflate, gzip":     "gzip",
+		"GZIP":              "gzip",
+		"br;q=1.0, gzip":    "br",
+		"*":                 "br",
+		"*, br;q=0":         "gzip",
+		"identity":          "",
+		"gzip;q=0, deflate": "deflate",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header, encodings); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Description: This file contains the concurrency limiting middleware. It caps
+// the number of requests being handled at once with a semaphore, so a slow
+// downstream (a database, another service) can't pile up goroutines and memory
+// until the server falls over. Requests over the cap get 503 Service
+// Unavailable with Retry-After, which load balancers and clients know to retry.
+//
+// Each call creates its own semaphore: r.Use(ConcurrencyLimit(100)) caps the
+// whole router, router.With(ConcurrencyLimit(5)) caps a single route.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// ConcurrencyOptions configures ConcurrencyLimitWith.
+type ConcurrencyOptions struct {
+	// Max is the number of requests handled at once.
+	Max int
+
+	// Wait is how long a request may queue for a free slot before it's
+	// rejected. Zero rejects immediately, which sheds load fastest.
+	Wait time.Duration
+
+	// RetryAfter is sent to rejected clients. The default is one second.
+	RetryAfter time.Duration
+}
+
+// ConcurrencyLimit returns middleware handling at most max requests at once
+// and rejecting the rest right away.
+func ConcurrencyLimit(max int) Middleware {
+	return ConcurrencyLimitWith(ConcurrencyOptions{Max: max})
+}
+
+// ConcurrencyLimitWith returns concurrency limiting middleware configured by
+// opts.
+func ConcurrencyLimitWith(opts ConcurrencyOptions) Middleware {
+	if opts.Max <= 0 {
+		panic(fmt.Errorf("middleware: concurrency limit must be positive, got %d", opts.Max))
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+	retryAfter := strconv.Itoa(ceilSeconds(opts.RetryAfter))
+	slots := make(chan struct{}, opts.Max)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if !acquire(c, slots, opts.Wait) {
+				c.SetHeader("Retry-After", retryAfter)
+				abortWithError(c, http.StatusServiceUnavailable, "server is busy, please retry")
+				return
+			}
+			defer func() { <-slots }()
+			next(c)
+		}
+	}
+}
+
+// acquire takes a slot, waiting up to wait for one to free up. It gives up
+// early if the client goes away.
+func acquire(c *httpcontext.Context, slots chan struct{}, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Done():
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+// Description: This file contains tests for the concurrency limiting middleware.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestConcurrencyLimit tests rejecting and queueing requests over the limit.
+func TestConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		wait       time.Duration
+		wantStatus int
+	}{
+		{"rejects when saturated", 0, http.StatusServiceUnavailable},
+		{"queues within the wait", time.Second, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup: One slot, held by a blocked request.
+			started, release := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			h := Compose(func(c *httpcontext.Context) {
+				first := false
+				once.Do(func() { first = true })
+				if first {
+					close(started)
+					<-release
+				}
+				c.Status(http.StatusOK)
+			}, ConcurrencyLimitWith(ConcurrencyOptions{Max: 1, Wait: tt.wait, RetryAfter: 2 * time.Second}))
+			go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			<-started
+
+			// 2. Execute: A second request arrives; the first finishes soon.
+			time.AfterFunc(20*time.Millisecond, func() { close(release) })
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && rr.Header().Get("Retry-After") != "2" {
+				t.Errorf("expected Retry-After: 2, got %q", rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
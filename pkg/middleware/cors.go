@@ -0,0 +1,174 @@
+// Description: This file contains the CORS middleware, which lets browsers call
+// the API from other origins (the "CORS protocol" of the Fetch standard). It answers
+// preflight OPTIONS requests itself and adds the Access-Control-* headers to
+// regular responses. Register it with r.Use, so it also sees preflights for
+// paths that only have, say, a POST route.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// CORSOptions configures CORS.
+type CORSOptions struct {
+	// AllowOrigins lists the origins allowed to call the API, such as
+	// "https://app.example.com". "*" allows any origin, and a "*." label
+	// allows subdomains: "https://*.example.com".
+	AllowOrigins []string
+
+	// AllowOriginFunc, if set, decides for origins AllowOrigins doesn't
+	// list, e.g. after looking them up in a database.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods lists the methods cross-origin requests may use. The
+	// default is GET, HEAD, POST, PUT, PATCH, and DELETE.
+	AllowMethods []string
+
+	// AllowHeaders lists the request headers clients may send. When empty,
+	// whatever the preflight asks for is allowed.
+	AllowHeaders []string
+
+	// ExposeHeaders lists response headers scripts may read, beyond the
+	// basic ones (e.g. "X-Total-Count", "Link").
+	ExposeHeaders []string
+
+	// AllowCredentials lets requests carry cookies and HTTP authentication,
+	// and the calling page read the answers. It needs the origins listed
+	// explicitly (or AllowOriginFunc): with "*", any website could read
+	// its visitors' credentialed responses, so CORS panics.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache a preflight result. Zero leaves
+	// it to the browser (usually a few seconds).
+	MaxAge time.Duration
+}
+
+// defaultCORSMethods are allowed when CORSOptions.AllowMethods is empty.
+var defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+// CORS returns middleware implementing the CORS protocol with the given options.
+func CORS(opts CORSOptions) Middleware {
+	allowAny := slices.Contains(opts.AllowOrigins, "*")
+	if allowAny && opts.AllowCredentials {
+		panic(errors.New(`middleware: CORS can't allow credentials from any origin ("*"); list the origins`))
+	}
+	if len(opts.AllowMethods) == 0 {
+		opts.AllowMethods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(opts.AllowMethods, ", ")
+	exposeHeaders := strings.Join(opts.ExposeHeaders, ", ")
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			// The answer depends on the Origin, so caches must key on it.
+			c.AddHeader("Vary", "Origin")
+
+			origin := c.GetHeader("Origin")
+			if origin == "" {
+				next(c) // Not a cross-origin browser request.
+				return
+			}
+			preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+			allowed := allowAny || originAllowed(opts.AllowOrigins, origin) ||
+				(opts.AllowOriginFunc != nil && opts.AllowOriginFunc(origin))
+
+			if !allowed {
+				if preflight {
+					c.AbortWithStatus(http.StatusForbidden)
+					return
+				}
+				// Serve the request without CORS headers; the browser
+				// won't let the calling page read the response.
+				next(c)
+				return
+			}
+
+			if allowAny {
+				c.SetHeader("Access-Control-Allow-Origin", "*")
+			} else {
+				c.SetHeader("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				c.SetHeader("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposeHeaders != "" {
+					c.SetHeader("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next(c)
+				return
+			}
+
+			// Preflight: check what the real request is going to do.
+			c.AddHeader("Vary", "Access-Control-Request-Method")
+			c.AddHeader("Vary", "Access-Control-Request-Headers")
+			method := c.GetHeader("Access-Control-Request-Method")
+			if !slices.Contains(opts.AllowMethods, method) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			requested := c.GetHeader("Access-Control-Request-Headers")
+			if !headersAllowed(opts.AllowHeaders, requested) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			c.SetHeader("Access-Control-Allow-Methods", allowMethods)
+			if len(opts.AllowHeaders) > 0 {
+				c.SetHeader("Access-Control-Allow-Headers", strings.Join(opts.AllowHeaders, ", "))
+			} else if requested != "" {
+				c.SetHeader("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				c.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+		}
+	}
+}
+
+// originAllowed reports whether origin matches one of the allowed patterns.
+func originAllowed(patterns []string, origin string) bool {
+	for _, p := range patterns {
+		if strings.EqualFold(p, origin) {
+			return true
+		}
+		// "https://*.example.com" matches "https://api.example.com" but not
+		// "https://example.com" or "https://evil-example.com".
+		if prefix, suffix, ok := strings.Cut(p, "*."); ok {
+			if len(origin) > len(prefix)+len(suffix)+1 &&
+				strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headersAllowed reports whether every header in a comma-separated
+// Access-Control-Request-Headers value is allowed. An empty allow list allows all.
+func headersAllowed(allowed []string, requested string) bool {
+	if len(allowed) == 0 || requested == "" {
+		return true
+	}
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, h) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+// Description: This file contains tests for the CORS middleware.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestCORS tests simple requests and preflights against the configuration.
+func TestCORS(t *testing.T) {
+	// 1. Setup: Only a POST route exists; preflights go through the fallback.
+	r := router.New()
+	r.Use(CORS(CORSOptions{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+	r.POST("/users", func(c *httpcontext.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name       string
+		method     string
+		headers    map[string]string
+		wantStatus int
+		wantOrigin string
+		wantExtra  map[string]string
+	}{
+		{
+			name: "simple request", method: "POST",
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantStatus: http.StatusCreated, wantOrigin: "https://app.example.com",
+			wantExtra: map[string]string{"Access-Control-Expose-Headers": "X-Total-Count", "Access-Control-Allow-Credentials": "true"},
+		},
+		{
+			name: "subdomain wildcard", method: "POST",
+			headers:    map[string]string{"Origin": "https://api.example.org"},
+			wantStatus: http.StatusCreated, wantOrigin: "https://api.example.org",
+		},
+		{
+			name: "lookalike domain", method: "POST",
+			headers:    map[string]string{"Origin": "https://evilexample.org"},
+			wantStatus: http.StatusCreated, wantOrigin: "",
+		},
+		{
+			name: "preflight", method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, authorization",
+			},
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com",
+			wantExtra: map[string]string{
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Content-Type, Authorization",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name: "preflight with forbidden method", method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+			wantStatus: http.StatusForbidden,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name: "preflight with forbidden header", method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+			wantStatus: http.StatusForbidden,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name: "preflight from unknown origin", method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "POST"},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			req := httptest.NewRequest(tc.method, "/users", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status: got %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tc.wantOrigin {
+				t.Errorf("Allow-Origin: got %q, want %q", got, tc.wantOrigin)
+			}
+			for k, v := range tc.wantExtra {
+				if got := rr.Header().Get(k); got != v {
+					t.Errorf("%s: got %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+// TestCORS_AnyOriginWithCredentials tests that credentials can't be allowed
+// from any origin.
+func TestCORS_AnyOriginWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	CORS(CORSOptions{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+// TestCORS_AnyOrigin tests "*" without credentials.
+func TestCORS_AnyOrigin(t *testing.T) {
+	h := Compose(func(c *httpcontext.Context) { c.Status(http.StatusOK) }, CORS(CORSOptions{AllowOrigins: []string{"*"}}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin: got %q, want *", got)
+	}
+	if got := rr.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary: got %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+// Description: This file contains the conditional GET middleware. It buffers
+// GET responses, tags each with an ETag computed from its body, and answers
+// 304 Not Modified, without the body, when the client's If-None-Match shows it
+// already has that exact version. Handlers get this for free; those that can
+// tell the version without building the body should still use c.IfNoneMatch
+// themselves, which skips the work too.
+
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// ETagOptions configures ETagWith.
+type ETagOptions struct {
+	// Weak makes the tags weak (W/"..."), promising only semantic
+	// equivalence. Use it when something further out may change the bytes,
+	// e.g. compression running before this middleware.
+	Weak bool
+
+	// MaxSize is the largest body buffered and tagged, in bytes. Bigger
+	// responses are streamed untagged. The default is 1 MiB.
+	MaxSize int
+
+	// CacheControl, if set, is sent with tagged responses that don't set
+	// their own, e.g. "no-cache" to make clients revalidate every time
+	// (cheap, thanks to the 304s) or "public, max-age=60".
+	CacheControl string
+}
+
+// ETag returns conditional GET middleware with the default options.
+func ETag() Middleware {
+	return ETagWith(ETagOptions{})
+}
+
+// ETagWith returns conditional GET middleware configured by opts.
+func ETagWith(opts ETagOptions) Middleware {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 20
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if c.Request.Method != http.MethodGet {
+				next(c)
+				return
+			}
+
+			w := &etagWriter{ResponseWriter: c.Writer, limit: opts.MaxSize}
+			c.Writer = w
+			next(c)
+			c.Writer = w.ResponseWriter
+			if w.passthrough || w.status == 0 {
+				return
+			}
+
+			h := w.Header()
+			if w.status == http.StatusOK {
+				tag := h.Get("ETag")
+				if tag == "" {
+					tag = bodyETag(w.buf.Bytes(), opts.Weak)
+				}
+				if opts.CacheControl != "" && h.Get("Cache-Control") == "" {
+					h.Set("Cache-Control", opts.CacheControl)
+				}
+				if c.IfNoneMatch(tag) {
+					// 304 sent; the body stays here.
+					return
+				}
+			}
+			w.ResponseWriter.WriteHeader(w.status)
+			w.ResponseWriter.Write(w.buf.Bytes())
+		}
+	}
+}
+
+// bodyETag computes a tag from a hash of the body.
+func bodyETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// etagWriter buffers a response until it's complete, or until it turns out too
+// big or streamed, after which it passes everything through.
+type etagWriter struct {
+	http.ResponseWriter
+	limit int
+
+	status      int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+// WriteHeader records the status code.
+func (w *etagWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	// Informational responses go out immediately.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write buffers part of the body, switching to passthrough past the limit.
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.buf.Len()+len(b) > w.limit {
+		w.startPassthrough()
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// startPassthrough sends what was buffered and stops buffering.
+func (w *etagWriter) startPassthrough() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// Flush gives up on tagging: a streamed response can't be hashed up front.
+func (w *etagWriter) Flush() {
+	w.startPassthrough()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over.
+func (w *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.passthrough = true
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
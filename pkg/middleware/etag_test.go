@@ -0,0 +1,83 @@
+// Description: This file contains tests for the conditional GET middleware.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestETag tests tagging responses and answering 304.
+func TestETag(t *testing.T) {
+	users := func(c *httpcontext.Context) { c.String(http.StatusOK, "ann, bob") }
+	tag := bodyETag([]byte("ann, bob"), false)
+
+	tests := []struct {
+		name        string
+		opts        ETagOptions
+		method      string
+		handler     HandlerFunc
+		ifNoneMatch string
+		wantStatus  int
+		wantETag    string
+		wantBody    string
+	}{
+		{name: "tags the response", handler: users, wantStatus: http.StatusOK, wantETag: tag, wantBody: "ann, bob"},
+		{name: "not modified", handler: users, ifNoneMatch: tag, wantStatus: http.StatusNotModified, wantETag: tag},
+		{name: "weak match", handler: users, ifNoneMatch: "W/" + tag, wantStatus: http.StatusNotModified, wantETag: tag},
+		{name: "changed", handler: users, ifNoneMatch: `"old"`, wantStatus: http.StatusOK, wantETag: tag, wantBody: "ann, bob"},
+		{name: "weak tags", opts: ETagOptions{Weak: true}, handler: users, wantStatus: http.StatusOK, wantETag: "W/" + tag, wantBody: "ann, bob"},
+		{
+			name: "handler's own tag", ifNoneMatch: `"v7"`, wantStatus: http.StatusNotModified, wantETag: `"v7"`,
+			handler: func(c *httpcontext.Context) { c.SetETag("v7"); c.String(http.StatusOK, "x") },
+		},
+		{
+			name: "errors untagged", wantStatus: http.StatusNotFound, wantBody: "nope",
+			handler: func(c *httpcontext.Context) { c.String(http.StatusNotFound, "nope") },
+		},
+		{
+			name: "too big", opts: ETagOptions{MaxSize: 4}, wantStatus: http.StatusOK, wantBody: "ann, bob",
+			handler: users,
+		},
+		{name: "not a GET", method: "POST", handler: users, wantStatus: http.StatusOK, wantBody: "ann, bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			h := Compose(tt.handler, ETagWith(tt.opts))
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, "/users", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus || rr.Header().Get("ETag") != tt.wantETag || rr.Body.String() != tt.wantBody {
+				t.Errorf("expected %d %q %q, got %d %q %q", tt.wantStatus, tt.wantETag, tt.wantBody,
+					rr.Code, rr.Header().Get("ETag"), rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestETag_CacheControl tests the default Cache-Control of tagged responses.
+func TestETag_CacheControl(t *testing.T) {
+	h := Compose(func(c *httpcontext.Context) { c.String(http.StatusOK, "x") }, ETagWith(ETagOptions{CacheControl: "no-cache"}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rr.Header().Get("Cache-Control"), "no-cache") {
+		t.Errorf("expected Cache-Control: no-cache, got %q", rr.Header().Get("Cache-Control"))
+	}
+}
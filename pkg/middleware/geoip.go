@@ -0,0 +1,137 @@
+// Description: This file contains the GeoIP hook. GeoIP resolves each client's
+// IP address to a country and network (ASN) through a pluggable GeoResolver and
+// records the result on the request, where handlers, logs, and rate limits can
+// use it. This package has no database of its own; wrap your provider's reader,
+// e.g. a MaxMind GeoLite2 database:
+//
+//	r.Use(middleware.GeoIP(middleware.GeoResolverFunc(func(ctx context.Context, ip netip.Addr) (middleware.GeoInfo, error) {
+//		rec, err := db.Country(ip.AsSlice())
+//		if err != nil {
+//			return middleware.GeoInfo{}, err
+//		}
+//		return middleware.GeoInfo{Country: rec.Country.IsoCode}, nil
+//	})))
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// GeoInfo is what is known about where a client is.
+type GeoInfo struct {
+	// Country is the ISO 3166-1 alpha-2 code, e.g. "DE", or "" if unknown.
+	Country string
+
+	// ASN is the autonomous system number of the client's network, and
+	// Organization its owner, when the resolver knows them.
+	ASN          uint32
+	Organization string
+}
+
+// GeoResolver looks up IP addresses. Resolve returns the zero GeoInfo and no
+// error for addresses it knows nothing about; errors are for lookups that
+// failed.
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip netip.Addr) (GeoInfo, error)
+}
+
+// GeoResolverFunc adapts a function to GeoResolver.
+type GeoResolverFunc func(ctx context.Context, ip netip.Addr) (GeoInfo, error)
+
+// Resolve implements GeoResolver.
+func (f GeoResolverFunc) Resolve(ctx context.Context, ip netip.Addr) (GeoInfo, error) {
+	return f(ctx, ip)
+}
+
+// geoKey is the request context key for the GeoInfo.
+type geoKey struct{}
+
+// Geo returns the GeoInfo recorded by GeoIP. ok is false if the request didn't
+// go through GeoIP or the lookup failed.
+func Geo(c *httpcontext.Context) (info GeoInfo, ok bool) {
+	info, ok = c.Value(geoKey{}).(GeoInfo)
+	return info, ok
+}
+
+// GeoIP returns middleware resolving the address from c.ClientIP(). A failed
+// lookup is logged and the request goes on without a GeoInfo.
+func GeoIP(resolver GeoResolver) Middleware {
+	if resolver == nil {
+		panic(fmt.Errorf("middleware: GeoIP needs a resolver"))
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			ip, err := netip.ParseAddr(c.ClientIP())
+			if err != nil {
+				next(c)
+				return
+			}
+			info, err := resolver.Resolve(c, ip.Unmap())
+			if err != nil {
+				c.Logger().Warn("GeoIP lookup failed", "ip", ip, "err", err)
+				next(c)
+				return
+			}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), geoKey{}, info))
+			next(c)
+		}
+	}
+}
+
+// KeyByCountry returns a rate limit Key function sharing one bucket per
+// country, for limits such as "at most 100 requests per second from any one
+// country". Clients of unknown origin share the "unknown" bucket. It needs
+// GeoIP earlier in the chain.
+func KeyByCountry() func(c *httpcontext.Context) string {
+	return func(c *httpcontext.Context) string {
+		if info, ok := Geo(c); ok && info.Country != "" {
+			return "country:" + info.Country
+		}
+		return "country:unknown"
+	}
+}
+
+// GeoTable is a GeoResolver backed by a fixed list of networks, for tests and
+// for tagging known ranges such as offices or partners without a database.
+type GeoTable struct {
+	entries []geoEntry
+}
+
+// geoEntry is one network of a GeoTable.
+type geoEntry struct {
+	prefix netip.Prefix
+	info   GeoInfo
+}
+
+// NewGeoTable creates a GeoTable from CIDR prefixes such as "203.0.113.0/24".
+// When prefixes overlap, the most specific one wins. It panics on an invalid
+// prefix.
+func NewGeoTable(networks map[string]GeoInfo) *GeoTable {
+	t := &GeoTable{}
+	for cidr, info := range networks {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic(fmt.Errorf("middleware: invalid GeoTable network %q: %w", cidr, err))
+		}
+		t.entries = append(t.entries, geoEntry{prefix: prefix.Masked(), info: info})
+	}
+	return t
+}
+
+// Resolve implements GeoResolver.
+func (t *GeoTable) Resolve(_ context.Context, ip netip.Addr) (GeoInfo, error) {
+	best := -1
+	var info GeoInfo
+	for _, e := range t.entries {
+		if e.prefix.Contains(ip) && e.prefix.Bits() > best {
+			best, info = e.prefix.Bits(), e.info
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,64 @@
+// Description: This file contains tests for the GeoIP hook.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestGeoIP tests what ends up on the context.
+func TestGeoIP(t *testing.T) {
+	table := NewGeoTable(map[string]GeoInfo{
+		"203.0.113.0/24":   {Country: "DE", ASN: 64500, Organization: "Example GmbH"},
+		"203.0.113.128/25": {Country: "AT"},
+		"2001:db8::/32":    {Country: "JP"},
+	})
+	failing := GeoResolverFunc(func(context.Context, netip.Addr) (GeoInfo, error) {
+		return GeoInfo{}, errors.New("database closed")
+	})
+
+	tests := []struct {
+		name       string
+		resolver   GeoResolver
+		remoteAddr string
+		want       GeoInfo
+		wantOK     bool
+		wantKey    string
+	}{
+		{"known network", table, "203.0.113.5:1234", GeoInfo{Country: "DE", ASN: 64500, Organization: "Example GmbH"}, true, "country:DE"},
+		{"most specific network", table, "203.0.113.200:1234", GeoInfo{Country: "AT"}, true, "country:AT"},
+		{"ipv6", table, "[2001:db8::1]:1234", GeoInfo{Country: "JP"}, true, "country:JP"},
+		{"unknown address", table, "198.51.100.1:1234", GeoInfo{}, true, "country:unknown"},
+		{"failed lookup", failing, "203.0.113.5:1234", GeoInfo{}, false, "country:unknown"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			var got GeoInfo
+			var ok bool
+			var key string
+			h := Compose(func(c *httpcontext.Context) {
+				got, ok = Geo(c)
+				key = KeyByCountry()(c)
+				c.Status(http.StatusOK)
+			}, GeoIP(tc.resolver))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+
+			// 2. Execute
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			// 3. Assert
+			if got != tc.want || ok != tc.wantOK || key != tc.wantKey {
+				t.Errorf("expected %+v %v %q, got %+v %v %q", tc.want, tc.wantOK, tc.wantKey, got, ok, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Description: This file contains the access logging middleware. It writes one
+// line per request with the method, route pattern, status, latency, response
+// size, client IP, and request ID, as plain text for humans or as JSON for log
+// pipelines.
+
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// LogFormat selects how Logger writes its lines.
+type LogFormat int
+
+const (
+	// LogText writes lines such as:
+	// 2024/05/01 12:00:00 GET /users/42 (/users/:id) 200 1.2ms 512B ip=203.0.113.5 id=abc
+	LogText LogFormat = iota
+
+	// LogJSON writes one JSON object per line.
+	LogJSON
+)
+
+// LoggerOptions configures LoggerWith.
+type LoggerOptions struct {
+	// Format is the line format; the default is LogText.
+	Format LogFormat
+
+	// Output receives the log lines. The default is os.Stderr, like the
+	// standard logger.
+	Output io.Writer
+
+	// SkipPaths lists request paths that aren't logged, such as "/health",
+	// which load balancers poll every few seconds.
+	SkipPaths []string
+}
+
+// accessEntry is one access log line, also used as the JSON shape.
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route,omitempty"`
+	Status    int       `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Bytes     int64     `json:"bytes"`
+	ClientIP  string    `json:"client_ip"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// Logger returns access logging middleware writing text lines to os.Stderr.
+func Logger() Middleware {
+	return LoggerWith(LoggerOptions{})
+}
+
+// LoggerWith is Logger with options.
+func LoggerWith(opts LoggerOptions) Middleware {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	flags := log.LstdFlags
+	if opts.Format == LogJSON {
+		flags = 0 // The JSON object carries its own timestamp.
+	}
+	logger := log.New(out, "", flags)
+	skip := append([]string(nil), opts.SkipPaths...)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if slices.Contains(skip, c.Request.URL.Path) {
+				next(c)
+				return
+			}
+
+			start := time.Now()
+			next(c)
+			latency := time.Since(start)
+
+			entry := accessEntry{
+				Time:      start,
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Route:     c.FullPath(),
+				Status:    c.ResponseStatus(),
+				LatencyMS: float64(latency.Microseconds()) / 1000,
+				Bytes:     c.ResponseSize(),
+				ClientIP:  c.ClientIP(),
+				RequestID: requestID(c),
+			}
+			if opts.Format == LogJSON {
+				line, _ := json.Marshal(entry)
+				logger.Print(string(line))
+				return
+			}
+			route := ""
+			if entry.Route != "" && entry.Route != entry.Path {
+				route = " (" + entry.Route + ")"
+			}
+			logger.Printf("%s %s%s %d %v %dB ip=%s id=%s",
+				entry.Method, entry.Path, route, entry.Status,
+				latency.Round(time.Microsecond),
+				entry.Bytes, entry.ClientIP, entry.RequestID)
+		}
+	}
+}
+
+// requestID returns the request's ID: the one set on the response by the
+// request ID middleware, or else the one sent by the client or a proxy.
+func requestID(c *httpcontext.Context) string {
+	if id := c.RequestID(); id != "" {
+		return id
+	}
+	if id := c.Writer.Header().Get("X-Request-ID"); id != "" {
+		return id
+	}
+	return c.GetHeader("X-Request-ID")
+}
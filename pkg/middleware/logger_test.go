@@ -0,0 +1,58 @@
+// Description: This file contains tests for the access logging middleware.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// newLoggedRouter returns a router logging to buf with the given format.
+func newLoggedRouter(buf *bytes.Buffer, format LogFormat) *router.Router {
+	r := router.New()
+	r.Use(LoggerWith(LoggerOptions{Format: format, Output: buf, SkipPaths: []string{"/health"}}))
+	r.GET("/users/:id", func(c *httpcontext.Context) { c.String(http.StatusOK, "user") })
+	r.GET("/health", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+// TestLogger_Text tests the text format and skipped paths.
+func TestLogger_Text(t *testing.T) {
+	// 1. Setup
+	var buf bytes.Buffer
+	r := newLoggedRouter(&buf, LogText)
+
+	// 2. Execute
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	// 3. Assert: One line, for the user request only.
+	line := regexp.MustCompile(`^\S+ \S+ GET /users/42 \(/users/:id\) 200 \S+ 4B ip=192\.0\.2\.1 id=abc\n$`)
+	if !line.MatchString(buf.String()) {
+		t.Errorf("unexpected log output %q", buf.String())
+	}
+}
+
+// TestLogger_JSON tests the JSON format.
+func TestLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := newLoggedRouter(&buf, LogJSON)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	var entry accessEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v: %q", err, buf.String())
+	}
+	if entry.Method != "GET" || entry.Path != "/missing" || entry.Status != http.StatusNotFound || entry.Route != "" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
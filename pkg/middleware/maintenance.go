@@ -0,0 +1,172 @@
+// Description: This file contains maintenance mode. While it's on, every
+// request except the exempt ones (health checks, the admin endpoint, ...) gets
+// 503 Service Unavailable with Retry-After, so deploys and database migrations
+// can run without clients seeing half-finished states. It's switched at
+// runtime, through an admin endpoint or a signal, without a restart:
+//
+//	m := middleware.NewMaintenance(middleware.MaintenanceOptions{
+//		Exempt: []string{"/health", "/admin/*"},
+//	})
+//	r.Use(m.Middleware())
+//	r.Handle("PUT", "/admin/maintenance", m.Handler(), router.With(middleware.BasicAuth(admins)))
+//	stop := m.ToggleOnSignal(syscall.SIGUSR2)
+//	defer stop()
+
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// MaintenanceOptions configures a Maintenance switch.
+type MaintenanceOptions struct {
+	// RetryAfter tells clients when to come back. The default is a minute.
+	RetryAfter time.Duration
+
+	// Message is the error message of the 503 response. The default is
+	// "down for maintenance".
+	Message string
+
+	// Exempt lists paths served as usual during maintenance. An entry
+	// ending in "*" matches every path with that prefix.
+	Exempt []string
+
+	// ExemptFunc exempts further requests, e.g. from the office network.
+	ExemptFunc func(c *httpcontext.Context) bool
+}
+
+// Maintenance is a runtime switch for maintenance mode. It's safe for
+// concurrent use.
+type Maintenance struct {
+	opts       MaintenanceOptions
+	retryAfter string
+	enabled    atomic.Bool
+}
+
+// NewMaintenance creates a switch, initially off.
+func NewMaintenance(opts MaintenanceOptions) *Maintenance {
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Minute
+	}
+	if opts.Message == "" {
+		opts.Message = "down for maintenance"
+	}
+	return &Maintenance{opts: opts, retryAfter: strconv.Itoa(ceilSeconds(opts.RetryAfter))}
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set turns maintenance mode on or off.
+func (m *Maintenance) Set(enabled bool) {
+	if m.enabled.Swap(enabled) != enabled {
+		logger.Info("Maintenance mode " + onOff(enabled))
+	}
+}
+
+// onOff spells a switch state for logs and responses.
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// exempt reports whether a request is served during maintenance.
+func (m *Maintenance) exempt(c *httpcontext.Context) bool {
+	path := c.Request.URL.Path
+	for _, pattern := range m.opts.Exempt {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return m.opts.ExemptFunc != nil && m.opts.ExemptFunc(c)
+}
+
+// Middleware returns middleware rejecting non-exempt requests while
+// maintenance mode is on.
+func (m *Maintenance) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if m.Enabled() && !m.exempt(c) {
+				c.SetHeader("Retry-After", m.retryAfter)
+				abortWithError(c, http.StatusServiceUnavailable, m.opts.Message)
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// Handler returns an admin handler for the switch. GET reports the state as
+// {"data": {"maintenance": true}}; other methods set it from the "enabled"
+// query parameter or a JSON body like {"enabled": true}. It must be protected,
+// e.g. with BasicAuth, and exempted from maintenance itself.
+func (m *Maintenance) Handler() HandlerFunc {
+	return func(c *httpcontext.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			var body struct {
+				Enabled *bool `json:"enabled"`
+			}
+			enabled, err := c.QueryBool("enabled", false)
+			switch {
+			case err != nil:
+				c.Fail(http.StatusBadRequest, err)
+				return
+			case c.Query("enabled") != "":
+				m.Set(enabled)
+			default:
+				if err := c.BindJSON(&body); err != nil {
+					c.Fail(http.StatusBadRequest, err)
+					return
+				}
+				if body.Enabled == nil {
+					c.Fail(http.StatusBadRequest, &httpcontext.BindError{
+						Status: http.StatusBadRequest, Field: "enabled", Message: `"enabled" is required`,
+					})
+					return
+				}
+				m.Set(*body.Enabled)
+			}
+		}
+		c.OK(map[string]bool{"maintenance": m.Enabled()})
+	}
+}
+
+// ToggleOnSignal flips maintenance mode every time the process receives one
+// of the signals, e.g. syscall.SIGUSR2, so operators can run
+// "kill -USR2 <pid>". The returned function stops listening.
+func (m *Maintenance) ToggleOnSignal(signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				m.Set(!m.Enabled())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
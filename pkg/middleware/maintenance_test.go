@@ -0,0 +1,57 @@
+// Description: This file contains tests for maintenance mode.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestMaintenance tests the switch, exemptions, and the admin endpoint.
+func TestMaintenance(t *testing.T) {
+	// 1. Setup
+	m := NewMaintenance(MaintenanceOptions{RetryAfter: 2 * time.Minute, Exempt: []string{"/health", "/admin/*"}})
+	r := router.New()
+	r.Use(m.Middleware())
+	ok := func(c *httpcontext.Context) { c.Status(http.StatusOK) }
+	r.GET("/users", ok)
+	r.GET("/health", ok)
+	r.Handle("PUT", "/admin/maintenance", m.Handler())
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 2. Execute & 3. Assert
+	if rr := send("GET", "/users", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 before maintenance, got %d", rr.Code)
+	}
+	if rr := send("PUT", "/admin/maintenance", `{"enabled": true}`); rr.Code != http.StatusOK || !m.Enabled() {
+		t.Fatalf("expected maintenance to be enabled, got %d %s", rr.Code, rr.Body)
+	}
+	rr := send("GET", "/users", "")
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "120" ||
+		!strings.Contains(rr.Body.String(), "down for maintenance") {
+		t.Errorf("expected 503 during maintenance, got %d %v %s", rr.Code, rr.Header(), rr.Body)
+	}
+	if rr := send("GET", "/health", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected exempt /health to pass, got %d", rr.Code)
+	}
+	if rr := send("PUT", "/admin/maintenance", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without \"enabled\", got %d", rr.Code)
+	}
+	if rr := send("PUT", "/admin/maintenance?enabled=false", ""); rr.Code != http.StatusOK || m.Enabled() {
+		t.Errorf("expected maintenance to be disabled, got %d", rr.Code)
+	}
+}
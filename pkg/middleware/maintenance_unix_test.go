@@ -0,0 +1,32 @@
+//go:build unix
+
+// Description: This file contains the maintenance mode tests that send
+// signals, which only Unix-like systems support.
+
+package middleware
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMaintenance_Signal tests toggling with a signal.
+func TestMaintenance_Signal(t *testing.T) {
+	// 1. Setup
+	m := NewMaintenance(MaintenanceOptions{})
+	stop := m.ToggleOnSignal(syscall.SIGUSR2)
+	defer stop()
+
+	// 2. Execute
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+
+	// 3. Assert
+	deadline := time.Now().Add(time.Second)
+	for !m.Enabled() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !m.Enabled() {
+		t.Error("expected the signal to enable maintenance mode")
+	}
+}
@@ -0,0 +1,66 @@
+// Description: This package contains the built-in middleware (logging, recovery,
+// CORS, ...) and helpers to compose middleware. Each middleware lives in a file
+// of its own, next to its tests, and is a plain router.Middleware, so it can be
+// used router-wide with r.Use, on a single route with router.With, or combined
+// with others using Chain:
+//
+//	api := middleware.Chain(middleware.Recovery(), middleware.Logger())
+//	r.Use(api)
+//	r.GET("/admin", h, router.With(middleware.BasicAuth(...)))
+
+package middleware
+
+import (
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// Middleware is the router's middleware type, re-exported so that code using
+// this package doesn't have to spell out router.Middleware.
+type Middleware = router.Middleware
+
+// HandlerFunc is the router's handler type.
+type HandlerFunc = router.HandlerFunc
+
+// Chain combines several middleware into one. The first one is the outermost,
+// exactly as if they had been passed to r.Use in the same order. Once a
+// middleware aborts the request with c.Abort(), the rest of the chain is skipped.
+func Chain(middleware ...Middleware) Middleware {
+	// Copy, so the caller reusing its slice can't change the chain later.
+	middleware = append([]Middleware(nil), middleware...)
+	return func(next HandlerFunc) HandlerFunc {
+		return Compose(next, middleware...)
+	}
+}
+
+// Compose wraps a handler in middleware, the first one outermost. It's useful
+// outside the router, e.g. to serve a handler with http.Handle:
+//
+//	http.Handle("/metrics", middleware.Compose(metricsHandler, middleware.BasicAuth(users)))
+func Compose(h HandlerFunc, middleware ...Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](unlessAborted(h))
+	}
+	return h
+}
+
+// unlessAborted returns a handler that calls h only if the chain wasn't aborted.
+func unlessAborted(h HandlerFunc) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		if !c.IsAborted() {
+			h(c)
+		}
+	}
+}
+
+// abortWithError stops the chain with an error response in the standard
+// envelope, e.g. {"error": {"status": 401, "message": "unauthorized"}}.
+func abortWithError(c *httpcontext.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, map[string]interface{}{
+		httpcontext.DefaultEnvelope.ErrorKey: httpcontext.EnvelopeError{
+			Status:    status,
+			Message:   message,
+			RequestID: c.RequestID(),
+		},
+	})
+}
@@ -0,0 +1,59 @@
+// Description: This file contains tests for the composition helpers.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// trace returns middleware that records its name in order.
+func trace(order *[]string, name string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			*order = append(*order, name)
+			next(c)
+		}
+	}
+}
+
+// TestChain tests that a chain behaves like the same middleware passed to Use.
+func TestChain(t *testing.T) {
+	// 1. Setup
+	var order []string
+	r := router.New()
+	r.Use(Chain(trace(&order, "a"), trace(&order, "b")), trace(&order, "c"))
+	r.GET("/", func(c *httpcontext.Context) { order = append(order, "handler") })
+
+	// 2. Execute
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	// 3. Assert
+	if fmt.Sprint(order) != "[a b c handler]" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+// TestCompose tests wrapping a handler outside the router, including aborts.
+func TestCompose(t *testing.T) {
+	var order []string
+	deny := func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			c.AbortWithStatus(http.StatusForbidden)
+			next(c)
+		}
+	}
+	h := Compose(func(c *httpcontext.Context) { order = append(order, "handler") },
+		trace(&order, "a"), deny, trace(&order, "b"))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusForbidden || fmt.Sprint(order) != "[a]" {
+		t.Errorf("unexpected result: %d %v", rr.Code, order)
+	}
+}
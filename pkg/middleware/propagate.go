@@ -0,0 +1,116 @@
+// Description: This file contains header propagation. Calls a handler makes to
+// other services should carry some of the incoming request's headers along
+// (request ID, trace context, tenant ID) so the whole call tree can be followed
+// in the logs. PropagateHeaders remembers those headers in the request's
+// context, and PropagatingTransport adds them to outgoing requests made with
+// that context:
+//
+//	r.Use(middleware.RequestID(), middleware.PropagateHeaders())
+//	client := middleware.PropagatingClient()
+//	...
+//	req, _ := http.NewRequestWithContext(c, "GET", "http://billing/invoices", nil)
+//	resp, err := client.Do(req)
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/tracing"
+)
+
+// DefaultPropagateHeaders are the headers PropagateHeaders forwards when called
+// without any.
+var DefaultPropagateHeaders = []string{
+	httpcontext.RequestIDHeader,
+	tracing.TraceparentHeader,
+	tracing.TracestateHeader,
+	"X-Tenant-ID",
+}
+
+// propagateKey is the request context key for the headers to forward.
+type propagateKey struct{}
+
+// PropagateHeaders returns middleware recording the named headers of each
+// request, DefaultPropagateHeaders if none are given, for PropagatingTransport.
+// The request ID header carries c.RequestID() when one was assigned, so put
+// this after RequestID.
+func PropagateHeaders(names ...string) Middleware {
+	if len(names) == 0 {
+		names = DefaultPropagateHeaders
+	}
+	canonical := make([]string, len(names))
+	for i, name := range names {
+		canonical[i] = http.CanonicalHeaderKey(name)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			out := make(http.Header, len(canonical))
+			for _, name := range canonical {
+				if values := c.Request.Header.Values(name); len(values) > 0 {
+					out[name] = append([]string(nil), values...)
+				}
+				if name == http.CanonicalHeaderKey(httpcontext.RequestIDHeader) && c.RequestID() != "" {
+					out.Set(name, c.RequestID())
+				}
+			}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), propagateKey{}, out))
+			next(c)
+		}
+	}
+}
+
+// OutgoingHeaders returns a copy of the headers PropagateHeaders recorded in
+// ctx, for clients that don't go through PropagatingTransport. It returns an
+// empty header if there are none.
+func OutgoingHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(propagateKey{}).(http.Header)
+	if h == nil {
+		return http.Header{}
+	}
+	return h.Clone()
+}
+
+// PropagatingTransport is an http.RoundTripper adding the headers recorded by
+// PropagateHeaders in the request's context. Headers the request sets itself
+// are kept. When the context has a span (see Tracing), the trace context sent
+// names that span as the parent rather than repeating the incoming one.
+type PropagatingTransport struct {
+	// Base makes the requests. The default is http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *PropagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	h, _ := ctx.Value(propagateKey{}).(http.Header)
+	traced := tracing.SpanFromContext(ctx) != nil
+	if len(h) == 0 && !traced {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given.
+	req = req.Clone(ctx)
+	for name, values := range h {
+		if _, set := req.Header[name]; !set {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+	if traced {
+		tracing.Inject(ctx, req.Header)
+	}
+	return base.RoundTrip(req)
+}
+
+// PropagatingClient returns an HTTP client using PropagatingTransport over
+// http.DefaultTransport.
+func PropagatingClient() *http.Client {
+	return &http.Client{Transport: &PropagatingTransport{}}
+}
@@ -0,0 +1,74 @@
+// Description: This file contains tests for header propagation.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/tracing"
+)
+
+// TestPropagateHeaders tests which headers reach a downstream service.
+func TestPropagateHeaders(t *testing.T) {
+	// 1. Setup: The downstream service reports the headers it got.
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	client := PropagatingClient()
+	var spanTraceparent string
+	h := Compose(func(c *httpcontext.Context) {
+		spanTraceparent = tracing.SpanFromContext(c).SpanContext().Traceparent()
+		req, _ := http.NewRequestWithContext(c, "GET", downstream.URL, nil)
+		req.Header.Set("X-Tenant-ID", "override")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	},
+		RequestIDWith(RequestIDOptions{Generate: func() string { return "generated" }, IgnoreIncoming: true}),
+		Tracing(tracing.NewTracer(nil)),
+		PropagateHeaders(),
+	)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "from-client")
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	// 2. Execute
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 3. Assert
+	want := map[string]string{
+		"X-Request-Id":  "generated",
+		"X-Tenant-Id":   "override",
+		"Traceparent":   spanTraceparent,
+		"Authorization": "",
+	}
+	for name, value := range want {
+		if got.Get(name) != value {
+			t.Errorf("%s: got %q, want %q", name, got.Get(name), value)
+		}
+	}
+}
+
+// TestOutgoingHeaders tests reading the recorded headers directly.
+func TestOutgoingHeaders(t *testing.T) {
+	var out http.Header
+	h := Compose(func(c *httpcontext.Context) { out = OutgoingHeaders(c) }, PropagateHeaders("X-Tenant-ID"))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if out.Get("X-Tenant-ID") != "acme" || out.Get("X-Request-ID") != "" {
+		t.Errorf("unexpected headers %v", out)
+	}
+}
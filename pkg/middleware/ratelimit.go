@@ -0,0 +1,107 @@
+// Description: This file contains the rate limiting middleware. Every client
+// (by IP address by default, or by API key) gets a token bucket: each request
+// takes a token, and tokens refill at a steady rate up to a burst size. A client
+// that runs out gets 429 Too Many Requests with a Retry-After header, and every
+// response carries the RateLimit-* headers so well-behaved clients can slow
+// down before hitting the limit. Where the buckets live is up to a
+// RateLimitStore, see ratelimitstore.go.
+
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// RateLimitOptions configures RateLimit.
+type RateLimitOptions struct {
+	// Rate is the number of requests per second a client may make on
+	// average, e.g. 10, or 100.0/60 for 100 per minute.
+	Rate float64
+
+	// Burst is how many requests a client may make at once after being idle.
+	// Zero means max(1, Rate).
+	Burst int
+
+	// Key identifies the client. The default is c.ClientIP(); see KeyByHeader
+	// for API keys. Requests with an empty key are not limited.
+	Key func(c *httpcontext.Context) string
+
+	// Store holds the buckets. The default is a new in-memory store, which
+	// limits each server instance separately; use a shared store such as
+	// NewRedisRateLimitStore to enforce one limit across instances.
+	Store RateLimitStore
+}
+
+// KeyByHeader returns a Key function using a request header, such as
+// "X-API-Key", and falling back to the client's IP when it's missing.
+func KeyByHeader(name string) func(c *httpcontext.Context) string {
+	return func(c *httpcontext.Context) string {
+		if key := c.GetHeader(name); key != "" {
+			return name + ":" + key
+		}
+		return c.ClientIP()
+	}
+}
+
+// RateLimit returns middleware limiting each client to opts.Rate requests per
+// second, with bursts of up to opts.Burst.
+//
+// If the store fails (say, Redis is unreachable), the error is logged and the
+// request is let through: an outage of the limiter shouldn't take the whole
+// API down with it.
+func RateLimit(opts RateLimitOptions) Middleware {
+	if opts.Rate <= 0 {
+		panic(fmt.Errorf("middleware: rate limit must be positive, got %v", opts.Rate))
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = max(1, int(opts.Rate))
+	}
+	if opts.Key == nil {
+		opts.Key = func(c *httpcontext.Context) string { return c.ClientIP() }
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			key := opts.Key(c)
+			if key == "" {
+				next(c)
+				return
+			}
+			result, err := opts.Store.Take(c, key, opts.Rate, opts.Burst)
+			if err != nil {
+				c.Logger().Error("Rate limit store error", "method", c.Request.Method, "path", c.Request.URL.Path, "err", err)
+				next(c)
+				return
+			}
+			setRateLimitHeaders(c, opts.Burst, result)
+			if !result.Allowed {
+				c.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				abortWithError(c, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// setRateLimitHeaders adds the RateLimit-Limit, -Remaining, and -Reset headers
+// from the IETF "RateLimit header fields for HTTP" draft.
+func setRateLimitHeaders(c *httpcontext.Context, limit int, r RateLimitResult) {
+	c.SetHeader("RateLimit-Limit", strconv.Itoa(limit))
+	c.SetHeader("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	c.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(r.Reset)))
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as HTTP headers need.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
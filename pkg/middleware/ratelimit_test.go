@@ -0,0 +1,66 @@
+// Description: This file contains tests for the rate limiting middleware.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestRateLimit tests bursts, 429 responses, and per-client keys.
+func TestRateLimit(t *testing.T) {
+	// 1. Setup: 1 request per second, bursts of 2, keyed by API key.
+	h := Compose(func(c *httpcontext.Context) { c.Status(http.StatusOK) },
+		RateLimit(RateLimitOptions{Rate: 1, Burst: 2, Key: KeyByHeader("X-API-Key")}))
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 2. Execute & 3. Assert: The burst passes, the next request is limited.
+	for i, wantRemaining := range []string{"1", "0"} {
+		rr := send("alice")
+		if rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: got %d remaining=%s", i, rr.Code, rr.Header().Get("RateLimit-Remaining"))
+		}
+	}
+	rr := send("alice")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "1" || rr.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("unexpected headers: %v", rr.Header())
+	}
+
+	// Another client has its own bucket.
+	if rr := send("bob"); rr.Code != http.StatusOK {
+		t.Errorf("expected bob to pass, got %d", rr.Code)
+	}
+}
+
+// TestRateLimit_StoreError tests that requests pass when the store fails.
+func TestRateLimit_StoreError(t *testing.T) {
+	// 1. Setup
+	failing := RedisEvalFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("connection refused")
+	})
+	h := Compose(func(c *httpcontext.Context) { c.Status(http.StatusOK) },
+		RateLimit(RateLimitOptions{Rate: 1, Store: NewRedisRateLimitStore(failing, "rl:")}))
+
+	// 2. Execute
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	// 3. Assert
+	if rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("expected the request to pass without headers, got %d %v", rr.Code, rr.Header())
+	}
+}
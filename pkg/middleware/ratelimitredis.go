@@ -0,0 +1,94 @@
+// Description: This file contains an example RateLimitStore adapter for Redis.
+// It doesn't import a Redis client; instead it needs a single Eval method,
+// which any client provides. The whole token bucket update runs as one Lua
+// script, so it's atomic even with many server instances sharing the keys.
+//
+// With github.com/redis/go-redis, for example:
+//
+//	store := middleware.NewRedisRateLimitStore(middleware.RedisEvalFunc(
+//		func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+//			return rdb.Eval(ctx, script, keys, args...).Result()
+//		}), "ratelimit:")
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// RedisEvaler runs a Lua script on a Redis server, as EVAL does.
+type RedisEvaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// RedisEvalFunc adapts a function to the RedisEvaler interface.
+type RedisEvalFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// Eval calls f.
+func (f RedisEvalFunc) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// redisTokenBucket is the bucket update as a Lua script. The clock is Redis's
+// own TIME, so server instances with skewed clocks still agree. Keys expire once
+// the bucket would be full, which evicts idle clients. Tokens are returned as a
+// string because Redis truncates Lua numbers to integers.
+const redisTokenBucket = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - last) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// RedisRateLimitStore is a RateLimitStore keeping its buckets in Redis.
+type RedisRateLimitStore struct {
+	client RedisEvaler
+	prefix string
+}
+
+// NewRedisRateLimitStore creates a store that keeps each client's bucket in a
+// Redis hash named prefix + key.
+func NewRedisRateLimitStore(client RedisEvaler, prefix string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: prefix}
+}
+
+// Take implements RateLimitStore.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	reply, err := s.client.Eval(ctx, redisTokenBucket, []string{s.prefix + key}, rate, burst)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("redis rate limit: %w", err)
+	}
+
+	// The reply is {allowed, "tokens"}: an integer and a bulk string.
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("redis rate limit: unexpected reply %v", reply)
+	}
+	allowed, ok := values[0].(int64)
+	if !ok {
+		return RateLimitResult{}, fmt.Errorf("redis rate limit: unexpected reply %v", reply)
+	}
+	tokensText, ok := values[1].(string)
+	if !ok {
+		return RateLimitResult{}, fmt.Errorf("redis rate limit: unexpected reply %v", reply)
+	}
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("redis rate limit: bad token count %q", tokensText)
+	}
+	return bucketResult(allowed == 1, tokens, rate, burst), nil
+}
@@ -0,0 +1,159 @@
+// Description: This file contains the storage side of the rate limiter. The
+// RateLimitStore interface is all the middleware needs, so the buckets can
+// live in this process (NewMemoryRateLimitStore) or in a shared service such
+// as Redis or memcached, letting several server instances enforce one limit.
+
+package middleware
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitStore takes tokens from per-client token buckets. Implementations
+// must be safe for concurrent use, and Take must be atomic: two requests racing
+// for the last token must not both get it.
+type RateLimitStore interface {
+	// Take refills key's bucket at rate tokens per second, up to burst, and
+	// then tries to take one token. A client seen for the first time starts
+	// with a full bucket.
+	Take(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error)
+}
+
+// RateLimitResult is the outcome of RateLimitStore.Take.
+type RateLimitResult struct {
+	// Allowed reports whether a token was taken.
+	Allowed bool
+
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+
+	// RetryAfter is how long until the next token, when not Allowed.
+	RetryAfter time.Duration
+
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// bucketResult builds a RateLimitResult from the tokens left in a bucket.
+// Stores only need to track the tokens; the timings follow from the rate.
+func bucketResult(allowed bool, tokens, rate float64, burst int) RateLimitResult {
+	r := RateLimitResult{Allowed: allowed, Remaining: int(tokens)}
+	if !allowed {
+		r.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	r.Reset = secondsToDuration((float64(burst) - tokens) / rate)
+	return r
+}
+
+// secondsToDuration converts fractional seconds to a time.Duration.
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// bucket is the state of one client's token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+
+	// full is when the bucket will have refilled completely, after which
+	// it's the same as no bucket at all and can be evicted.
+	full time.Time
+}
+
+// take refills the bucket for the time elapsed since its last use and then
+// tries to take one token.
+func (b *bucket) take(now time.Time, rate float64, burst int) RateLimitResult {
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	r := bucketResult(allowed, b.tokens, rate, burst)
+	b.full = now.Add(r.Reset)
+	return r
+}
+
+// bucketShards splits the buckets over several locks, so clients don't all
+// contend for one mutex under load.
+const bucketShards = 32
+
+// MemoryRateLimitStore keeps token buckets in memory. It's the default store
+// and the reference implementation of RateLimitStore.
+type MemoryRateLimitStore struct {
+	now  func() time.Time
+	seed maphash.Seed
+
+	// sweepEvery is how often each shard drops buckets that have refilled.
+	sweepEvery time.Duration
+
+	shards [bucketShards]bucketShard
+}
+
+// bucketShard is one lock and its share of the buckets.
+type bucketShard struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store. Clients that have
+// been idle long enough for their bucket to refill are evicted, so memory
+// stays proportional to the number of recently active clients.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return newMemoryRateLimitStore(time.Now, time.Minute)
+}
+
+// newMemoryRateLimitStore is NewMemoryRateLimitStore with a fake clock for tests.
+func newMemoryRateLimitStore(now func() time.Time, sweepEvery time.Duration) *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{now: now, seed: maphash.MakeSeed(), sweepEvery: sweepEvery}
+	for i := range s.shards {
+		s.shards[i].buckets = make(map[string]*bucket)
+		s.shards[i].lastSweep = now()
+	}
+	return s
+}
+
+// Take implements RateLimitStore. It never returns an error.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	shard := &s.shards[maphash.String(s.seed, key)%bucketShards]
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Sweeping lazily keeps memory bounded without a background goroutine
+	// that would need stopping.
+	if now.Sub(shard.lastSweep) > s.sweepEvery {
+		for k, b := range shard.buckets {
+			if now.After(b.full) {
+				delete(shard.buckets, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	b, ok := shard.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		shard.buckets[key] = b
+	}
+	return b.take(now, rate, burst), nil
+}
+
+// Len returns the number of clients currently tracked.
+func (s *MemoryRateLimitStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].buckets)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
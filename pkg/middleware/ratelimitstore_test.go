@@ -0,0 +1,94 @@
+// Description: This file contains tests for the rate limit stores.
+
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMemoryRateLimitStore tests refilling and eviction of idle clients.
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	s := newMemoryRateLimitStore(func() time.Time { return now }, time.Second)
+	take := func(key string) RateLimitResult {
+		r, err := s.Take(ctx, key, 2, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	for i := 0; i < 4; i++ {
+		if !take("a").Allowed {
+			t.Fatalf("take %d should be allowed", i)
+		}
+	}
+	if r := take("a"); r.Allowed || r.RetryAfter != 500*time.Millisecond || r.Reset != 2*time.Second {
+		t.Fatalf("expected a denial with retry in 500ms, got %+v", r)
+	}
+
+	// Half a second refills one token.
+	now = now.Add(500 * time.Millisecond)
+	if !take("a").Allowed {
+		t.Error("expected a refilled token")
+	}
+
+	// Once their buckets are full again, idle clients are evicted on the
+	// next sweep of their shard. A thousand keys touch every shard.
+	for i := 0; i < 1000; i++ {
+		take("old" + strconv.Itoa(i))
+	}
+	now = now.Add(5 * time.Second)
+	for i := 0; i < 1000; i++ {
+		take("new" + strconv.Itoa(i))
+	}
+	if n := s.Len(); n != 1000 {
+		t.Errorf("expected idle clients to be evicted, %d tracked", n)
+	}
+}
+
+// TestRedisRateLimitStore tests the Redis adapter against a fake client.
+func TestRedisRateLimitStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   interface{}
+		want    RateLimitResult
+		wantErr bool
+	}{
+		{"allowed", []interface{}{int64(1), "2.5"}, RateLimitResult{Allowed: true, Remaining: 2, Reset: 750 * time.Millisecond}, false},
+		{"denied", []interface{}{int64(0), "0.5"}, RateLimitResult{Remaining: 0, RetryAfter: 250 * time.Millisecond, Reset: 1750 * time.Millisecond}, false},
+		{"bad reply", "OK", RateLimitResult{}, true},
+		{"bad tokens", []interface{}{int64(1), "many"}, RateLimitResult{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			var gotKeys []string
+			var gotArgs []interface{}
+			client := RedisEvalFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+				gotKeys, gotArgs = keys, args
+				return tt.reply, nil
+			})
+			s := NewRedisRateLimitStore(client, "rl:")
+
+			// 2. Execute
+			got, err := s.Take(context.Background(), "10.0.0.1", 2, 4)
+
+			// 3. Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+			if len(gotKeys) != 1 || gotKeys[0] != "rl:10.0.0.1" || len(gotArgs) != 2 {
+				t.Errorf("unexpected call: keys=%v args=%v", gotKeys, gotArgs)
+			}
+		})
+	}
+}
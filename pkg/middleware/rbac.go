@@ -0,0 +1,133 @@
+// Description: This file contains role-based access control. Routes declare the
+// permissions they need when they're registered, the authentication middleware
+// (or the application) records who the caller is as a Principal, and RBAC
+// checks one against the other:
+//
+//	r.Use(middleware.BasicAuth(accounts), loadPrincipal, middleware.RBAC(middleware.RBACOptions{
+//		Roles: map[string][]string{"admin": {"*"}, "editor": {"users:read", "users:write"}},
+//	}))
+//	r.POST("/users", createUser, middleware.RequirePermissions("users:write"))
+//
+// Refusals are sent as application/problem+json: 401 when nobody is
+// authenticated, 403 when the caller lacks a permission.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// PermissionsMetaKey is the route metadata key holding the permissions, a
+// []string, that RequirePermissions attaches to a route.
+const PermissionsMetaKey = "permissions"
+
+// RequirePermissions declares the permissions a route needs; RBAC requires
+// the caller to have all of them.
+func RequirePermissions(permissions ...string) router.RouteOption {
+	return router.Meta(PermissionsMetaKey, permissions)
+}
+
+// Principal is an authenticated caller: who they are, their roles, and any
+// permissions granted to them directly.
+type Principal struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+// principalKey is the request context key for the Principal.
+type principalKey struct{}
+
+// SetPrincipal records the authenticated caller for RBAC and later handlers.
+func SetPrincipal(c *httpcontext.Context, p *Principal) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalKey{}, p))
+}
+
+// PrincipalFrom returns the Principal recorded with SetPrincipal.
+func PrincipalFrom(c *httpcontext.Context) (*Principal, bool) {
+	p, ok := c.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// RBACOptions configures RBAC.
+type RBACOptions struct {
+	// Roles maps each role to the permissions it grants. A permission of "*"
+	// grants everything, and one ending in ":*", such as "users:*", grants
+	// everything with that prefix.
+	Roles map[string][]string
+
+	// Principal, if set, finds the caller instead of PrincipalFrom, e.g. by
+	// looking up the roles of AuthUser(c).
+	Principal func(c *httpcontext.Context) (*Principal, bool)
+}
+
+// RBAC returns middleware enforcing the permissions declared with
+// RequirePermissions. Routes without any are left alone.
+func RBAC(opts RBACOptions) Middleware {
+	principal := opts.Principal
+	if principal == nil {
+		principal = PrincipalFrom
+	}
+	roles := make(map[string][]string, len(opts.Roles))
+	for role, perms := range opts.Roles {
+		roles[role] = slices.Clone(perms)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			required, _ := c.RouteInfo().Metadata[PermissionsMetaKey].([]string)
+			if len(required) == 0 {
+				next(c)
+				return
+			}
+
+			p, ok := principal(c)
+			if !ok {
+				c.Abort()
+				c.SetHeader("WWW-Authenticate", `Bearer realm="api"`)
+				c.Problem(httpcontext.Problem{Status: http.StatusUnauthorized, Detail: "authentication required"})
+				return
+			}
+			for _, perm := range required {
+				if !p.can(perm, roles) {
+					c.Abort()
+					c.Problem(httpcontext.Problem{Status: http.StatusForbidden, Detail: "missing permission " + perm})
+					return
+				}
+			}
+			next(c)
+		}
+	}
+}
+
+// can reports whether the principal has perm, directly or through a role.
+func (p *Principal) can(perm string, roles map[string][]string) bool {
+	if grants(p.Permissions, perm) {
+		return true
+	}
+	for _, role := range p.Roles {
+		if grants(roles[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// grants reports whether any of the granted permissions covers perm.
+func grants(granted []string, perm string) bool {
+	for _, g := range granted {
+		if g == perm || g == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(g, "*"); ok && strings.HasSuffix(prefix, ":") && strings.HasPrefix(perm, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Description: This file contains tests for the RBAC middleware.
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestRBAC tests permission checks against roles and direct grants.
+func TestRBAC(t *testing.T) {
+	// 1. Setup: The X-User header stands in for real authentication.
+	principals := map[string]*Principal{
+		"root":   {ID: "root", Roles: []string{"admin"}},
+		"ed":     {ID: "ed", Roles: []string{"editor"}},
+		"viewer": {ID: "viewer", Permissions: []string{"users:read"}},
+	}
+	r := router.New()
+	r.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if p, ok := principals[c.GetHeader("X-User")]; ok {
+				SetPrincipal(c, p)
+			}
+			next(c)
+		}
+	}, RBAC(RBACOptions{Roles: map[string][]string{
+		"admin":  {"*"},
+		"editor": {"users:*"},
+	}}))
+	ok := func(c *httpcontext.Context) { c.Status(http.StatusOK) }
+	r.GET("/health", ok)
+	r.GET("/users", ok, RequirePermissions("users:read"))
+	r.POST("/users", ok, RequirePermissions("users:write"))
+	r.POST("/billing", ok, RequirePermissions("billing:write", "users:read"))
+
+	tests := []struct {
+		name       string
+		user       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"open route", "", "GET", "/health", http.StatusOK},
+		{"anonymous", "", "GET", "/users", http.StatusUnauthorized},
+		{"direct grant", "viewer", "GET", "/users", http.StatusOK},
+		{"missing grant", "viewer", "POST", "/users", http.StatusForbidden},
+		{"prefix wildcard", "ed", "POST", "/users", http.StatusOK},
+		{"needs all permissions", "ed", "POST", "/billing", http.StatusForbidden},
+		{"admin", "root", "POST", "/billing", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.user != "" {
+				req.Header.Set("X-User", tc.user)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status: got %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if rr.Code == http.StatusOK {
+				return
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != httpcontext.ProblemContentType {
+				t.Errorf("Content-Type: got %q", ct)
+			}
+			var p httpcontext.Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil || p.Status != tc.wantStatus || p.Instance != tc.path {
+				t.Errorf("unexpected problem %+v (%v)", p, err)
+			}
+		})
+	}
+}
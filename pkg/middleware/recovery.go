@@ -0,0 +1,80 @@
+// Description: This file contains the panic recovery middleware. Without it, a
+// panicking handler makes net/http drop the connection, so the client gets no
+// response at all and the log only has net/http's terse message. Recovery turns
+// the panic into a logged stack trace and a 500 JSON error.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/report"
+)
+
+// RecoveryOptions configures RecoveryWith.
+type RecoveryOptions struct {
+	// Report, if set, is called with every recovered panic and its stack
+	// trace, e.g. to forward it to an error tracking service. It runs before
+	// the response is sent.
+	Report func(c *httpcontext.Context, recovered interface{}, stack []byte)
+
+	// Reporter, if set, receives every recovered panic as a report.Event,
+	// with the stack and a snapshot of the request. It runs before the
+	// response is sent, after Report.
+	Reporter report.Reporter
+}
+
+// Recovery returns middleware that recovers from panics in later middleware
+// and handlers. Add it first, so it covers everything after it.
+func Recovery() Middleware {
+	return RecoveryWith(RecoveryOptions{})
+}
+
+// RecoveryWith is Recovery with options.
+func RecoveryWith(opts RecoveryOptions) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// http.ErrAbortHandler is the documented way for a
+				// handler to abort a response on purpose; let net/http
+				// handle it as usual.
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				stack := debug.Stack()
+				c.Logger().Error("Panic handling request", "method", c.Request.Method, "path", c.Request.URL.Path, "panic", recovered, "stack", string(stack))
+				if opts.Report != nil {
+					opts.Report(c, recovered, stack)
+				}
+				if opts.Reporter != nil {
+					e := report.NewEvent(c, report.PanicError(recovered), http.StatusInternalServerError)
+					e.Panic, e.Stack = recovered, stack
+					opts.Reporter.Report(e)
+				}
+
+				c.Abort()
+				if c.Written() {
+					// Part of the response is already out; all we can do
+					// is stop here.
+					return
+				}
+				c.JSON(http.StatusInternalServerError, map[string]interface{}{
+					httpcontext.DefaultEnvelope.ErrorKey: httpcontext.EnvelopeError{
+						Status:    http.StatusInternalServerError,
+						Message:   http.StatusText(http.StatusInternalServerError),
+						RequestID: c.RequestID(),
+					},
+				})
+			}()
+			next(c)
+		}
+	}
+}
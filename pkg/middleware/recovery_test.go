@@ -0,0 +1,77 @@
+// Description: This file contains tests for the recovery middleware.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/report"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestRecovery tests that a panic becomes a 500 JSON error and is reported.
+func TestRecovery(t *testing.T) {
+	// 1. Setup: A handler that panics, with a reporting hook.
+	var reported interface{}
+	var stack []byte
+	var event *report.Event
+	r := router.New()
+	r.Use(RecoveryWith(RecoveryOptions{
+		Report: func(c *httpcontext.Context, rec interface{}, s []byte) {
+			reported, stack = rec, s
+		},
+		Reporter: report.ReporterFunc(func(e *report.Event) { event = e }),
+	}))
+	r.GET("/boom", func(c *httpcontext.Context) { panic("nil map") })
+
+	// 2. Execute
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/boom", nil))
+
+	// 3. Assert
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	if want := `{"error":{"status":500,"message":"Internal Server Error"}}`; strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("unexpected body %s", rr.Body.String())
+	}
+	if reported != "nil map" || !strings.Contains(string(stack), "recovery_test.go") {
+		t.Errorf("unexpected report: %v\n%s", reported, stack)
+	}
+	if event == nil || event.Panic != "nil map" || event.Err.Error() != "panic: nil map" || event.Request.Route != "/boom" || len(event.Stack) == 0 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+// TestRecovery_AfterWrite tests that a panic after the response started
+// doesn't append an error body.
+func TestRecovery_AfterWrite(t *testing.T) {
+	r := router.New()
+	r.Use(Recovery())
+	r.GET("/half", func(c *httpcontext.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("late")
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/half", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "partial" {
+		t.Errorf("unexpected response: %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+// TestRecovery_ErrAbortHandler tests that http.ErrAbortHandler is re-panicked.
+func TestRecovery_ErrAbortHandler(t *testing.T) {
+	h := Compose(func(c *httpcontext.Context) { panic(http.ErrAbortHandler) }, Recovery())
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("expected http.ErrAbortHandler to propagate")
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,80 @@
+// Description: This file contains the request ID middleware. It gives every
+// request an ID, reusing the one a client or proxy sent in X-Request-ID when it
+// looks sane, and echoes it in the response. The ID then shows up in the
+// access log, in lines logged with c.Logger, and in error responses, so a client
+// reporting "request 4f2a... failed" can be matched with the server's logs.
+
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// RequestIDOptions configures RequestIDWith.
+type RequestIDOptions struct {
+	// Header carries the ID in both directions. The default is
+	// httpcontext.RequestIDHeader ("X-Request-ID").
+	Header string
+
+	// Generate creates a new ID. The default is 16 random bytes in hex.
+	Generate func() string
+
+	// IgnoreIncoming always generates a new ID, for servers facing clients
+	// that shouldn't be able to choose what ends up in the logs.
+	IgnoreIncoming bool
+}
+
+// RequestID returns request ID middleware with the default options.
+func RequestID() Middleware {
+	return RequestIDWith(RequestIDOptions{})
+}
+
+// RequestIDWith returns request ID middleware configured by opts. It should
+// run early, before Logger and Recovery, so they see the ID.
+func RequestIDWith(opts RequestIDOptions) Middleware {
+	if opts.Header == "" {
+		opts.Header = httpcontext.RequestIDHeader
+	}
+	if opts.Generate == nil {
+		opts.Generate = randomRequestID
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			id := c.GetHeader(opts.Header)
+			if opts.IgnoreIncoming || !validRequestID(id) {
+				id = opts.Generate()
+			}
+			c.SetRequestID(id)
+			// Set it on the request too, so handlers proxying the request
+			// or calling other services with its headers pass it along.
+			c.Request.Header.Set(opts.Header, id)
+			c.SetHeader(opts.Header, id)
+			next(c)
+		}
+	}
+}
+
+// validRequestID reports whether an incoming ID is safe to reuse: not empty,
+// not huge, and only visible ASCII, so it can't forge log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// randomRequestID returns 16 random bytes in hex.
+func randomRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,84 @@
+// Description: This file contains tests for the request ID middleware.
+
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestRequestID tests reusing, generating, and echoing request IDs.
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RequestIDOptions
+		incoming string
+		want     string // "" means a generated ID
+	}{
+		{name: "reuses incoming", incoming: "abc-123", want: "abc-123"},
+		{name: "generates when missing"},
+		{name: "rejects unsafe incoming", incoming: "bad id\nforged"},
+		{name: "rejects huge incoming", incoming: strings.Repeat("a", 200)},
+		{name: "ignores incoming", opts: RequestIDOptions{IgnoreIncoming: true}, incoming: "abc-123"},
+		{name: "custom generator", opts: RequestIDOptions{Generate: func() string { return "fixed" }}, want: "fixed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			var seen string
+			h := Compose(func(c *httpcontext.Context) { seen = c.RequestID() }, RequestIDWith(tt.opts))
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			got := rr.Header().Get("X-Request-ID")
+			if got != seen || got == "" {
+				t.Fatalf("expected the handler's ID %q echoed, got %q", seen, got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected ID %q, got %q", tt.want, got)
+			}
+			if tt.want == "" && (got == tt.incoming || len(got) != 32) {
+				t.Errorf("expected a generated ID, got %q", got)
+			}
+		})
+	}
+}
+
+// TestRequestID_ErrorResponse tests that error responses carry the ID.
+func TestRequestID_ErrorResponse(t *testing.T) {
+	// 1. Setup
+	h := Compose(func(c *httpcontext.Context) {
+		c.Fail(http.StatusNotFound, errors.New("no such user"))
+	}, RequestID())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	rr := httptest.NewRecorder()
+
+	// 2. Execute
+	h.ServeHTTP(rr, req)
+
+	// 3. Assert
+	var body struct {
+		Error httpcontext.EnvelopeError `json:"error"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.RequestID != "req-7" || body.Error.Message != "no such user" {
+		t.Errorf("unexpected error body: %+v", body.Error)
+	}
+}
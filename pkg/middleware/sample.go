@@ -0,0 +1,58 @@
+// Description: This file contains request sampling for verbose logs. Debug
+// lines and full request dumps are too much for every request in production,
+// but they're what explains a misbehaving one. Sample picks a fraction of the
+// requests, plus those sending a secret debug header, and marks them with
+// c.SetSampled: their c.Logger writes debug lines too, and BodyDump can be
+// limited to them:
+//
+//	r.Use(middleware.Sample(middleware.SampleOptions{Rate: 0.01, Token: os.Getenv("DEBUG_LOG_TOKEN")}))
+//	r.Use(middleware.BodyDumpWith(middleware.BodyDumpOptions{OnlySampled: true}))
+//
+//	curl -H "X-Debug-Log: $DEBUG_LOG_TOKEN" https://api.example.com/users
+
+package middleware
+
+import (
+	"math/rand/v2"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// DefaultSampleHeader is the header requests send the sampling token in.
+const DefaultSampleHeader = "X-Debug-Log"
+
+// SampleOptions configures Sample.
+type SampleOptions struct {
+	// Rate is the fraction of requests sampled, from 0 (none) to 1 (all).
+	Rate float64
+
+	// Token, if set, samples every request sending it in Header. Without
+	// one the header is ignored: anyone could otherwise turn on verbose
+	// logging, and with it the log volume, at will.
+	Token string
+
+	// Header is the header carrying the token. The default is
+	// DefaultSampleHeader.
+	Header string
+}
+
+// Sample returns middleware marking the sampled requests. Add it before the
+// middleware and handlers that log verbosely.
+func Sample(opts SampleOptions) Middleware {
+	if opts.Header == "" {
+		opts.Header = DefaultSampleHeader
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			switch {
+			case opts.Rate > 0 && rand.Float64() < opts.Rate:
+				c.SetSampled(true)
+			case opts.Token != "":
+				if token := c.Request.Header.Get(opts.Header); token != "" && secureEqual(token, opts.Token) {
+					c.SetSampled(true)
+				}
+			}
+			next(c)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Description: This file contains tests for request sampling.
+
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestSample tests which requests are sampled.
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   SampleOptions
+		header string
+		want   bool
+	}{
+		{"off", SampleOptions{}, "", false},
+		{"all", SampleOptions{Rate: 1}, "", true},
+		{"token", SampleOptions{Token: "s3cret-token"}, "s3cret-token", true},
+		{"wrong token", SampleOptions{Token: "s3cret-token"}, "guess", false},
+		{"header without token", SampleOptions{}, "anything", false},
+		{"custom header", SampleOptions{Token: "s3cret-token", Header: "X-Trace-Me"}, "s3cret-token", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			var sampled bool
+			h := Compose(func(c *httpcontext.Context) { sampled = c.Sampled() }, Sample(tc.opts))
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				req.Header.Set(DefaultSampleHeader, tc.header)
+			}
+
+			// 2. Execute
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			// 3. Assert
+			if sampled != tc.want {
+				t.Errorf("expected sampled=%v, got %v", tc.want, sampled)
+			}
+		})
+	}
+}
+
+// TestSample_Verbose tests that sampled requests log debug lines and get
+// dumped, and others don't.
+func TestSample_Verbose(t *testing.T) {
+	// 1. Setup: The default logger is at the info level.
+	var logs, dumps bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	h := Compose(func(c *httpcontext.Context) {
+		c.Logger().Debug("Details", "path", c.Request.URL.Path)
+		c.Status(http.StatusOK)
+	}, Sample(SampleOptions{Token: "s3cret-token"}), BodyDumpWith(BodyDumpOptions{Output: &dumps, OnlySampled: true}))
+
+	// 2. Execute
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/plain", nil))
+	req := httptest.NewRequest("GET", "/sampled", nil)
+	req.Header.Set(DefaultSampleHeader, "s3cret-token")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 3. Assert
+	if !strings.Contains(logs.String(), "DEBUG Details path=/sampled") || strings.Contains(logs.String(), "/plain") {
+		t.Errorf("unexpected logs:\n%s", logs.String())
+	}
+	if !strings.Contains(dumps.String(), "--> GET /sampled") || strings.Contains(dumps.String(), "/plain") {
+		t.Errorf("unexpected dumps:\n%s", dumps.String())
+	}
+}
@@ -0,0 +1,140 @@
+// Description: This file contains middleware verifying HMAC request
+// signatures, the scheme webhook senders (GitHub, Stripe, Slack, ...) use to
+// prove a callback really comes from them: the sender signs the raw body with
+// a shared secret and sends the signature in a header. Optionally the signature
+// covers a timestamp too, and old requests are refused, so a captured callback
+// can't be replayed later.
+
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// SignatureEncoding is how the signature is written in its header.
+type SignatureEncoding int
+
+// The supported signature encodings.
+const (
+	SignatureHex SignatureEncoding = iota
+	SignatureBase64
+)
+
+// SignatureOptions configures VerifySignature.
+type SignatureOptions struct {
+	// Secrets are the shared secrets. A signature made with any of them is
+	// accepted, which allows rotating the secret without downtime.
+	Secrets [][]byte
+
+	// Header carries the signature. The default is "X-Signature".
+	Header string
+
+	// Prefix is stripped from the header value, e.g. "sha256=" for
+	// GitHub's X-Hub-Signature-256.
+	Prefix string
+
+	// Hash is the HMAC's hash function. The default is sha256.New.
+	Hash func() hash.Hash
+
+	// Encoding is how the signature is encoded. The default is hex.
+	Encoding SignatureEncoding
+
+	// TimestampHeader, if set, names a header with the Unix time the
+	// request was signed at. The signed message is then
+	// "<timestamp>.<body>", and requests outside Tolerance are refused.
+	TimestampHeader string
+
+	// Tolerance is how far the timestamp may be from our clock. The
+	// default is five minutes.
+	Tolerance time.Duration
+}
+
+// VerifySignature returns middleware that lets a request through only if
+// it's signed with one of opts.Secrets. Others get 401 Unauthorized. The body
+// is read to check it, and rewound for the handler.
+func VerifySignature(opts SignatureOptions) Middleware {
+	if len(opts.Secrets) == 0 {
+		panic(errors.New("middleware: VerifySignature needs at least one secret"))
+	}
+	if opts.Header == "" {
+		opts.Header = "X-Signature"
+	}
+	if opts.Hash == nil {
+		opts.Hash = sha256.New
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if err := checkSignature(c, &opts, time.Now()); err != nil {
+				c.Logger().Warn("Rejected signature", "method", c.Request.Method, "path", c.Request.URL.Path, "err", err)
+				abortWithError(c, http.StatusUnauthorized, "invalid signature")
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// checkSignature verifies the request's signature.
+func checkSignature(c *httpcontext.Context, opts *SignatureOptions, now time.Time) error {
+	value, ok := strings.CutPrefix(c.GetHeader(opts.Header), opts.Prefix)
+	if !ok || value == "" {
+		return fmt.Errorf("missing %s header", opts.Header)
+	}
+	var got []byte
+	var err error
+	if opts.Encoding == SignatureBase64 {
+		got, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		got, err = hex.DecodeString(value)
+	}
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+
+	var timestamp string
+	if opts.TimestampHeader != "" {
+		timestamp = c.GetHeader(opts.TimestampHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("missing or malformed %s header", opts.TimestampHeader)
+		}
+		skew := now.Sub(time.Unix(seconds, 0))
+		if skew > opts.Tolerance || skew < -opts.Tolerance {
+			return fmt.Errorf("timestamp is %v off", skew.Round(time.Second))
+		}
+	}
+
+	body, err := c.Body()
+	if err != nil {
+		return err
+	}
+	for _, secret := range opts.Secrets {
+		mac := hmac.New(opts.Hash, secret)
+		if opts.TimestampHeader != "" {
+			mac.Write([]byte(timestamp + "."))
+		}
+		mac.Write(body)
+		// hmac.Equal compares in constant time, so timing doesn't reveal
+		// how much of a forged signature was right.
+		if hmac.Equal(got, mac.Sum(nil)) {
+			return nil
+		}
+	}
+	return errors.New("signature mismatch")
+}
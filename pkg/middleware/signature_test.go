@@ -0,0 +1,86 @@
+// Description: This file contains tests for the signature verification
+// middleware.
+
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// hmacSHA256 signs message with secret.
+func hmacSHA256(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// TestVerifySignature tests valid, forged, and stale signatures.
+func TestVerifySignature(t *testing.T) {
+	body := `{"event":"paid"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	github := SignatureOptions{Secrets: [][]byte{[]byte("new"), []byte("old")}, Header: "X-Hub-Signature-256", Prefix: "sha256="}
+	stamped := SignatureOptions{Secrets: [][]byte{[]byte("k")}, TimestampHeader: "X-Timestamp", Encoding: SignatureBase64}
+
+	tests := []struct {
+		name       string
+		opts       SignatureOptions
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"valid", github, map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(hmacSHA256("new", body))}, http.StatusOK},
+		{"rotated secret", github, map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(hmacSHA256("old", body))}, http.StatusOK},
+		{"wrong secret", github, map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(hmacSHA256("guess", body))}, http.StatusUnauthorized},
+		{"missing prefix", github, map[string]string{"X-Hub-Signature-256": hex.EncodeToString(hmacSHA256("new", body))}, http.StatusUnauthorized},
+		{"missing header", github, nil, http.StatusUnauthorized},
+		{"timestamped", stamped, map[string]string{
+			"X-Timestamp": now, "X-Signature": base64.StdEncoding.EncodeToString(hmacSHA256("k", now+"."+body)),
+		}, http.StatusOK},
+		{"stale timestamp", stamped, map[string]string{
+			"X-Timestamp": old, "X-Signature": base64.StdEncoding.EncodeToString(hmacSHA256("k", old+"."+body)),
+		}, http.StatusUnauthorized},
+		{"timestamp not signed", stamped, map[string]string{
+			"X-Timestamp": now, "X-Signature": base64.StdEncoding.EncodeToString(hmacSHA256("k", body)),
+		}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup: The handler must still see the whole body.
+			var seen string
+			h := Compose(func(c *httpcontext.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				seen = string(b)
+				c.Status(http.StatusOK)
+			}, VerifySignature(tt.opts))
+			req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus == http.StatusOK && seen != body {
+				t.Errorf("expected the handler to read the body, got %q", seen)
+			}
+		})
+	}
+}
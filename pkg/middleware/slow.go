@@ -0,0 +1,112 @@
+// Description: This file contains slow request detection. Requests taking
+// longer than a threshold are logged with their route, path parameters, and
+// status, and counted per route, which surfaces tail latency problems without
+// full tracing. The counts can be published with expvar:
+//
+//	slow := middleware.NewSlowRequests(middleware.SlowRequestOptions{Threshold: 500 * time.Millisecond})
+//	r.Use(slow.Middleware())
+//	expvar.Publish("slow_requests", slow)
+
+package middleware
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// SlowRequestOptions configures a SlowRequests detector.
+type SlowRequestOptions struct {
+	// Threshold is the latency above which a request counts as slow. The
+	// default is one second.
+	Threshold time.Duration
+
+	// OnSlow, if set, is called for every slow request after it was logged
+	// and counted, e.g. to record it in the application's metrics.
+	OnSlow func(c *httpcontext.Context, latency time.Duration)
+}
+
+// SlowRequests detects and counts slow requests. It's safe for concurrent use.
+type SlowRequests struct {
+	opts  SlowRequestOptions
+	total atomic.Uint64
+
+	// routes maps "METHOD /pattern" to its *atomic.Uint64 count.
+	routes sync.Map
+}
+
+// NewSlowRequests creates a detector.
+func NewSlowRequests(opts SlowRequestOptions) *SlowRequests {
+	if opts.Threshold <= 0 {
+		opts.Threshold = time.Second
+	}
+	return &SlowRequests{opts: opts}
+}
+
+// Total returns how many slow requests there were.
+func (s *SlowRequests) Total() uint64 {
+	return s.total.Load()
+}
+
+// Counts returns the number of slow requests per route, keyed by
+// "METHOD /pattern". Requests that matched no route are counted under the
+// method alone.
+func (s *SlowRequests) Counts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	s.routes.Range(func(key, value interface{}) bool {
+		counts[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+	return counts
+}
+
+// String returns the counts as JSON, making SlowRequests an expvar.Var.
+func (s *SlowRequests) String() string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"threshold_ms": s.opts.Threshold.Milliseconds(),
+		"total":        s.Total(),
+		"routes":       s.Counts(),
+	})
+	return string(data)
+}
+
+// Middleware returns the middleware timing requests. Put it early in the chain
+// so the latency includes the other middleware.
+func (s *SlowRequests) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			start := time.Now()
+			next(c)
+			if latency := time.Since(start); latency > s.opts.Threshold {
+				s.record(c, latency)
+			}
+		}
+	}
+}
+
+// record logs and counts a slow request.
+func (s *SlowRequests) record(c *httpcontext.Context, latency time.Duration) {
+	key := strings.TrimSpace(c.Request.Method + " " + c.FullPath())
+	count, ok := s.routes.Load(key)
+	if !ok {
+		count, _ = s.routes.LoadOrStore(key, new(atomic.Uint64))
+	}
+	count.(*atomic.Uint64).Add(1)
+	s.total.Add(1)
+
+	var params strings.Builder
+	for _, p := range c.Params() {
+		params.WriteString(" " + p.Key + "=" + p.Value)
+	}
+	c.Logf("Slow request: %s %s (%s) took %v, threshold %v, status %d%s",
+		c.Request.Method, c.Request.URL.RequestURI(), c.FullPath(),
+		latency.Round(time.Millisecond), s.opts.Threshold, c.ResponseStatus(), params.String())
+
+	if s.opts.OnSlow != nil {
+		s.opts.OnSlow(c, latency)
+	}
+}
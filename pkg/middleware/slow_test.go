@@ -0,0 +1,60 @@
+// Description: This file contains tests for slow request detection.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestSlowRequests tests which requests are logged and counted.
+func TestSlowRequests(t *testing.T) {
+	// 1. Setup
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var hooked int
+	slow := NewSlowRequests(SlowRequestOptions{
+		Threshold: 20 * time.Millisecond,
+		OnSlow:    func(*httpcontext.Context, time.Duration) { hooked++ },
+	})
+	r := router.New()
+	r.Use(slow.Middleware())
+	r.GET("/users/:id", func(c *httpcontext.Context) {
+		if c.Param("id") == "slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// 2. Execute
+	for _, path := range []string{"/users/1", "/users/slow", "/users/2", "/users/slow"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// 3. Assert
+	if slow.Total() != 2 || hooked != 2 {
+		t.Errorf("expected 2 slow requests, got %d (hook: %d)", slow.Total(), hooked)
+	}
+	if got := slow.Counts()["GET /users/:id"]; got != 2 {
+		t.Errorf("expected 2 for the route, got %v", slow.Counts())
+	}
+	if !strings.Contains(logs.String(), "Slow request: GET /users/slow (/users/:id)") || !strings.Contains(logs.String(), "id=slow") {
+		t.Errorf("unexpected log:\n%s", logs.String())
+	}
+	var expvarValue struct{ Total uint64 }
+	if err := json.Unmarshal([]byte(slow.String()), &expvarValue); err != nil || expvarValue.Total != 2 {
+		t.Errorf("unexpected String(): %s", slow.String())
+	}
+}
@@ -0,0 +1,97 @@
+// Description: This file contains basic request statistics: how many
+// requests were served, how often each route was hit, how many failed, and
+// for how long the server has been up. It's enough monitoring for setups
+// without Prometheus, published with expvar, which the admin server's
+// /metrics serves:
+//
+//	stats := middleware.NewRequestStats()
+//	r.Use(stats.Middleware())
+//	expvar.Publish("requests", stats)
+
+package middleware
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// unmatchedRoute is the key requests that matched no route are counted
+// under, so unknown paths can't grow the map.
+const unmatchedRoute = "unmatched"
+
+// RequestStats counts requests. It's safe for concurrent use.
+type RequestStats struct {
+	start                       time.Time
+	total, client4xx, server5xx atomic.Uint64
+
+	// routes maps "METHOD /pattern" to its *atomic.Uint64 count.
+	routes sync.Map
+}
+
+// RequestStatsSnapshot is the counts at one moment, also the JSON shape.
+type RequestStatsSnapshot struct {
+	Total         uint64            `json:"total"`
+	ClientErrors  uint64            `json:"client_errors"` // 4xx responses
+	ServerErrors  uint64            `json:"server_errors"` // 5xx responses
+	Routes        map[string]uint64 `json:"routes"`
+	UptimeSeconds float64           `json:"uptime_seconds"`
+}
+
+// NewRequestStats creates the counters. The uptime counts from now.
+func NewRequestStats() *RequestStats {
+	return &RequestStats{start: time.Now()}
+}
+
+// Snapshot returns the current counts. Routes are keyed by
+// "METHOD /pattern"; requests that matched none are counted as "unmatched".
+func (s *RequestStats) Snapshot() RequestStatsSnapshot {
+	routes := make(map[string]uint64)
+	s.routes.Range(func(key, value interface{}) bool {
+		routes[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+	return RequestStatsSnapshot{
+		Total:         s.total.Load(),
+		ClientErrors:  s.client4xx.Load(),
+		ServerErrors:  s.server5xx.Load(),
+		Routes:        routes,
+		UptimeSeconds: time.Since(s.start).Seconds(),
+	}
+}
+
+// String returns the snapshot as JSON, making RequestStats an expvar.Var.
+func (s *RequestStats) String() string {
+	data, _ := json.Marshal(s.Snapshot())
+	return string(data)
+}
+
+// Middleware returns the middleware counting requests once they're done. Put
+// it early in the chain, so requests rejected by later middleware count too.
+func (s *RequestStats) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			next(c)
+
+			key := unmatchedRoute
+			if route := c.FullPath(); route != "" {
+				key = c.Request.Method + " " + route
+			}
+			count, ok := s.routes.Load(key)
+			if !ok {
+				count, _ = s.routes.LoadOrStore(key, new(atomic.Uint64))
+			}
+			count.(*atomic.Uint64).Add(1)
+			s.total.Add(1)
+			switch status := c.ResponseStatus(); {
+			case status >= 500:
+				s.server5xx.Add(1)
+			case status >= 400:
+				s.client4xx.Add(1)
+			}
+		}
+	}
+}
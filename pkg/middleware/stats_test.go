@@ -0,0 +1,49 @@
+// Description: This file contains tests for the request statistics.
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+)
+
+// TestRequestStats tests the counts by route and by failure.
+func TestRequestStats(t *testing.T) {
+	// 1. Setup
+	stats := NewRequestStats()
+	r := router.New()
+	r.Use(stats.Middleware())
+	r.GET("/users/:id", func(c *httpcontext.Context) {
+		switch c.Param("id") {
+		case "missing":
+			c.Status(http.StatusNotFound)
+		case "broken":
+			c.Status(http.StatusInternalServerError)
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+
+	// 2. Execute
+	for _, path := range []string{"/users/1", "/users/missing", "/users/broken", "/users/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// 3. Assert
+	got := stats.Snapshot()
+	if got.Total != 5 || got.ClientErrors != 2 || got.ServerErrors != 1 {
+		t.Errorf("expected 5 requests, 2 client and 1 server error, got %+v", got)
+	}
+	if got.Routes["GET /users/:id"] != 4 || got.Routes[unmatchedRoute] != 1 || len(got.Routes) != 2 {
+		t.Errorf("unexpected routes: %v", got.Routes)
+	}
+	var expvarValue RequestStatsSnapshot
+	if err := json.Unmarshal([]byte(stats.String()), &expvarValue); err != nil || expvarValue.Total != 5 || expvarValue.UptimeSeconds <= 0 {
+		t.Errorf("unexpected String(): %s", stats.String())
+	}
+}
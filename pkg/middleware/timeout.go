@@ -0,0 +1,178 @@
+// Description: This file contains the timeout middleware. It gives a handler a
+// deadline: the request's context is cancelled when it passes, and if the
+// handler hasn't answered by then, the client gets 504 Gateway Timeout in the
+// standard error envelope. Whatever the handler tries to write afterwards is
+// refused with http.ErrHandlerTimeout, so a slow handler can never corrupt the
+// timeout response.
+//
+// Unlike router.LimitDuration (which wraps http.TimeoutHandler and answers a
+// plain-text 503), this keeps the request ID, sampling mark, JSON options,
+// recorded errors, and panics flowing between it and the middleware around
+// it.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Timeout returns middleware that gives handlers at most d to respond.
+//
+// The handler's response is buffered until it returns, so it suits ordinary
+// API responses, not streaming ones. Handlers doing slow work should watch
+// c.Done() and stop early; the handler keeps running after the timeout
+// otherwise, it just can't answer anymore.
+func Timeout(d time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+			defer cancel()
+
+			// The handler runs on its own goroutine and may outlive this
+			// call, while c goes back to the router's pool when we return.
+			// So it gets a context of its own, writing into a buffer.
+			tw := &timeoutWriter{ctx: ctx, header: make(http.Header)}
+			inner := new(httpcontext.Context)
+			inner.Reset(tw, c.Request.WithContext(ctx))
+			info := c.RouteInfo()
+			inner.SetRouteInfo(&info)
+			inner.SetParams(append(httpcontext.Params(nil), c.Params()...))
+			inner.SetRequestID(c.RequestID())
+			inner.SetSampled(c.Sampled())
+			inner.SetJSONOptions(c.JSONOptions())
+			for k, v := range c.Writer.Header() {
+				tw.header[k] = append([]string(nil), v...)
+			}
+
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next(inner)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// Re-panic here, so Recovery around us still sees it.
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			finished := false
+			select {
+			case <-done:
+				finished = true
+			default:
+			}
+			// A handler that finished in time, without any refused writes,
+			// gets its response sent. Everything else is a timeout.
+			if !finished || tw.timedOut {
+				tw.timedOut = true
+				log := c.Logger()
+				log.Warn("Handler timed out", "method", c.Request.Method, "path", c.Request.URL.Path, "after", d)
+				abortWithError(c, http.StatusGatewayTimeout, "request timed out")
+				// Recovery can't see a panic after the timeout, so it's
+				// logged here instead of vanishing.
+				method, path := c.Request.Method, c.Request.URL.Path
+				go func() {
+					select {
+					case p := <-panicked:
+						log.Error("Handler panicked after timing out", "method", method, "path", path, "panic", p)
+					case <-done:
+					}
+				}()
+				return
+			}
+			for _, e := range inner.Errors() {
+				*c.Error(e.Err) = *e
+			}
+			if inner.IsAborted() {
+				c.Abort()
+			}
+			tw.flushTo(c.Writer)
+		}
+	}
+}
+
+// timeoutWriter buffers a handler's response until the handler finishes, and
+// refuses writes once the deadline has passed. The mutex orders the handler's
+// writes against the timeout.
+type timeoutWriter struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered response headers.
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code.
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired() || w.status != 0 {
+		return
+	}
+	w.status = code
+}
+
+// Write buffers part of the body, or fails after the timeout.
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// expired reports whether the deadline has passed, marking the response as
+// timed out if so. The caller holds w.mu.
+func (w *timeoutWriter) expired() bool {
+	if w.ctx.Err() != nil {
+		w.timedOut = true
+	}
+	return w.timedOut
+}
+
+// flushTo sends the buffered response. The caller holds w.mu.
+func (w *timeoutWriter) flushTo(dst http.ResponseWriter) {
+	if w.status == 0 {
+		// Nothing was written; leave the response to the middleware
+		// around us, as if we weren't here.
+		return
+	}
+	h := dst.Header()
+	for k := range h {
+		if _, ok := w.header[k]; !ok {
+			delete(h, k)
+		}
+	}
+	for k, v := range w.header {
+		h[k] = v
+	}
+	dst.WriteHeader(w.status)
+	dst.Write(w.buf.Bytes())
+}
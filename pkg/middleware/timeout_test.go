@@ -0,0 +1,156 @@
+// Description: This file contains tests for the timeout middleware.
+
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestTimeout tests fast handlers, slow handlers, and late writes.
+func TestTimeout(t *testing.T) {
+	// 1. Setup: A slow handler reports how its late write went.
+	lateWrite := make(chan error, 1)
+	cancelled := make(chan bool, 1)
+	slow := func(c *httpcontext.Context) {
+		<-c.Done()
+		cancelled <- c.Err() != nil
+		_, err := c.Writer.Write([]byte("too late"))
+		lateWrite <- err
+	}
+	fast := func(c *httpcontext.Context) {
+		c.SetHeader("X-Fast", "yes")
+		c.String(http.StatusAccepted, "done")
+	}
+
+	tests := []struct {
+		name       string
+		handler    HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{"fast handler", fast, http.StatusAccepted, "done"},
+		{"slow handler", slow, http.StatusGatewayTimeout, `"request timed out"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compose(tt.handler, Timeout(20*time.Millisecond))
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			// 3. Assert
+			if rr.Code != tt.wantStatus || !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Fatalf("expected %d with %s, got %d %q", tt.wantStatus, tt.wantBody, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if !<-cancelled {
+		t.Error("expected the slow handler's context to be cancelled")
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("expected the late write to fail with ErrHandlerTimeout, got %v", err)
+	}
+}
+
+// TestTimeout_Propagation tests that headers, errors, and panics cross over
+// to the middleware around Timeout.
+func TestTimeout_Propagation(t *testing.T) {
+	// 1. Setup
+	h := Compose(func(c *httpcontext.Context) {
+		c.Error(errors.New("soft failure")).SetStatus(http.StatusConflict)
+		panic("boom")
+	}, Recovery(), RequestID(), Timeout(time.Second))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	rr := httptest.NewRecorder()
+
+	// 2. Execute
+	h.ServeHTTP(rr, req)
+
+	// 3. Assert: Recovery outside the timeout caught the panic.
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), `"request_id":"req-1"`) {
+		t.Errorf("expected a 500 with the request ID, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	// Errors recorded by the handler are visible outside.
+	var outer *httpcontext.Context
+	h = Compose(func(c *httpcontext.Context) {
+		c.Error(errors.New("soft failure")).SetStatus(http.StatusConflict)
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			next(c)
+			outer = c
+			if errs := c.Errors(); len(errs) != 1 || errs[0].StatusCode() != http.StatusConflict {
+				t.Errorf("expected the handler's error, got %v", errs)
+			}
+		}
+	}, Timeout(time.Second))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if outer == nil {
+		t.Error("expected the outer middleware to run")
+	}
+}
+
+// TestTimeout_Context tests that the handler sees the request's sampling
+// mark and JSON options, and that a panic after the timeout is logged.
+func TestTimeout_Context(t *testing.T) {
+	// 1. Setup: The logs go to a pipe, as the late panic is logged after
+	// the response.
+	pr, pw := io.Pipe()
+	defer pr.Close() // after the output is reset, so nothing blocks on it
+	log.SetOutput(pw)
+	defer log.SetOutput(os.Stderr)
+	lines := bufio.NewScanner(pr)
+	var sampled bool
+	h := Compose(func(c *httpcontext.Context) {
+		sampled = c.Sampled()
+		c.JSON(http.StatusOK, map[string]int{"a": 1})
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			c.SetSampled(true)
+			c.SetJSONOptions(httpcontext.JSONOptions{Indent: " "})
+			next(c)
+		}
+	}, Timeout(time.Second))
+	release := make(chan struct{})
+	late := Compose(func(c *httpcontext.Context) {
+		<-release
+		panic("late boom")
+	}, Timeout(10*time.Millisecond))
+
+	// 2. Execute
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	go func() {
+		late.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/late", nil))
+		close(release)
+	}()
+
+	// 3. Assert
+	if !sampled || rr.Body.String() != "{\n \"a\": 1\n}\n" {
+		t.Errorf("expected a sampled handler and indented JSON, got %v and %q", sampled, rr.Body.String())
+	}
+	for lines.Scan() {
+		if strings.Contains(lines.Text(), "Handler panicked after timing out") {
+			if !strings.Contains(lines.Text(), "late boom") {
+				t.Errorf("expected the panic value in %q", lines.Text())
+			}
+			return
+		}
+	}
+	t.Error("expected the late panic to be logged")
+}
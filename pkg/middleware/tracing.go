@@ -0,0 +1,68 @@
+// Description: This file contains the tracing middleware. It continues the
+// caller's trace from the W3C traceparent header (or starts a new one), runs
+// the request inside a server span named after the route pattern, like
+// "GET /users/:id", and records the response status and any errors on it.
+// Handlers find the span in the request context and can start child spans:
+//
+//	_, span := tracer.Start(c, "users.load")
+//	defer span.End()
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/tracing"
+)
+
+// Tracing returns middleware tracing every request with tracer.
+func Tracing(tracer *tracing.Tracer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			req := c.Request
+			ctx := tracing.Extract(req.Context(), req.Header)
+			ctx, span := tracer.Start(ctx, req.Method, tracing.WithKind(tracing.KindServer),
+				tracing.WithAttributes(map[string]interface{}{
+					"http.request.method": req.Method,
+					"url.path":            req.URL.Path,
+					"client.address":      c.ClientIP(),
+				}))
+			c.Request = req.WithContext(ctx)
+			// The span ends even if the handler panics, recording the
+			// panic, before Recovery further out turns it into a 500.
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					span.RecordError(fmt.Errorf("panic: %v", recovered))
+					span.SetAttribute("http.response.status_code", http.StatusInternalServerError)
+					span.End()
+					panic(recovered)
+				}
+			}()
+
+			next(c)
+
+			// Named by route pattern, not the concrete path, so that all
+			// requests to one endpoint group together.
+			if route := c.FullPath(); route != "" {
+				span.SetName(req.Method + " " + route)
+				span.SetAttribute("http.route", route)
+			}
+			status := c.ResponseStatus()
+			span.SetAttribute("http.response.status_code", status)
+			if id := c.RequestID(); id != "" {
+				span.SetAttribute("http.request.id", id)
+			}
+			for _, e := range c.Errors() {
+				span.RecordError(e.Err)
+			}
+			// Following the OpenTelemetry conventions, only server errors
+			// fail a server span; a 404 is the client's problem.
+			if status >= 500 {
+				span.SetStatus(tracing.StatusError, http.StatusText(status))
+			}
+			span.End()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Description: This file contains tests for the tracing middleware.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/router"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/tracing"
+)
+
+// TestTracing tests server spans, child spans, and trace continuation.
+func TestTracing(t *testing.T) {
+	// 1. Setup
+	var spans []tracing.SpanData
+	tracer := tracing.NewTracer(tracing.ExporterFunc(func(s tracing.SpanData) { spans = append(spans, s) }))
+	r := router.New()
+	r.Use(Tracing(tracer))
+	r.GET("/users/:id", func(c *httpcontext.Context) {
+		_, span := tracer.Start(c, "users.load")
+		span.End()
+		c.Error(errors.New("cache miss"))
+		c.Status(http.StatusServiceUnavailable)
+	})
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// 2. Execute
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 3. Assert
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name != "GET /users/:id" || server.Kind != tracing.KindServer || server.Attributes["http.route"] != "/users/:id" {
+		t.Errorf("unexpected server span %+v", server)
+	}
+	if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("expected the caller's trace to continue, got %+v", server)
+	}
+	if server.Attributes["http.response.status_code"] != http.StatusServiceUnavailable ||
+		server.Status != tracing.StatusError || len(server.Events) != 1 {
+		t.Errorf("expected the status and error to be recorded, got %+v", server)
+	}
+	if child.ParentSpanID != server.SpanID || child.TraceID != server.TraceID {
+		t.Errorf("expected the handler's span to be a child, got %+v", child)
+	}
+}
+
+// TestTracing_Panic tests that a panicking handler still ends its span.
+func TestTracing_Panic(t *testing.T) {
+	// 1. Setup
+	var spans []tracing.SpanData
+	tracer := tracing.NewTracer(tracing.ExporterFunc(func(s tracing.SpanData) { spans = append(spans, s) }))
+	h := Compose(func(c *httpcontext.Context) { panic("boom") }, Recovery(), Tracing(tracer))
+
+	// 2. Execute
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	// 3. Assert
+	if rr.Code != http.StatusInternalServerError || len(spans) != 1 || spans[0].Status != tracing.StatusError {
+		t.Errorf("expected a failed span and a 500, got %d %+v", rr.Code, spans)
+	}
+}
@@ -0,0 +1,117 @@
+// Description: This package contains the hook for reporting failed requests to
+// an error tracking service such as Sentry or Rollbar. The recovery middleware
+// reports panics, and the router's error-handling stage reports server errors,
+// each as an Event with the stack and a snapshot of the request. Supporting a
+// service is a matter of writing a Reporter that converts events to its
+// format, so the packages here don't depend on any service's SDK:
+//
+//	reporter := report.ReporterFunc(func(e *report.Event) {
+//		sentry.CaptureEvent(toSentry(e))
+//	})
+//	r.Use(router.HandleErrorsWith(router.ErrorOptions{Reporter: reporter}))
+//	r.Use(middleware.RecoveryWith(middleware.RecoveryOptions{Reporter: reporter}))
+
+package report
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Reporter receives the failures of requests. Report is called on the
+// request's goroutine before the response is sent, so reporters that talk to
+// a service should queue the event and send it in the background. The event
+// isn't used after Report returns.
+type Reporter interface {
+	Report(e *Event)
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(e *Event)
+
+// Report calls f(e).
+func (f ReporterFunc) Report(e *Event) { f(e) }
+
+// Event is one failure.
+type Event struct {
+	// Time is when the failure happened.
+	Time time.Time
+
+	// Err is the error; for a panic, the recovered value as an error.
+	Err error
+
+	// Panic is the value a panicking handler was recovered with, or nil if
+	// the event is for an error.
+	Panic interface{}
+
+	// Stack is the stack trace of the panic, or of where the error was
+	// recorded, in the format of runtime/debug.Stack.
+	Stack []byte
+
+	// Status is the HTTP status the client gets.
+	Status int
+
+	// Request is what the request looked like.
+	Request Request
+}
+
+// Request is a snapshot of a request, safe to keep after the request ended.
+type Request struct {
+	ID       string            // the request ID, see httpcontext.RequestIDHeader
+	Method   string            // e.g. "GET"
+	URL      string            // the path and query, e.g. "/users/42?expand=teams"
+	Route    string            // the matched pattern, e.g. "/users/:id"; "" if none matched
+	Params   map[string]string // the path parameters
+	Header   http.Header       // with RedactedHeaders masked
+	ClientIP string
+}
+
+// RedactedHeaders are the headers whose values are replaced with
+// "[REDACTED]" in snapshots, so credentials aren't sent to a third party.
+var RedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Debug-Token"}
+
+// NewEvent creates an event for a failure of the request c. The panic and
+// stack are filled in by the caller.
+func NewEvent(c *httpcontext.Context, err error, status int) *Event {
+	return &Event{
+		Time:    time.Now(),
+		Err:     err,
+		Status:  status,
+		Request: Snapshot(c),
+	}
+}
+
+// Snapshot returns what c's request looks like.
+func Snapshot(c *httpcontext.Context) Request {
+	req := Request{
+		ID:       c.RequestID(),
+		Method:   c.Request.Method,
+		URL:      c.Request.URL.RequestURI(),
+		Route:    c.FullPath(),
+		Params:   make(map[string]string, len(c.Params())),
+		Header:   c.Request.Header.Clone(),
+		ClientIP: c.ClientIP(),
+	}
+	for _, p := range c.Params() {
+		req.Params[p.Key] = p.Value
+	}
+	for name := range req.Header {
+		if slices.Contains(RedactedHeaders, name) {
+			req.Header[name] = []string{"[REDACTED]"}
+		}
+	}
+	return req
+}
+
+// PanicError turns a recovered value into an error: the value itself if it
+// is one, or its text.
+func PanicError(recovered interface{}) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", recovered)
+}
@@ -0,0 +1,62 @@
+// Description: This file contains tests for request snapshots.
+
+package report
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// TestSnapshot tests that a snapshot has the request's details, minus the
+// credentials.
+func TestSnapshot(t *testing.T) {
+	// 1. Setup
+	req := httptest.NewRequest("POST", "/users?notify=1", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("User-Agent", "curl/8.0")
+	c := new(httpcontext.Context)
+	c.Reset(httptest.NewRecorder(), req)
+	c.SetRequestID("abc")
+
+	// 2. Execute
+	got := Snapshot(c)
+
+	// 3. Assert
+	if got.ID != "abc" || got.Method != "POST" || got.URL != "/users?notify=1" || got.ClientIP != "203.0.113.7" {
+		t.Errorf("unexpected snapshot: %+v", got)
+	}
+	for name, want := range map[string]string{"Authorization": "[REDACTED]", "Cookie": "[REDACTED]", "User-Agent": "curl/8.0"} {
+		if v := got.Header.Get(name); v != want {
+			t.Errorf("%s: got %q, want %q", name, v, want)
+		}
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		t.Error("the request's own headers were changed")
+	}
+}
+
+// TestPanicError tests turning recovered values into errors.
+func TestPanicError(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		recovered interface{}
+		want      string
+	}{
+		{errBoom, "boom"},
+		{"nil map", "panic: nil map"},
+		{42, "panic: 42"},
+	}
+	for _, tc := range tests {
+		if got := PanicError(tc.recovered); got.Error() != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.recovered, got, tc.want)
+		}
+	}
+	if !errors.Is(PanicError(errBoom), errBoom) {
+		t.Error("expected an error to be returned as-is")
+	}
+}
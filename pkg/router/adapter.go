@@ -0,0 +1,39 @@
+package router
+
+// Description: This file contains adapters between our custom HandlerFunc and the
+// standard library's handler types. They make sure that choosing our context-based
+// handlers never locks us out of the wider net/http ecosystem.
+
+import (
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// WrapF converts a standard `http.HandlerFunc` into one of our HandlerFuncs.
+// This lets us register existing net/http handlers with our router, e.g.
+// r.GET("/legacy", router.WrapF(legacyHandler)).
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		// The standard handler only needs the underlying writer and request,
+		// both of which our context already carries.
+		f(c.Writer, c.Request)
+	}
+}
+
+// WrapH converts any `http.Handler` (for example http.FileServer) into one of
+// our HandlerFuncs.
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// ServeHTTP makes every HandlerFunc satisfy the `http.Handler` interface.
+// It creates a fresh context for the request, just as the router would, so a
+// single HandlerFunc can be plugged into http.Handle, httptest, or a standard mux.
+func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := new(httpcontext.Context)
+	ctx.Reset(w, req)
+	h(ctx)
+}
@@ -0,0 +1,75 @@
+package router
+
+// Description: This file implements the router's debug/trace mode. When it's
+// enabled, every request logs how the router reached its decision: which
+// candidate routes were considered, which parameters were extracted, and which
+// middleware wraps the chosen handler. It's meant for diagnosing "why does this
+// request 404?" or "why did it hit the wrong handler?", not for production use.
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// SetDebug turns the debug/trace mode on or off. It's safe to call while the
+// router is serving requests.
+func (r *Router) SetDebug(enabled bool) {
+	r.debug.Store(enabled)
+}
+
+// trace logs the routing decision that find made for a request. It repeats the
+// candidate checks so the normal lookup doesn't pay for logging.
+func (t *table) trace(method, path string, chosen *route, params httpcontext.Params) {
+	tracef := func(format string, args ...any) {
+		logger.Info("[router debug] "+fmt.Sprintf(format, args...), "method", method, "path", path)
+	}
+
+	if rt, ok := t.routes[method][path]; ok && rt.segments == nil {
+		tracef("static route %q matched", rt.info.Path)
+	} else {
+		tracef("no static route")
+		for _, rt := range t.patterns[method] {
+			_, ok := matchPattern(rt.segments, path, nil)
+			tracef("  candidate %q: match=%v", rt.info.Path, ok)
+		}
+	}
+
+	if chosen == nil {
+		if m := t.findMount(path); m != nil {
+			tracef("dispatching to subrouter mounted at %q", m.prefix)
+		} else {
+			tracef("no route matched; running %d fallback(s), then not-found", len(t.fallbacks))
+		}
+		tracef("middleware: %s", middlewareNames(t.middleware))
+		return
+	}
+
+	for _, p := range params {
+		tracef("  param %s=%q", p.Key, p.Value)
+	}
+	tracef("middleware: router [%s], route [%s]", middlewareNames(t.middleware), middlewareNames(chosen.middleware))
+}
+
+// middlewareNames returns a readable list of middleware function names, e.g.
+// "router.LimitBody.func1". Closures don't have nicer names, but the enclosing
+// constructor usually tells you enough.
+func middlewareNames(middleware []Middleware) string {
+	names := make([]string, 0, len(middleware))
+	for _, mw := range middleware {
+		name := "?"
+		if fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()); fn != nil {
+			name = fn.Name()
+			// Trim the module path so only "package.Function" remains.
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				name = name[i+1:]
+			}
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,77 @@
+// Description: This file contains the error-handling stage for errors recorded
+// with c.Error. Add it with r.Use(router.HandleErrors()) as the first
+// middleware, so it sees the errors of everything inside it.
+
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/report"
+)
+
+// HandleErrors returns middleware that logs the errors handlers recorded with
+// c.Error and, if nothing was written to the response yet, answers with a JSON
+// error based on the last one:
+//
+//   - bind errors are sent as-is, with their status and field details;
+//   - public errors send their message: {"error": "email already taken"};
+//   - private errors send only the status text, so internals don't leak.
+func HandleErrors() Middleware {
+	return HandleErrorsWith(ErrorOptions{})
+}
+
+// ErrorOptions configures HandleErrorsWith.
+type ErrorOptions struct {
+	// Reporter, if set, receives the recorded errors with a 5xx status as
+	// report.Events, with the stack of where each was recorded. Client
+	// errors, like failed binding, aren't failures worth tracking.
+	Reporter report.Reporter
+}
+
+// HandleErrorsWith is HandleErrors with options.
+func HandleErrorsWith(opts ErrorOptions) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			next(c)
+
+			errs := c.Errors()
+			if len(errs) == 0 {
+				return
+			}
+			for _, e := range errs {
+				c.Logger().Error("Error handling request", "method", c.Request.Method, "path", c.Request.URL.Path,
+					"err", e.Err, "type", e.Type, "status", e.StatusCode(), "meta", e.Meta)
+				if opts.Reporter != nil && e.StatusCode() >= http.StatusInternalServerError {
+					event := report.NewEvent(c, e.Err, e.StatusCode())
+					event.Stack = e.Stack()
+					opts.Reporter.Report(event)
+				}
+			}
+			if c.Written() {
+				// The handler already answered; all we can do is log.
+				return
+			}
+			renderError(c, errs[len(errs)-1])
+		}
+	}
+}
+
+// renderError sends the client-facing response for a recorded error.
+func renderError(c *httpcontext.Context, e *httpcontext.Error) {
+	status := e.StatusCode()
+	switch e.Type {
+	case httpcontext.ErrorTypeBind:
+		var bindErr *httpcontext.BindError
+		if errors.As(e.Err, &bindErr) {
+			c.JSON(status, bindErr)
+			return
+		}
+	case httpcontext.ErrorTypePublic:
+		c.JSON(status, map[string]string{"error": e.Err.Error()})
+		return
+	}
+	c.JSON(status, map[string]string{"error": http.StatusText(status)})
+}
@@ -0,0 +1,63 @@
+package router
+
+// Description: This file implements the catch-all fallback chain. When no route
+// matches a request, the router tries each fallback in the order they were added,
+// for example: "try a static file, then proxy to the legacy service, then 404".
+// The first fallback that handles the request wins.
+
+import (
+	"net/http"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// FallbackFunc is a handler that may decline a request. It returns true if it
+// wrote a response, or false (without writing anything) to pass the request on
+// to the next fallback in the chain.
+type FallbackFunc func(*httpcontext.Context) bool
+
+// FallbackTo turns a regular handler into a fallback that always handles the
+// request. It's useful as the last link of a chain, e.g. a reverse proxy.
+func FallbackTo(h HandlerFunc) FallbackFunc {
+	return func(c *httpcontext.Context) bool {
+		h(c)
+		return true
+	}
+}
+
+// Fallback appends one or more fallbacks to the chain that runs when no route
+// matches the request.
+func (r *Router) Fallback(fallbacks ...FallbackFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	t.fallbacks = append(t.fallbacks, fallbacks...)
+	r.publish(t)
+}
+
+// NotFound sets the handler that runs when no route matches and no fallback
+// handled the request. By default the router responds with http.NotFound.
+func (r *Router) NotFound(h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	t.notFound = h
+	r.publish(t)
+}
+
+// serveFallback runs the fallback chain for a request that matched no route,
+// ending with the not-found handler.
+func (t *table) serveFallback(c *httpcontext.Context) {
+	for _, fallback := range t.fallbacks {
+		if fallback(c) {
+			return
+		}
+	}
+	if t.notFound != nil {
+		t.notFound(c)
+		return
+	}
+	http.NotFound(c.Writer, c.Request)
+}
@@ -0,0 +1,144 @@
+package router
+
+// Description: This file contains request limits that the router enforces before
+// a handler runs. Limits can be attached to a single route with a RouteOption,
+// or to a whole group of routes by calling Use on a (mounted) subrouter.
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// LimitBody returns middleware that caps the size of request bodies at maxBytes.
+// Requests that declare a larger Content-Length are rejected up front with
+// 413 Request Entity Too Large. For bodies of unknown length the body is wrapped
+// with http.MaxBytesReader, so reading past the limit fails with an
+// *http.MaxBytesError that the handler can turn into a 413.
+func LimitBody(maxBytes int64) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if c.Request.ContentLength > maxBytes {
+				http.Error(c.Writer, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if c.Request.Body != nil && c.Request.Body != http.NoBody {
+				c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+			}
+			next(c)
+		}
+	}
+}
+
+// MaxBodySize limits the request body of a single route, e.g. 1MB for a JSON
+// API or 50MB for an upload endpoint:
+//
+//	r.POST("/upload", h, router.MaxBodySize(50<<20))
+func MaxBodySize(maxBytes int64) RouteOption {
+	return With(LimitBody(maxBytes))
+}
+
+// RequireContentType returns middleware that only lets through requests whose
+// Content-Type is one of the given media types, answering 415 Unsupported Media
+// Type otherwise. Parameters such as "; charset=utf-8" are ignored, and a type
+// like "image/*" accepts any subtype. Requests without a body are not checked.
+func RequireContentType(mediaTypes ...string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if hasBody(c.Request) && !contentTypeAllowed(c.Request.Header.Get("Content-Type"), mediaTypes) {
+				http.Error(c.Writer, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// Consumes restricts the request content types a single route accepts, e.g.
+//
+//	r.POST("/users", h, router.Consumes("application/json"))
+func Consumes(mediaTypes ...string) RouteOption {
+	return With(RequireContentType(mediaTypes...))
+}
+
+// hasBody reports whether the request carries (or may carry) a body.
+func hasBody(req *http.Request) bool {
+	// ContentLength is -1 when the length is unknown, e.g. a chunked upload.
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
+
+// contentTypeAllowed reports whether the Content-Type header matches one of the
+// allowed media types.
+func contentTypeAllowed(header string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == mediaType {
+			return true
+		}
+		// "image/*" matches "image/png", "image/jpeg", ...
+		if strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitDuration returns middleware that gives handlers at most d to respond.
+// It behaves like http.TimeoutHandler: the request context is cancelled when the
+// deadline passes, the client receives 503 Service Unavailable, and anything the
+// handler writes afterwards is discarded instead of reaching the client. Handlers
+// doing slow work should watch c.Request.Context().Done() and stop early.
+func LimitDuration(d time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			// The wrapped handler runs on its own goroutine and may outlive
+			// this call, while c goes back to the router's pool when we return.
+			// So the handler gets a context of its own, with copies of the
+			// route info, parameters, request ID, sampling mark, and JSON
+			// options.
+			info := c.RouteInfo()
+			params := append(httpcontext.Params(nil), c.Params()...)
+			id, sampled, jsonOpts := c.RequestID(), c.Sampled(), c.JSONOptions()
+			ctx := new(httpcontext.Context)
+			finished := make(chan struct{})
+			inner := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				ctx.Reset(w, req)
+				ctx.SetRouteInfo(&info)
+				ctx.SetParams(params)
+				ctx.SetRequestID(id)
+				ctx.SetSampled(sampled)
+				ctx.SetJSONOptions(jsonOpts)
+				next(ctx)
+				close(finished)
+			})
+			http.TimeoutHandler(inner, d, http.StatusText(http.StatusServiceUnavailable)).ServeHTTP(c.Writer, c.Request)
+
+			// The errors of a handler that finished in time are handed
+			// back for HandleErrors; one still running keeps its own.
+			select {
+			case <-finished:
+				for _, e := range ctx.Errors() {
+					*c.Error(e.Err) = *e
+				}
+				if ctx.IsAborted() {
+					c.Abort()
+				}
+			default:
+			}
+		}
+	}
+}
+
+// Timeout limits how long a single route's handler may run, e.g.
+//
+//	r.GET("/reports", h, router.Timeout(2*time.Second))
+func Timeout(d time.Duration) RouteOption {
+	return With(LimitDuration(d))
+}
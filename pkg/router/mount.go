@@ -0,0 +1,99 @@
+package router
+
+// Description: This file implements subrouter mounting. A Router can be attached
+// to another Router at a path prefix, e.g. parent.Mount("/admin", adminRouter).
+// The subrouter keeps its own route table, middleware stack, fallbacks, and
+// not-found handler, so a team can own a module of routes in isolation. The
+// parent's middleware runs first, then the request is handed to the subrouter
+// with the prefix stripped from its path.
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// mount is a subrouter attached at a prefix.
+type mount struct {
+	prefix string
+	sub    *Router
+
+	// handler strips the prefix and dispatches to the subrouter; chain is
+	// handler wrapped in the parent's middleware stack.
+	handler HandlerFunc
+	chain   HandlerFunc
+}
+
+// Mount attaches sub at prefix. Requests for the prefix itself or anything below
+// it (e.g. "/admin" and "/admin/users/7") that don't match one of the parent's
+// own routes are served by sub, which sees the path without the prefix ("/" and
+// "/users/7"). Inside the subrouter, c.FullPath() is relative to the mount point.
+func (r *Router) Mount(prefix string, sub *Router) {
+	// Normalize the prefix: it must start with "/" and must not end with one.
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		panic(fmt.Errorf("router: cannot mount a subrouter at the root; use Fallback instead"))
+	}
+	if sub == r {
+		panic(fmt.Errorf("router: cannot mount a router inside itself"))
+	}
+
+	m := &mount{prefix: prefix, sub: sub}
+	m.handler = func(c *httpcontext.Context) {
+		sub.serve(c.Writer, stripPrefix(c.Request, prefix), c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	for i, existing := range t.mounts {
+		if existing.prefix == prefix {
+			// Mounting at the same prefix again replaces the subrouter.
+			m.chain = t.wrap(m.handler)
+			t.mounts[i] = m
+			r.publish(t)
+			logger.Info("Replaced mount", "prefix", prefix)
+			return
+		}
+	}
+	m.chain = t.wrap(m.handler)
+	t.mounts = append(t.mounts, m)
+	r.publish(t)
+	logger.Info("Mounted subrouter", "prefix", prefix)
+}
+
+// findMount returns the mount responsible for a path. A longer prefix wins over
+// a shorter one, so "/api/v2" can be mounted alongside "/api".
+func (t *table) findMount(path string) *mount {
+	var best *mount
+	for _, m := range t.mounts {
+		if path != m.prefix && !strings.HasPrefix(path, m.prefix+"/") {
+			continue
+		}
+		if best == nil || len(m.prefix) > len(best.prefix) {
+			best = m
+		}
+	}
+	return best
+}
+
+// stripPrefix returns a shallow copy of req whose URL path has prefix removed.
+// The request for the prefix itself becomes a request for "/".
+func stripPrefix(req *http.Request, prefix string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *req
+	u := *req.URL
+	u.Path = strings.TrimPrefix(req.URL.Path, prefix)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	// RawPath is only a hint for the encoded form of Path; once we've
+	// changed Path it may no longer match, so we drop it.
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
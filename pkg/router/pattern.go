@@ -0,0 +1,155 @@
+package router
+
+// Description: This file implements parameterized route patterns. A pattern is a
+// path whose segments may be named parameters (":id") or, as the last segment,
+// a catch-all wildcard ("*filepath"). For example:
+//
+//	/users/:id           matches /users/42         with id="42"
+//	/static/*filepath    matches /static/css/a.css with filepath="css/a.css"
+//
+// When several routes match the same path, the most precise one wins. Routes are
+// compared segment by segment from the left, and at the first segment where they
+// differ a static segment beats a parameter, which beats a wildcard:
+//
+//	/users/new        beats /users/:id     for /users/new
+//	/users/:id/posts  beats /users/*rest   for /users/7/posts
+//	/files/:dir/x     beats /files/*path   for /files/a/x
+//
+// A fully static route always wins, since it's found with a direct map lookup
+// before any pattern is tried. Patterns of equal precedence (differing only in
+// parameter names) are tried in registration order.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// isPattern reports whether a registered path contains parameters or a wildcard.
+func isPattern(path string) bool {
+	return strings.ContainsAny(path, ":*")
+}
+
+// parsePattern splits a pattern into its segments and checks that it's well formed.
+// The leading slash is dropped, so "/users/:id" becomes ["users", ":id"].
+func parsePattern(pattern string) ([]string, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("router: pattern %q must begin with '/'", pattern)
+	}
+
+	segments := strings.Split(pattern[1:], "/")
+	seen := make(map[string]bool)
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			// A plain segment must not hide parameter syntax in the middle,
+			// e.g. "/a:b", because we only recognize whole-segment parameters.
+			if strings.ContainsAny(seg, ":*") {
+				return nil, fmt.Errorf("router: pattern %q has a parameter that doesn't span a whole segment", pattern)
+			}
+			continue
+		}
+
+		name := seg[1:]
+		if name == "" {
+			return nil, fmt.Errorf("router: pattern %q has a parameter without a name", pattern)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("router: pattern %q uses parameter %q twice", pattern, name)
+		}
+		seen[name] = true
+
+		// A wildcard swallows the rest of the path, so nothing may follow it.
+		if seg[0] == '*' && i != len(segments)-1 {
+			return nil, fmt.Errorf("router: wildcard in pattern %q must be the last segment", pattern)
+		}
+	}
+	return segments, nil
+}
+
+// matchPattern checks a request path against a parsed pattern and, on success,
+// returns the extracted parameters appended to params. It walks the path in
+// place instead of splitting it, and reuses params' capacity, so matching a
+// request doesn't allocate.
+func matchPattern(segments []string, path string, params httpcontext.Params) (httpcontext.Params, bool) {
+	remaining := strings.TrimPrefix(path, "/")
+	exhausted := false
+	for _, seg := range segments {
+		// The path ran out of segments before the pattern did.
+		if exhausted {
+			return nil, false
+		}
+
+		// A wildcard captures everything that's left, including slashes.
+		if seg != "" && seg[0] == '*' {
+			params = append(params, httpcontext.Param{Key: seg[1:], Value: remaining})
+			return params, true
+		}
+
+		// Cut the next segment off the front of the remaining path.
+		var part string
+		if i := strings.IndexByte(remaining, '/'); i >= 0 {
+			part, remaining = remaining[:i], remaining[i+1:]
+		} else {
+			part, remaining, exhausted = remaining, "", true
+		}
+
+		switch {
+		case seg != "" && seg[0] == ':':
+			// A parameter matches any non-empty segment.
+			if part == "" {
+				return nil, false
+			}
+			params = append(params, httpcontext.Param{Key: seg[1:], Value: part})
+		case seg != part:
+			return nil, false
+		}
+	}
+
+	// Every pattern segment matched; the path must not have anything left over.
+	return params, exhausted
+}
+
+// Segment kinds, ordered by precedence: a lower value wins.
+const (
+	staticSegment = iota
+	paramSegment
+	wildcardSegment
+)
+
+// segmentKind classifies a pattern segment.
+func segmentKind(seg string) int {
+	switch {
+	case seg != "" && seg[0] == ':':
+		return paramSegment
+	case seg != "" && seg[0] == '*':
+		return wildcardSegment
+	default:
+		return staticSegment
+	}
+}
+
+// morePrecise reports whether pattern a takes precedence over pattern b.
+// It defines a strict ordering so the pattern list can be kept sorted.
+func morePrecise(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ka, kb := segmentKind(a[i]), segmentKind(b[i])
+		if ka != kb {
+			return ka < kb
+		}
+		// Two different static segments can never match the same path, so
+		// their relative order doesn't matter; we compare the text only to
+		// keep the ordering total.
+		if ka == staticSegment && a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	// If one pattern is a prefix of the other, the longer one is more specific.
+	return len(a) > len(b)
+}
+
+// samePrecedence reports whether two patterns have exactly the same shape, so
+// they would match the same paths (e.g. "/users/:id" and "/users/:name").
+func samePrecedence(a, b []string) bool {
+	return !morePrecise(a, b) && !morePrecise(b, a)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package router
+
+// raceEnabled reports whether the tests were built with -race. The race detector
+// makes sync.Pool drop items at random, so allocation counts aren't meaningful.
+const raceEnabled = true
@@ -0,0 +1,70 @@
+package router
+
+// Description: This file implements declarative redirects. Instead of writing a
+// one-off handler for every legacy URL, we declare the mapping as a route:
+//
+//	r.Redirect("GET", "/old-users/:id", "/users/:id", http.StatusMovedPermanently)
+//
+// Parameters and wildcards captured by the source pattern can be reused by name
+// in the target.
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Redirect registers a route that redirects requests for from to to, using the
+// given 3xx status code (e.g. http.StatusMovedPermanently for a permanent move,
+// http.StatusTemporaryRedirect for a temporary one). Any ":name" or "*name"
+// segment in to is replaced with the value captured by from, and the query
+// string of the original request is preserved, after any query of to's own.
+func (r *Router) Redirect(method, from, to string, code int, opts ...RouteOption) {
+	if code < 300 || code > 399 {
+		panic(fmt.Errorf("router: redirect from %q uses non-3xx status code %d", from, code))
+	}
+	r.addRoute(method, from, func(c *httpcontext.Context) {
+		target := withQuery(expandTarget(to, c.Params()), c.Request.URL.RawQuery)
+		http.Redirect(c.Writer, c.Request, target, code)
+	}, opts)
+}
+
+// withQuery adds rawQuery to the query of target, which may have one
+// already, and a fragment.
+func withQuery(target, rawQuery string) string {
+	if rawQuery == "" {
+		return target
+	}
+	target, fragment, hasFragment := strings.Cut(target, "#")
+	switch {
+	case !strings.Contains(target, "?"):
+		target += "?"
+	case !strings.HasSuffix(target, "?") && !strings.HasSuffix(target, "&"):
+		target += "&"
+	}
+	target += rawQuery
+	if hasFragment {
+		target += "#" + fragment
+	}
+	return target
+}
+
+// expandTarget fills the ":name" and "*name" segments of a redirect target with
+// the matching parameter values. Unknown parameters are left as they are.
+func expandTarget(to string, params httpcontext.Params) string {
+	if len(params) == 0 || !isPattern(to) {
+		return to
+	}
+	segments := strings.Split(to, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		if value, ok := params.Get(seg[1:]); ok {
+			segments[i] = value
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -4,13 +4,17 @@ package router
 // and URL paths to specific handler functions.
 
 import (
-	"log"
 	"net/http"
+	"path"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	// We import our custom context package. The router's job is to create
 	// this context for each request and pass it to the handler.
 	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
 )
 
 // HandlerFunc defines the type for our custom handler functions.
@@ -20,81 +24,410 @@ type HandlerFunc func(*httpcontext.Context)
 
 // Router is our main router struct. It holds the routing rules.
 type Router struct {
-	// We use a sync.RWMutex to protect the routes map from concurrent access.
-	// This is important because routes might be read (during request handling)
-	// and written (during setup) at the same time in more complex scenarios.
-	mu sync.RWMutex
+	// mu serializes writers (route registration and removal). Readers never
+	// take it: they load the current routing table atomically instead.
+	mu sync.Mutex
 
-	// routes is a map that stores the handlers. The structure is:
-	// map[HTTP_METHOD]map[URL_PATH]HandlerFunc
-	// For example: routes["GET"]["/users"] = GetUsersHandler
-	routes map[string]map[string]HandlerFunc
+	// table is the current routing table. It is replaced, never modified,
+	// so ServeHTTP can read it without locking. See table.go.
+	table atomic.Pointer[table]
+
+	// pool recycles Context objects between requests. Allocating a fresh
+	// Context (and its params slice) for every request adds garbage collector
+	// pressure under load, so ServeHTTP borrows one and returns it when done.
+	pool sync.Pool
+
+	// debug enables per-request logging of routing decisions. See debug.go.
+	debug atomic.Bool
+
+	// jsonOptions, if set, are how this router's handlers encode JSON. See
+	// SetJSONOptions.
+	jsonOptions atomic.Pointer[httpcontext.JSONOptions]
+}
+
+// route is a single entry in the routing table: the handler plus the
+// metadata that was attached to it at registration time.
+type route struct {
+	handler HandlerFunc
+	info    httpcontext.RouteInfo
+
+	// chain is handler wrapped in the router's middleware stack. It's what
+	// ServeHTTP actually calls, and is rebuilt whenever Use adds middleware.
+	chain HandlerFunc
+
+	// segments is the pre-split pattern for parameterized routes.
+	// It is nil for static routes.
+	segments []string
+
+	// middleware only applies to this route. It runs inside the router's
+	// own stack, right around the handler.
+	middleware []Middleware
+}
+
+// RouteOption configures a route when it is registered, e.g.
+// r.GET("/users", h, router.Describe("List users"), router.Tags("users")).
+type RouteOption func(*route)
+
+// Describe attaches a human-readable description to a route.
+func Describe(description string) RouteOption {
+	return func(rt *route) {
+		rt.info.Description = description
+	}
+}
+
+// Tags attaches one or more tags to a route, useful for grouping routes in
+// generated documentation.
+func Tags(tags ...string) RouteOption {
+	return func(rt *route) {
+		rt.info.Tags = append(rt.info.Tags, tags...)
+	}
+}
+
+// Meta attaches an arbitrary key/value pair to a route. Middleware can read it
+// back with c.RouteInfo().Metadata to apply per-route policies, for example
+// router.Meta("auth", "admin").
+func Meta(key string, value interface{}) RouteOption {
+	return func(rt *route) {
+		if rt.info.Metadata == nil {
+			rt.info.Metadata = make(map[string]interface{})
+		}
+		rt.info.Metadata[key] = value
+	}
+}
+
+// With attaches middleware to a single route. It runs after the router-wide
+// middleware added with Use, in the order given.
+func With(middleware ...Middleware) RouteOption {
+	return func(rt *route) {
+		rt.middleware = append(rt.middleware, middleware...)
+	}
 }
 
 // New creates and returns a new Router instance.
 func New() *Router {
-	return &Router{
-		// Initialize the routes map. It's crucial to initialize nested maps as well.
-		routes: make(map[string]map[string]HandlerFunc),
+	r := &Router{}
+	// Start with an empty routing table.
+	r.publish(newTable())
+	// The pool's New function is only called when the pool is empty.
+	r.pool.New = func() interface{} {
+		return new(httpcontext.Context)
+	}
+	return r
+}
+
+// Middleware wraps a HandlerFunc with extra behaviour (logging, auth, ...) and
+// returns the wrapped handler. Calling next passes the request on down the chain;
+// returning without calling it stops the request there.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// MiddlewareFunc turns a plain handler into middleware, for code that reads
+// better with c.Next() than with a wrapped next function:
+//
+//	r.Use(router.MiddlewareFunc(func(c *httpcontext.Context) {
+//		start := time.Now()
+//		c.Next()
+//		log.Printf("%s took %v", c.FullPath(), time.Since(start))
+//	}))
+//
+// If h returns without calling c.Next() or c.Abort(), the chain continues
+// after it, so "before" middleware doesn't need to call Next at all.
+func MiddlewareFunc(h HandlerFunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			c.SetNext(next)
+			h(c)
+			c.Next() // No-op if h already called it or aborted.
+		}
+	}
+}
+
+// apply wraps h in middleware, outermost first. Every layer is guarded so that
+// once a middleware calls c.Abort(), the layers inside it, and the handler,
+// are skipped even if next is still called.
+func apply(h HandlerFunc, middleware []Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](unlessAborted(h))
+	}
+	return h
+}
+
+// unlessAborted returns a handler that calls h only if the chain wasn't aborted.
+func unlessAborted(h HandlerFunc) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		if !c.IsAborted() {
+			h(c)
+		}
 	}
 }
 
+// Use appends middleware to the router's stack. Middleware applies to every
+// route of this router, whether it was registered before or after the call,
+// as well as to mounted subrouters and the not-found/fallback handling.
+// Middleware runs in the order it was added: the first one is the outermost.
+func (r *Router) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	t.middleware = append(t.middleware, middleware...)
+	t.rewrap()
+	r.publish(t)
+}
+
+// SetJSONOptions makes the router's handlers, and those of subrouters
+// without options of their own, encode JSON with opts instead of
+// httpcontext.DefaultJSONOptions, e.g. indented in development. Handlers
+// can still pick options for one response with c.JSONWith. It's safe to
+// call while the router is serving requests.
+func (r *Router) SetJSONOptions(opts httpcontext.JSONOptions) {
+	r.jsonOptions.Store(&opts)
+}
+
+// publish makes t the routing table used by new requests. Every writer calls it
+// after modifying its private copy of the table. The caller must hold r.mu.
+func (r *Router) publish(t *table) {
+	// The fallback chain reads the table's fallbacks and not-found handler,
+	// so it's rebuilt for every published version.
+	t.fallbackChain = t.wrap(t.serveFallback)
+	r.table.Store(t)
+}
+
 // addRoute is an internal helper to add a new route to the map.
-func (r *Router) addRoute(method, path string, handler HandlerFunc) {
-	// Lock the mutex for writing to ensure thread safety.
+func (r *Router) addRoute(method, path string, handler HandlerFunc, opts []RouteOption) {
+	// Build the route's metadata before taking the lock; the options are
+	// plain functions and don't touch the routing table.
+	rt := &route{
+		handler: handler,
+		info:    httpcontext.RouteInfo{Method: method, Path: path},
+	}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	// Route-level middleware never changes after registration, so we apply
+	// it once here; the router-wide stack is layered on top by the table.
+	rt.handler = apply(rt.handler, rt.middleware)
+	if isPattern(path) {
+		// Invalid patterns are programming errors, so like http.ServeMux we
+		// panic at registration time rather than misroute requests later.
+		segments, err := parsePattern(path)
+		if err != nil {
+			panic(err)
+		}
+		rt.segments = segments
+	}
+
+	// Lock the mutex for writing so two registrations can't race and lose
+	// one of the updates.
 	r.mu.Lock()
 	defer r.mu.Unlock() // Ensure the mutex is unlocked when the function exits.
 
-	// Check if the map for the given HTTP method exists.
-	if r.routes[method] == nil {
-		// If not, create it.
-		r.routes[method] = make(map[string]HandlerFunc)
+	// Registering the same method and path again replaces the old handler.
+	// We modify a copy of the table and publish it in one atomic step, so
+	// in-flight requests either see the old handler or the new one, never a
+	// half-written map.
+	t := r.table.Load().clone()
+	replaced := t.set(method, path, rt)
+	r.publish(t)
+
+	if replaced {
+		logger.Info("Replaced route", "method", method, "path", path)
+		return
+	}
+	logger.Info("Registered route", "method", method, "path", path)
+}
+
+// Remove unregisters the handler for the given method and path, so routes can be
+// taken down at runtime (e.g. a feature flag was switched off) without restarting
+// the server. It reports whether a route was actually removed.
+func (r *Router) Remove(method, path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.table.Load().clone()
+	if !t.delete(method, path) {
+		return false
 	}
-	r.routes[method][path] = handler
-	log.Printf("Registered route: %s %s", method, path)
+	r.publish(t)
+	logger.Info("Removed route", "method", method, "path", path)
+	return true
+}
+
+// Handle registers a handler for any HTTP method, including ones without a
+// convenience method of their own (e.g. "PROPFIND").
+func (r *Router) Handle(method, path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute(method, path, handler, opts)
 }
 
 // GET is a convenience method for registering a handler for the GET HTTP method.
-func (r *Router) GET(path string, handler HandlerFunc) {
-	r.addRoute("GET", path, handler)
+func (r *Router) GET(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute("GET", path, handler, opts)
 }
 
 // POST is a convenience method for registering a handler for the POST HTTP method.
-func (r *Router) POST(path string, handler HandlerFunc) {
-	r.addRoute("POST", path, handler)
+func (r *Router) POST(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute("POST", path, handler, opts)
+}
+
+// PUT is a convenience method for registering a handler for the PUT HTTP method.
+func (r *Router) PUT(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute("PUT", path, handler, opts)
+}
+
+// PATCH is a convenience method for registering a handler for the PATCH HTTP method.
+func (r *Router) PATCH(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute("PATCH", path, handler, opts)
+}
+
+// DELETE is a convenience method for registering a handler for the DELETE HTTP method.
+func (r *Router) DELETE(path string, handler HandlerFunc, opts ...RouteOption) {
+	r.addRoute("DELETE", path, handler, opts)
+}
+
+// Routes returns the metadata of every registered route, including those of
+// mounted subrouters, sorted by path and then method. It's the starting point for generating API documentation.
+func (r *Router) Routes() []httpcontext.RouteInfo {
+	t := r.table.Load()
+	var infos []httpcontext.RouteInfo
+	for _, pathRoutes := range t.routes {
+		for _, rt := range pathRoutes {
+			infos = append(infos, rt.info)
+		}
+	}
+	// Routes of mounted subrouters are listed with their full public path.
+	for _, m := range t.mounts {
+		for _, info := range m.sub.Routes() {
+			if info.Path == "/" {
+				info.Path = m.prefix
+			} else {
+				info.Path = m.prefix + info.Path
+			}
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
 }
 
 // ServeHTTP makes our Router implement the `http.Handler` interface.
 // This method is called for every incoming HTTP request.
 // It's the heart of the router.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	// Lock the mutex for reading. A read lock allows multiple readers at the same time.
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	// Find the handlers for the request's method.
-	pathHandlers, ok := r.routes[req.Method]
-	if !ok {
-		// If no handlers are registered for this HTTP method, send a 404 Not Found.
-		http.NotFound(w, req)
+	r.serve(w, req, nil)
+}
+
+// serve handles a request. A mounted subrouter gets the parent router's
+// context as parent: its context takes over the request ID, sampling mark,
+// and JSON options, and hands the errors it records back, so the parent's
+// HandleErrors and logs treat the request as one.
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, parent *httpcontext.Context) {
+	// Normalize the path before matching. A request for "/users//" or
+	// "/static/../users" should not miss the "/users" route, and must not be
+	// allowed to sneak "." or ".." segments past handlers that serve files.
+	// When the path had to be fixed, we redirect the client to the clean URL
+	// instead of silently serving it, so there is one canonical address.
+	if cleaned := cleanPath(req.URL.Path); cleaned != req.URL.Path {
+		if r.debug.Load() {
+			logger.Info("[router debug] path normalized, redirecting", "method", req.Method, "path", req.URL.Path, "cleaned", cleaned)
+		}
+		redirectToCleanPath(w, req, cleaned)
 		return
 	}
 
-	// Find the specific handler for the request's URL path.
-	handler, ok := pathHandlers[req.URL.Path]
-	if !ok {
-		// If no handler is registered for this specific path, send a 404 Not Found.
-		http.NotFound(w, req)
+	// Borrow a context from the pool and reset it for this request.
+	// This context wraps the original ResponseWriter and Request.
+	// Handlers must not keep a reference to it after they return, since it
+	// will be handed to another request.
+	ctx := r.pool.Get().(*httpcontext.Context)
+	ctx.Reset(w, req)
+	defer r.pool.Put(ctx)
+	if parent != nil {
+		ctx.SetRequestID(parent.RequestID())
+		ctx.SetSampled(parent.Sampled())
+		ctx.SetJSONOptions(parent.JSONOptions())
+		// Runs before the context goes back to the pool.
+		defer func() {
+			for _, e := range ctx.Errors() {
+				*parent.Error(e.Err) = *e
+			}
+			if ctx.IsAborted() {
+				parent.Abort()
+			}
+		}()
+	}
+	if opts := r.jsonOptions.Load(); opts != nil {
+		ctx.SetJSONOptions(*opts)
+	}
+
+	// Find the route for the request's method and URL path. Parameters are
+	// collected into the pooled context's params slice to avoid allocating.
+	// The table is loaded once, so the whole lookup (including the fallback
+	// chain) sees one consistent version even if routes are being changed
+	// concurrently.
+	t := r.table.Load()
+	rt, params := t.find(req.Method, req.URL.Path, ctx.Params())
+	if r.debug.Load() {
+		t.trace(req.Method, req.URL.Path, rt, params)
+	}
+	if rt == nil {
+		// If no route matches this method and path, the request may belong
+		// to a mounted subrouter. Otherwise give the fallback chain a chance;
+		// it ends in a 404 Not Found if nobody claims the request.
+		if m := t.findMount(req.URL.Path); m != nil {
+			m.chain(ctx)
+			return
+		}
+		t.fallbackChain(ctx)
 		return
 	}
 
-	// Create a new instance of our custom context for this request.
-	// This context wraps the original ResponseWriter and Request.
-	ctx := &httpcontext.Context{
-		Writer:  w,
-		Request: req,
+	// Let the handler (and any middleware) see which route was matched.
+	ctx.SetRouteInfo(&rt.info)
+	ctx.SetParams(params)
+
+	// Call the matched handler function with the prepared context.
+	rt.chain(ctx)
+}
+
+// cleanPath returns the canonical form of a URL path: it collapses repeated
+// slashes and resolves "." and ".." segments. A trailing slash is preserved,
+// because "/dir/" and "/dir" may deliberately be different routes.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		// If the only difference is the trailing slash we stripped, return
+		// the original string instead of allocating a new one.
+		if len(p) == len(cleaned)+1 && strings.HasPrefix(p, cleaned) {
+			return p
+		}
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// redirectToCleanPath sends the client to the normalized version of its URL,
+// keeping the query string intact.
+func redirectToCleanPath(w http.ResponseWriter, req *http.Request, cleaned string) {
+	target := cleaned
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
 	}
 
-	// Call the matched handler function with the newly created context.
-	handler(ctx)
+	// 301 Moved Permanently lets browsers change a POST into a GET, so for
+	// anything other than GET/HEAD we use 308, which preserves the method and body.
+	code := http.StatusMovedPermanently
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, req, target, code)
 }
@@ -5,11 +5,20 @@
 package router
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/report"
 )
 
 // TestRouter_ServeHTTP_Found tests if a registered route is correctly handled.
@@ -89,3 +98,1078 @@ func TestRouter_ServeHTTP_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected status code %d for wrong method, but got %d", http.StatusNotFound, rr.Code)
 	}
 }
+
+// TestRouter_Methods tests the convenience methods for each HTTP method.
+func TestRouter_Methods(t *testing.T) {
+	// 1. Setup: One route per method, answering with its method.
+	r := New()
+	register := map[string]func(string, HandlerFunc, ...RouteOption){
+		"GET": r.GET, "POST": r.POST, "PUT": r.PUT, "PATCH": r.PATCH, "DELETE": r.DELETE,
+	}
+	for method, add := range register {
+		add("/items/"+method, func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", c.Request.Method) })
+	}
+
+	for method := range register {
+		// 2. Execute
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, "/items/"+method, nil))
+
+		// 3. Assert
+		if rr.Code != http.StatusOK || rr.Body.String() != method {
+			t.Errorf("%s: got %d %q", method, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+// TestWrapF tests that a standard http.HandlerFunc can be registered on our router.
+func TestWrapF(t *testing.T) {
+	// 1. Setup: Register a plain net/http handler through the adapter.
+	r := New()
+	r.GET("/std", WrapF(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	// 2. Execute the request.
+	req := httptest.NewRequest("GET", "/std", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	// 3. Assert: The standard handler should have written the response.
+	if rr.Code != http.StatusTeapot {
+		t.Errorf("expected status code %d, but got %d", http.StatusTeapot, rr.Code)
+	}
+}
+
+// TestWrapH tests that any http.Handler can be registered on our router.
+func TestWrapH(t *testing.T) {
+	r := New()
+	r.GET("/std", WrapH(http.NotFoundHandler()))
+
+	req := httptest.NewRequest("GET", "/std", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, but got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+// TestHandlerFunc_ServeHTTP tests that a HandlerFunc can be used as a standard http.Handler.
+func TestHandlerFunc_ServeHTTP(t *testing.T) {
+	// 1. Setup: Mount one of our handlers on the standard library's mux.
+	mux := http.NewServeMux()
+	mux.Handle("/ours", HandlerFunc(func(c *httpcontext.Context) {
+		c.String(http.StatusOK, "hello %s", "world")
+	}))
+
+	// 2. Execute the request through the standard mux.
+	req := httptest.NewRequest("GET", "/ours", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	// 3. Assert: Our handler should have received a working context.
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if body := rr.Body.String(); body != "hello world" {
+		t.Errorf("expected body %q, but got %q", "hello world", body)
+	}
+}
+
+// TestRouter_Remove tests that a removed route stops being served.
+func TestRouter_Remove(t *testing.T) {
+	// 1. Setup: Register a route and make sure it's served.
+	r := New()
+	r.GET("/flag", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/flag", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %d before removal, but got %d", http.StatusOK, rr.Code)
+	}
+
+	// 2. Execute: Remove the route. Removing it a second time should be a no-op.
+	if !r.Remove("GET", "/flag") {
+		t.Error("expected Remove to report that the route was removed")
+	}
+	if r.Remove("GET", "/flag") {
+		t.Error("expected a second Remove to report false")
+	}
+
+	// 3. Assert: The route should now return 404.
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/flag", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d after removal, but got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+// TestRouter_ReRegister tests that registering a route again replaces its handler.
+func TestRouter_ReRegister(t *testing.T) {
+	r := New()
+	r.GET("/v", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	r.GET("/v", func(c *httpcontext.Context) { c.Status(http.StatusAccepted) })
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/v", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("expected the replacement handler (status %d), but got %d", http.StatusAccepted, rr.Code)
+	}
+}
+
+// TestRouter_ConcurrentUpdates exercises registration and removal while requests
+// are being served. Run with `go test -race` to detect unsafe access.
+func TestRouter_ConcurrentUpdates(t *testing.T) {
+	r := New()
+	r.GET("/hot", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			r.Remove("GET", "/hot")
+			r.GET("/hot", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/hot", nil))
+		if rr.Code != http.StatusOK && rr.Code != http.StatusNotFound {
+			t.Fatalf("unexpected status code %d", rr.Code)
+		}
+	}
+	<-done
+}
+
+// TestRouter_PathNormalization tests that unclean paths are redirected to their
+// canonical form.
+func TestRouter_PathNormalization(t *testing.T) {
+	r := New()
+	r.GET("/users", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	r.POST("/users", func(c *httpcontext.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		method   string
+		path     string
+		query    string
+		wantCode int
+		wantLoc  string
+	}{
+		{"GET", "/users", "", http.StatusOK, ""},
+		{"GET", "//users", "", http.StatusMovedPermanently, "/users"},
+		{"GET", "/static/../users", "page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"GET", "/./users", "", http.StatusMovedPermanently, "/users"},
+		{"GET", "/users//", "", http.StatusMovedPermanently, "/users/"},
+		{"POST", "/a/../users", "", http.StatusPermanentRedirect, "/users"},
+	}
+
+	for _, tt := range tests {
+		// We build the request by hand so the path reaches the router exactly
+		// as written, instead of being interpreted by the URL parser.
+		req := httptest.NewRequest(tt.method, "/", nil)
+		req.URL.Path = tt.path
+		req.URL.RawQuery = tt.query
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s %s: expected status code %d, but got %d", tt.method, tt.path, tt.wantCode, rr.Code)
+		}
+		if loc := rr.Header().Get("Location"); loc != tt.wantLoc {
+			t.Errorf("%s %s: expected Location %q, but got %q", tt.method, tt.path, tt.wantLoc, loc)
+		}
+	}
+}
+
+// TestRouter_RouteInfo tests that metadata attached at registration is visible
+// to the handler and listed by Routes().
+func TestRouter_RouteInfo(t *testing.T) {
+	// 1. Setup: Register a route with a description, tags, and metadata.
+	r := New()
+	var got httpcontext.RouteInfo
+	r.GET("/users", func(c *httpcontext.Context) {
+		got = c.RouteInfo()
+		c.Status(http.StatusOK)
+	}, Describe("List users"), Tags("users", "public"), Meta("auth", "none"))
+	r.POST("/users", func(c *httpcontext.Context) {})
+
+	// 2. Execute the request.
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	// 3. Assert: The handler should have seen the route's metadata.
+	if got.Method != "GET" || got.Path != "/users" {
+		t.Errorf("expected route GET /users, but got %s %s", got.Method, got.Path)
+	}
+	if got.Description != "List users" {
+		t.Errorf("expected description %q, but got %q", "List users", got.Description)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "users" || got.Tags[1] != "public" {
+		t.Errorf("unexpected tags: %v", got.Tags)
+	}
+	if got.Metadata["auth"] != "none" {
+		t.Errorf("expected metadata auth=none, but got %v", got.Metadata["auth"])
+	}
+
+	// 4. Assert: Routes() lists both routes in a stable order.
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, but got %d", len(routes))
+	}
+	if routes[0].Method != "GET" || routes[1].Method != "POST" {
+		t.Errorf("expected routes sorted by method, but got %s then %s", routes[0].Method, routes[1].Method)
+	}
+}
+
+// TestRouter_Params tests that parameterized routes match and expose both the
+// parameter values and the route pattern.
+func TestRouter_Params(t *testing.T) {
+	// 1. Setup: Register a static, a parameterized, and a wildcard route.
+	r := New()
+	var fullPath, id, file string
+	handler := func(c *httpcontext.Context) {
+		fullPath, id, file = c.FullPath(), c.Param("id"), c.Param("filepath")
+		c.Status(http.StatusOK)
+	}
+	r.GET("/users", handler)
+	r.GET("/users/:id", handler)
+	r.GET("/static/*filepath", handler)
+
+	tests := []struct {
+		path         string
+		wantCode     int
+		wantFullPath string
+		wantID       string
+		wantFile     string
+	}{
+		{"/users", http.StatusOK, "/users", "", ""},
+		{"/users/42", http.StatusOK, "/users/:id", "42", ""},
+		{"/static/css/site.css", http.StatusOK, "/static/*filepath", "", "css/site.css"},
+		{"/users/42/friends", http.StatusNotFound, "", "", ""},
+		{"/users/:id/x", http.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		fullPath, id, file = "", "", ""
+		rr := httptest.NewRecorder()
+
+		// 2. Execute the request.
+		r.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		// 3. Assert: Check the status, the pattern, and the extracted parameters.
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status code %d, but got %d", tt.path, tt.wantCode, rr.Code)
+		}
+		if fullPath != tt.wantFullPath || id != tt.wantID || file != tt.wantFile {
+			t.Errorf("%s: got FullPath=%q id=%q filepath=%q, want %q %q %q",
+				tt.path, fullPath, id, file, tt.wantFullPath, tt.wantID, tt.wantFile)
+		}
+	}
+}
+
+// TestRouter_InvalidPattern tests that malformed patterns are rejected at registration.
+func TestRouter_InvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"/users/:", "/files/*path/more", "/a:b", "/x/:id/:id"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %q to panic", pattern)
+				}
+			}()
+			New().GET(pattern, func(c *httpcontext.Context) {})
+		}()
+	}
+}
+
+// discardWriter is a minimal http.ResponseWriter that throws everything away.
+// Unlike httptest.ResponseRecorder it doesn't allocate per request, so the
+// benchmarks below measure only the router.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// newBenchRouter builds a router with a mix of static and parameterized routes.
+func newBenchRouter() *Router {
+	r := New()
+	noop := func(c *httpcontext.Context) {}
+	r.GET("/health", noop)
+	r.GET("/users", noop)
+	r.GET("/users/:id", noop)
+	r.GET("/users/:id/posts/:post", noop)
+	return r
+}
+
+// TestRouter_ZeroAllocs tests that serving a matched route doesn't allocate once
+// the context pool is warm.
+func TestRouter_ZeroAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not reliable under the race detector")
+	}
+	r := newBenchRouter()
+	w := &discardWriter{header: make(http.Header)}
+
+	for _, path := range []string{"/health", "/users/42/posts/7"} {
+		req := httptest.NewRequest("GET", path, nil)
+		allocs := testing.AllocsPerRun(100, func() {
+			r.ServeHTTP(w, req)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: expected 0 allocations per request, but got %v", path, allocs)
+		}
+	}
+}
+
+// TestRouter_PooledContextsRace serves many concurrent requests and checks that
+// every handler sees its own parameters, i.e. pooled contexts are never shared
+// between two in-flight requests. Run with `go test -race`.
+func TestRouter_PooledContextsRace(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", func(c *httpcontext.Context) {
+		c.String(http.StatusOK, "%s", c.Param("id"))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				want := fmt.Sprintf("%d-%d", i, j)
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, httptest.NewRequest("GET", "/users/"+want, nil))
+				if got := rr.Body.String(); got != want {
+					t.Errorf("expected param %q, but got %q", want, got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// BenchmarkRouter_Static measures dispatch to a static route.
+func BenchmarkRouter_Static(b *testing.B) {
+	r := newBenchRouter()
+	w := &discardWriter{header: make(http.Header)}
+	req := httptest.NewRequest("GET", "/users", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkRouter_Param measures dispatch to a route with two parameters.
+func BenchmarkRouter_Param(b *testing.B) {
+	r := newBenchRouter()
+	w := &discardWriter{header: make(http.Header)}
+	req := httptest.NewRequest("GET", "/users/42/posts/7", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkRouter_Parallel measures dispatch from many goroutines at once.
+// Since the routing table is read without a lock, throughput should scale with
+// GOMAXPROCS instead of contending on a mutex.
+func BenchmarkRouter_Parallel(b *testing.B) {
+	r := newBenchRouter()
+	req := httptest.NewRequest("GET", "/users/42", nil)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		w := &discardWriter{header: make(http.Header)}
+		for pb.Next() {
+			r.ServeHTTP(w, req)
+		}
+	})
+}
+
+// TestRouter_Fallback tests that fallbacks run in order when no route matches,
+// and that the not-found handler runs when none of them handles the request.
+func TestRouter_Fallback(t *testing.T) {
+	// 1. Setup: A "static" fallback that only knows /logo.png, followed by a
+	// custom not-found handler.
+	r := New()
+	r.GET("/users", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	var tried []string
+	r.Fallback(
+		func(c *httpcontext.Context) bool {
+			tried = append(tried, "static")
+			if c.Request.URL.Path != "/logo.png" {
+				return false
+			}
+			c.Status(http.StatusNoContent)
+			return true
+		},
+		func(c *httpcontext.Context) bool {
+			tried = append(tried, "proxy")
+			return false
+		},
+	)
+	r.NotFound(func(c *httpcontext.Context) {
+		c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantTried int
+	}{
+		{"/users", http.StatusOK, 0},           // A route matched; no fallback runs.
+		{"/logo.png", http.StatusNoContent, 1}, // The first fallback handles it.
+		{"/missing", http.StatusNotFound, 2},   // Both decline; not-found runs.
+	}
+	for _, tt := range tests {
+		tried = nil
+		rr := httptest.NewRecorder()
+
+		// 2. Execute the request.
+		r.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		// 3. Assert: Check the status code and how many fallbacks were tried.
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status code %d, but got %d", tt.path, tt.wantCode, rr.Code)
+		}
+		if len(tried) != tt.wantTried {
+			t.Errorf("%s: expected %d fallbacks to be tried, but got %v", tt.path, tt.wantTried, tried)
+		}
+	}
+}
+
+// TestFallbackTo tests that FallbackTo always claims the request.
+func TestFallbackTo(t *testing.T) {
+	r := New()
+	r.Fallback(FallbackTo(func(c *httpcontext.Context) { c.Status(http.StatusBadGateway) }))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/anything", nil))
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected status code %d, but got %d", http.StatusBadGateway, rr.Code)
+	}
+}
+
+// TestRouter_Use tests that middleware wraps routes in the order it was added,
+// including routes registered before Use was called.
+func TestRouter_Use(t *testing.T) {
+	// 1. Setup: Two middleware that record the order they run in.
+	r := New()
+	var order []string
+	r.GET("/early", func(c *httpcontext.Context) { order = append(order, "handler") })
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *httpcontext.Context) {
+				order = append(order, name)
+				next(c)
+			}
+		}
+	}
+	r.Use(trace("first"), trace("second"))
+	r.GET("/late", func(c *httpcontext.Context) { order = append(order, "handler") })
+
+	// 2. Execute: Both routes, plus one that doesn't exist.
+	for _, path := range []string{"/early", "/late", "/missing"} {
+		order = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+
+		// 3. Assert: The middleware ran outermost-first, even for the 404.
+		if len(order) < 2 || order[0] != "first" || order[1] != "second" {
+			t.Errorf("%s: unexpected middleware order %v", path, order)
+		}
+	}
+}
+
+// TestRouter_Abort tests that aborting skips the rest of the chain, whether
+// the middleware calls next afterwards or not.
+func TestRouter_Abort(t *testing.T) {
+	// 1. Setup: An auth check that aborts, followed by more middleware.
+	r := New()
+	var order []string
+	r.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			if c.Request.Header.Get("Authorization") == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "login required"})
+			}
+			next(c) // Must be a no-op after Abort.
+			order = append(order, "auth done")
+		}
+	})
+	r.Use(MiddlewareFunc(func(c *httpcontext.Context) { order = append(order, "second") }))
+	r.GET("/secret", func(c *httpcontext.Context) {
+		order = append(order, "handler")
+		c.String(http.StatusOK, "ok")
+	}, With(MiddlewareFunc(func(c *httpcontext.Context) { order = append(order, "route") })))
+
+	// 2. Execute: Without credentials.
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/secret", nil))
+
+	// 3. Assert: Only the aborting middleware ran, and its response was sent.
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "login required") {
+		t.Errorf("unexpected response: %d %q", rr.Code, rr.Body.String())
+	}
+	if fmt.Sprint(order) != "[auth done]" {
+		t.Errorf("unexpected order %v", order)
+	}
+
+	// 4. With credentials, the whole chain runs.
+	order = nil
+	req := httptest.NewRequest("GET", "/secret", nil)
+	req.Header.Set("Authorization", "Bearer x")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if fmt.Sprint(order) != "[second route handler auth done]" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+// TestMiddlewareFunc tests c.Next() in handler-style middleware.
+func TestMiddlewareFunc(t *testing.T) {
+	// 1. Setup: Middleware that runs code around the handler, and one that aborts.
+	r := New()
+	var order []string
+	r.Use(MiddlewareFunc(func(c *httpcontext.Context) {
+		order = append(order, "before")
+		c.Next()
+		c.Next() // A second call must not run the chain again.
+		order = append(order, "after")
+	}))
+	r.GET("/", func(c *httpcontext.Context) { order = append(order, "handler") })
+	r.GET("/blocked", func(c *httpcontext.Context) { order = append(order, "handler") },
+		With(MiddlewareFunc(func(c *httpcontext.Context) { c.AbortWithStatus(http.StatusForbidden) })))
+
+	// 2. Execute & 3. Assert
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if fmt.Sprint(order) != "[before handler after]" {
+		t.Errorf("unexpected order %v", order)
+	}
+
+	order = nil
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/blocked", nil))
+	if rr.Code != http.StatusForbidden || fmt.Sprint(order) != "[before after]" {
+		t.Errorf("unexpected result: %d %v", rr.Code, order)
+	}
+}
+
+// TestHandleErrors tests that recorded errors become consistent responses.
+func TestHandleErrors(t *testing.T) {
+	// 1. Setup: Routes that record different kinds of errors.
+	r := New()
+	r.Use(HandleErrors())
+	r.GET("/private", func(c *httpcontext.Context) { c.Error(errors.New("pq: connection refused")) })
+	r.GET("/public", func(c *httpcontext.Context) {
+		c.Error(errors.New("email already taken")).SetType(httpcontext.ErrorTypePublic).SetStatus(http.StatusConflict)
+	})
+	r.GET("/bind", func(c *httpcontext.Context) {
+		c.Error(&httpcontext.BindError{Status: http.StatusBadRequest, Message: "malformed JSON"})
+	})
+	r.GET("/written", func(c *httpcontext.Context) {
+		c.String(http.StatusAccepted, "done")
+		c.Error(errors.New("late failure"))
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/private", http.StatusInternalServerError, `{"error":"Internal Server Error"}`},
+		{"/public", http.StatusConflict, `{"error":"email already taken"}`},
+		{"/bind", http.StatusBadRequest, `{"error":"malformed JSON"}`},
+		{"/written", http.StatusAccepted, "done"},
+	}
+	for _, tc := range tests {
+		// 2. Execute
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+
+		// 3. Assert
+		if rr.Code != tc.wantStatus || strings.TrimSpace(rr.Body.String()) != tc.wantBody {
+			t.Errorf("%s: got %d %q, want %d %q", tc.path, rr.Code, rr.Body.String(), tc.wantStatus, tc.wantBody)
+		}
+	}
+}
+
+// TestHandleErrorsWith_Reporter tests that server errors are reported with
+// the request and where they were recorded, and client errors aren't.
+func TestHandleErrorsWith_Reporter(t *testing.T) {
+	// 1. Setup
+	var events []*report.Event
+	r := New()
+	r.Use(HandleErrorsWith(ErrorOptions{Reporter: report.ReporterFunc(func(e *report.Event) {
+		events = append(events, e)
+	})}))
+	r.GET("/users/:id", func(c *httpcontext.Context) {
+		if c.Param("id") == "bad" {
+			c.Error(&httpcontext.BindError{Status: http.StatusBadRequest, Message: "bad id"})
+			return
+		}
+		c.Error(errors.New("pq: connection refused"))
+	})
+
+	// 2. Execute
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/bad", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42?expand=teams", nil))
+
+	// 3. Assert
+	if len(events) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(events))
+	}
+	e := events[0]
+	if e.Err.Error() != "pq: connection refused" || e.Status != http.StatusInternalServerError || e.Panic != nil {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e.Request.URL != "/users/42?expand=teams" || e.Request.Route != "/users/:id" || e.Request.Params["id"] != "42" {
+		t.Errorf("unexpected request: %+v", e.Request)
+	}
+	if !strings.Contains(string(e.Stack), "router_test.go") {
+		t.Errorf("expected the stack of c.Error, got:\n%s", e.Stack)
+	}
+}
+
+// TestRouter_Mount tests that a mounted subrouter keeps its own routes,
+// middleware, and not-found handler.
+func TestRouter_Mount(t *testing.T) {
+	// 1. Setup: A subrouter with its own middleware and not-found handler.
+	sub := New()
+	sub.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			c.Writer.Header().Set("X-Sub", "yes")
+			next(c)
+		}
+	})
+	sub.GET("/", func(c *httpcontext.Context) { c.String(http.StatusOK, "admin home") })
+	sub.GET("/users/:id", func(c *httpcontext.Context) {
+		c.String(http.StatusOK, "%s %s", c.FullPath(), c.Param("id"))
+	})
+	sub.NotFound(func(c *httpcontext.Context) { c.Status(http.StatusTeapot) })
+
+	parent := New()
+	parent.GET("/health", func(c *httpcontext.Context) { c.Status(http.StatusOK) })
+	parent.Mount("/admin", sub)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+		wantSub  bool
+	}{
+		{"/health", http.StatusOK, "", false},
+		{"/admin", http.StatusOK, "admin home", true},
+		{"/admin/users/7", http.StatusOK, "/users/:id 7", true},
+		{"/admin/nope", http.StatusTeapot, "", true},
+		{"/administrator", http.StatusNotFound, "404 page not found\n", false},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+
+		// 2. Execute the request against the parent.
+		parent.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		// 3. Assert: The right router answered.
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status code %d, but got %d", tt.path, tt.wantCode, rr.Code)
+		}
+		if rr.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected body %q, but got %q", tt.path, tt.wantBody, rr.Body.String())
+		}
+		if got := rr.Header().Get("X-Sub") == "yes"; got != tt.wantSub {
+			t.Errorf("%s: expected subrouter middleware to run: %v, but got %v", tt.path, tt.wantSub, got)
+		}
+	}
+
+	// 4. Assert: The parent lists the subrouter's routes with the prefix.
+	var paths []string
+	for _, info := range parent.Routes() {
+		paths = append(paths, info.Path)
+	}
+	want := []string{"/admin", "/admin/users/:id", "/health"}
+	if fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("expected routes %v, but got %v", want, paths)
+	}
+}
+
+// TestRouter_Redirect tests declarative redirects, including parameter
+// substitution and query string preservation.
+func TestRouter_Redirect(t *testing.T) {
+	r := New()
+	r.Redirect("GET", "/old-users", "/users", http.StatusMovedPermanently)
+	r.Redirect("GET", "/old-users/:id", "/users/:id", http.StatusFound)
+	r.Redirect("GET", "/assets/*file", "https://cdn.example.com/static/*file", http.StatusTemporaryRedirect)
+	r.Redirect("GET", "/search", "/users?sort=name", http.StatusFound)
+	r.Redirect("GET", "/docs", "/help?#top", http.StatusFound)
+
+	tests := []struct {
+		target   string
+		wantCode int
+		wantLoc  string
+	}{
+		{"/old-users?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"/old-users/42", http.StatusFound, "/users/42"},
+		{"/assets/css/site.css", http.StatusTemporaryRedirect, "https://cdn.example.com/static/css/site.css"},
+		{"/search?name=ann&page=2", http.StatusFound, "/users?sort=name&name=ann&page=2"},
+		{"/search", http.StatusFound, "/users?sort=name"},
+		{"/docs?lang=en", http.StatusFound, "/help?lang=en#top"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", tt.target, nil))
+
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status code %d, but got %d", tt.target, tt.wantCode, rr.Code)
+		}
+		if loc := rr.Header().Get("Location"); loc != tt.wantLoc {
+			t.Errorf("%s: expected Location %q, but got %q", tt.target, tt.wantLoc, loc)
+		}
+	}
+
+	// A non-3xx code is a programming error.
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Redirect with status 200 to panic")
+		}
+	}()
+	r.Redirect("GET", "/bad", "/", http.StatusOK)
+}
+
+// TestRouter_MaxBodySize tests that per-route body limits are enforced both for
+// declared and undeclared content lengths.
+func TestRouter_MaxBodySize(t *testing.T) {
+	// 1. Setup: A route that accepts at most 8 bytes and reads the whole body.
+	r := New()
+	r.POST("/small", func(c *httpcontext.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.Status(http.StatusRequestEntityTooLarge)
+				return
+			}
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	}, MaxBodySize(8))
+
+	tests := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		wantCode      int
+	}{
+		{"within limit", "12345678", false, http.StatusOK},
+		{"declared too large", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"streamed too large", "123456789", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/small", strings.NewReader(tt.body))
+		if tt.unknownLength {
+			// Hide the length, as a chunked upload would.
+			req.ContentLength = -1
+		}
+		rr := httptest.NewRecorder()
+
+		// 2. Execute the request.
+		r.ServeHTTP(rr, req)
+
+		// 3. Assert: Check the status code.
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s: expected status code %d, but got %d", tt.name, tt.wantCode, rr.Code)
+		}
+	}
+}
+
+// TestRouter_Consumes tests that routes reject request bodies with an
+// unsupported Content-Type.
+func TestRouter_Consumes(t *testing.T) {
+	r := New()
+	r.POST("/users", func(c *httpcontext.Context) { c.Status(http.StatusCreated) }, Consumes("application/json"))
+	r.POST("/avatars", func(c *httpcontext.Context) { c.Status(http.StatusCreated) }, Consumes("image/*"))
+
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{"/users", "application/json", "{}", http.StatusCreated},
+		{"/users", "application/json; charset=utf-8", "{}", http.StatusCreated},
+		{"/users", "text/plain", "hi", http.StatusUnsupportedMediaType},
+		{"/users", "", "{}", http.StatusUnsupportedMediaType},
+		{"/users", "", "", http.StatusCreated}, // No body, nothing to check.
+		{"/avatars", "image/png", "PNG", http.StatusCreated},
+		{"/avatars", "application/json", "{}", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != tt.wantCode {
+			t.Errorf("%s with %q: expected status code %d, but got %d", tt.path, tt.contentType, tt.wantCode, rr.Code)
+		}
+	}
+}
+
+// TestRouter_Debug tests that debug mode logs the routing decision.
+func TestRouter_Debug(t *testing.T) {
+	// 1. Setup: Capture the standard logger's output.
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	r := New()
+	r.GET("/users/:id", func(c *httpcontext.Context) {}, MaxBodySize(10))
+	r.SetDebug(true)
+
+	// 2. Execute a request that matches a parameterized route.
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	// 3. Assert: The log explains the decision.
+	out := buf.String()
+	for _, want := range []string{
+		`no static route`,
+		`candidate "/users/:id": match=true`,
+		`param id="42"`,
+		`router.LimitBody`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected debug output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// 4. Assert: Nothing is logged once debug mode is off again.
+	r.SetDebug(false)
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	if strings.Contains(buf.String(), "[router debug]") {
+		t.Errorf("expected no debug output, got:\n%s", buf.String())
+	}
+}
+
+// TestByVersion tests dispatching on the API version in the Accept header.
+func TestByVersion(t *testing.T) {
+	r := New()
+	r.GET("/users", ByVersion("myapp", "v1", Versions{
+		"v1": func(c *httpcontext.Context) { c.String(http.StatusOK, "v1") },
+		"v2": func(c *httpcontext.Context) { c.String(http.StatusOK, "v2") },
+	}))
+
+	tests := []struct {
+		accept   string
+		wantCode int
+		wantBody string
+	}{
+		{"", http.StatusOK, "v1"},
+		{"application/json", http.StatusOK, "v1"},
+		{"application/vnd.myapp.v2+json", http.StatusOK, "v2"},
+		{"text/html, application/vnd.myapp+json; version=2", http.StatusOK, "v2"},
+		{"application/vnd.myapp.v9+json", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != tt.wantCode {
+			t.Errorf("Accept %q: expected status code %d, but got %d", tt.accept, tt.wantCode, rr.Code)
+		}
+		if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+			t.Errorf("Accept %q: expected body %q, but got %q", tt.accept, tt.wantBody, rr.Body.String())
+		}
+		if vary := rr.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept, but got %q", tt.accept, vary)
+		}
+	}
+}
+
+// TestRouter_Precedence tests that overlapping routes resolve deterministically
+// (static > param > wildcard), regardless of registration order.
+func TestRouter_Precedence(t *testing.T) {
+	patterns := []string{
+		"/users/*rest",
+		"/users/:id/posts",
+		"/users/:id",
+		"/users/new",
+		"/users/new/:tab",
+		"/files/*path",
+		"/files/:dir/x",
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/new", "/users/new"},
+		{"/users/42", "/users/:id"},
+		{"/users/42/posts", "/users/:id/posts"},
+		{"/users/new/settings", "/users/new/:tab"},
+		{"/users/42/posts/7", "/users/*rest"},
+		{"/files/a/x", "/files/:dir/x"},
+		{"/files/a/y", "/files/*path"},
+	}
+
+	// Register the routes forwards and backwards: the outcome must not change.
+	for _, reverse := range []bool{false, true} {
+		r := New()
+		for i := range patterns {
+			p := patterns[i]
+			if reverse {
+				p = patterns[len(patterns)-1-i]
+			}
+			r.GET(p, func(c *httpcontext.Context) { c.String(http.StatusOK, "%s", c.FullPath()) })
+		}
+
+		for _, tt := range tests {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if got := rr.Body.String(); got != tt.want {
+				t.Errorf("reverse=%v %s: expected route %q, but got %q", reverse, tt.path, tt.want, got)
+			}
+		}
+	}
+}
+
+// TestRouter_Timeout tests that a slow handler is cut off and its request
+// context is cancelled.
+func TestRouter_Timeout(t *testing.T) {
+	// 1. Setup: A handler that waits for its context to be cancelled.
+	r := New()
+	cancelled := make(chan struct{})
+	r.GET("/slow/:id", func(c *httpcontext.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			close(cancelled)
+		case <-time.After(time.Second):
+		}
+		// This write happens after the timeout and must not reach the client.
+		c.String(http.StatusOK, "too late for %s", c.Param("id"))
+	}, Timeout(20*time.Millisecond))
+	r.GET("/fast", func(c *httpcontext.Context) { c.String(http.StatusOK, "ok") }, Timeout(time.Second))
+
+	// 2. Execute the slow request.
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/slow/1", nil))
+
+	// 3. Assert: The client got a 503 and the handler saw the cancellation.
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status code %d, but got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the handler's request context to be cancelled")
+	}
+
+	// 4. Assert: A fast handler is unaffected.
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/fast", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+		t.Errorf("expected 200 ok, but got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+// TestRouter_ContextCarriedOver tests that handlers run in a mounted
+// subrouter or under a timeout see the request's ID and sampling mark, and
+// that the errors they record reach the outer router's middleware.
+func TestRouter_ContextCarriedOver(t *testing.T) {
+	// 1. Setup: Outer middleware that marks the request and collects its
+	// errors, and handlers that record what they saw.
+	var seenID string
+	var seenSampled bool
+	handler := func(c *httpcontext.Context) {
+		seenID, seenSampled = c.RequestID(), c.Sampled()
+		c.Error(errors.New("inner failure"))
+		c.Abort()
+	}
+	sub := New()
+	sub.GET("/x", handler)
+
+	parent := New()
+	var gotErrs []string
+	var gotAborted bool
+	parent.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *httpcontext.Context) {
+			c.SetRequestID("req-1")
+			c.SetSampled(true)
+			next(c)
+			gotErrs = nil
+			for _, e := range c.Errors() {
+				gotErrs = append(gotErrs, e.Error())
+			}
+			gotAborted = c.IsAborted()
+		}
+	})
+	parent.Mount("/sub", sub)
+	parent.GET("/timed", handler, Timeout(time.Second))
+
+	for _, path := range []string{"/sub/x", "/timed"} {
+		seenID, seenSampled = "", false
+
+		// 2. Execute the request.
+		parent.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+
+		// 3. Assert: The handler saw the request's marks, and its error
+		// and abort came back out.
+		if seenID != "req-1" || !seenSampled {
+			t.Errorf("%s: expected request ID %q, sampled, but got %q, %v", path, "req-1", seenID, seenSampled)
+		}
+		if fmt.Sprint(gotErrs) != "[inner failure]" {
+			t.Errorf("%s: expected errors [inner failure], but got %v", path, gotErrs)
+		}
+		if !gotAborted {
+			t.Errorf("%s: expected the request to be aborted", path)
+		}
+	}
+}
+
+// TestRouter_SetJSONOptions tests that a router's JSON options apply to its
+// handlers, time-limited ones, and subrouters without options of their own.
+func TestRouter_SetJSONOptions(t *testing.T) {
+	// 1. Setup: An indenting parent, a subrouter inheriting that, and one
+	// with options of its own.
+	handler := func(c *httpcontext.Context) { c.JSON(http.StatusOK, map[string]string{"a": "<b>"}) }
+	inherits := New()
+	inherits.GET("/x", handler)
+	own := New()
+	own.GET("/x", handler)
+	own.SetJSONOptions(httpcontext.JSONOptions{DisableHTMLEscape: true})
+	parent := New()
+	parent.SetJSONOptions(httpcontext.JSONOptions{Indent: " "})
+	parent.GET("/x", handler)
+	parent.GET("/timed", handler, Timeout(time.Second))
+	parent.Mount("/inherits", inherits)
+	parent.Mount("/own", own)
+
+	indented := "{\n \"a\": \"\\u003cb\\u003e\"\n}\n"
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/x", indented},
+		{"/timed", indented},
+		{"/inherits/x", indented},
+		{"/own/x", `{"a":"<b>"}` + "\n"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+
+		// 2. Execute
+		parent.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		// 3. Assert
+		if rr.Body.String() != tt.want {
+			t.Errorf("%s: expected body %q, but got %q", tt.path, tt.want, rr.Body.String())
+		}
+	}
+}
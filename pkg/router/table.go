@@ -0,0 +1,194 @@
+package router
+
+// Description: This file implements the routing table. The table is treated as
+// immutable once published: every registration or removal builds a modified copy
+// and atomically swaps it in ("copy-on-write"). Requests therefore read the
+// table without taking any lock, which removes lock contention from the hot path.
+// Registration gets a little slower, but it happens rarely compared to serving.
+
+import (
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// table holds every route known to a Router at one point in time.
+type table struct {
+	// routes is a map that stores the registered routes. The structure is:
+	// map[HTTP_METHOD]map[URL_PATH]*route
+	// For example: routes["GET"]["/users"].handler = GetUsersHandler
+	routes map[string]map[string]*route
+
+	// patterns holds, per HTTP method, the routes whose path contains
+	// parameters (e.g. "/users/:id") or a wildcard (e.g. "/static/*filepath").
+	// They can't be found with a single map lookup, so find falls back to
+	// checking them in order when no static route matches.
+	patterns map[string][]*route
+
+	// fallbacks are tried in order when no route matches, and notFound runs
+	// when none of them handled the request. See fallback.go.
+	fallbacks []FallbackFunc
+	notFound  HandlerFunc
+
+	// fallbackChain is serveFallback wrapped in the middleware stack, so
+	// middleware like logging also sees requests that matched no route.
+	fallbackChain HandlerFunc
+
+	// middleware is the router's middleware stack, outermost first.
+	middleware []Middleware
+
+	// mounts are the subrouters attached with Mount. See mount.go.
+	mounts []*mount
+}
+
+// newTable returns an empty routing table.
+func newTable() *table {
+	return &table{
+		// It's crucial to initialize nested maps as well.
+		routes:   make(map[string]map[string]*route),
+		patterns: make(map[string][]*route),
+	}
+}
+
+// clone returns a copy of the table that can be modified without affecting
+// requests that are still reading the original. Routes themselves are shared,
+// since they are never modified after registration.
+func (t *table) clone() *table {
+	c := newTable()
+	for method, pathRoutes := range t.routes {
+		copied := make(map[string]*route, len(pathRoutes))
+		for path, rt := range pathRoutes {
+			copied[path] = rt
+		}
+		c.routes[method] = copied
+	}
+	for method, list := range t.patterns {
+		c.patterns[method] = append([]*route(nil), list...)
+	}
+	c.fallbacks = append([]FallbackFunc(nil), t.fallbacks...)
+	c.notFound = t.notFound
+	c.middleware = append([]Middleware(nil), t.middleware...)
+	c.mounts = append([]*mount(nil), t.mounts...)
+	return c
+}
+
+// set adds or replaces the route for a method and path. It reports whether an
+// existing route was replaced. New patterns are inserted according to their
+// precedence (see morePrecise); a replaced pattern keeps its position.
+func (t *table) set(method, path string, rt *route) (replaced bool) {
+	rt.chain = t.wrap(rt.handler)
+
+	// Check if the map for the given HTTP method exists.
+	if t.routes[method] == nil {
+		// If not, create it.
+		t.routes[method] = make(map[string]*route)
+	}
+
+	old, replaced := t.routes[method][path]
+	t.routes[method][path] = rt
+	if rt.segments == nil {
+		return replaced
+	}
+	if replaced {
+		for i, p := range t.patterns[method] {
+			if p == old {
+				t.patterns[method][i] = rt
+				return true
+			}
+		}
+	}
+	// Insert the pattern before the first one it takes precedence over, so
+	// find can simply return the first match. Patterns of equal precedence
+	// (e.g. "/users/:id" and "/users/:name") keep registration order.
+	list := t.patterns[method]
+	i := 0
+	for i < len(list) && !morePrecise(rt.segments, list[i].segments) {
+		if samePrecedence(rt.segments, list[i].segments) {
+			logger.Warn("Overlapping routes; the first one registered wins",
+				"method", method, "path", rt.info.Path, "overlaps", list[i].info.Path)
+		}
+		i++
+	}
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = rt
+	t.patterns[method] = list
+	return replaced
+}
+
+// delete removes the route for a method and path, reporting whether it existed.
+func (t *table) delete(method, path string) bool {
+	rt, ok := t.routes[method][path]
+	if !ok {
+		return false
+	}
+	delete(t.routes[method], path)
+
+	// Drop the method's map once it is empty so we don't keep an entry
+	// around for a method that has no routes left.
+	if len(t.routes[method]) == 0 {
+		delete(t.routes, method)
+	}
+
+	if rt.segments != nil {
+		list := t.patterns[method]
+		for i, p := range list {
+			if p == rt {
+				t.patterns[method] = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(t.patterns[method]) == 0 {
+			delete(t.patterns, method)
+		}
+	}
+	return true
+}
+
+// find looks up the route for a method and path. Static routes are tried first
+// with a single map lookup; parameterized routes are then tried from the most to
+// the least precise. Extracted parameters are appended to buf, whose
+// capacity is reused.
+func (t *table) find(method, path string, buf httpcontext.Params) (*route, httpcontext.Params) {
+	// A pattern route is also stored in the routes map under its pattern
+	// text, so we make sure a request for the literal "/users/:id" doesn't hit it.
+	if rt, ok := t.routes[method][path]; ok && rt.segments == nil {
+		return rt, nil
+	}
+	for _, rt := range t.patterns[method] {
+		if params, ok := matchPattern(rt.segments, path, buf[:0]); ok {
+			return rt, params
+		}
+	}
+	return nil, nil
+}
+
+// wrap applies the table's middleware stack to a handler. The middleware is
+// applied in reverse so that the first one added ends up outermost.
+func (t *table) wrap(h HandlerFunc) HandlerFunc {
+	return apply(h, t.middleware)
+}
+
+// rewrap rebuilds the middleware chain of every route and mount after the
+// middleware stack changed. Routes are shared with older versions of the table,
+// so we replace them with updated copies instead of modifying them.
+func (t *table) rewrap() {
+	updated := make(map[*route]*route)
+	for _, pathRoutes := range t.routes {
+		for path, rt := range pathRoutes {
+			copied := *rt
+			copied.chain = t.wrap(rt.handler)
+			pathRoutes[path] = &copied
+			updated[rt] = &copied
+		}
+	}
+	for _, list := range t.patterns {
+		for i, rt := range list {
+			list[i] = updated[rt]
+		}
+	}
+	for i, m := range t.mounts {
+		copied := *m
+		copied.chain = t.wrap(m.handler)
+		t.mounts[i] = &copied
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+// Description: This file implements API version routing based on the Accept
+// header. Besides path-prefix versioning (mount "/v1" and "/v2" subrouters), a
+// client can keep the same URL and ask for a version through a vendor media type:
+//
+//	Accept: application/vnd.myapp.v2+json
+//	Accept: application/vnd.myapp+json; version=2
+//
+// Both forms select version "v2".
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/httpcontext"
+)
+
+// Versions maps an API version (e.g. "v1", "v2") to the handler implementing it.
+type Versions map[string]HandlerFunc
+
+// ByVersion returns a handler that dispatches to the handler for the API version
+// requested in the Accept header. vendor is the vendor name used in the media
+// type ("myapp" in "application/vnd.myapp.v2+json"). Requests that don't ask for
+// a specific version get defaultVersion; requests for an unknown version get
+// 406 Not Acceptable.
+//
+//	r.GET("/users", router.ByVersion("myapp", "v1", router.Versions{
+//		"v1": listUsersV1,
+//		"v2": listUsersV2,
+//	}))
+func ByVersion(vendor, defaultVersion string, versions Versions) HandlerFunc {
+	return func(c *httpcontext.Context) {
+		version := RequestedVersion(c.Request, vendor)
+		if version == "" {
+			version = defaultVersion
+		}
+		// Caches must know the response depends on the Accept header,
+		// the 406 as much as the versions' answers.
+		c.AddHeader("Vary", "Accept")
+		handler, ok := versions[version]
+		if !ok {
+			http.Error(c.Writer, "unsupported API version "+version, http.StatusNotAcceptable)
+			return
+		}
+		handler(c)
+	}
+}
+
+// RequestedVersion extracts the API version a request asks for from its Accept
+// header, or returns "" if it doesn't ask for one.
+func RequestedVersion(req *http.Request, vendor string) string {
+	prefix := "application/vnd." + strings.ToLower(vendor)
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+
+		// Drop the "+json" style structured-syntax suffix, if any.
+		rest := strings.TrimPrefix(mediaType, prefix)
+		if i := strings.IndexByte(rest, '+'); i >= 0 {
+			rest = rest[:i]
+		}
+
+		// "application/vnd.myapp.v2" form.
+		if strings.HasPrefix(rest, ".") && len(rest) > 1 {
+			return rest[1:]
+		}
+		// "application/vnd.myapp; version=2" form.
+		if rest == "" {
+			if v := params["version"]; v != "" {
+				if !strings.HasPrefix(v, "v") {
+					v = "v" + v
+				}
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+// Description: This file contains WithAdmin, which runs a second, internal
+// server next to the public one, for endpoints like health checks, metrics,
+// and profiling (see the admin package) that must not be reachable through
+// the public listener. Bind it to a loopback or private address.
+
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// WithAdmin also serves h on addr over plain HTTP, e.g. "127.0.0.1:9090". It
+// starts and stops with the server. The write timeout is generous, since CPU
+// profiles and traces take 30 seconds by default.
+func WithAdmin(addr string, h http.Handler) Option {
+	return func(s *Server) {
+		s.admin = &http.Server{
+			Addr:         addr,
+			Handler:      h,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 2 * time.Minute,
+			IdleTimeout:  120 * time.Second,
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Description: This file contains tests for the admin server option.
+
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// TestServer_WithAdmin tests serving the admin handler on its own port.
+func TestServer_WithAdmin(t *testing.T) {
+	// 1. Setup
+	addr, adminAddr := freeAddr(t), freeAddr(t)
+	admin := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "admin")
+	})
+	startServer(t, New(addr, hello, WithAdmin(adminAddr, admin)))
+
+	// 2. Execute
+	public := get(t, http.DefaultClient, "http://"+addr+"/")
+	defer public.Body.Close()
+	internal := get(t, http.DefaultClient, "http://"+adminAddr+"/")
+	defer internal.Body.Close()
+
+	// 3. Assert: Each port serves its own handler.
+	if body, _ := io.ReadAll(public.Body); string(body) != "hello" {
+		t.Errorf("public port got %q", body)
+	}
+	if body, _ := io.ReadAll(internal.Body); string(body) != "admin" {
+		t.Errorf("admin port got %q", body)
+	}
+}
+
+// TestServer_AdminPortInUse tests that Start reports a taken admin port.
+func TestServer_AdminPortInUse(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	addr := freeAddr(t)
+
+	if err := New(addr, hello, WithAdmin(taken.Addr().String(), hello)).Start(); err == nil {
+		t.Error("expected an error for the admin port in use")
+	}
+	// The public listener was released again.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("public port not released: %v", err)
+	}
+	ln.Close()
+}
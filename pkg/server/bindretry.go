@@ -0,0 +1,66 @@
+// Description: This file contains the bind retry. During a rolling restart
+// the new instance may start before the old one has released the port, and
+// failing right away would just get it restarted again, so Start can keep
+// trying for a while instead, waiting longer between attempts.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// BindRetryOptions configures WithBindRetry.
+type BindRetryOptions struct {
+	// For is how long to keep trying before Start fails. It must be
+	// positive.
+	For time.Duration
+
+	// InitialDelay is the wait after the first failure, doubling after each
+	// further one up to MaxDelay. The defaults are 100ms and 2s.
+	InitialDelay, MaxDelay time.Duration
+}
+
+// WithBindRetry makes Start retry listening on an address that's already in
+// use, with exponential backoff, for up to opts.For. Other errors, like a
+// malformed address, still fail right away.
+func WithBindRetry(opts BindRetryOptions) Option {
+	if opts.For <= 0 {
+		panic(fmt.Errorf("server: bind retry duration must be positive, got %v", opts.For))
+	}
+	if opts.InitialDelay <= 0 {
+		opts.InitialDelay = 100 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 2 * time.Second
+	}
+	return func(s *Server) {
+		s.bindRetry = &opts
+	}
+}
+
+// bind listens on addr, retrying as configured with WithBindRetry.
+func (s *Server) bind(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if s.bindRetry == nil {
+		return ln, err
+	}
+	deadline := time.Now().Add(s.bindRetry.For)
+	delay := s.bindRetry.InitialDelay
+	for err != nil && errors.Is(err, syscall.EADDRINUSE) {
+		wait := min(delay, time.Until(deadline))
+		if wait <= 0 {
+			return nil, fmt.Errorf("%w (retried for %v)", err, s.bindRetry.For)
+		}
+		logger.Warn("Address in use, retrying", "addr", addr, "in", wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		delay = min(2*delay, s.bindRetry.MaxDelay)
+		ln, err = net.Listen("tcp", addr)
+	}
+	return ln, err
+}
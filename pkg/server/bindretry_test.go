@@ -0,0 +1,70 @@
+// Description: This file contains tests for the bind retry.
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServer_BindRetry tests that Start waits for a port to be released.
+func TestServer_BindRetry(t *testing.T) {
+	// 1. Setup: The "old instance" holds the port for a while.
+	old, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := old.Addr().String()
+	time.AfterFunc(200*time.Millisecond, func() { old.Close() })
+
+	// 2. Execute
+	startServer(t, New(addr, hello, WithBindRetry(BindRetryOptions{For: 5 * time.Second, InitialDelay: 10 * time.Millisecond})))
+
+	// 3. Assert
+	resp := get(t, http.DefaultClient, "http://"+addr+"/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got %d", resp.StatusCode)
+	}
+}
+
+// TestServer_BindRetryGivesUp tests that Start fails once the retry time is
+// up, and right away on errors other than the port being in use.
+func TestServer_BindRetryGivesUp(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr string
+		maxTime time.Duration
+	}{
+		{"in use", taken.Addr().String(), "retried for 100ms", 2 * time.Second},
+		{"malformed", "127.0.0.1:http-alt-nope", "unknown port", 50 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			s := New(tc.addr, hello, WithBindRetry(BindRetryOptions{For: 100 * time.Millisecond, InitialDelay: 10 * time.Millisecond}))
+
+			// 2. Execute
+			start := time.Now()
+			err := s.Start()
+
+			// 3. Assert
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+			if elapsed := time.Since(start); elapsed > tc.maxTime {
+				t.Errorf("Start took %v", elapsed)
+			}
+		})
+	}
+}
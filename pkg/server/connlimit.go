@@ -0,0 +1,142 @@
+// Description: This file contains the connection limit. Every connection costs
+// a file descriptor and some memory until it's closed, including idle
+// keep-alive and hijacked (WebSocket) ones, so a traffic spike can exhaust the
+// process's descriptors and break everything else it does, like opening
+// files or connecting to a database. Capping the connections at the listener
+// keeps that in check.
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ConnLimitOptions configures LimitListener and WithConnLimit.
+type ConnLimitOptions struct {
+	// Max is the most connections open at once. It must be positive.
+	Max int
+
+	// Reject closes connections over the limit right away, after answering
+	// plain HTTP ones with 503 Service Unavailable. By default they aren't
+	// accepted until a connection closes, so they wait in the kernel's
+	// listen backlog, which costs no descriptors; clients whose connect
+	// times out meanwhile see the same as a refusal.
+	Reject bool
+}
+
+// LimitListener returns a listener accepting at most opts.Max connections at
+// once from ln. A connection counts until it's closed.
+func LimitListener(ln net.Listener, opts ConnLimitOptions) net.Listener {
+	return limitListener(ln, opts, nil)
+}
+
+// WithConnLimit limits the server's open connections, see LimitListener.
+func WithConnLimit(opts ConnLimitOptions) Option {
+	if opts.Max <= 0 {
+		panic(fmt.Errorf("server: connection limit must be positive, got %d", opts.Max))
+	}
+	return func(s *Server) {
+		s.connLimit = &opts
+	}
+}
+
+// rejectResponse answers plain HTTP connections over the limit.
+var rejectResponse = []byte("HTTP/1.1 503 Service Unavailable\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Retry-After: 1\r\n" +
+	"Connection: close\r\n" +
+	"Content-Length: 20\r\n" +
+	"\r\n" +
+	"too many connections")
+
+// limitListener is LimitListener, answering rejected connections with reply,
+// if not nil.
+func limitListener(ln net.Listener, opts ConnLimitOptions, reply []byte) net.Listener {
+	if opts.Max <= 0 {
+		panic(fmt.Errorf("server: connection limit must be positive, got %d", opts.Max))
+	}
+	return &connLimiter{
+		Listener: ln,
+		opts:     opts,
+		reply:    reply,
+		slots:    make(chan struct{}, opts.Max),
+		done:     make(chan struct{}),
+	}
+}
+
+// connLimiter holds a slot in slots for each open connection.
+type connLimiter struct {
+	net.Listener
+	opts  ConnLimitOptions
+	reply []byte
+
+	slots     chan struct{}
+	done      chan struct{} // closed by Close, to wake a waiting Accept
+	closeOnce sync.Once
+}
+
+// Accept implements net.Listener.
+func (l *connLimiter) Accept() (net.Conn, error) {
+	for {
+		if !l.opts.Reject {
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.done:
+				return nil, net.ErrClosed
+			}
+		}
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if !l.opts.Reject {
+				<-l.slots
+			}
+			return nil, err
+		}
+		if l.opts.Reject {
+			select {
+			case l.slots <- struct{}{}:
+			default:
+				l.reject(conn)
+				continue
+			}
+		}
+		return &limitedConn{Conn: conn, release: l.release}, nil
+	}
+}
+
+// reject turns conn away without blocking the accept loop for long.
+func (l *connLimiter) reject(conn net.Conn) {
+	if l.reply != nil {
+		conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+		conn.Write(l.reply)
+	}
+	conn.Close()
+}
+
+// release frees a connection's slot.
+func (l *connLimiter) release() {
+	<-l.slots
+}
+
+// Close implements net.Listener.
+func (l *connLimiter) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// limitedConn frees its slot when it's first closed.
+type limitedConn struct {
+	net.Conn
+	release   func()
+	closeOnce sync.Once
+}
+
+// Close implements net.Conn.
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.release)
+	return err
+}
@@ -0,0 +1,117 @@
+// Description: This file contains tests for the connection limit.
+
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestLimitListener tests that connections over the limit wait until one
+// closes.
+func TestLimitListener(t *testing.T) {
+	// 1. Setup
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := LimitListener(inner, ConnLimitOptions{Max: 1})
+	defer ln.Close()
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	// 2. Execute: Two clients connect; the kernel completes both handshakes.
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+	}
+
+	// 3. Assert: Only the first is accepted, until it's closed.
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.Close()
+	first.Close() // frees the slot only once
+	select {
+	case second := <-accepted:
+		second.Close()
+	case <-time.After(time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+}
+
+// TestServer_ConnLimitReject tests rejecting connections over the limit.
+func TestServer_ConnLimitReject(t *testing.T) {
+	// 1. Setup: /slow keeps the only connection busy.
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.Handle("/", hello)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	s := New(addr, mux, WithConnLimit(ConnLimitOptions{Max: 1, Reject: true}))
+	startServer(t, s)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp := get(t, client, "http://"+addr+"/")
+	resp.Body.Close()
+	go func() {
+		if resp, err := client.Get("http://" + addr + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// 2. Execute
+	resp, err := client.Get("http://" + addr + "/")
+
+	// 3. Assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || string(body) != "too many connections" {
+		t.Errorf("got %d %q, want a 503", resp.StatusCode, body)
+	}
+
+	// The slot frees up once /slow is done.
+	close(release)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = client.Get("http://" + addr + "/"); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+	}
+	t.Error("connections still rejected after the busy one closed")
+}
+
+// TestWithConnLimit_Invalid tests that a limit below one is refused.
+func TestWithConnLimit_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	WithConnLimit(ConnLimitOptions{})
+}
@@ -0,0 +1,127 @@
+// Description: This file contains the connection state observers. The
+// http.Server reports every connection's transitions (new, active while a
+// request is handled, idle between keep-alive requests, then closed or
+// hijacked), which is what connection accounting needs: how many clients
+// hold a connection, and how many of those are only idling. ConnMetrics
+// keeps the counts and can be published with expvar:
+//
+//	conns := new(server.ConnMetrics)
+//	expvar.Publish("connections", conns)
+//	s := server.New(":8080", r, server.WithConnState(conns))
+
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ConnObserver is told about every connection state change.
+type ConnObserver interface {
+	// ConnState is called like http.Server.ConnState, on the connection's
+	// goroutine, so it must be quick and safe for concurrent use.
+	ConnState(c net.Conn, state http.ConnState)
+}
+
+// ConnObserverFunc adapts a function to a ConnObserver.
+type ConnObserverFunc func(c net.Conn, state http.ConnState)
+
+// ConnState calls f.
+func (f ConnObserverFunc) ConnState(c net.Conn, state http.ConnState) {
+	f(c, state)
+}
+
+// WithConnState reports the main server's connection state changes to the
+// observers, in order. It may be given more than once.
+func WithConnState(observers ...ConnObserver) Option {
+	return func(s *Server) {
+		prev := s.httpServer.ConnState
+		s.httpServer.ConnState = func(c net.Conn, state http.ConnState) {
+			if prev != nil {
+				prev(c, state)
+			}
+			for _, o := range observers {
+				o.ConnState(c, state)
+			}
+		}
+	}
+}
+
+// ConnStats are the connection counts at one moment.
+type ConnStats struct {
+	// New, Active, and Idle are the open connections in each state: ones
+	// that haven't sent a request yet, ones handling one, and keep-alive
+	// ones waiting for the next.
+	New    int `json:"new"`
+	Active int `json:"active"`
+	Idle   int `json:"idle"`
+
+	// Accepted, Closed, and Hijacked count the connections since the start.
+	// Hijacked ones (e.g. WebSockets) are no longer tracked.
+	Accepted uint64 `json:"accepted"`
+	Closed   uint64 `json:"closed"`
+	Hijacked uint64 `json:"hijacked"`
+}
+
+// ConnMetrics is a ConnObserver counting the connections by state. The zero
+// value is ready to use, and it's safe for concurrent use.
+type ConnMetrics struct {
+	mu    sync.Mutex
+	stats ConnStats
+
+	// states holds each open connection's current state, so a transition
+	// can take it off the right gauge.
+	states map[net.Conn]http.ConnState
+}
+
+// ConnState implements ConnObserver.
+func (m *ConnMetrics) ConnState(c net.Conn, state http.ConnState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.states == nil {
+		m.states = make(map[net.Conn]http.ConnState)
+	}
+	if prev, ok := m.states[c]; ok {
+		*m.gauge(prev)--
+		delete(m.states, c)
+	}
+	switch state {
+	case http.StateNew:
+		m.stats.Accepted++
+	case http.StateClosed:
+		m.stats.Closed++
+		return
+	case http.StateHijacked:
+		m.stats.Hijacked++
+		return
+	}
+	*m.gauge(state)++
+	m.states[c] = state
+}
+
+// gauge returns the count of open connections in state, which is New,
+// Active, or Idle.
+func (m *ConnMetrics) gauge(state http.ConnState) *int {
+	switch state {
+	case http.StateActive:
+		return &m.stats.Active
+	case http.StateIdle:
+		return &m.stats.Idle
+	}
+	return &m.stats.New
+}
+
+// Stats returns the current counts.
+func (m *ConnMetrics) Stats() ConnStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// String returns the counts as JSON, making ConnMetrics an expvar.Var.
+func (m *ConnMetrics) String() string {
+	data, _ := json.Marshal(m.Stats())
+	return string(data)
+}
@@ -0,0 +1,99 @@
+// Description: This file contains tests for the connection state observers.
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConnMetrics tests the counts after sequences of state changes.
+func TestConnMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		states [][]http.ConnState // per connection
+		want   ConnStats
+	}{
+		{"new", [][]http.ConnState{{http.StateNew}}, ConnStats{New: 1, Accepted: 1}},
+		{"keep-alive", [][]http.ConnState{
+			{http.StateNew, http.StateActive, http.StateIdle},
+			{http.StateNew, http.StateActive},
+		}, ConnStats{Active: 1, Idle: 1, Accepted: 2}},
+		{"closed", [][]http.ConnState{
+			{http.StateNew, http.StateActive, http.StateIdle, http.StateActive, http.StateIdle, http.StateClosed},
+		}, ConnStats{Accepted: 1, Closed: 1}},
+		{"hijacked", [][]http.ConnState{
+			{http.StateNew, http.StateActive, http.StateHijacked},
+		}, ConnStats{Accepted: 1, Hijacked: 1}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			var m ConnMetrics
+
+			// 2. Execute
+			for _, states := range tc.states {
+				c, other := net.Pipe()
+				defer c.Close()
+				defer other.Close()
+				for _, state := range states {
+					m.ConnState(c, state)
+				}
+			}
+
+			// 3. Assert
+			if got := m.Stats(); got != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestServer_ConnState tests that the observers see a server's connections.
+func TestServer_ConnState(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	var metrics ConnMetrics
+	var changes atomic.Int32
+	counter := ConnObserverFunc(func(net.Conn, http.ConnState) { changes.Add(1) })
+	startServer(t, New(addr, hello, WithConnState(&metrics), WithConnState(counter)))
+	transport := &http.Transport{}
+	client := &http.Client{Transport: transport}
+
+	// 2. Execute: The keep-alive connection stays open and idle.
+	resp := get(t, client, "http://"+addr+"/")
+	resp.Body.Close()
+	idle := waitStats(t, &metrics, func(s ConnStats) bool { return s.Idle == 1 })
+	transport.CloseIdleConnections()
+	closed := waitStats(t, &metrics, func(s ConnStats) bool { return s.Closed == 1 })
+
+	// 3. Assert
+	if idle.Accepted != 1 || idle.Active != 0 {
+		t.Errorf("unexpected counts with an idle connection: %+v", idle)
+	}
+	if closed.Idle != 0 || closed.New != 0 {
+		t.Errorf("unexpected counts after closing: %+v", closed)
+	}
+	if changes.Load() < 4 {
+		t.Errorf("expected the second observer to see the changes, got %d", changes.Load())
+	}
+	if want := `"idle":0`; !strings.Contains(metrics.String(), want) {
+		t.Errorf("expected %s in %s", want, metrics.String())
+	}
+}
+
+// waitStats polls m until ok accepts its counts.
+func waitStats(t *testing.T, m *ConnMetrics, ok func(ConnStats) bool) ConnStats {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if s := m.Stats(); ok(s) {
+			return s
+		}
+	}
+	t.Fatalf("counts never matched, last %+v", m.Stats())
+	return ConnStats{}
+}
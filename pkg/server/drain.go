@@ -0,0 +1,29 @@
+// Description: This file contains drain mode, for taking an instance out of
+// a load balancer before stopping it. While draining, the server still
+// serves every request, but closes each connection after its response and
+// closes the idle ones, so clients reconnect, and readiness checks fail (see
+// admin.RegisterDrain), so the load balancer sends the new connections
+// elsewhere. Once traffic has moved, Stop finds little left to wait for.
+
+package server
+
+import "github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+
+// SetDraining switches drain mode on or off. Turning it off serves
+// keep-alive connections again.
+func (s *Server) SetDraining(on bool) {
+	if s.draining.Swap(on) == on {
+		return
+	}
+	s.httpServer.SetKeepAlivesEnabled(!on)
+	if on {
+		logger.Info("Draining: closing connections after their responses")
+	} else {
+		logger.Info("No longer draining")
+	}
+}
+
+// Draining reports whether drain mode is on.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
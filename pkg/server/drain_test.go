@@ -0,0 +1,46 @@
+// Description: This file contains tests for drain mode.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestServer_Draining tests that draining closes connections after their
+// responses, and that turning it off keeps them open again.
+func TestServer_Draining(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	s := New(addr, hello)
+	startServer(t, s)
+	client := &http.Client{Transport: &http.Transport{}}
+	resp := get(t, client, "http://"+addr+"/")
+	resp.Body.Close()
+	if resp.Close {
+		t.Fatal("expected a keep-alive response before draining")
+	}
+
+	tests := []struct {
+		name      string
+		draining  bool
+		wantClose bool
+	}{
+		{"draining", true, true},
+		{"again", true, true},
+		{"undrained", false, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			s.SetDraining(tc.draining)
+			resp := get(t, client, "http://"+addr+"/")
+			resp.Body.Close()
+
+			// 3. Assert
+			if s.Draining() != tc.draining || resp.StatusCode != http.StatusOK || resp.Close != tc.wantClose {
+				t.Errorf("expected draining %v and Close %v, got %v, %d, Close %v", tc.draining, tc.wantClose, s.Draining(), resp.StatusCode, resp.Close)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Description: This file contains the lifecycle hooks. Resources the handlers
+// depend on are registered with the server, so one place controls their life:
+//
+//	s.OnStart(registry.Register)     // once the port is bound
+//	s.OnShutdown(db.Close)           // once in-flight requests are done
+//	s.OnShutdown(logs.Flush)         // runs before db.Close
+//
+// Start hooks run in the order they were registered and shutdown hooks in the
+// reverse order, like deferred calls, so a resource set up later, which may
+// use an earlier one, is torn down first.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Hook is a function run when the server starts or shuts down. It should
+// give up once ctx is done.
+type Hook func(ctx context.Context) error
+
+// DefaultHookTimeout is how long each hook may run unless WithHookTimeout
+// says otherwise.
+const DefaultHookTimeout = 10 * time.Second
+
+// WithHookTimeout sets how long each start and shutdown hook may run.
+func WithHookTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.hookTimeout = d
+	}
+}
+
+// OnStart registers h to run in Start, after the listeners are bound and
+// before requests are served. If a hook fails, the later ones don't run and
+// Start returns its error without serving. Register hooks before Start.
+func (s *Server) OnStart(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startHooks = append(s.startHooks, h)
+}
+
+// OnShutdown registers h to run in Stop, after the in-flight requests are
+// done or cut off. All shutdown hooks run, once, even if some fail; Stop
+// returns their errors. Register hooks before Stop.
+func (s *Server) OnShutdown(h Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, h)
+}
+
+// runStartHooks runs the start hooks in order, stopping at the first error.
+func (s *Server) runStartHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.startHooks
+	s.mu.Unlock()
+	for i, h := range hooks {
+		if err := s.runHook(ctx, h); err != nil {
+			return fmt.Errorf("server: start hook %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// runShutdownHooks runs the shutdown hooks in reverse order, the first time
+// it's called. Each one gets its own timeout, even when ctx, the drain
+// deadline, has already passed: a pool still has to be closed after requests
+// were cut off.
+func (s *Server) runShutdownHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.shutdownHooks
+	s.shutdownHooks = nil
+	s.mu.Unlock()
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := s.runHook(ctx, hooks[i]); err != nil {
+			errs = append(errs, fmt.Errorf("server: shutdown hook %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs h with the hook timeout. A hook that ignores its context is
+// abandoned at the timeout, so it can't hang the transition.
+func (s *Server) runHook(ctx context.Context, h Hook) error {
+	timeout := s.hookTimeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- h(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
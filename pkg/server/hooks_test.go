@@ -0,0 +1,108 @@
+// Description: This file contains tests for the lifecycle hooks.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServer_Hooks tests that the hooks run in order around serving.
+func TestServer_Hooks(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	s := New(addr, hello)
+	var calls []string
+	record := func(name string, err error) Hook {
+		return func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("%s: hook without a deadline", name)
+			}
+			calls = append(calls, name)
+			return err
+		}
+	}
+	s.OnStart(record("register", nil))
+	s.OnStart(func(ctx context.Context) error {
+		// The port is bound before the start hooks run.
+		calls = append(calls, "check")
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	})
+	s.OnShutdown(record("close db", nil))
+	s.OnShutdown(record("flush logs", errors.New("disk full")))
+	s.OnShutdown(record("deregister", nil))
+
+	// 2. Execute
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+	resp := get(t, http.DefaultClient, "http://"+addr+"/")
+	resp.Body.Close()
+	err := s.Stop(context.Background())
+
+	// 3. Assert
+	if startErr := <-errc; startErr != nil {
+		t.Fatalf("Start: %v", startErr)
+	}
+	want := []string{"register", "check", "deregister", "flush logs", "close db"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %q, want %q", calls, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "shutdown hook 2: disk full") {
+		t.Errorf("expected the failing hook's error, got %v", err)
+	}
+
+	// The shutdown hooks run once.
+	calls = nil
+	if err := s.Stop(context.Background()); err != nil || len(calls) != 0 {
+		t.Errorf("second Stop: %v, calls %q", err, calls)
+	}
+}
+
+// TestServer_StartHookFailure tests that a failing start hook stops Start.
+func TestServer_StartHookFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Hook
+		wantErr string
+	}{
+		{name: "error", hook: func(ctx context.Context) error { return errors.New("registry down") }, wantErr: "start hook 1: registry down"},
+		{name: "timeout", hook: func(ctx context.Context) error {
+			time.Sleep(time.Second) // ignores ctx
+			return nil
+		}, wantErr: "deadline exceeded"},
+		{name: "panic", hook: func(ctx context.Context) error { panic("boom") }, wantErr: "panic: boom"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			addr := freeAddr(t)
+			s := New(addr, hello, WithHookTimeout(20*time.Millisecond))
+			s.OnStart(tc.hook)
+			s.OnStart(func(ctx context.Context) error {
+				t.Error("hook after the failure ran")
+				return nil
+			})
+
+			// 2. Execute
+			err := s.Start()
+
+			// 3. Assert
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+			if _, err := http.Get("http://" + addr + "/"); err == nil {
+				t.Error("listener still open after the failed start")
+			}
+		})
+	}
+}
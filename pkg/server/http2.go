@@ -0,0 +1,32 @@
+// Description: This file contains the HTTP/2 options. Over TLS, Go negotiates
+// HTTP/2 by itself; WithHTTP2Config tunes it. WithH2C additionally accepts
+// HTTP/2 over plain TCP ("h2c"), for gRPC-style and multiplexing clients inside
+// a trusted network, where TLS is terminated elsewhere or not used at all.
+
+package server
+
+import (
+	"net/http"
+)
+
+// WithHTTP2Config sets the HTTP/2 settings, such as MaxConcurrentStreams or
+// the ping health checks. The fields that only apply to clients are ignored.
+func WithHTTP2Config(cfg http.HTTP2Config) Option {
+	return func(s *Server) {
+		s.httpServer.HTTP2 = &cfg
+	}
+}
+
+// WithH2C accepts unencrypted HTTP/2 next to HTTP/1, using "prior knowledge":
+// clients must open the connection with the HTTP/2 preface, as gRPC clients
+// and curl --http2-prior-knowledge do. The HTTP/1 "Upgrade: h2c" handshake
+// isn't supported. Only use it where the network itself is trusted.
+func WithH2C() Option {
+	return func(s *Server) {
+		var p http.Protocols
+		p.SetHTTP1(true)
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		s.httpServer.Protocols = &p
+	}
+}
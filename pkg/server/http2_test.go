@@ -0,0 +1,55 @@
+// Description: This file contains tests for the HTTP/2 options.
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+// TestServer_H2C tests HTTP/2 without TLS, next to HTTP/1.
+func TestServer_H2C(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	startServer(t, New(addr, hello, WithH2C(), WithHTTP2Config(http.HTTP2Config{MaxConcurrentStreams: 50})))
+
+	tests := []struct {
+		name      string
+		protocol  func(*http.Protocols)
+		wantMajor int
+	}{
+		{"prior knowledge", func(p *http.Protocols) { p.SetUnencryptedHTTP2(true) }, 2},
+		{"HTTP/1 still works", func(p *http.Protocols) { p.SetHTTP1(true) }, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var p http.Protocols
+			tc.protocol(&p)
+			client := &http.Client{Transport: &http.Transport{Protocols: &p}}
+
+			// 2. Execute
+			resp := get(t, client, "http://"+addr+"/")
+			resp.Body.Close()
+
+			// 3. Assert
+			if resp.ProtoMajor != tc.wantMajor {
+				t.Errorf("expected HTTP/%d, got %s", tc.wantMajor, resp.Proto)
+			}
+		})
+	}
+}
+
+// TestServer_HTTP2OverTLS tests that HTTPS negotiates HTTP/2.
+func TestServer_HTTP2OverTLS(t *testing.T) {
+	certFile, keyFile, pool := selfSigned(t)
+	addr := freeAddr(t)
+	startServer(t, New(addr, hello, WithTLS(certFile, keyFile)))
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}, ForceAttemptHTTP2: true}}
+	resp := get(t, client, "https://"+addr+"/")
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+}
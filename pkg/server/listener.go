@@ -0,0 +1,16 @@
+// Description: This file contains WithListener, for serving on a listener
+// created elsewhere instead of one the server opens itself: a socket inherited
+// from systemd or from a previous process during a restart, a Unix socket, or a
+// listener wrapped with extra behavior.
+
+package server
+
+import "net"
+
+// WithListener serves on ln instead of listening on the server's address. The
+// server takes ownership: ln is closed when the server stops.
+func WithListener(ln net.Listener) Option {
+	return func(s *Server) {
+		s.listener = ln
+	}
+}
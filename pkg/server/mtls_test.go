@@ -0,0 +1,123 @@
+// Description: This file contains tests for client certificate verification.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// issue creates a certificate from tmpl, signed by parent (self-signed when
+// parent is nil).
+func issue(t *testing.T, tmpl *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore, tmpl.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// TestServer_ClientAuth tests the handshake with and without client
+// certificates, and the revocation hook.
+func TestServer_ClientAuth(t *testing.T) {
+	// 1. Setup: One CA for clients; the server uses a self-signed
+	// certificate.
+	certFile, keyFile, serverPool := selfSigned(t)
+	ca := issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Client CA"}, IsCA: true,
+		KeyUsage: x509.KeyUsageCertSign, BasicConstraintsValid: true,
+	}, nil)
+	clientPool := x509.NewCertPool()
+	clientPool.AddCert(ca.Leaf)
+	client := func(name string) tls.Certificate {
+		return issue(t, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: name},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+		}, &ca)
+	}
+	stranger := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "stranger"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil)
+
+	addr := freeAddr(t)
+	who := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	})
+	startServer(t, New(addr, who, WithClientAuth(ClientAuthOptions{
+		CAs: clientPool,
+		Revoked: func(cert *x509.Certificate) (bool, error) {
+			switch cert.Subject.CommonName {
+			case "revoked":
+				return true, nil
+			case "unknown":
+				return false, errors.New("CRL unavailable")
+			}
+			return false, nil
+		},
+	}), WithTLS(certFile, keyFile)))
+	up := get(t, &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs: serverPool, Certificates: []tls.Certificate{client("probe")},
+	}}}, "https://"+addr+"/")
+	up.Body.Close()
+
+	tests := []struct {
+		name    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{name: "trusted client", certs: []tls.Certificate{client("billing")}},
+		{name: "no certificate", wantErr: true},
+		{name: "untrusted CA", certs: []tls.Certificate{stranger}, wantErr: true},
+		{name: "revoked", certs: []tls.Certificate{client("revoked")}, wantErr: true},
+		{name: "revocation unknown", certs: []tls.Certificate{client("unknown")}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 2. Execute
+			c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs: serverPool, Certificates: tc.certs,
+			}}}
+			resp, err := c.Get("https://" + addr + "/")
+
+			// 3. Assert
+			if tc.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected the handshake to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); string(body) != "billing" {
+				t.Errorf("got %q", body)
+			}
+		})
+	}
+}
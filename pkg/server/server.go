@@ -1,23 +1,86 @@
 // Description: This package provides a wrapper around the standard Go http.Server,
-// making it easier to configure and manage.
+// making it easier to configure and manage. Features beyond plain HTTP are
+// switched on with options passed to New, e.g.
+//
+//	s := server.New(":443", r, server.WithTLS("cert.pem", "key.pem"), server.WithHTTPRedirect(":80"))
 
 package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"net"
 	"net/http" // The core Go package for HTTP servers and clients.
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/acme"
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
 )
 
 // Server holds the details for our HTTP server.
 type Server struct {
 	httpServer *http.Server
+
+	// tls is set when the server serves HTTPS, with certificates from
+	// certFile and keyFile or from httpServer.TLSConfig. See tls.go.
+	tls               bool
+	certFile, keyFile string
+
+	// redirect, if set, is the plain HTTP server sending clients to HTTPS.
+	redirect *http.Server
+
+	// acme, if set, provides the certificates and answers the CA's
+	// challenges on the redirect server.
+	acme *acme.Manager
+
+	// tlsPolicy, if set, restricts the TLS handshake. See tlspolicy.go.
+	tlsPolicy *TLSPolicy
+
+	// clientAuth, if set, makes the HTTPS server verify client
+	// certificates. See mtls.go.
+	clientAuth *ClientAuthOptions
+
+	// admin, if set, is the internal server for the admin endpoints. See
+	// admin.go.
+	admin *http.Server
+
+	// listener, redirectListener, and adminListener, if set, are served on
+	// instead of listening on the configured addresses; systemd asks for
+	// them to be taken from systemd socket activation in Start. See
+	// listener.go.
+	listener, redirectListener, adminListener net.Listener
+	systemd                                   bool
+
+	// bindRetry, if set, makes Start wait for an address in use. See
+	// bindretry.go.
+	bindRetry *BindRetryOptions
+
+	// connLimit, if set, caps the open connections. See connlimit.go.
+	connLimit *ConnLimitOptions
+
+	// draining is set in drain mode. See drain.go.
+	draining atomic.Bool
+
+	// hookTimeout bounds each lifecycle hook. See hooks.go.
+	hookTimeout time.Duration
+
+	// mu guards lns, the listeners being served on once Start has begun,
+	// which Upgrade hands to the new process, and the hooks.
+	mu                        sync.Mutex
+	lns                       listeners
+	startHooks, shutdownHooks []Hook
 }
 
+// Option configures a Server in New.
+type Option func(*Server)
+
 // New creates and configures a new Server instance.
 // It takes a listening address (e.g., ":8080") and an http.Handler (our router) as arguments.
 // An http.Handler is an interface that responds to an HTTP request. Our router will implement this.
-func New(addr string, handler http.Handler) *Server {
+func New(addr string, handler http.Handler, opts ...Option) *Server {
 	// We create an instance of the standard http.Server.
 	// It's good practice to configure timeouts to prevent resource exhaustion
 	// from slow or malicious clients.
@@ -29,27 +92,208 @@ func New(addr string, handler http.Handler) *Server {
 		IdleTimeout:  120 * time.Second, // Max time for a connection to be idle.
 	}
 
-	return &Server{
+	s := &Server{
 		httpServer: srv,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithTimeouts replaces the read, write, and idle timeouts New sets. Zero
+// means no timeout, like in http.Server.
+func WithTimeouts(read, write, idle time.Duration) Option {
+	return func(s *Server) {
+		s.httpServer.ReadTimeout = read
+		s.httpServer.WriteTimeout = write
+		s.httpServer.IdleTimeout = idle
+	}
 }
 
 // Start makes the server begin listening for and serving HTTP requests.
 // It's a blocking call.
 func (s *Server) Start() error {
-	// ListenAndServe starts the server and blocks until the server is shut down
-	// or an error occurs. The error is returned, except for http.ErrServerClosed,
-	// which indicates a graceful shutdown.
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// The TLS options may come in any order, so the policy and the client
+	// certificate settings are only added now.
+	if s.tls && (s.tlsPolicy != nil || s.clientAuth != nil) {
+		cfg := s.httpServer.TLSConfig.Clone()
+		if s.tlsPolicy != nil {
+			if err := s.tlsPolicy.apply(cfg); err != nil {
+				return err
+			}
+		}
+		if s.clientAuth != nil {
+			if err := applyClientAuth(cfg, s.clientAuth); err != nil {
+				return err
+			}
+		}
+		s.httpServer.TLSConfig = cfg
+	}
+
+	lns, err := s.listen()
+	if err != nil {
 		return err
 	}
+	// The listeners are recorded before the start hooks run, so a hook can
+	// tell others where to find us with Addr.
+	s.mu.Lock()
+	s.lns = lns
+	s.mu.Unlock()
+	if err := s.runStartHooks(context.Background()); err != nil {
+		lns.close()
+		s.mu.Lock()
+		s.lns = listeners{}
+		s.mu.Unlock()
+		return err
+	}
+	// We're ready to serve: a parent waiting for us to take over can stop
+	// accepting now.
+	notifyUpgradeReady()
+	ln := lns.main
+	// The limit wraps the socket only now, so Upgrade gets the original.
+	if s.connLimit != nil {
+		var reply []byte
+		if !s.tls {
+			reply = rejectResponse
+		}
+		ln = limitListener(ln, *s.connLimit, reply)
+	}
+	if s.redirect != nil {
+		go serveSide(s.redirect, lns.redirect, "HTTPS redirect")
+	}
+	if s.admin != nil {
+		go serveSide(s.admin, lns.admin, "admin")
+	}
+
+	// Serve blocks until the server is shut down or an error occurs. The error
+	// is returned, except for http.ErrServerClosed, which indicates a graceful
+	// shutdown.
+	if s.tls {
+		err = s.httpServer.ServeTLS(ln, s.certFile, s.keyFile)
+	} else {
+		err = s.httpServer.Serve(ln)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// listeners are the sockets a Server serves on.
+type listeners struct {
+	main, redirect, admin net.Listener
+}
+
+// close closes the listeners.
+func (l listeners) close() {
+	for _, ln := range []net.Listener{l.main, l.redirect, l.admin} {
+		if ln != nil {
+			ln.Close()
+		}
+	}
+}
+
+// listen returns the listeners to serve on: the ones inherited from a
+// restarting parent or from systemd, or given with WithListener, or else new
+// ones on the configured addresses. We open those ourselves, rather than
+// calling ListenAndServe, so that a redirect or admin port that's already
+// taken is reported by Start too.
+func (s *Server) listen() (lns listeners, err error) {
+	if err := s.useInheritedListeners(); err != nil {
+		return listeners{}, err
+	}
+	open := func(given net.Listener, addr string) (net.Listener, error) {
+		if given != nil {
+			return given, nil
+		}
+		return s.bind(addr)
+	}
+	if lns.main, err = open(s.listener, s.listenAddr()); err != nil {
+		return listeners{}, err
+	}
+	if s.redirect != nil {
+		if lns.redirect, err = open(s.redirectListener, s.redirect.Addr); err != nil {
+			lns.close()
+			return listeners{}, err
+		}
+	}
+	if s.admin != nil {
+		if lns.admin, err = open(s.adminListener, s.admin.Addr); err != nil {
+			lns.close()
+			return listeners{}, err
+		}
+	}
+	return lns, nil
+}
+
+// serveSide serves one of the plain HTTP servers running next to the main
+// one. Failures are only logged: the main server carries on.
+func serveSide(srv *http.Server, ln net.Listener, name string) {
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		logger.Error("Server failed", "server", name, "err", err)
+	}
+}
+
+// listenAddr returns the address to listen on, defaulting like ListenAndServe.
+func (s *Server) listenAddr() string {
+	if s.httpServer.Addr != "" {
+		return s.httpServer.Addr
+	}
+	if s.tls {
+		return ":https"
+	}
+	return ":http"
+}
+
+// Addr returns the address the server listens on once Start has bound it,
+// with the port the system picked if the configured one was 0 (e.g.
+// "127.0.0.1:0"). Before that, it returns nil.
+func (s *Server) Addr() net.Addr {
+	return s.boundAddr(func(l listeners) net.Listener { return l.main })
+}
+
+// RedirectAddr is Addr for the HTTPS redirect listener.
+func (s *Server) RedirectAddr() net.Addr {
+	return s.boundAddr(func(l listeners) net.Listener { return l.redirect })
+}
+
+// AdminAddr is Addr for the admin server.
+func (s *Server) AdminAddr() net.Addr {
+	return s.boundAddr(func(l listeners) net.Listener { return l.admin })
+}
+
+// boundAddr returns the address of the listener pick chooses, or nil.
+func (s *Server) boundAddr(pick func(listeners) net.Listener) net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ln := pick(s.lns); ln != nil {
+		return ln.Addr()
+	}
 	return nil
 }
 
 // Stop provides a way to gracefully shut down the server.
-// It allows active connections to finish before closing.
+// It allows active connections to finish before closing, then runs the
+// shutdown hooks.
 func (s *Server) Stop(ctx context.Context) error {
 	// Shutdown gracefully shuts down the server without interrupting any
 	// active connections. It waits for them to finish up to the context deadline.
-	return s.httpServer.Shutdown(ctx)
+	errs := []error{s.httpServer.Shutdown(ctx)}
+	for _, side := range []*http.Server{s.redirect, s.admin} {
+		if side != nil {
+			errs = append(errs, side.Shutdown(ctx))
+		}
+	}
+	errs = append(errs, s.runShutdownHooks(ctx))
+	return errors.Join(errs...)
+}
+
+// TLSConfig returns the server's TLS configuration, or nil when it serves
+// plain HTTP. Changes must be made before Start.
+func (s *Server) TLSConfig() *tls.Config {
+	if !s.tls {
+		return nil
+	}
+	return s.httpServer.TLSConfig
 }
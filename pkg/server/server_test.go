@@ -0,0 +1,286 @@
+// Description: This file contains tests for the server wrapper: starting,
+// stopping, and serving HTTPS.
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/acme"
+)
+
+// freeAddr returns a local address nothing is listening on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// hello answers every request with "hello".
+var hello = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "hello")
+})
+
+// startServer starts s in the background and stops it when the test ends.
+func startServer(t *testing.T, s *Server) {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s.Stop(ctx)
+		if err := <-errc; err != nil {
+			t.Errorf("Start: %v", err)
+		}
+	})
+}
+
+// get polls url with client until it answers.
+func get(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var resp *http.Response
+		if resp, err = client.Get(url); err == nil {
+			return resp
+		}
+	}
+	t.Fatalf("GET %s: %v", url, err)
+	return nil
+}
+
+// selfSigned writes a self-signed certificate for 127.0.0.1 and returns the
+// file paths and a pool trusting it.
+func selfSigned(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	cert, _ := x509.ParseCertificate(der)
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// TestServer_HTTP tests serving plain HTTP and stopping.
+func TestServer_HTTP(t *testing.T) {
+	addr := freeAddr(t)
+	startServer(t, New(addr, hello))
+
+	resp := get(t, http.DefaultClient, "http://"+addr+"/")
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hello" {
+		t.Errorf("got %q", body)
+	}
+}
+
+// TestServer_Addr tests that a server configured with port 0 reports the
+// port it got, both to start hooks and after Start.
+func TestServer_Addr(t *testing.T) {
+	// 1. Setup
+	s := New("127.0.0.1:0", hello, WithAdmin("127.0.0.1:0", hello))
+	if s.Addr() != nil {
+		t.Fatalf("expected no address before Start, got %s", s.Addr())
+	}
+	hookAddr := make(chan net.Addr, 1)
+	s.OnStart(func(context.Context) error {
+		hookAddr <- s.Addr()
+		return nil
+	})
+
+	// 2. Execute
+	startServer(t, s)
+	addr := <-hookAddr
+
+	// 3. Assert
+	if addr == nil || strings.HasSuffix(addr.String(), ":0") {
+		t.Fatalf("expected the bound address, got %v", addr)
+	}
+	if s.AdminAddr() == nil || s.AdminAddr().String() == addr.String() {
+		t.Errorf("expected a separate admin address, got %v", s.AdminAddr())
+	}
+	if s.RedirectAddr() != nil {
+		t.Errorf("expected no redirect address, got %s", s.RedirectAddr())
+	}
+	resp := get(t, http.DefaultClient, "http://"+addr.String()+"/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got %d", resp.StatusCode)
+	}
+}
+
+// TestServer_TLS tests serving HTTPS from certificate files, with the
+// redirect listener.
+func TestServer_TLS(t *testing.T) {
+	// 1. Setup
+	certFile, keyFile, pool := selfSigned(t)
+	addr, redirectAddr := freeAddr(t), freeAddr(t)
+	s := New(addr, hello, WithTLS(certFile, keyFile), WithHTTPRedirect(redirectAddr))
+	startServer(t, s)
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// 2. Execute
+	resp := get(t, client, "https://"+addr+"/")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	redirect := get(t, client, "http://"+redirectAddr+"/users?page=2")
+	redirect.Body.Close()
+
+	// 3. Assert
+	if string(body) != "hello" || resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("unexpected HTTPS response %q %+v", body, resp.TLS)
+	}
+	if want := "https://" + addr + "/users?page=2"; redirect.StatusCode != http.StatusMovedPermanently || redirect.Header.Get("Location") != want {
+		t.Errorf("expected 301 to %s, got %d %s", want, redirect.StatusCode, redirect.Header.Get("Location"))
+	}
+}
+
+// TestServer_TLSConfig tests serving HTTPS from a tls.Config.
+func TestServer_TLSConfig(t *testing.T) {
+	certFile, keyFile, pool := selfSigned(t)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	startServer(t, New(addr, hello, WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}})))
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp := get(t, client, "https://"+addr+"/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got %d", resp.StatusCode)
+	}
+}
+
+// TestRedirectHandler tests the redirect targets.
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		method   string
+		host     string
+		wantCode int
+		wantURL  string
+	}{
+		{"default port", ":443", "GET", "example.com", http.StatusMovedPermanently, "https://example.com/a?b=c"},
+		{"http port dropped", ":443", "GET", "example.com:80", http.StatusMovedPermanently, "https://example.com/a?b=c"},
+		{"custom port", ":8443", "GET", "example.com:8080", http.StatusMovedPermanently, "https://example.com:8443/a?b=c"},
+		{"ipv6", ":443", "GET", "[::1]:80", http.StatusMovedPermanently, "https://[::1]/a?b=c"},
+		{"post keeps method", ":443", "POST", "example.com", http.StatusPermanentRedirect, "https://example.com/a?b=c"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			s := New(tc.addr, hello, WithTLS("cert.pem", "key.pem"), WithHTTPRedirect(":80"))
+			req := httptest.NewRequest(tc.method, "/a?b=c", nil)
+			req.Host = tc.host
+			rr := httptest.NewRecorder()
+
+			// 2. Execute
+			s.redirect.Handler.ServeHTTP(rr, req)
+
+			// 3. Assert
+			if rr.Code != tc.wantCode || rr.Header().Get("Location") != tc.wantURL {
+				t.Errorf("expected %d %s, got %d %s", tc.wantCode, tc.wantURL, rr.Code, rr.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+// TestServer_RedirectPortInUse tests that Start reports a taken redirect port.
+func TestServer_RedirectPortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s := New(freeAddr(t), hello, WithTLS("cert.pem", "key.pem"), WithHTTPRedirect(ln.Addr().String()))
+	if err := s.Start(); err == nil {
+		t.Error("expected an error for the redirect port in use")
+	}
+}
+
+// TestRedirectHandler_ACME tests that ACME challenges aren't redirected.
+func TestRedirectHandler_ACME(t *testing.T) {
+	m, err := acme.NewManager(acme.Config{Hosts: []string{"example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(":443", hello, WithHTTPRedirect(":80"), WithAutoCert(m))
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"/.well-known/acme-challenge/unknown-token", http.StatusNotFound},
+		{"/users", http.StatusMovedPermanently},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.Host = "example.com"
+		rr := httptest.NewRecorder()
+		s.redirect.Handler.ServeHTTP(rr, req)
+		if rr.Code != tc.wantCode {
+			t.Errorf("%s: got %d, want %d", tc.path, rr.Code, tc.wantCode)
+		}
+	}
+	if s.TLSConfig() == nil || s.TLSConfig().GetCertificate == nil {
+		t.Error("expected the Manager's TLS configuration")
+	}
+}
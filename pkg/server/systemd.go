@@ -0,0 +1,144 @@
+// Description: This file contains systemd socket activation. systemd can open
+// the listening socket itself and start the server on the first connection
+// (or at boot, with the port already bound and connections queuing up while
+// the server starts). It passes the socket as file descriptor 3 onwards and
+// describes it in the LISTEN_PID, LISTEN_FDS, and LISTEN_FDNAMES variables
+// (see sd_listen_fds(3)). A matching unit pair:
+//
+//	# myapp.socket
+//	[Socket]
+//	ListenStream=443
+//	FileDescriptorName=https
+//	[Install]
+//	WantedBy=sockets.target
+//
+//	# myapp.service
+//	[Service]
+//	ExecStart=/usr/local/bin/myapp
+//
+// Further socket units for the HTTP redirect and the admin server use
+// FileDescriptorName=redirect and FileDescriptorName=admin.
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/logger"
+)
+
+// listenFDsStart is the first file descriptor systemd passes.
+const listenFDsStart = 3
+
+// ActivatedListener is a listening socket passed by systemd, with the name
+// given by FileDescriptorName= (systemd defaults it to the socket unit's name).
+type ActivatedListener struct {
+	Name string
+	net.Listener
+}
+
+// activation hands out the inherited sockets, once.
+var activation struct {
+	sync.Mutex
+	taken bool
+}
+
+// SystemdListeners returns the sockets systemd passed to this process, or none
+// when it wasn't socket-activated. The sockets are handed out once; later calls
+// return none. The LISTEN_* variables are cleared, so child processes don't
+// mistake the sockets for their own.
+func SystemdListeners() ([]ActivatedListener, error) {
+	activation.Lock()
+	defer activation.Unlock()
+	if activation.taken {
+		return nil, nil
+	}
+	activation.taken = true
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	return activatedListeners(os.Getenv, os.Getpid(), listenFDsStart)
+}
+
+// activatedListeners turns the sockets described by the environment into
+// listeners. The sockets are only ours if LISTEN_PID names this process.
+func activatedListeners(getenv func(string) string, pid, firstFD int) ([]ActivatedListener, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	return fdListeners(getenv("LISTEN_FDS"), getenv("LISTEN_FDNAMES"), firstFD)
+}
+
+// fdListeners turns count inherited sockets, starting at firstFD, into
+// listeners named by the colon-separated names.
+func fdListeners(count, names string, firstFD int) ([]ActivatedListener, error) {
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("server: invalid socket count %q", count)
+	}
+	nameList := strings.Split(names, ":")
+
+	listeners := make([]ActivatedListener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "unknown"
+		if i < len(nameList) && nameList[i] != "" {
+			name = nameList[i]
+		}
+		ln, err := fileListener(uintptr(firstFD+i), name)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("server: inherited socket %d (%s): %w", firstFD+i, name, err)
+		}
+		listeners = append(listeners, ActivatedListener{Name: name, Listener: ln})
+	}
+	return listeners, nil
+}
+
+// WithSystemd serves on the sockets systemd passed, when the process was
+// socket-activated: the one named "redirect" for the HTTPS redirect (see
+// WithHTTPRedirect), "admin" for the admin server (see WithAdmin), and the
+// first other one for the server itself. Without
+// socket activation the server listens on its address as usual, so the same
+// binary runs under systemd and elsewhere.
+func WithSystemd() Option {
+	return func(s *Server) {
+		s.systemd = true
+	}
+}
+
+// useInheritedListeners assigns the sockets handed over by a restarting
+// parent (see Upgrade) or, with WithSystemd, by systemd to the servers.
+func (s *Server) useInheritedListeners() error {
+	listeners, err := upgradeListeners()
+	if err != nil {
+		return err
+	}
+	if len(listeners) == 0 && s.systemd {
+		if listeners, err = SystemdListeners(); err != nil {
+			return err
+		}
+	}
+	for _, l := range listeners {
+		switch {
+		case l.Name == "redirect" && s.redirect != nil && s.redirectListener == nil:
+			s.redirectListener = l.Listener
+		case l.Name == "admin" && s.admin != nil && s.adminListener == nil:
+			s.adminListener = l.Listener
+		case s.listener == nil:
+			s.listener = l.Listener
+		default:
+			logger.Warn("Closing unused inherited socket", "name", l.Name, "addr", l.Addr())
+			l.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+// Description: This file stands in for socket activation on systems without
+// systemd.
+
+package server
+
+import (
+	"errors"
+	"net"
+)
+
+// fileListener reports that inherited sockets aren't supported here.
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	return nil, errors.New("socket activation is only supported on Unix")
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+// Description: This file turns inherited file descriptors into listeners on
+// Unix systems.
+
+package server
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// fileListener returns a listener for the inherited socket fd.
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	// Don't pass the socket on to processes we start.
+	syscall.CloseOnExec(int(fd))
+	f := os.NewFile(fd, name)
+	// FileListener works on a duplicate, so the original can go.
+	defer f.Close()
+	return net.FileListener(f)
+}
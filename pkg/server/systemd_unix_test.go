@@ -0,0 +1,81 @@
+//go:build unix
+
+// Description: This file contains tests for systemd socket activation.
+
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// TestActivatedListeners tests reading inherited sockets from the environment.
+func TestActivatedListeners(t *testing.T) {
+	// 1. Setup: Stand in for systemd with a socket of our own, passed by
+	// its file descriptor.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "1", "LISTEN_FDNAMES": "https"}
+	getenv := func(k string) string { return env[k] }
+
+	// 2. Execute
+	listeners, err := activatedListeners(getenv, 42, int(f.Fd()))
+
+	// 3. Assert
+	if err != nil || len(listeners) != 1 {
+		t.Fatalf("expected one listener, got %v, %v", listeners, err)
+	}
+	if listeners[0].Name != "https" || listeners[0].Addr().String() != ln.Addr().String() {
+		t.Errorf("unexpected listener %s on %s", listeners[0].Name, listeners[0].Addr())
+	}
+
+	// The inherited socket serves.
+	s := New("unused:1", hello, WithListener(listeners[0]))
+	startServer(t, s)
+	resp := get(t, http.DefaultClient, "http://"+ln.Addr().String()+"/")
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hello" {
+		t.Errorf("got %q", body)
+	}
+}
+
+// TestActivatedListeners_NotOurs tests environments that don't pass sockets
+// to this process.
+func TestActivatedListeners_NotOurs(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "not activated", env: map[string]string{}},
+		{name: "meant for another process", env: map[string]string{"LISTEN_PID": "7", "LISTEN_FDS": "1"}},
+		{name: "invalid count", env: map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "many"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			listeners, err := activatedListeners(func(k string) string { return tc.env[k] }, 42, listenFDsStart)
+			if (err != nil) != tc.wantErr || len(listeners) != 0 {
+				t.Errorf("got %v, %v", listeners, err)
+			}
+		})
+	}
+}
+
+// TestServer_WithSystemd tests the fallback when the process wasn't
+// socket-activated.
+func TestServer_WithSystemd(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	addr := freeAddr(t)
+	startServer(t, New(addr, hello, WithSystemd()))
+	resp := get(t, http.DefaultClient, "http://"+addr+"/")
+	resp.Body.Close()
+}
@@ -0,0 +1,122 @@
+// Description: This file contains the TLS options. With them the server
+// terminates HTTPS itself instead of relying on a proxy in front of it, and can
+// run a second, plain HTTP listener that sends clients who typed http:// over
+// to HTTPS. With WithAutoCert, the certificates come from an ACME CA such as
+// Let's Encrypt, and the redirect listener also answers the CA's challenges.
+
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hanzalaareeb/HTTPGolang/pkg/acme"
+)
+
+// WithTLS serves HTTPS with the certificate and private key in the given PEM
+// files. The certificate file may hold a chain, leaf first.
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.tls = true
+		s.certFile, s.keyFile = certFile, keyFile
+		s.httpServer.TLSConfig = defaultTLSConfig(s.httpServer.TLSConfig)
+	}
+}
+
+// WithTLSConfig serves HTTPS using cfg, which must provide the certificates
+// through Certificates or GetCertificate. It's the option for certificates
+// that don't come from files, or that are reloaded at runtime.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tls = true
+		s.httpServer.TLSConfig = defaultTLSConfig(cfg.Clone())
+	}
+}
+
+// WithAutoCert serves HTTPS with certificates obtained and renewed by m. The
+// CA checks control over the host names by fetching a token over plain HTTP
+// on port 80, so combine it with WithHTTPRedirect(":80"), which answers those
+// requests and redirects the rest.
+func WithAutoCert(m *acme.Manager) Option {
+	return func(s *Server) {
+		s.tls = true
+		s.acme = m
+		s.httpServer.TLSConfig = m.TLSConfig()
+	}
+}
+
+// defaultTLSConfig fills in the settings we insist on: no protocol versions
+// older than TLS 1.2.
+func defaultTLSConfig(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	return cfg
+}
+
+// WithHTTPRedirect listens for plain HTTP on addr (e.g. ":80") as well,
+// answering every request with a permanent redirect to the same URL over
+// HTTPS. It only takes effect on a server serving HTTPS.
+func WithHTTPRedirect(addr string) Option {
+	return func(s *Server) {
+		s.redirect = &http.Server{
+			Addr:         addr,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+			IdleTimeout:  30 * time.Second,
+		}
+		s.redirect.Handler = redirectHandler(s)
+	}
+}
+
+// redirectHandler sends requests to the HTTPS server, keeping the host name,
+// path, and query. The port is included unless it's the default 443. ACME
+// challenges are answered instead when the certificates come from WithAutoCert.
+func redirectHandler(s *Server) http.Handler {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.tls {
+			http.Error(w, "HTTPS is not enabled", http.StatusMisdirectedRequest)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "missing Host header", http.StatusBadRequest)
+			return
+		}
+		if _, port, err := net.SplitHostPort(s.listenAddr()); err == nil && port != "443" && port != "https" {
+			host = net.JoinHostPort(host, port)
+		} else if isIPv6(host) {
+			host = "[" + host + "]"
+		}
+
+		// 308 keeps the method and body of non-GET requests; older clients
+		// only know 301 for GETs.
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Options may come in any order, so look for the Manager now.
+		if s.acme != nil {
+			s.acme.HTTPHandler(redirect).ServeHTTP(w, r)
+			return
+		}
+		redirect(w, r)
+	})
+}
+
+// isIPv6 reports whether host is an IPv6 address literal (without brackets).
+func isIPv6(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,104 @@
+// Description: This file contains the TLS policy: which protocol versions,
+// cipher suites, key exchange curves, and ALPN protocols the HTTPS server
+// accepts. Without one, the server requires TLS 1.2 and otherwise keeps Go's
+// defaults, which follow current advice and improve with each release. The
+// presets follow Mozilla's server side TLS recommendations:
+//
+//	s := server.New(":443", r, server.WithTLS(cert, key), server.WithTLSPolicy(server.ModernTLS))
+
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TLSPolicy configures the TLS handshake. Zero fields keep the defaults.
+type TLSPolicy struct {
+	// MinVersion is the oldest protocol version accepted, e.g.
+	// tls.VersionTLS13.
+	MinVersion uint16
+
+	// CipherSuites are the TLS 1.2 cipher suites accepted, in preference
+	// order. TLS 1.3's suites aren't configurable; they're all secure.
+	// Suites Go considers insecure are refused.
+	CipherSuites []uint16
+
+	// CurvePreferences are the key exchange mechanisms accepted. Leaving it
+	// empty keeps Go's choice, including the post-quantum hybrid.
+	CurvePreferences []tls.CurveID
+
+	// NextProtos are the ALPN protocols offered, e.g. []string{"http/1.1"}
+	// to rule out HTTP/2. The default offers h2 and http/1.1.
+	NextProtos []string
+}
+
+// The presets.
+var (
+	// ModernTLS only accepts TLS 1.3, for services whose clients are all
+	// recent (browsers since 2018 and current HTTP libraries).
+	ModernTLS = TLSPolicy{MinVersion: tls.VersionTLS13}
+
+	// IntermediateTLS also accepts TLS 1.2 with forward-secret AEAD cipher
+	// suites, for general-purpose services.
+	IntermediateTLS = TLSPolicy{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+)
+
+// TLSPolicyNames are the names ParseTLSPolicy accepts.
+var TLSPolicyNames = []string{"modern", "intermediate"}
+
+// ParseTLSPolicy returns the preset with the given name, e.g. from a flag.
+func ParseTLSPolicy(name string) (TLSPolicy, error) {
+	switch strings.ToLower(name) {
+	case "modern":
+		return ModernTLS, nil
+	case "intermediate":
+		return IntermediateTLS, nil
+	}
+	return TLSPolicy{}, fmt.Errorf("server: unknown TLS policy %q, want one of %s", name, strings.Join(TLSPolicyNames, ", "))
+}
+
+// WithTLSPolicy applies p to the HTTPS server. It only takes effect on a
+// server serving HTTPS, and may come before or after the TLS options.
+func WithTLSPolicy(p TLSPolicy) Option {
+	return func(s *Server) {
+		s.tlsPolicy = &p
+	}
+}
+
+// apply sets the policy's fields on cfg, refusing insecure cipher suites.
+func (p *TLSPolicy) apply(cfg *tls.Config) error {
+	for _, insecure := range tls.InsecureCipherSuites() {
+		if slices.Contains(p.CipherSuites, insecure.ID) {
+			return fmt.Errorf("server: TLS policy: cipher suite %s is insecure", insecure.Name)
+		}
+	}
+	if p.MinVersion != 0 {
+		if p.MinVersion < tls.VersionTLS12 {
+			return fmt.Errorf("server: TLS policy: versions before TLS 1.2 are insecure, got %s", tls.VersionName(p.MinVersion))
+		}
+		cfg.MinVersion = p.MinVersion
+	}
+	if p.CipherSuites != nil {
+		cfg.CipherSuites = slices.Clone(p.CipherSuites)
+	}
+	if p.CurvePreferences != nil {
+		cfg.CurvePreferences = slices.Clone(p.CurvePreferences)
+	}
+	if p.NextProtos != nil {
+		cfg.NextProtos = slices.Clone(p.NextProtos)
+	}
+	return nil
+}
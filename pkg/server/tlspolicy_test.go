@@ -0,0 +1,107 @@
+// Description: This file contains tests for the TLS policy.
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestParseTLSPolicy tests looking up the presets by name.
+func TestParseTLSPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantVersion uint16
+		wantErr     bool
+	}{
+		{"modern", tls.VersionTLS13, false},
+		{"Intermediate", tls.VersionTLS12, false},
+		{"old", 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseTLSPolicy(tc.name)
+			if (err != nil) != tc.wantErr || p.MinVersion != tc.wantVersion {
+				t.Errorf("got %+v, %v", p, err)
+			}
+		})
+	}
+}
+
+// TestTLSPolicy_Apply tests that the policy's fields replace the defaults and
+// that insecure settings are refused.
+func TestTLSPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  TLSPolicy
+		wantErr string
+	}{
+		{"modern", ModernTLS, ""},
+		{"intermediate", IntermediateTLS, ""},
+		{"old version", TLSPolicy{MinVersion: tls.VersionTLS11}, "versions before TLS 1.2"},
+		{"insecure suite", TLSPolicy{CipherSuites: []uint16{tls.TLS_RSA_WITH_RC4_128_SHA}}, "TLS_RSA_WITH_RC4_128_SHA is insecure"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			cfg := defaultTLSConfig(nil)
+
+			// 2. Execute
+			err := tc.policy.apply(cfg)
+
+			// 3. Assert
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MinVersion != tc.policy.MinVersion || len(cfg.CipherSuites) != len(tc.policy.CipherSuites) {
+				t.Errorf("policy not applied: %+v", cfg)
+			}
+		})
+	}
+}
+
+// TestServer_TLSPolicy tests that a server with a policy turns away clients
+// it doesn't allow, and that a policy given before WithTLS still applies.
+func TestServer_TLSPolicy(t *testing.T) {
+	// 1. Setup
+	certFile, keyFile, pool := selfSigned(t)
+	addr := freeAddr(t)
+	policy := ModernTLS
+	policy.NextProtos = []string{"http/1.1"}
+	startServer(t, New(addr, hello, WithTLSPolicy(policy), WithTLS(certFile, keyFile)))
+	client := func(max uint16) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool, MaxVersion: max},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	// 2. Execute
+	resp := get(t, client(0), "https://"+addr+"/")
+	resp.Body.Close()
+	_, oldErr := client(tls.VersionTLS12).Get("https://" + addr + "/")
+
+	// 3. Assert
+	if resp.TLS.Version != tls.VersionTLS13 || resp.ProtoMajor != 1 {
+		t.Errorf("expected HTTP/1.1 over TLS 1.3, got %s over %s", resp.Proto, tls.VersionName(resp.TLS.Version))
+	}
+	if oldErr == nil {
+		t.Error("expected a TLS 1.2 client to be refused")
+	}
+}
+
+// TestServer_TLSPolicyInsecure tests that Start reports an insecure policy.
+func TestServer_TLSPolicyInsecure(t *testing.T) {
+	s := New(freeAddr(t), hello, WithTLS("cert.pem", "key.pem"), WithTLSPolicy(TLSPolicy{MinVersion: tls.VersionTLS10}))
+	if err := s.Start(); err == nil {
+		t.Error("expected an error for TLS 1.0")
+	}
+}
@@ -0,0 +1,79 @@
+// Description: This file contains the receiving side of a zero-downtime
+// restart (see Upgrade). The old process starts the new binary with its
+// listening sockets as file descriptor 3 onwards, described in the
+// SERVER_UPGRADE_* variables, plus a pipe the new process writes to once it
+// serves on them. The sockets are never closed in between, so the kernel
+// keeps queuing connections while the new process starts, and nothing is
+// refused.
+
+package server
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// The variables describing the sockets handed over by Upgrade.
+const (
+	upgradePPIDEnv    = "SERVER_UPGRADE_PPID"
+	upgradeFDsEnv     = "SERVER_UPGRADE_FDS"
+	upgradeNamesEnv   = "SERVER_UPGRADE_FDNAMES"
+	upgradeReadyFDEnv = "SERVER_UPGRADE_READY_FD"
+)
+
+// upgrade holds the descriptors inherited from a restarting parent.
+var upgrade struct {
+	sync.Mutex
+	read    bool
+	taken   bool
+	readyFD int
+}
+
+// readUpgradeEnv parses and clears the SERVER_UPGRADE_* variables, once. Like
+// LISTEN_PID, the parent's pid guards against variables that leaked into an
+// unrelated process. Must be called with upgrade locked.
+func readUpgradeEnv() (count, names string) {
+	if upgrade.read {
+		return "", ""
+	}
+	upgrade.read = true
+	defer func() {
+		for _, v := range []string{upgradePPIDEnv, upgradeFDsEnv, upgradeNamesEnv, upgradeReadyFDEnv} {
+			os.Unsetenv(v)
+		}
+	}()
+	if os.Getenv(upgradePPIDEnv) != strconv.Itoa(os.Getppid()) {
+		return "", ""
+	}
+	upgrade.readyFD, _ = strconv.Atoi(os.Getenv(upgradeReadyFDEnv))
+	return os.Getenv(upgradeFDsEnv), os.Getenv(upgradeNamesEnv)
+}
+
+// upgradeListeners returns the sockets handed over by a restarting parent, or
+// none when the process wasn't started by Upgrade. They're handed out once.
+func upgradeListeners() ([]ActivatedListener, error) {
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	count, names := readUpgradeEnv()
+	if count == "" || upgrade.taken {
+		return nil, nil
+	}
+	upgrade.taken = true
+	return fdListeners(count, names, listenFDsStart)
+}
+
+// notifyUpgradeReady tells the parent that started us with Upgrade that we
+// serve on its sockets, so it can drain and exit. It does nothing otherwise.
+func notifyUpgradeReady() {
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	readUpgradeEnv()
+	if upgrade.readyFD < listenFDsStart {
+		return
+	}
+	f := os.NewFile(uintptr(upgrade.readyFD), "upgrade-ready")
+	upgrade.readyFD = 0
+	f.Write([]byte{1})
+	f.Close()
+}
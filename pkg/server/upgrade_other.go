@@ -0,0 +1,17 @@
+//go:build !unix
+
+// Description: This file stands in for zero-downtime restarts on systems
+// without Unix file descriptor passing.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// Upgrade reports that restarts with socket handoff aren't supported here.
+func (s *Server) Upgrade(ctx context.Context) (*os.Process, error) {
+	return nil, errors.New("server: upgrade is only supported on Unix")
+}
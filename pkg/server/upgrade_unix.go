@@ -0,0 +1,119 @@
+//go:build unix
+
+// Description: This file contains Upgrade, the sending side of a zero-downtime
+// restart on Unix systems. Deploying a new binary then looks like
+//
+//	mv myapp.new /usr/local/bin/myapp && kill -USR2 $(pidof myapp)
+//
+// cmd/server calls Upgrade on SIGUSR2 and, once it succeeds, drains like on
+// SIGTERM. Under systemd, the old process's exit ends the service unless the
+// unit tracks the new main pid (e.g. with a PIDFile=).
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Upgrade starts a new copy of the running executable, with the same
+// arguments and environment, and hands it the sockets the server listens on.
+// It returns once the new process serves on them, or with an error if it
+// exits or ctx ends first; the new process is then killed and the server
+// carries on. On success the caller stops the server to drain the in-flight
+// requests: the new process keeps accepting throughout.
+func (s *Server) Upgrade(ctx context.Context) (*os.Process, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("server: upgrade: %w", err)
+	}
+	return s.upgrade(ctx, path, os.Args, os.Environ())
+}
+
+// upgrade starts path with args and env, plus the SERVER_UPGRADE_* variables.
+func (s *Server) upgrade(ctx context.Context, path string, args, env []string) (*os.Process, error) {
+	s.mu.Lock()
+	listeners, names := []net.Listener{s.lns.main}, []string{"http"}
+	if s.tls {
+		names[0] = "https"
+	}
+	if s.lns.redirect != nil {
+		listeners, names = append(listeners, s.lns.redirect), append(names, "redirect")
+	}
+	if s.lns.admin != nil {
+		listeners, names = append(listeners, s.lns.admin), append(names, "admin")
+	}
+	s.mu.Unlock()
+	if listeners[0] == nil {
+		return nil, errors.New("server: upgrade: server isn't started")
+	}
+
+	// The child gets dups of our sockets; ours stay open and accepting.
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	defer func() {
+		for _, f := range files[3:] {
+			f.Close()
+		}
+	}()
+	for i, ln := range listeners {
+		filer, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf("server: upgrade: %s listener %T has no file descriptor", names[i], ln)
+		}
+		f, err := filer.File()
+		if err != nil {
+			return nil, fmt.Errorf("server: upgrade: %s listener: %w", names[i], err)
+		}
+		files = append(files, f)
+	}
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("server: upgrade: %w", err)
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+
+	env = append(env[:len(env):len(env)],
+		upgradePPIDEnv+"="+strconv.Itoa(os.Getpid()),
+		upgradeFDsEnv+"="+strconv.Itoa(len(names)),
+		upgradeNamesEnv+"="+strings.Join(names, ":"),
+		upgradeReadyFDEnv+"="+strconv.Itoa(len(files)-1),
+	)
+	proc, err := os.StartProcess(path, args, &os.ProcAttr{Env: env, Files: files})
+	if err != nil {
+		return nil, fmt.Errorf("server: upgrade: %w", err)
+	}
+	// Only the child holds the write end now, so the read below sees EOF
+	// if it exits without reporting ready.
+	readyW.Close()
+	files = files[:len(files)-1]
+
+	done := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		if n, _ := ready.Read(b[:]); n == 1 {
+			done <- nil
+			return
+		}
+		done <- errors.New("server: upgrade: new process exited before serving")
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("server: upgrade: %w", ctx.Err())
+	}
+	if err != nil {
+		proc.Kill()
+		proc.Wait()
+		return nil, err
+	}
+	// Wait reaps the child should it exit while we drain.
+	go proc.Wait()
+	return proc, nil
+}
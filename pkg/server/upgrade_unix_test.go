@@ -0,0 +1,97 @@
+//go:build unix
+
+// Description: This file contains tests for zero-downtime restarts. The test
+// binary plays the new process itself, by running only TestUpgradeChild.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestUpgradeChild is the new process started by TestServer_Upgrade. It
+// serves "child" on the inherited socket until it's killed.
+func TestUpgradeChild(t *testing.T) {
+	if os.Getenv("UPGRADE_TEST_CHILD") != "1" {
+		t.Skip("only runs as the process started by TestServer_Upgrade")
+	}
+	s := New("unused:1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "child")
+	}))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestServer_Upgrade tests handing the listening socket to a new process.
+func TestServer_Upgrade(t *testing.T) {
+	// 1. Setup: A running server. Keep-alives are off so every request
+	// gets whichever process accepts the new connection.
+	addr := freeAddr(t)
+	s := New(addr, hello)
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp := get(t, client, "http://"+addr+"/")
+	resp.Body.Close()
+
+	// 2. Execute
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc, err := s.upgrade(ctx, exe, []string{exe, "-test.run=^TestUpgradeChild$"}, append(os.Environ(), "UPGRADE_TEST_CHILD=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Kill()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// 3. Assert: The old server is gone, yet the address still answers,
+	// from the new process.
+	resp = get(t, client, "http://"+addr+"/")
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "child" {
+		t.Errorf("got %q, want the new process's answer", body)
+	}
+}
+
+// TestServer_UpgradeFailure tests that a new process that never serves
+// leaves the server running.
+func TestServer_UpgradeFailure(t *testing.T) {
+	// 1. Setup
+	addr := freeAddr(t)
+	s := New(addr, hello)
+	startServer(t, s)
+	resp := get(t, http.DefaultClient, "http://"+addr+"/")
+	resp.Body.Close()
+
+	// 2. Execute: The "new binary" exits straight away.
+	_, err := s.upgrade(context.Background(), "/bin/sh", []string{"sh", "-c", "exit 1"}, os.Environ())
+
+	// 3. Assert
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	resp = get(t, http.DefaultClient, "http://"+addr+"/")
+	resp.Body.Close()
+}
+
+// TestServer_UpgradeNotStarted tests upgrading before Start.
+func TestServer_UpgradeNotStarted(t *testing.T) {
+	if _, err := New(freeAddr(t), hello).Upgrade(context.Background()); err == nil {
+		t.Error("expected an error")
+	}
+}
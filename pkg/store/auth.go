@@ -0,0 +1,119 @@
+// Description: This file contains the storage of password logins: each
+// account's credentials, and the refresh tokens handed out at login. Tokens
+// are stored by hash and grouped in families, one per login; refreshing
+// marks a token used and adds the next one to its family, so a token used
+// twice, which means it was stolen, can revoke the whole family.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrExists is returned when creating a record whose key is taken.
+var ErrExists = errors.New("store: already exists")
+
+// ErrTokenUsed is returned by UseRefreshToken for tokens already used.
+var ErrTokenUsed = errors.New("store: refresh token already used")
+
+// Credentials are what a user logs in with.
+type Credentials struct {
+	UserID int
+
+	// Email is the login, unique across accounts. Callers normalize it,
+	// e.g. to lower case, before storing and looking it up.
+	Email string
+
+	// PasswordHash is the password as hashed by auth.HashPassword.
+	PasswordHash string
+}
+
+// RefreshToken is a refresh token handed out to a client.
+type RefreshToken struct {
+	// Hash is the token's hash (auth.HashRefreshToken); the token itself
+	// is never stored.
+	Hash string
+
+	UserID int
+
+	// Family is shared by a login's tokens, from the first to the latest
+	// refresh.
+	Family string
+
+	Expires time.Time
+}
+
+// AuthStore stores credentials and refresh tokens. Implementations must be
+// safe for concurrent use.
+type AuthStore interface {
+	// CreateCredentials stores c, or returns ErrExists if its email is
+	// taken.
+	CreateCredentials(ctx context.Context, c Credentials) error
+
+	// CredentialsByEmail returns the credentials with the given email, or
+	// ErrNotFound.
+	CredentialsByEmail(ctx context.Context, email string) (Credentials, error)
+
+	// SaveRefreshToken stores a new refresh token.
+	SaveRefreshToken(ctx context.Context, t RefreshToken) error
+
+	// UseRefreshToken marks the token with the given hash used and returns
+	// it. It returns ErrNotFound for unknown (or revoked) tokens, and the
+	// token with ErrTokenUsed if it was used before. Of two concurrent
+	// calls, only one succeeds.
+	UseRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+
+	// RevokeRefreshTokens deletes the tokens of a family. Unknown families
+	// are no error.
+	RevokeRefreshTokens(ctx context.Context, family string) error
+
+	// DeleteAccount deletes the credentials and refresh tokens of a user.
+	// Users without any are no error.
+	DeleteAccount(ctx context.Context, userID int) error
+}
+
+// WithAccounts returns users, except that deleting users also deletes their
+// accounts in accounts: their emails can be registered again, and their
+// refresh tokens stop working.
+func WithAccounts(users UserStore, accounts AuthStore) UserStore {
+	return &accountsUserStore{UserStore: users, accounts: accounts}
+}
+
+// accountsUserStore is the UserStore of WithAccounts. The two stores can't
+// share a transaction, so it deletes the accounts first and the users only
+// once they're gone: on error a user may be left without an account, which
+// can't log in anymore, but never an account without its user, which could.
+type accountsUserStore struct {
+	UserStore
+	accounts AuthStore
+}
+
+// Delete implements UserStore.
+func (s *accountsUserStore) Delete(ctx context.Context, id int) error {
+	if _, err := s.UserStore.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.accounts.DeleteAccount(ctx, id); err != nil {
+		return err
+	}
+	return s.UserStore.Delete(ctx, id)
+}
+
+// DeleteMany implements UserStore.
+func (s *accountsUserStore) DeleteMany(ctx context.Context, ids []int) error {
+	for _, id := range ids {
+		if _, err := s.UserStore.Get(ctx, id); errors.Is(err, ErrNotFound) {
+			return &NotFoundError{ID: id}
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if err := s.accounts.DeleteAccount(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.UserStore.DeleteMany(ctx, ids)
+}
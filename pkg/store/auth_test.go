@@ -0,0 +1,172 @@
+// Description: This file contains tests for the stores of credentials and
+// refresh tokens, run against each implementation.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestMemoryAuthStore tests the in-memory store.
+func TestMemoryAuthStore(t *testing.T) {
+	testAuthStore(t, NewMemoryAuthStore())
+}
+
+// TestSQLAuthStore tests the database/sql store.
+func TestSQLAuthStore(t *testing.T) {
+	newFakeDB(t.Name())
+	db, err := OpenSQL(context.Background(), SQLOptions{Driver: "postgres", DSN: t.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	testAuthStore(t, NewSQLAuthStore(db))
+}
+
+// failingAuthStore is a MemoryAuthStore whose DeleteAccount fails for one
+// user.
+type failingAuthStore struct {
+	*MemoryAuthStore
+	failID int
+}
+
+// DeleteAccount implements AuthStore.
+func (s *failingAuthStore) DeleteAccount(ctx context.Context, userID int) error {
+	if userID == s.failID {
+		return errors.New("disk full")
+	}
+	return s.MemoryAuthStore.DeleteAccount(ctx, userID)
+}
+
+// TestWithAccounts tests that deleting users deletes their accounts, and
+// that no account outlives its user when that fails.
+func TestWithAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		failID  int
+		delete  func(ctx context.Context, s UserStore) error
+		wantErr bool
+
+		// wantUsers and wantAccounts are the IDs of the users and accounts
+		// left.
+		wantUsers, wantAccounts []int
+	}{
+		{name: "Delete", delete: func(ctx context.Context, s UserStore) error { return s.Delete(ctx, 1) }, wantUsers: []int{2, 3}, wantAccounts: []int{2, 3}},
+		{name: "DeleteMany", delete: func(ctx context.Context, s UserStore) error { return s.DeleteMany(ctx, []int{1, 2}) }, wantUsers: []int{3}, wantAccounts: []int{3}},
+		{name: "Delete unknown", delete: func(ctx context.Context, s UserStore) error { return s.Delete(ctx, 9) }, wantErr: true, wantUsers: []int{1, 2, 3}, wantAccounts: []int{1, 2, 3}},
+		{name: "DeleteMany with unknown", delete: func(ctx context.Context, s UserStore) error { return s.DeleteMany(ctx, []int{1, 9}) }, wantErr: true, wantUsers: []int{1, 2, 3}, wantAccounts: []int{1, 2, 3}},
+		{name: "Delete failing account", failID: 1, delete: func(ctx context.Context, s UserStore) error { return s.Delete(ctx, 1) }, wantErr: true, wantUsers: []int{1, 2, 3}, wantAccounts: []int{1, 2, 3}},
+		// The first account is gone, but its user can't log in anymore.
+		{name: "DeleteMany failing account", failID: 2, delete: func(ctx context.Context, s UserStore) error { return s.DeleteMany(ctx, []int{1, 2}) }, wantErr: true, wantUsers: []int{1, 2, 3}, wantAccounts: []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup
+			ctx := context.Background()
+			users := NewMemoryUserStore()
+			accounts := &failingAuthStore{MemoryAuthStore: NewMemoryAuthStore(), failID: tt.failID}
+			for _, name := range []string{"ann", "bob", "cat"} {
+				u, _ := users.Create(ctx, User{Name: name})
+				accounts.CreateCredentials(ctx, Credentials{UserID: u.ID, Email: name + "@example.com", PasswordHash: "hash"})
+			}
+
+			// 2. Execute
+			err := tt.delete(ctx, WithAccounts(users, accounts))
+
+			// 3. Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			var gotUsers, gotAccounts []int
+			left, _, _ := users.List(ctx, ListOptions{})
+			for _, u := range left {
+				gotUsers = append(gotUsers, u.ID)
+			}
+			for i, name := range []string{"ann", "bob", "cat"} {
+				if _, err := accounts.CredentialsByEmail(ctx, name+"@example.com"); err == nil {
+					gotAccounts = append(gotAccounts, i+1)
+				}
+			}
+			if !slices.Equal(gotUsers, tt.wantUsers) {
+				t.Errorf("got users %v, want %v", gotUsers, tt.wantUsers)
+			}
+			if !slices.Equal(gotAccounts, tt.wantAccounts) {
+				t.Errorf("got accounts %v, want %v", gotAccounts, tt.wantAccounts)
+			}
+		})
+	}
+}
+
+// testAuthStore tests each operation of s, which must be empty.
+func testAuthStore(t *testing.T, s AuthStore) {
+	// 1. Setup
+	ctx := context.Background()
+	expires := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	ann := Credentials{UserID: 1, Email: "ann@example.com", PasswordHash: "hash"}
+
+	// 2. Execute & 3. Assert: credentials.
+	if err := s.CreateCredentials(ctx, ann); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateCredentials(ctx, Credentials{UserID: 2, Email: ann.Email, PasswordHash: "other"}); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists for a taken email, got %v", err)
+	}
+	if c, err := s.CredentialsByEmail(ctx, ann.Email); err != nil || c != ann {
+		t.Errorf("got %v, %v, want %v", c, err, ann)
+	}
+	if _, err := s.CredentialsByEmail(ctx, "bob@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown email, got %v", err)
+	}
+
+	// 2. Execute & 3. Assert: refresh tokens.
+	for _, tok := range []RefreshToken{
+		{Hash: "a1", UserID: 1, Family: "a", Expires: expires},
+		{Hash: "a2", UserID: 1, Family: "a", Expires: expires},
+		{Hash: "b1", UserID: 1, Family: "b", Expires: expires},
+	} {
+		if err := s.SaveRefreshToken(ctx, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tok, err := s.UseRefreshToken(ctx, "a1")
+	if want := (RefreshToken{Hash: "a1", UserID: 1, Family: "a", Expires: expires}); err != nil || tok != want {
+		t.Errorf("got %v, %v, want %v", tok, err, want)
+	}
+	if tok, err := s.UseRefreshToken(ctx, "a1"); !errors.Is(err, ErrTokenUsed) || tok.Family != "a" {
+		t.Errorf("expected ErrTokenUsed and the token's family, got %v, %v", tok, err)
+	}
+	if err := s.RevokeRefreshTokens(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UseRefreshToken(ctx, "a2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a revoked token, got %v", err)
+	}
+	if _, err := s.UseRefreshToken(ctx, "b1"); err != nil {
+		t.Errorf("expected another family's token to work, got %v", err)
+	}
+
+	// 2. Execute & 3. Assert: deleting the account.
+	if err := s.SaveRefreshToken(ctx, RefreshToken{Hash: "c1", UserID: 2, Family: "c", Expires: expires}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAccount(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CredentialsByEmail(ctx, ann.Email); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted account, got %v", err)
+	}
+	if _, err := s.UseRefreshToken(ctx, "b1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted account's token, got %v", err)
+	}
+	if _, err := s.UseRefreshToken(ctx, "c1"); err != nil {
+		t.Errorf("expected another user's token to work, got %v", err)
+	}
+	if err := s.DeleteAccount(ctx, 7); err != nil {
+		t.Errorf("expected no error for a user without an account, got %v", err)
+	}
+}
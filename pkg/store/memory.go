@@ -0,0 +1,244 @@
+// Description: This file contains the in-memory stores. They keep everything
+// in maps guarded by a mutex: fine for tests, development, and the example
+// API, but the data is gone when the process exits.
+
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryUserStore is a UserStore in memory.
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+}
+
+// NewMemoryUserStore returns a store holding the given users, at version 1
+// unless they have one. New users get IDs after the highest one given.
+func NewMemoryUserStore(seed ...User) *MemoryUserStore {
+	s := &MemoryUserStore{users: make(map[int]User, len(seed)), nextID: 1}
+	for _, u := range seed {
+		u.Version = max(u.Version, 1)
+		s.users[u.ID] = u
+		s.nextID = max(s.nextID, u.ID+1)
+	}
+	return s
+}
+
+// List implements UserStore.
+func (s *MemoryUserStore) List(ctx context.Context, opts ListOptions) ([]User, int, error) {
+	if err := checkSort(opts.Sort, UserSortFields); err != nil {
+		return nil, 0, err
+	}
+	name := strings.ToLower(opts.NameContains)
+
+	s.mu.RLock()
+	list := make([]User, 0, len(s.users))
+	for _, u := range s.users {
+		if strings.Contains(strings.ToLower(u.Name), name) {
+			list = append(list, u)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(list, func(a, b User) int {
+		for _, f := range opts.Sort {
+			c := cmp.Compare(a.ID, b.ID)
+			if f.Field == "name" {
+				c = strings.Compare(a.Name, b.Name)
+			}
+			if f.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	total := len(list)
+	if opts.Limit > 0 {
+		list = list[min(opts.Offset, total):min(opts.Offset+opts.Limit, total)]
+	}
+	return list, total, nil
+}
+
+// Get implements UserStore.
+func (s *MemoryUserStore) Get(ctx context.Context, id int) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+// Create implements UserStore.
+func (s *MemoryUserStore) Create(ctx context.Context, u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(u), nil
+}
+
+// create stores u under a new ID. The caller holds s.mu.
+func (s *MemoryUserStore) create(u User) User {
+	u.ID = s.nextID
+	u.Version = 1
+	s.nextID++
+	s.users[u.ID] = u
+	return u
+}
+
+// CreateMany implements UserStore.
+func (s *MemoryUserStore) CreateMany(ctx context.Context, users []User) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created := make([]User, len(users))
+	for i, u := range users {
+		created[i] = s.create(u)
+	}
+	return created, nil
+}
+
+// Update implements UserStore.
+func (s *MemoryUserStore) Update(ctx context.Context, u User) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.users[u.ID]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	if u.Version != 0 && u.Version != old.Version {
+		return User{}, ErrConflict
+	}
+	u.Version = old.Version + 1
+	u.Role = old.Role
+	s.users[u.ID] = u
+	return u, nil
+}
+
+// Delete implements UserStore.
+func (s *MemoryUserStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+// DeleteMany implements UserStore.
+func (s *MemoryUserStore) DeleteMany(ctx context.Context, ids []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Check them all first, so nothing is deleted if one is missing.
+	for _, id := range ids {
+		if _, ok := s.users[id]; !ok {
+			return &NotFoundError{ID: id}
+		}
+	}
+	for _, id := range ids {
+		delete(s.users, id)
+	}
+	return nil
+}
+
+// MemoryAuthStore is an AuthStore in memory.
+type MemoryAuthStore struct {
+	mu          sync.Mutex
+	credentials map[string]Credentials // by email
+	tokens      map[string]*memoryToken
+}
+
+// memoryToken is a stored refresh token and whether it was used.
+type memoryToken struct {
+	RefreshToken
+	used bool
+}
+
+// NewMemoryAuthStore returns an empty store.
+func NewMemoryAuthStore() *MemoryAuthStore {
+	return &MemoryAuthStore{credentials: map[string]Credentials{}, tokens: map[string]*memoryToken{}}
+}
+
+// CreateCredentials implements AuthStore.
+func (s *MemoryAuthStore) CreateCredentials(ctx context.Context, c Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.credentials[c.Email]; ok {
+		return ErrExists
+	}
+	s.credentials[c.Email] = c
+	return nil
+}
+
+// CredentialsByEmail implements AuthStore.
+func (s *MemoryAuthStore) CredentialsByEmail(ctx context.Context, email string) (Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.credentials[email]
+	if !ok {
+		return Credentials{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// SaveRefreshToken implements AuthStore.
+func (s *MemoryAuthStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.Hash] = &memoryToken{RefreshToken: t}
+	return nil
+}
+
+// UseRefreshToken implements AuthStore.
+func (s *MemoryAuthStore) UseRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[hash]
+	if !ok {
+		return RefreshToken{}, ErrNotFound
+	}
+	if t.used {
+		return t.RefreshToken, ErrTokenUsed
+	}
+	t.used = true
+	return t.RefreshToken, nil
+}
+
+// RevokeRefreshTokens implements AuthStore.
+func (s *MemoryAuthStore) RevokeRefreshTokens(ctx context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, t := range s.tokens {
+		if t.Family == family {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// DeleteAccount implements AuthStore.
+func (s *MemoryAuthStore) DeleteAccount(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for email, c := range s.credentials {
+		if c.UserID == userID {
+			delete(s.credentials, email)
+		}
+	}
+	for hash, t := range s.tokens {
+		if t.UserID == userID {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Description: This file contains tests for the in-memory stores.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestMemoryUserStore tests each operation, including on unknown users.
+func TestMemoryUserStore(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	s := NewMemoryUserStore(User{ID: 1, Name: "Hanzala"}, User{ID: 5, Name: "Areeb"})
+
+	// 2. Execute
+	created, err := s.Create(ctx, User{ID: 1, Name: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Update(ctx, User{ID: 1, Name: "Hanzala Areeb", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Assert
+	if created.ID != 6 {
+		t.Errorf("expected the ID after the highest seeded one, got %d", created.ID)
+	}
+	list, _, _ := s.List(ctx, ListOptions{})
+	if want := []User{{ID: 1, Name: "Hanzala Areeb", Version: 2}, {ID: 6, Name: "Ann", Version: 1}}; !reflect.DeepEqual(list, want) {
+		t.Errorf("got %v, want %v", list, want)
+	}
+	if u, err := s.Get(ctx, 6); err != nil || u.Name != "Ann" {
+		t.Errorf("unexpected Get: %v, %v", u, err)
+	}
+	for name, err := range map[string]error{
+		"get":    func() error { _, err := s.Get(ctx, 5); return err }(),
+		"update": func() error { _, err := s.Update(ctx, User{ID: 5}); return err }(),
+		"delete": s.Delete(ctx, 5),
+	} {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+	if _, err := s.Update(ctx, User{ID: 1, Name: "Stale", Version: 1}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict updating an old version, got %v", err)
+	}
+	if u, err := s.Update(ctx, User{ID: 1, Name: "Anyway"}); err != nil || u.Version != 3 {
+		t.Errorf("expected an unconditional update to version 3, got %v, %v", u, err)
+	}
+	admin, _ := s.Create(ctx, User{Name: "Root", Role: RoleAdmin})
+	if u, err := s.Update(ctx, User{ID: admin.ID, Name: "Root 2"}); err != nil || u.Role != RoleAdmin {
+		t.Errorf("expected the update to keep the role, got %v, %v", u, err)
+	}
+}
+
+// TestMemoryUserStore_Many tests that bulk changes are all or nothing.
+func TestMemoryUserStore_Many(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	s := NewMemoryUserStore(User{ID: 1, Name: "Hanzala"})
+
+	// 2. Execute
+	created, err := s.CreateMany(ctx, []User{{Name: "Ann"}, {ID: 1, Name: "Bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errMissing := s.DeleteMany(ctx, []int{1, 9})
+
+	// 3. Assert
+	if want := []User{{ID: 2, Name: "Ann", Version: 1}, {ID: 3, Name: "Bob", Version: 1}}; !reflect.DeepEqual(created, want) {
+		t.Errorf("got %v, want %v", created, want)
+	}
+	if !errors.Is(errMissing, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", errMissing)
+	}
+	if _, total, _ := s.List(ctx, ListOptions{}); total != 3 {
+		t.Errorf("expected nothing deleted, %d users left", total)
+	}
+	if err := s.DeleteMany(ctx, []int{1, 3, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _, _ := s.List(ctx, ListOptions{}); len(list) != 1 || list[0].ID != 2 {
+		t.Errorf("expected user 2 left, got %v", list)
+	}
+}
+
+// TestMemoryUserStore_List tests filtering, sorting, and paging.
+func TestMemoryUserStore_List(t *testing.T) {
+	s := NewMemoryUserStore(
+		User{ID: 1, Name: "Ann"}, User{ID: 2, Name: "Bob"}, User{ID: 3, Name: "Anna"}, User{ID: 4, Name: "Ann"},
+	)
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"all, by ID", ListOptions{}, []int{1, 2, 3, 4}, 4},
+		{"name filter ignores case", ListOptions{NameContains: "AN"}, []int{1, 3, 4}, 3},
+		{"no match", ListOptions{NameContains: "zed"}, []int{}, 0},
+		{"by name, ties by ID", ListOptions{Sort: []SortField{{Field: "name"}}}, []int{1, 4, 3, 2}, 4},
+		{"by name descending", ListOptions{Sort: []SortField{{Field: "name", Desc: true}, {Field: "id", Desc: true}}}, []int{2, 3, 4, 1}, 4},
+		{"page", ListOptions{Offset: 1, Limit: 2}, []int{2, 3}, 4},
+		{"page past the end", ListOptions{Offset: 10, Limit: 2}, []int{}, 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup & 2. Execute
+			users, total, err := s.List(context.Background(), tc.opts)
+
+			// 3. Assert
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []int{}
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+			if !reflect.DeepEqual(ids, tc.wantIDs) || total != tc.wantTotal {
+				t.Errorf("got %v of %d, want %v of %d", ids, total, tc.wantIDs, tc.wantTotal)
+			}
+		})
+	}
+
+	if _, _, err := s.List(context.Background(), ListOptions{Sort: []SortField{{Field: "password"}}}); err == nil {
+		t.Error("expected an error sorting by an unknown field")
+	}
+}
+
+// TestMemoryUserStore_Concurrent tests that concurrent creates get distinct
+// IDs. Run it with -race.
+func TestMemoryUserStore_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryUserStore()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, _ := s.Create(ctx, User{Name: "user"})
+			s.Get(ctx, u.ID)
+			s.List(ctx, ListOptions{})
+		}()
+	}
+	wg.Wait()
+	list, _, _ := s.List(ctx, ListOptions{})
+	if len(list) != 50 || list[49].ID != 50 {
+		t.Errorf("expected IDs 1 to 50, got %d users", len(list))
+	}
+}
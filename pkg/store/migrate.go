@@ -0,0 +1,67 @@
+// Description: This file contains the schema migrations, applied by OpenSQL
+// at startup. Each migration runs once, in order, in a transaction with the
+// row recording it in schema_migrations, so a failed one leaves nothing
+// behind and is retried on the next start. Migrations are never edited once
+// released; changing the schema means appending one.
+
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// migrations are the schema's changes, oldest first. Migration n (from 1)
+// is recorded as version n.
+var migrations = []func(d Dialect) string{
+	func(d Dialect) string {
+		return "CREATE TABLE users (id " + d.serialKey + ", name TEXT NOT NULL)"
+	},
+	func(d Dialect) string {
+		return "ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1"
+	},
+	func(d Dialect) string {
+		return "CREATE TABLE credentials (user_id BIGINT PRIMARY KEY, email TEXT NOT NULL UNIQUE, password_hash TEXT NOT NULL)"
+	},
+	func(d Dialect) string {
+		return "CREATE TABLE refresh_tokens (hash TEXT PRIMARY KEY, user_id BIGINT NOT NULL, family TEXT NOT NULL, expires_at BIGINT NOT NULL, used BOOLEAN NOT NULL DEFAULT FALSE)"
+	},
+	func(d Dialect) string {
+		return "ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT ''"
+	},
+}
+
+// Migrate applies the migrations db hasn't had yet. Two instances starting
+// at once may both try; the second then fails on recording the version, and
+// succeeds when restarted.
+func Migrate(ctx context.Context, db *DB) error {
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("store: migrating: %w", err)
+	}
+	var current int
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("store: migrating: %w", err)
+	}
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := migrate(ctx, db, version); err != nil {
+			return fmt.Errorf("store: migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// migrate applies one migration.
+func migrate(ctx context.Context, db *DB, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // a no-op after Commit
+	if _, err := tx.ExecContext(ctx, migrations[version-1](db.Dialect)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, db.Dialect.rebind("INSERT INTO schema_migrations (version) VALUES (?)"), version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,438 @@
+// Description: This file contains the database/sql stores, for SQLite and
+// PostgreSQL. The package doesn't import a driver, so the binary only
+// carries the one it uses; the application imports it for its side effect:
+//
+//	import _ "github.com/jackc/pgx/v5/stdlib" // registers "pgx"
+//
+//	db, err := store.OpenSQL(ctx, store.SQLOptions{Driver: "pgx", DSN: dsn, MaxOpenConns: 20})
+//	users := store.NewSQLUserStore(db)
+//
+// OpenSQL brings the schema up to date before returning, see migrate.go.
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect holds what differs between the databases.
+type Dialect struct {
+	// Name is the database's name, e.g. "postgres".
+	Name string
+
+	// placeholder returns the nth (from 1) query parameter.
+	placeholder func(n int) string
+
+	// serialKey is the type of an auto-incrementing primary key.
+	serialKey string
+}
+
+// The supported dialects.
+var (
+	Postgres = Dialect{Name: "postgres", placeholder: func(n int) string { return "$" + strconv.Itoa(n) }, serialKey: "BIGSERIAL PRIMARY KEY"}
+	SQLite   = Dialect{Name: "sqlite", placeholder: func(int) string { return "?" }, serialKey: "INTEGER PRIMARY KEY AUTOINCREMENT"}
+)
+
+// DialectFor returns the dialect of the database/sql driver named driver.
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	return Dialect{}, fmt.Errorf("store: unsupported driver %q", driver)
+}
+
+// rebind replaces the ? parameters of query with the dialect's.
+func (d Dialect) rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLOptions configures OpenSQL. The pool settings are those of *sql.DB;
+// zero leaves database/sql's default.
+type SQLOptions struct {
+	// Driver is the name the driver registered, e.g. "pgx" or "sqlite".
+	Driver string
+
+	// DSN is the driver's data source name, e.g. a postgres:// URL or a
+	// file name.
+	DSN string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DB is an open database and its dialect.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// OpenSQL opens the database, configures its connection pool, checks that
+// it's reachable, and migrates its schema.
+func OpenSQL(ctx context.Context, opts SQLOptions) (*DB, error) {
+	dialect, err := DialectFor(opts.Driver)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := sql.Open(opts.Driver, opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+
+	db := &DB{DB: sqlDB, Dialect: dialect}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: connecting to the database: %w", err)
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// SQLUserStore is a UserStore in the users table.
+type SQLUserStore struct {
+	db *DB
+}
+
+// NewSQLUserStore returns the store of the users in db.
+func NewSQLUserStore(db *DB) *SQLUserStore {
+	return &SQLUserStore{db: db}
+}
+
+// query runs a query written with ? parameters.
+func (s *SQLUserStore) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.db.Dialect.rebind(query), args...)
+}
+
+// exec runs a statement written with ? parameters and returns how many rows
+// it affected.
+func (s *SQLUserStore) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.db.Dialect.rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("store: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// List implements UserStore.
+func (s *SQLUserStore) List(ctx context.Context, opts ListOptions) ([]User, int, error) {
+	if err := checkSort(opts.Sort, UserSortFields); err != nil {
+		return nil, 0, err
+	}
+	where, args := "", []any{}
+	if opts.NameContains != "" {
+		where = ` WHERE LOWER(name) LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(opts.NameContains))+"%")
+	}
+	var total int
+	if err := s.db.QueryRowContext(ctx, s.db.Dialect.rebind("SELECT COUNT(*) FROM users"+where), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("store: %w", err)
+	}
+
+	query := "SELECT id, name, version, role FROM users" + where + " ORDER BY "
+	for _, f := range opts.Sort {
+		// The fields were checked against UserSortFields above.
+		query += f.Field
+		if f.Desc {
+			query += " DESC"
+		}
+		query += ", "
+	}
+	query += "id"
+	if opts.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Offset)
+	}
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("store: %w", err)
+	}
+	defer rows.Close()
+	users := []User{}
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Version, &u.Role); err != nil {
+			return nil, 0, fmt.Errorf("store: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("store: %w", err)
+	}
+	return users, total, nil
+}
+
+// likeEscaper escapes LIKE's wildcards, so they match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Get implements UserStore.
+func (s *SQLUserStore) Get(ctx context.Context, id int) (User, error) {
+	u := User{ID: id}
+	err := s.db.QueryRowContext(ctx, s.db.Dialect.rebind("SELECT name, version, role FROM users WHERE id = ?"), id).Scan(&u.Name, &u.Version, &u.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("store: %w", err)
+	}
+	return u, nil
+}
+
+// Create implements UserStore.
+func (s *SQLUserStore) Create(ctx context.Context, u User) (User, error) {
+	// Both databases return the new ID with RETURNING (SQLite since 3.35).
+	err := s.db.QueryRowContext(ctx, s.db.Dialect.rebind("INSERT INTO users (name, role, version) VALUES (?, ?, 1) RETURNING id"), u.Name, u.Role).Scan(&u.ID)
+	if err != nil {
+		return User{}, fmt.Errorf("store: %w", err)
+	}
+	u.Version = 1
+	return u, nil
+}
+
+// CreateMany implements UserStore, in a transaction.
+func (s *SQLUserStore) CreateMany(ctx context.Context, users []User) ([]User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	defer tx.Rollback() // a no-op after Commit
+	stmt, err := tx.PrepareContext(ctx, s.db.Dialect.rebind("INSERT INTO users (name, role, version) VALUES (?, ?, 1) RETURNING id"))
+	if err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	defer stmt.Close()
+	created := make([]User, len(users))
+	for i, u := range users {
+		if err := stmt.QueryRowContext(ctx, u.Name, u.Role).Scan(&u.ID); err != nil {
+			return nil, fmt.Errorf("store: %w", err)
+		}
+		u.Version = 1
+		created[i] = u
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("store: %w", err)
+	}
+	return created, nil
+}
+
+// Update implements UserStore. The version is checked in the UPDATE's
+// WHERE clause, so a concurrent change can't slip in between checking and
+// writing.
+func (s *SQLUserStore) Update(ctx context.Context, u User) (User, error) {
+	query, args := "UPDATE users SET name = ?, version = version + 1 WHERE id = ?", []any{u.Name, u.ID}
+	if u.Version != 0 {
+		query += " AND version = ?"
+		args = append(args, u.Version)
+	}
+	err := s.db.QueryRowContext(ctx, s.db.Dialect.rebind(query+" RETURNING version, role"), args...).Scan(&u.Version, &u.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either there's no such user, or its version is another.
+		if _, err := s.Get(ctx, u.ID); err != nil {
+			return User{}, err
+		}
+		return User{}, ErrConflict
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("store: %w", err)
+	}
+	return u, nil
+}
+
+// Delete implements UserStore.
+func (s *SQLUserStore) Delete(ctx context.Context, id int) error {
+	n, err := s.exec(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteMany implements UserStore, in a transaction.
+func (s *SQLUserStore) DeleteMany(ctx context.Context, ids []int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	defer tx.Rollback() // a no-op after Commit
+	stmt, err := tx.PrepareContext(ctx, s.db.Dialect.rebind("DELETE FROM users WHERE id = ?"))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	defer stmt.Close()
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		// A repeated ID would find its user gone.
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+		if n == 0 {
+			return &NotFoundError{ID: id}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	return nil
+}
+
+// SQLAuthStore is an AuthStore in the credentials and refresh_tokens
+// tables.
+type SQLAuthStore struct {
+	db *DB
+}
+
+// NewSQLAuthStore returns the store of the credentials and refresh tokens
+// in db.
+func NewSQLAuthStore(db *DB) *SQLAuthStore {
+	return &SQLAuthStore{db: db}
+}
+
+// exec runs a statement written with ? parameters and returns how many rows
+// it affected.
+func (s *SQLAuthStore) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.db.Dialect.rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("store: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// CreateCredentials implements AuthStore.
+func (s *SQLAuthStore) CreateCredentials(ctx context.Context, c Credentials) error {
+	// ON CONFLICT leaves a taken email to the unique index, with no race
+	// between checking and inserting.
+	n, err := s.exec(ctx, "INSERT INTO credentials (user_id, email, password_hash) VALUES (?, ?, ?) ON CONFLICT (email) DO NOTHING",
+		c.UserID, c.Email, c.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+// CredentialsByEmail implements AuthStore.
+func (s *SQLAuthStore) CredentialsByEmail(ctx context.Context, email string) (Credentials, error) {
+	c := Credentials{Email: email}
+	err := s.db.QueryRowContext(ctx, s.db.Dialect.rebind("SELECT user_id, password_hash FROM credentials WHERE email = ?"), email).
+		Scan(&c.UserID, &c.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Credentials{}, ErrNotFound
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("store: %w", err)
+	}
+	return c, nil
+}
+
+// SaveRefreshToken implements AuthStore.
+func (s *SQLAuthStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	_, err := s.exec(ctx, "INSERT INTO refresh_tokens (hash, user_id, family, expires_at) VALUES (?, ?, ?, ?)",
+		t.Hash, t.UserID, t.Family, t.Expires.Unix())
+	return err
+}
+
+// UseRefreshToken implements AuthStore. The token is marked used by an
+// UPDATE that only matches unused tokens, so of two concurrent refreshes
+// with the same token, one gets no row.
+func (s *SQLAuthStore) UseRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	t, used, err := s.scanToken(ctx, "UPDATE refresh_tokens SET used = TRUE WHERE hash = ? AND NOT used RETURNING user_id, family, expires_at, used", hash)
+	if errors.Is(err, ErrNotFound) {
+		// Either there's no such token, or it was used.
+		t, used, err = s.scanToken(ctx, "SELECT user_id, family, expires_at, used FROM refresh_tokens WHERE hash = ?", hash)
+		if err == nil && used {
+			err = ErrTokenUsed
+		}
+		return t, err
+	}
+	return t, err
+}
+
+// scanToken runs a query returning one refresh token's columns.
+func (s *SQLAuthStore) scanToken(ctx context.Context, query, hash string) (t RefreshToken, used bool, err error) {
+	t.Hash = hash
+	var expires int64
+	err = s.db.QueryRowContext(ctx, s.db.Dialect.rebind(query), hash).Scan(&t.UserID, &t.Family, &expires, &used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefreshToken{}, false, ErrNotFound
+	}
+	if err != nil {
+		return RefreshToken{}, false, fmt.Errorf("store: %w", err)
+	}
+	t.Expires = time.Unix(expires, 0)
+	return t, used, nil
+}
+
+// RevokeRefreshTokens implements AuthStore.
+func (s *SQLAuthStore) RevokeRefreshTokens(ctx context.Context, family string) error {
+	_, err := s.exec(ctx, "DELETE FROM refresh_tokens WHERE family = ?", family)
+	return err
+}
+
+// DeleteAccount implements AuthStore, in a transaction.
+func (s *SQLAuthStore) DeleteAccount(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	defer tx.Rollback() // a no-op after Commit
+	for _, query := range []string{
+		"DELETE FROM credentials WHERE user_id = ?",
+		"DELETE FROM refresh_tokens WHERE user_id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, s.db.Dialect.rebind(query), userID); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,478 @@
+// Description: This file contains tests for the database/sql stores. No
+// real driver is available to the tests, so they run against a fake
+// registered as "postgres", which understands exactly the statements the
+// store and migrations send. It filters users by name, but returns them in ID
+// order whatever the ORDER BY says.
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDBs are the fake databases by DSN, so each test gets its own.
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]*fakeDB{}
+)
+
+func init() {
+	sql.Register("postgres", fakeDriver{})
+}
+
+// newFakeDB creates the fake database with the given DSN.
+func newFakeDB(dsn string) *fakeDB {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	db := &fakeDB{users: map[int64]*fakeUser{}, credentials: map[string][]driver.Value{}, tokens: map[string]*fakeToken{}}
+	fakeDBs[dsn] = db
+	return db
+}
+
+// fakeDB is the state of a fake database, and the statements it ran.
+type fakeDB struct {
+	mu         sync.Mutex
+	users      map[int64]*fakeUser
+	nextID     int64
+	versions   []int64
+	usersTable bool
+	statements []string
+
+	// credentials are the user ID and password hash by email.
+	credentials map[string][]driver.Value
+	tokens      map[string]*fakeToken
+}
+
+// fakeUser is a row of the users table.
+type fakeUser struct {
+	name    string
+	version int64
+	role    string
+}
+
+// fakeToken is a row of the refresh_tokens table.
+type fakeToken struct {
+	userID, expires int64
+	family          string
+	used            bool
+}
+
+// run executes a statement.
+func (db *fakeDB) run(query string, args []driver.Value) (cols []string, rows [][]driver.Value, affected int64, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+	switch query {
+	case "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)":
+	case "CREATE TABLE users (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL)":
+		if db.usersTable {
+			return nil, nil, 0, errors.New(`relation "users" already exists`)
+		}
+		db.usersTable = true
+	case "SELECT COALESCE(MAX(version), 0) FROM schema_migrations":
+		var latest int64
+		for _, v := range db.versions {
+			latest = max(latest, v)
+		}
+		return []string{"max"}, [][]driver.Value{{latest}}, 0, nil
+	case "ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1",
+		"CREATE TABLE credentials (user_id BIGINT PRIMARY KEY, email TEXT NOT NULL UNIQUE, password_hash TEXT NOT NULL)",
+		"CREATE TABLE refresh_tokens (hash TEXT PRIMARY KEY, user_id BIGINT NOT NULL, family TEXT NOT NULL, expires_at BIGINT NOT NULL, used BOOLEAN NOT NULL DEFAULT FALSE)",
+		"ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT ''":
+	case "INSERT INTO credentials (user_id, email, password_hash) VALUES ($1, $2, $3) ON CONFLICT (email) DO NOTHING":
+		if _, ok := db.credentials[args[1].(string)]; ok {
+			return nil, nil, 0, nil
+		}
+		db.credentials[args[1].(string)] = []driver.Value{args[0], args[2]}
+		return nil, nil, 1, nil
+	case "SELECT user_id, password_hash FROM credentials WHERE email = $1":
+		if c, ok := db.credentials[args[0].(string)]; ok {
+			rows = append(rows, c)
+		}
+		return []string{"user_id", "password_hash"}, rows, 0, nil
+	case "INSERT INTO refresh_tokens (hash, user_id, family, expires_at) VALUES ($1, $2, $3, $4)":
+		db.tokens[args[0].(string)] = &fakeToken{userID: args[1].(int64), family: args[2].(string), expires: args[3].(int64)}
+		return nil, nil, 1, nil
+	case "UPDATE refresh_tokens SET used = TRUE WHERE hash = $1 AND NOT used RETURNING user_id, family, expires_at, used",
+		"SELECT user_id, family, expires_at, used FROM refresh_tokens WHERE hash = $1":
+		t, ok := db.tokens[args[0].(string)]
+		if !ok || (strings.HasPrefix(query, "UPDATE") && t.used) {
+			return []string{"user_id", "family", "expires_at", "used"}, nil, 0, nil
+		}
+		if strings.HasPrefix(query, "UPDATE") {
+			t.used = true
+		}
+		return []string{"user_id", "family", "expires_at", "used"}, [][]driver.Value{{t.userID, t.family, t.expires, t.used}}, 1, nil
+	case "DELETE FROM refresh_tokens WHERE family = $1":
+		for hash, t := range db.tokens {
+			if t.family == args[0].(string) {
+				delete(db.tokens, hash)
+				affected++
+			}
+		}
+		return nil, nil, affected, nil
+	case "DELETE FROM credentials WHERE user_id = $1":
+		for email, c := range db.credentials {
+			if c[0] == args[0] {
+				delete(db.credentials, email)
+				affected++
+			}
+		}
+		return nil, nil, affected, nil
+	case "DELETE FROM refresh_tokens WHERE user_id = $1":
+		for hash, t := range db.tokens {
+			if t.userID == args[0].(int64) {
+				delete(db.tokens, hash)
+				affected++
+			}
+		}
+		return nil, nil, affected, nil
+	case "INSERT INTO schema_migrations (version) VALUES ($1)":
+		db.versions = append(db.versions, args[0].(int64))
+		return nil, nil, 1, nil
+	case "SELECT COUNT(*) FROM users", "SELECT COUNT(*) FROM users WHERE LOWER(name) LIKE $1 ESCAPE '\\'":
+		var n int64
+		for id := int64(1); id <= db.nextID; id++ {
+			if u, ok := db.users[id]; ok && db.like(u.name, args) {
+				n++
+			}
+		}
+		return []string{"count"}, [][]driver.Value{{n}}, 0, nil
+	case "SELECT name, version, role FROM users WHERE id = $1":
+		if u, ok := db.users[args[0].(int64)]; ok {
+			rows = append(rows, []driver.Value{u.name, u.version, u.role})
+		}
+		return []string{"name", "version", "role"}, rows, 0, nil
+	case "INSERT INTO users (name, role, version) VALUES ($1, $2, 1) RETURNING id":
+		db.nextID++
+		db.users[db.nextID] = &fakeUser{name: args[0].(string), role: args[1].(string), version: 1}
+		return []string{"id"}, [][]driver.Value{{db.nextID}}, 1, nil
+	case "UPDATE users SET name = $1, version = version + 1 WHERE id = $2 RETURNING version, role",
+		"UPDATE users SET name = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING version, role":
+		u, ok := db.users[args[1].(int64)]
+		if ok && (len(args) == 2 || u.version == args[2].(int64)) {
+			u.name = args[0].(string)
+			u.version++
+			rows = append(rows, []driver.Value{u.version, u.role})
+		}
+		return []string{"version", "role"}, rows, int64(len(rows)), nil
+	case "DELETE FROM users WHERE id = $1":
+		if _, ok := db.users[args[0].(int64)]; ok {
+			delete(db.users, args[0].(int64))
+			return nil, nil, 1, nil
+		}
+	default:
+		if !strings.HasPrefix(query, "SELECT id, name, version, role FROM users") {
+			return nil, nil, 0, errors.New("fake: unexpected statement " + query)
+		}
+		for id := int64(1); id <= db.nextID; id++ {
+			if u, ok := db.users[id]; ok && db.like(u.name, args) {
+				rows = append(rows, []driver.Value{id, u.name, u.version, u.role})
+			}
+		}
+		if strings.Contains(query, " LIMIT ") {
+			limit, offset := int(args[len(args)-2].(int64)), int(args[len(args)-1].(int64))
+			rows = rows[min(offset, len(rows)):min(offset+limit, len(rows))]
+		}
+		return []string{"id", "name", "version", "role"}, rows, 0, nil
+	}
+	return nil, nil, 0, nil
+}
+
+// like reports whether name matches the LIKE pattern in args, if any. Only
+// the %substring% patterns the store sends are understood.
+func (db *fakeDB) like(name string, args []driver.Value) bool {
+	if len(args) == 0 {
+		return true
+	}
+	pattern, ok := args[0].(string)
+	if !ok {
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), strings.Trim(pattern, "%"))
+}
+
+// The database/sql/driver plumbing around fakeDB.
+type (
+	fakeDriver struct{}
+	fakeConn   struct{ db *fakeDB }
+	fakeStmt   struct {
+		db    *fakeDB
+		query string
+	}
+	fakeRows struct {
+		cols []string
+		rows [][]driver.Value
+	}
+)
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	db, ok := fakeDBs[dsn]
+	if !ok {
+		return nil, errors.New("fake: connection refused")
+	}
+	return fakeConn{db}, nil
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c fakeConn) Commit() error                             { return nil }
+func (c fakeConn) Rollback() error                           { return nil }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, _, affected, err := s.db.run(s.query, args)
+	return driver.RowsAffected(affected), err
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	cols, rows, _, err := s.db.run(s.query, args)
+	return &fakeRows{cols, rows}, err
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// TestSQLUserStore tests each operation against the database.
+func TestSQLUserStore(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	newFakeDB(t.Name())
+	db, err := OpenSQL(ctx, SQLOptions{Driver: "postgres", DSN: t.Name(), MaxOpenConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := NewSQLUserStore(db)
+
+	// 2. Execute
+	ann, err := s.Create(ctx, User{Name: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, _ := s.Create(ctx, User{Name: "Bob"})
+	if _, err := s.Update(ctx, User{ID: ann.ID, Name: "Ann B.", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Assert
+	list, total, err := s.List(ctx, ListOptions{})
+	if want := []User{{ID: 1, Name: "Ann B.", Version: 2}}; err != nil || total != 1 || !reflect.DeepEqual(list, want) {
+		t.Errorf("got %v of %d (%v), want %v", list, total, err, want)
+	}
+	if u, err := s.Get(ctx, ann.ID); err != nil || u.Name != "Ann B." {
+		t.Errorf("unexpected Get: %v, %v", u, err)
+	}
+	for name, err := range map[string]error{
+		"get":    func() error { _, err := s.Get(ctx, bob.ID); return err }(),
+		"update": func() error { _, err := s.Update(ctx, User{ID: bob.ID}); return err }(),
+		"delete": s.Delete(ctx, bob.ID),
+	} {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+	if _, err := s.Update(ctx, User{ID: ann.ID, Name: "Stale", Version: 1}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict updating an old version, got %v", err)
+	}
+	admin, _ := s.Create(ctx, User{Name: "Root", Role: RoleAdmin})
+	if u, err := s.Update(ctx, User{ID: admin.ID, Name: "Root 2"}); err != nil || u.Role != RoleAdmin {
+		t.Errorf("expected the update to keep the role, got %v, %v", u, err)
+	}
+	if u, _ := s.Get(ctx, admin.ID); u.Role != RoleAdmin {
+		t.Errorf("expected the role stored, got %v", u)
+	}
+}
+
+// TestSQLUserStore_Many tests the bulk operations.
+func TestSQLUserStore_Many(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	newFakeDB(t.Name())
+	db, err := OpenSQL(ctx, SQLOptions{Driver: "postgres", DSN: t.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := NewSQLUserStore(db)
+
+	// 2. Execute
+	created, err := s.CreateMany(ctx, []User{{Name: "Ann"}, {Name: "Bob"}, {Name: "Cy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errDelete := s.DeleteMany(ctx, []int{1, 3, 1})
+	errMissing := s.DeleteMany(ctx, []int{3})
+
+	// 3. Assert
+	if want := []User{{ID: 1, Name: "Ann", Version: 1}, {ID: 2, Name: "Bob", Version: 1}, {ID: 3, Name: "Cy", Version: 1}}; !reflect.DeepEqual(created, want) {
+		t.Errorf("got %v, want %v", created, want)
+	}
+	if errDelete != nil {
+		t.Fatal(errDelete)
+	}
+	var notFound *NotFoundError
+	if !errors.Is(errMissing, ErrNotFound) || !errors.As(errMissing, &notFound) || notFound.ID != 3 {
+		t.Errorf("expected ErrNotFound naming user 3, got %v", errMissing)
+	}
+	if list, _, _ := s.List(ctx, ListOptions{}); len(list) != 1 || list[0].ID != 2 {
+		t.Errorf("expected user 2 left, got %v", list)
+	}
+}
+
+// TestSQLUserStore_List tests the queries List builds from its options.
+func TestSQLUserStore_List(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantQuery string
+		wantUsers []User
+		wantTotal int
+	}{
+		{
+			name:      "all",
+			wantQuery: "SELECT id, name, version, role FROM users ORDER BY id",
+			wantUsers: []User{{ID: 1, Name: "Ann", Version: 1}, {ID: 2, Name: "Bob", Version: 1}, {ID: 3, Name: "Anna", Version: 1}},
+			wantTotal: 3,
+		},
+		{
+			name:      "filtered",
+			opts:      ListOptions{NameContains: "AN"},
+			wantQuery: `SELECT id, name, version, role FROM users WHERE LOWER(name) LIKE $1 ESCAPE '\' ORDER BY id`,
+			wantUsers: []User{{ID: 1, Name: "Ann", Version: 1}, {ID: 3, Name: "Anna", Version: 1}},
+			wantTotal: 2,
+		},
+		{
+			name:      "sorted page",
+			opts:      ListOptions{Sort: []SortField{{Field: "name", Desc: true}}, Offset: 1, Limit: 1},
+			wantQuery: "SELECT id, name, version, role FROM users ORDER BY name DESC, id LIMIT $1 OFFSET $2",
+			wantUsers: []User{{ID: 2, Name: "Bob", Version: 1}},
+			wantTotal: 3,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Setup
+			ctx := context.Background()
+			fake := newFakeDB(t.Name())
+			db, err := OpenSQL(ctx, SQLOptions{Driver: "postgres", DSN: t.Name()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			s := NewSQLUserStore(db)
+			for _, name := range []string{"Ann", "Bob", "Anna"} {
+				s.Create(ctx, User{Name: name})
+			}
+
+			// 2. Execute
+			users, total, err := s.List(ctx, tc.opts)
+
+			// 3. Assert
+			if err != nil {
+				t.Fatal(err)
+			}
+			if last := fake.statements[len(fake.statements)-1]; last != tc.wantQuery {
+				t.Errorf("got query %q, want %q", last, tc.wantQuery)
+			}
+			if !reflect.DeepEqual(users, tc.wantUsers) || total != tc.wantTotal {
+				t.Errorf("got %v of %d, want %v of %d", users, total, tc.wantUsers, tc.wantTotal)
+			}
+		})
+	}
+
+	t.Run("unknown sort field", func(t *testing.T) {
+		s := NewSQLUserStore(nil)
+		_, _, err := s.List(context.Background(), ListOptions{Sort: []SortField{{Field: "name; DROP TABLE users"}}})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+// TestMigrate tests that migrations run once, however often the database
+// is opened.
+func TestMigrate(t *testing.T) {
+	// 1. Setup
+	ctx := context.Background()
+	fake := newFakeDB(t.Name())
+
+	// 2. Execute: Open twice.
+	for i := 0; i < 2; i++ {
+		db, err := OpenSQL(ctx, SQLOptions{Driver: "postgres", DSN: t.Name()})
+		if err != nil {
+			t.Fatalf("open %d: %v", i+1, err)
+		}
+		db.Close()
+	}
+
+	// 3. Assert
+	if !reflect.DeepEqual(fake.versions, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("expected versions 1 to 5 recorded once, got %v", fake.versions)
+	}
+	var creates int
+	for _, s := range fake.statements {
+		if strings.HasPrefix(s, "CREATE TABLE users") {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("expected the users table created once, got %d times", creates)
+	}
+}
+
+// TestOpenSQL_Errors tests drivers and databases that can't be used.
+func TestOpenSQL_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SQLOptions
+		wantErr string
+	}{
+		{"unsupported driver", SQLOptions{Driver: "oracle"}, `unsupported driver "oracle"`},
+		{"unregistered driver", SQLOptions{Driver: "sqlite"}, "unknown driver"},
+		{"unreachable", SQLOptions{Driver: "postgres", DSN: "nowhere"}, "connection refused"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := OpenSQL(context.Background(), tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestDialect_Rebind tests the parameter syntax of each database.
+func TestDialect_Rebind(t *testing.T) {
+	const query = "UPDATE users SET name = ? WHERE id = ?"
+	for d, want := range map[*Dialect]string{
+		&Postgres: "UPDATE users SET name = $1 WHERE id = $2",
+		&SQLite:   query,
+	} {
+		if got := d.rebind(query); got != want {
+			t.Errorf("%s: got %q, want %q", d.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Description: This package contains the application's storage: the
+// interfaces the handlers read and write data through, and their
+// implementations. Handlers only see the interfaces, so tests can use the
+// in-memory store and production a database, without the handlers changing.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNotFound is returned for records the store doesn't have.
+var ErrNotFound = errors.New("store: not found")
+
+// NotFoundError is ErrNotFound naming the missing record, for operations
+// on several records at once.
+type NotFoundError struct {
+	ID int
+}
+
+// Error implements the error interface.
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("store: %d not found", e.ID)
+}
+
+// Is makes errors.Is(err, ErrNotFound) true.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ErrConflict is returned for updates based on an outdated version of a
+// record, i.e. someone else changed it in the meantime.
+var ErrConflict = errors.New("store: version conflict")
+
+// User represents a user in our system.
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" validate:"required,max=100"`
+
+	// Version counts the user's changes, from 1 when it's created. Clients
+	// send back the version they read, so an update doesn't silently
+	// overwrite one they haven't seen.
+	Version int `json:"version"`
+
+	// Role is what the user may do, see handlers.UserRoles: empty for a
+	// regular user, or RoleAdmin. It's given when the user is created;
+	// updates keep it.
+	Role string `json:"role,omitempty" validate:"oneof=admin"`
+}
+
+// RoleAdmin is the role of the users who manage the others.
+const RoleAdmin = "admin"
+
+// UserSortFields are the fields users can be sorted by.
+var UserSortFields = []string{"id", "name"}
+
+// checkSort returns an error if sort has a field not in allowed. The SQL
+// stores rely on it to keep arbitrary text out of ORDER BY clauses.
+func checkSort(sort []SortField, allowed []string) error {
+	for _, f := range sort {
+		if !slices.Contains(allowed, f.Field) {
+			return fmt.Errorf("store: cannot sort by %q", f.Field)
+		}
+	}
+	return nil
+}
+
+// SortField is one field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions selects the users List returns.
+type ListOptions struct {
+	// NameContains keeps the users whose name contains it, ignoring case.
+	NameContains string
+
+	// Sort lists the fields to sort by, in priority order, each one of
+	// UserSortFields. Ties, and an empty Sort, are ordered by ID.
+	Sort []SortField
+
+	// Offset skips that many users, and Limit returns at most that many.
+	// A zero Limit returns them all, ignoring Offset.
+	Offset, Limit int
+}
+
+// UserStore stores users. Implementations must be safe for concurrent use.
+type UserStore interface {
+	// List returns the users opts selects, and how many match in total,
+	// regardless of Offset and Limit.
+	List(ctx context.Context, opts ListOptions) ([]User, int, error)
+
+	// Get returns the user with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id int) (User, error)
+
+	// Create stores a new user under a new ID, ignoring u.ID, and returns
+	// it with its ID and version 1.
+	Create(ctx context.Context, u User) (User, error)
+
+	// Update replaces the user with u.ID, or returns ErrNotFound, and
+	// returns it with its version incremented. If u.Version isn't zero, the
+	// stored user must still be at that version, or Update returns
+	// ErrConflict and changes nothing.
+	Update(ctx context.Context, u User) (User, error)
+
+	// Delete removes the user with the given ID, or returns ErrNotFound.
+	Delete(ctx context.Context, id int) error
+
+	// CreateMany creates users like Create, all of them or, on error, none.
+	CreateMany(ctx context.Context, users []User) ([]User, error)
+
+	// DeleteMany removes the users with the given IDs, all of them or, if
+	// one doesn't exist, none, returning a *NotFoundError. An ID given
+	// twice is deleted once.
+	DeleteMany(ctx context.Context, ids []int) error
+}
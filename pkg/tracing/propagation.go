@@ -0,0 +1,100 @@
+// Description: This file contains trace context propagation with the W3C
+// Trace Context headers (https://www.w3.org/TR/trace-context/):
+//
+//	traceparent: 00-<32 hex trace ID>-<16 hex parent span ID>-<2 hex flags>
+//	tracestate:  vendor-specific data, passed along unchanged
+//
+// Extract reads them from an incoming request; Inject writes them on an
+// outgoing one, so the services we call continue our trace.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Header names of the W3C Trace Context.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// sampledFlag is the trace-flags bit saying the trace is recorded.
+const sampledFlag = 0x01
+
+// ParseTraceparent parses a traceparent header value.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	// Version ff is forbidden. Future versions may append fields, but
+	// version 00 has exactly four.
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+	// The spec requires lowercase hex; hex.Decode would accept uppercase.
+	for _, part := range parts[:4] {
+		if strings.ToLower(part) != part {
+			return sc, false
+		}
+	}
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, false
+	}
+	if !sc.IsValid() {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&sampledFlag != 0
+	return sc, true
+}
+
+// Traceparent formats the span context as a traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Extract returns ctx with the remote span context from the request headers,
+// if they carry a valid one.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	sc.TraceState = header.Get(TracestateHeader)
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject writes the current span context of ctx into outgoing request
+// headers:
+//
+//	req, _ := http.NewRequestWithContext(c, "GET", url, nil)
+//	tracing.Inject(c, req.Header)
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	}
+}
@@ -0,0 +1,349 @@
+// Description: This package contains a small distributed tracing API modelled
+// on OpenTelemetry's: a Tracer starts Spans, spans form a tree through the
+// context, and finished spans go to an Exporter. Trace context travels between
+// services in the W3C traceparent header (see propagation.go), so traces join
+// up with services using OpenTelemetry or any other compliant tracer.
+//
+// The package has no dependencies. Sending spans to a real backend (Jaeger,
+// Tempo, an OTLP collector, ...) is a matter of writing an Exporter that
+// converts SpanData to that backend's format.
+//
+//	_, span := tracer.Start(c, "users.query")
+//	defer span.End()
+//	span.SetAttribute("db.rows", len(rows))
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a whole trace, across every service it touches.
+type TraceID [16]byte
+
+// String returns the ID in lowercase hex, as in traceparent headers.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID isn't all zeros, which W3C reserves as invalid.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID identifies one span within a trace.
+type SpanID [8]byte
+
+// String returns the ID in lowercase hex.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID isn't all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span that crosses process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+
+	// Sampled reports whether the trace is being recorded. Unsampled spans
+	// are still propagated, so downstream services make the same choice,
+	// but they aren't exported.
+	Sampled bool
+
+	// TraceState is the vendor-specific tracestate header, passed along
+	// unchanged.
+	TraceState string
+
+	// Remote reports whether the context was received from another service.
+	Remote bool
+}
+
+// IsValid reports whether both IDs are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind says what role a span plays, as in OpenTelemetry.
+type SpanKind int
+
+// The span kinds.
+const (
+	KindInternal SpanKind = iota
+	KindServer
+	KindClient
+)
+
+// String returns the kind's name.
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// StatusCode is the outcome of a span.
+type StatusCode int
+
+// The status codes. Unset means the span didn't say; OpenTelemetry treats it
+// as a success.
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Event is something that happened during a span, such as an error.
+type Event struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// SpanData is a snapshot of a finished span, as handed to exporters.
+type SpanData struct {
+	Name          string                 `json:"name"`
+	Kind          SpanKind               `json:"kind"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Start         time.Time              `json:"start"`
+	End           time.Time              `json:"end"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Status        StatusCode             `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+	Events        []Event                `json:"events,omitempty"`
+}
+
+// Exporter receives finished, sampled spans. ExportSpan is called from the
+// goroutine that ended the span, so slow exporters should batch and send in
+// the background.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// ExporterFunc adapts a function to the Exporter interface.
+type ExporterFunc func(span SpanData)
+
+// ExportSpan calls f.
+func (f ExporterFunc) ExportSpan(span SpanData) { f(span) }
+
+// Tracer starts spans and exports them when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a tracer exporting to exporter; nil discards every span.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// StartOption customizes a span started with Tracer.Start.
+type StartOption func(*Span)
+
+// WithKind sets the span's kind; the default is KindInternal.
+func WithKind(kind SpanKind) StartOption {
+	return func(s *Span) { s.kind = kind }
+}
+
+// WithAttributes sets initial attributes on the span.
+func WithAttributes(attrs map[string]interface{}) StartOption {
+	return func(s *Span) {
+		for k, v := range attrs {
+			s.attrs[k] = v
+		}
+	}
+}
+
+// Start starts a span as a child of the span in ctx, or of the remote span
+// context in ctx (see ContextWithRemoteSpanContext), or as the root of a new
+// trace. It returns a context holding the new span, for its own children.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	s := &Span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+	}
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.sc.TraceState = parent.TraceState
+		s.parent = parent.SpanID
+	} else {
+		rand.Read(s.sc.TraceID[:])
+		s.sc.Sampled = true
+	}
+	rand.Read(s.sc.SpanID[:])
+	for _, opt := range opts {
+		opt(s)
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+// Span is an operation being traced. Its methods are safe for concurrent use,
+// and do nothing on a nil *Span or once the span has ended, so code can trace
+// unconditionally.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanID
+	kind   SpanKind
+	start  time.Time
+
+	mu        sync.Mutex
+	name      string
+	attrs     map[string]interface{}
+	status    StatusCode
+	statusMsg string
+	events    []Event
+	ended     bool
+}
+
+// SpanContext returns the span's propagated identity.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName renames the span, e.g. once the matched route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.name = name
+}
+
+// SetAttribute records a key/value pair describing the operation. Keys should
+// follow the OpenTelemetry semantic conventions where one fits, e.g.
+// "http.route" or "db.system".
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.attrs[key] = value
+}
+
+// AddEvent records a named event at the current time.
+func (s *Span) AddEvent(name string, attrs map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, Event{Name: name, Time: time.Now(), Attributes: attrs})
+}
+
+// RecordError records err as an "exception" event and marks the span failed.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.AddEvent("exception", map[string]interface{}{"exception.message": err.Error()})
+	s.SetStatus(StatusError, err.Error())
+}
+
+// SetStatus sets the span's outcome. An error status can't be downgraded, so
+// that an early failure isn't hidden by a later success.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.status == StatusError && code != StatusError {
+		return
+	}
+	s.status, s.statusMsg = code, message
+}
+
+// End finishes the span and exports it if it's sampled. Calls after the first
+// are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		Name:          s.name,
+		Kind:          s.kind,
+		TraceID:       s.sc.TraceID.String(),
+		SpanID:        s.sc.SpanID.String(),
+		Start:         s.start,
+		End:           time.Now(),
+		Attributes:    s.attrs,
+		Status:        s.status,
+		StatusMessage: s.statusMsg,
+		Events:        s.events,
+	}
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+	s.mu.Unlock()
+
+	if s.sc.Sampled && s.tracer != nil && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// spanKey and remoteKey are the context keys of the current span and of a
+// span context received from another service.
+type (
+	spanKey   struct{}
+	remoteKey struct{}
+)
+
+// ContextWithSpan returns a context holding span as the current span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteSpanContext returns a context holding a span context
+// received from another service, which the next Start continues.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the current span, or
+// else the remote one, or the zero value.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
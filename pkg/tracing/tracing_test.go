@@ -0,0 +1,112 @@
+// Description: This file contains tests for spans and trace propagation.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestParseTraceparent tests valid and invalid traceparent headers.
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		wantOK      bool
+		wantSampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"short", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2. Execute
+			sc, ok := ParseTraceparent(tt.value)
+
+			// 3. Assert
+			if ok != tt.wantOK || sc.Sampled != tt.wantSampled {
+				t.Fatalf("expected ok=%v sampled=%v, got %v %v", tt.wantOK, tt.wantSampled, ok, sc.Sampled)
+			}
+			if ok && tt.value[:3] == "00-" && sc.Traceparent() != tt.value {
+				t.Errorf("expected a round trip, got %q", sc.Traceparent())
+			}
+		})
+	}
+}
+
+// TestTracer tests parent/child spans, export, and propagation.
+func TestTracer(t *testing.T) {
+	// 1. Setup
+	var exported []SpanData
+	tracer := NewTracer(ExporterFunc(func(s SpanData) { exported = append(exported, s) }))
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TracestateHeader, "vendor=1")
+
+	// 2. Execute
+	ctx := Extract(context.Background(), header)
+	ctx, server := tracer.Start(ctx, "GET /users", WithKind(KindServer))
+	childCtx, child := tracer.Start(ctx, "db.query")
+	child.SetAttribute("db.rows", 2)
+	child.RecordError(errors.New("slow query"))
+	child.SetStatus(StatusOK, "")
+	outgoing := http.Header{}
+	Inject(childCtx, outgoing)
+	child.End()
+	child.SetAttribute("ignored", true)
+	server.End()
+	server.End()
+
+	// 3. Assert
+	if len(exported) != 2 {
+		t.Fatalf("expected 2 exported spans, got %d", len(exported))
+	}
+	c, s := exported[0], exported[1]
+	if s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || s.ParentSpanID != "00f067aa0ba902b7" || s.Kind != KindServer {
+		t.Errorf("expected the server span to continue the remote trace, got %+v", s)
+	}
+	if c.TraceID != s.TraceID || c.ParentSpanID != s.SpanID {
+		t.Errorf("expected the child under the server span, got %+v", c)
+	}
+	if c.Status != StatusError || c.Attributes["db.rows"] != 2 || len(c.Events) != 1 || c.Attributes["ignored"] != nil {
+		t.Errorf("unexpected child span %+v", c)
+	}
+	if outgoing.Get(TraceparentHeader) != "00-"+c.TraceID+"-"+c.SpanID+"-01" || outgoing.Get(TracestateHeader) != "vendor=1" {
+		t.Errorf("unexpected injected headers %v", outgoing)
+	}
+}
+
+// TestSpan_Unsampled tests that unsampled traces propagate but aren't exported.
+func TestSpan_Unsampled(t *testing.T) {
+	exported := 0
+	tracer := NewTracer(ExporterFunc(func(SpanData) { exported++ }))
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, span := tracer.Start(Extract(context.Background(), header), "op")
+	span.End()
+
+	if exported != 0 || span.SpanContext().Sampled {
+		t.Errorf("expected an unsampled, unexported span")
+	}
+
+	// A nil span is safe to use.
+	var none *Span
+	none.SetAttribute("a", 1)
+	none.RecordError(errors.New("x"))
+	none.End()
+	if SpanFromContext(context.Background()) != nil {
+		t.Error("expected no span in an empty context")
+	}
+}
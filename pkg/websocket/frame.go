@@ -0,0 +1,231 @@
+// Description: This file implements WebSocket framing: reading client frames
+// (which are always masked), reassembling fragmented messages, answering pings
+// and close frames, and writing unmasked server frames.
+
+package websocket
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// Message types, as used by ReadMessage and WriteMessage.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// Close codes from RFC 6455 section 7.4.1.
+const (
+	CloseNormal         = 1000
+	CloseGoingAway      = 1001
+	CloseProtocolError  = 1002
+	CloseNoStatus       = 1005
+	CloseInvalidPayload = 1007
+	CloseMessageTooBig  = 1009
+	CloseInternalError  = 1011
+)
+
+// maxControlPayload is the largest payload a control frame may carry.
+const maxControlPayload = 125
+
+// errProtocol is reported for frames that break the protocol rules.
+var errProtocol = errors.New("websocket: protocol error")
+
+// frame is one decoded frame header plus its unmasked payload.
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered and
+// pongs skipped transparently. When the client closes the connection, the
+// closing handshake is completed and a *CloseError is returned.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		f, err := c.readFrame(c.readLimit - int64(len(data)))
+		if err != nil {
+			if errors.Is(err, errTooBig) {
+				c.WriteClose(CloseMessageTooBig, "message too big")
+			} else if errors.Is(err, errProtocol) {
+				c.WriteClose(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, f.payload); err != nil {
+				return 0, nil, err
+			}
+		case opPong:
+			// Unsolicited pongs serve as heartbeats; nothing to do.
+		case opClose:
+			return 0, nil, c.handleClose(f.payload)
+		case opText, opBinary:
+			if messageType != 0 {
+				// A new message started before the previous one ended.
+				c.WriteClose(CloseProtocolError, "")
+				return 0, nil, errProtocol
+			}
+			messageType = int(f.opcode)
+			data = f.payload
+		case opContinuation:
+			if messageType == 0 {
+				c.WriteClose(CloseProtocolError, "")
+				return 0, nil, errProtocol
+			}
+			data = append(data, f.payload...)
+		default:
+			c.WriteClose(CloseProtocolError, "")
+			return 0, nil, errProtocol
+		}
+
+		if messageType != 0 && f.fin && f.opcode <= opBinary {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				c.WriteClose(CloseInvalidPayload, "invalid UTF-8")
+				return 0, nil, errors.New("websocket: invalid UTF-8 in text message")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// handleClose answers a close frame and returns the matching CloseError.
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		c.WriteClose(CloseProtocolError, "")
+		return errProtocol
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+	}
+	// Echo the code back to complete the closing handshake.
+	if closeErr.Code == CloseNoStatus {
+		c.writeFrame(opClose, nil)
+	} else {
+		c.WriteClose(closeErr.Code, "")
+	}
+	return closeErr
+}
+
+// errTooBig is returned when a message exceeds the read limit.
+var errTooBig = errors.New("websocket: message exceeds read limit")
+
+// readFrame reads a single frame whose payload may be at most limit bytes.
+func (c *Conn) readFrame(limit int64) (frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return frame{}, err
+	}
+	f := frame{fin: header[0]&0x80 != 0, opcode: header[0] & 0x0f}
+	if header[0]&0x70 != 0 {
+		return frame{}, errProtocol // No extensions were negotiated, so RSV bits must be 0.
+	}
+	masked := header[1]&0x80 != 0
+	if !masked {
+		return frame{}, errProtocol // Clients must mask every frame.
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	isControl := f.opcode >= opClose
+	if isControl && (!f.fin || length > maxControlPayload) {
+		return frame{}, errProtocol
+	}
+	if !isControl && length > uint64(max(limit, 0)) {
+		return frame{}, errTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return frame{}, err
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return frame{}, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+	return f, nil
+}
+
+// WriteMessage sends data as a single text or binary message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// WritePing sends a ping; the client answers with a pong.
+func (c *Conn) WritePing(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: ping payload too long")
+	}
+	return c.writeFrame(opPing, data)
+}
+
+// WriteClose starts (or completes) the closing handshake with a status code
+// and an optional reason. The connection should be closed afterwards.
+func (c *Conn) WriteClose(code int, text string) error {
+	if len(text) > maxControlPayload-2 {
+		text = text[:maxControlPayload-2]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.writeFrame(opClose, append(payload, text...))
+}
+
+// writeFrame writes one final, unmasked frame (servers must not mask).
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|opcode)
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
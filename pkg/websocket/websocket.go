@@ -0,0 +1,226 @@
+// Description: This package implements the server side of the WebSocket protocol
+// (RFC 6455) using only the standard library: the opening handshake, and
+// reading and writing messages over the upgraded connection. It's enough for
+// bidirectional endpoints such as chat rooms or live dashboards:
+//
+//	conn, err := websocket.Upgrade(w, req, websocket.Options{})
+//	if err != nil {
+//		return // Upgrade already answered the client.
+//	}
+//	defer conn.Close()
+//	for {
+//		kind, msg, err := conn.ReadMessage()
+//		if err != nil {
+//			return
+//		}
+//		conn.WriteMessage(kind, msg) // echo
+//	}
+//
+// Extensions such as permessage-deflate are not supported; browsers fall back to
+// uncompressed messages automatically.
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures the handshake performed by Upgrade.
+type Options struct {
+	// Subprotocols lists the application protocols the server speaks, in
+	// order of preference. The first one also offered by the client is chosen.
+	Subprotocols []string
+
+	// CheckOrigin decides whether a browser on another site may connect.
+	// Browsers don't apply CORS to WebSockets, so without this check any web
+	// page could open an authenticated connection on a visitor's behalf. The
+	// default allows requests without an Origin header and same-host origins.
+	CheckOrigin func(req *http.Request) bool
+
+	// ReadLimit is the maximum size of a message in bytes. Zero means 1MB.
+	ReadLimit int64
+}
+
+// acceptGUID is the fixed string RFC 6455 mixes into Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// IsUpgrade reports whether req asks to switch to the WebSocket protocol.
+func IsUpgrade(req *http.Request) bool {
+	return headerHasToken(req.Header, "Connection", "upgrade") &&
+		headerHasToken(req.Header, "Upgrade", "websocket")
+}
+
+// Upgrade performs the opening handshake and returns the WebSocket connection.
+// If the request isn't a valid WebSocket handshake, Upgrade answers it with an
+// HTTP error and returns a non-nil error; the caller must then not write to w.
+func Upgrade(w http.ResponseWriter, req *http.Request, opts Options) (*Conn, error) {
+	fail := func(status int, reason string) (*Conn, error) {
+		if status == http.StatusUpgradeRequired {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+		http.Error(w, reason, status)
+		return nil, errors.New("websocket: " + reason)
+	}
+
+	if req.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "handshake must use GET")
+	}
+	if !IsUpgrade(req) {
+		return fail(http.StatusBadRequest, "not a websocket handshake")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(req) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+	protocol := chooseSubprotocol(req, opts.Subprotocols)
+
+	// Take over the TCP connection; from here on we speak WebSocket, not HTTP.
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "connection does not support hijacking")
+	}
+
+	// The handshake response is written by hand, since net/http no longer
+	// owns the connection.
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if protocol != "" {
+		response += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	response += "\r\n"
+
+	netConn.SetDeadline(time.Time{}) // Clear any deadlines set by the HTTP server.
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	limit := opts.ReadLimit
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	return &Conn{conn: netConn, br: rw.Reader, readLimit: limit, subprotocol: protocol}, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// sameOrigin is the default CheckOrigin: non-browser clients (no Origin
+// header) and pages from the same host are allowed.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, req.Host)
+}
+
+// chooseSubprotocol returns the first of our protocols the client offered.
+func chooseSubprotocol(req *http.Request, supported []string) string {
+	offered := strings.Split(strings.Join(req.Header.Values("Sec-WebSocket-Protocol"), ","), ",")
+	for _, want := range supported {
+		for _, p := range offered {
+			if strings.TrimSpace(p) == want {
+				return want
+			}
+		}
+	}
+	return ""
+}
+
+// headerHasToken reports whether a comma-separated header contains token,
+// ignoring case (e.g. "Connection: keep-alive, Upgrade").
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is an upgraded WebSocket connection. One goroutine may read while
+// another writes; writes from several goroutines are serialized.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	readLimit   int64
+	subprotocol string
+
+	// writeMu serializes frames, since a ping reply sent by the reader
+	// must not interleave with a message being written.
+	writeMu sync.Mutex
+}
+
+// Subprotocol returns the protocol chosen during the handshake, or "".
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the client's network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets how long ReadMessage may wait; use it with pings to
+// notice dead clients.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets how long a write may block on a slow client.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Close closes the underlying connection without a closing handshake. Use
+// WriteClose first for a clean shutdown.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// CloseError is returned by ReadMessage when the peer closed the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with code %d %s", e.Code, e.Text)
+}
@@ -0,0 +1,215 @@
+// Description: This file contains tests for the WebSocket handshake and framing.
+// They talk to a real server over TCP with a minimal hand-written client.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testClient is just enough of a WebSocket client to exercise the server.
+type testClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dial opens a TCP connection to srv and performs the handshake with extra
+// headers, returning the client and the server's status line.
+func dial(t *testing.T, srv *httptest.Server, headers string) (*testClient, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: "+strings.TrimPrefix(srv.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"+headers+"\r\n")
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// The example key and accept value from RFC 6455 section 1.3.
+		if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("unexpected Sec-WebSocket-Accept %q", got)
+		}
+	}
+	return &testClient{conn: conn, br: br}, resp.Status
+}
+
+// send writes one masked frame.
+func (c *testClient) send(fin bool, opcode byte, payload []byte) {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+// receive reads one unmasked server frame.
+func (c *testClient) receive(t *testing.T) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(header[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	io.ReadFull(c.br, payload)
+	return header[0] & 0x0f, payload
+}
+
+// newEchoServer starts a server that echoes messages and reports how the
+// connection ended on done.
+func newEchoServer(t *testing.T, done chan<- error) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := Upgrade(w, req, Options{Subprotocols: []string{"chat"}, ReadLimit: 1000})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			kind, msg, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			conn.WriteMessage(kind, msg)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestUpgrade_Echo tests the handshake, fragmented messages, pings, and the
+// closing handshake.
+func TestUpgrade_Echo(t *testing.T) {
+	// 1. Setup
+	done := make(chan error, 1)
+	srv := newEchoServer(t, done)
+	client, status := dial(t, srv, "Sec-WebSocket-Protocol: other, chat\r\n")
+	if !strings.HasPrefix(status, "101") {
+		t.Fatalf("expected 101, got %s", status)
+	}
+
+	// 2. Execute & 3. Assert: A fragmented text message with a ping in between.
+	client.send(false, opText, []byte("hel"))
+	client.send(true, opPing, []byte("p"))
+	client.send(true, opContinuation, []byte("lo"))
+	if op, payload := client.receive(t); op != opPong || string(payload) != "p" {
+		t.Errorf("expected pong, got %d %q", op, payload)
+	}
+	if op, payload := client.receive(t); op != opText || string(payload) != "hello" {
+		t.Errorf("expected echo, got %d %q", op, payload)
+	}
+
+	// A longer binary message uses the 16-bit length.
+	big := []byte(strings.Repeat("x", 300))
+	client.send(true, opBinary, big)
+	if op, payload := client.receive(t); op != opBinary || len(payload) != 300 {
+		t.Errorf("unexpected binary echo: %d, %d bytes", op, len(payload))
+	}
+
+	// Closing: the server echoes the code and ReadMessage reports it.
+	client.send(true, opClose, binary.BigEndian.AppendUint16(nil, CloseNormal))
+	if op, payload := client.receive(t); op != opClose || binary.BigEndian.Uint16(payload) != CloseNormal {
+		t.Errorf("expected close echo, got %d %v", op, payload)
+	}
+	var closeErr *CloseError
+	if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != CloseNormal {
+		t.Errorf("expected a CloseError, got %v", err)
+	}
+}
+
+// TestUpgrade_Limits tests that oversized and invalid messages close the connection.
+func TestUpgrade_Limits(t *testing.T) {
+	tests := []struct {
+		name     string
+		opcode   byte
+		payload  []byte
+		wantCode uint16
+	}{
+		{"too big", opBinary, make([]byte, 2000), CloseMessageTooBig},
+		{"invalid UTF-8", opText, []byte{0xff, 0xfe}, CloseInvalidPayload},
+		{"unknown opcode", 0x3, nil, CloseProtocolError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			client, _ := dial(t, newEchoServer(t, done), "")
+			client.send(true, tc.opcode, tc.payload)
+			op, payload := client.receive(t)
+			if op != opClose || len(payload) < 2 || binary.BigEndian.Uint16(payload) != tc.wantCode {
+				t.Errorf("expected close %d, got %d %v", tc.wantCode, op, payload)
+			}
+			if err := <-done; err == nil {
+				t.Error("expected ReadMessage to fail")
+			}
+		})
+	}
+}
+
+// TestUpgrade_Rejected tests handshakes that must be refused with HTTP errors.
+func TestUpgrade_Rejected(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, err := Upgrade(w, req, Options{}); err == nil {
+			t.Error("expected the upgrade to fail")
+		}
+	})
+
+	tests := []struct {
+		name       string
+		modify     func(req *http.Request)
+		wantStatus int
+	}{
+		{"plain request", func(req *http.Request) { req.Header.Del("Upgrade") }, http.StatusBadRequest},
+		{"old version", func(req *http.Request) { req.Header.Set("Sec-WebSocket-Version", "8") }, http.StatusUpgradeRequired},
+		{"bad key", func(req *http.Request) { req.Header.Set("Sec-WebSocket-Key", "short") }, http.StatusBadRequest},
+		{"foreign origin", func(req *http.Request) { req.Header.Set("Origin", "https://evil.example") }, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/ws", nil)
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			tc.modify(req)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+// Description: This package implements a small YAML encoder using only the
+// standard library. It covers what our ops-facing endpoints need (config dumps,
+// status reports): maps, structs, slices, and scalars, emitted in block style.
+//
+// Struct fields are named by their `yaml` tag, falling back to the `json` tag and
+// then to the lowercased field name. Both tags support "-" and ",omitempty".
+
+package yaml
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal returns the YAML encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encode writes v as a top-level document (or a block nested at indent).
+func encode(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	v = indirect(v)
+	if isBlock(v) {
+		return writeBlock(buf, v, indent)
+	}
+	s, err := scalar(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// indirect follows pointers and interfaces down to the concrete value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isBlock reports whether v is written as a multi-line mapping or sequence.
+// Empty collections are written inline as {} or [].
+func isBlock(v reflect.Value) bool {
+	if !v.IsValid() || isTextual(v) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return false // []byte is written as a string.
+		}
+		return v.Len() > 0
+	case reflect.Struct:
+		return len(structFields(v)) > 0
+	}
+	return false
+}
+
+// isTextual reports whether a value should be written as its text form, which
+// is true for time.Time and anything implementing encoding.TextMarshaler.
+func isTextual(v reflect.Value) bool {
+	if _, ok := v.Interface().(time.Time); ok {
+		return true
+	}
+	_, ok := v.Interface().(encoding.TextMarshaler)
+	return ok
+}
+
+// field is one key/value pair of a mapping.
+type field struct {
+	key   string
+	value reflect.Value
+}
+
+// writeBlock writes a non-empty mapping or sequence, one entry per line.
+func writeBlock(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	pad := strings.Repeat(" ", indent)
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			item := indirect(v.Index(i))
+			if !isBlock(item) {
+				s, err := scalar(item)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(buf, "%s- %s\n", pad, s)
+				continue
+			}
+			// Render the nested block one level deeper, then put the dash
+			// in place of the indentation of its first line:
+			//   - name: ann
+			//     age: 30
+			var nested bytes.Buffer
+			if err := writeBlock(&nested, item, indent+2); err != nil {
+				return err
+			}
+			buf.WriteString(pad + "- ")
+			buf.Write(nested.Bytes()[indent+2:])
+		}
+		return nil
+	}
+
+	fields, err := mappingFields(v)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		value := indirect(f.value)
+		if !isBlock(value) {
+			s, err := scalar(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s%s: %s\n", pad, f.key, s)
+			continue
+		}
+		fmt.Fprintf(buf, "%s%s:\n", pad, f.key)
+		if err := writeBlock(buf, value, indent+2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mappingFields returns the entries of a map (sorted by key, for stable output)
+// or a struct (in declaration order).
+func mappingFields(v reflect.Value) ([]field, error) {
+	if v.Kind() == reflect.Struct {
+		return structFields(v), nil
+	}
+
+	fields := make([]field, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := scalar(indirect(iter.Key()))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{key: key, value: iter.Value()})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields, nil
+}
+
+// structFields returns the exported fields of a struct with their YAML names,
+// honoring "-" and ",omitempty" in the yaml or json tag.
+func structFields(v reflect.Value) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, ok := sf.Tag.Lookup("yaml")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+
+		fv := v.Field(i)
+		if strings.Contains(opts, "omitempty") && isEmpty(fv) {
+			continue
+		}
+		fields = append(fields, field{key: quoteIfNeeded(name), value: fv})
+	}
+	return fields
+}
+
+// isEmpty mirrors encoding/json's definition of an empty value for omitempty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// scalar formats a value that fits on one line: a scalar, or an empty collection.
+func scalar(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "null", nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return quoteIfNeeded(string(text)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return ".nan", nil
+		case math.IsInf(f, 1):
+			return ".inf", nil
+		case math.IsInf(f, -1):
+			return "-.inf", nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, v.Type().Bits()), nil
+	case reflect.String:
+		return quoteIfNeeded(v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return quoteIfNeeded(string(v.Bytes())), nil
+		}
+		return "[]", nil
+	case reflect.Array:
+		return "[]", nil
+	case reflect.Map, reflect.Struct:
+		return "{}", nil
+	}
+	return "", fmt.Errorf("yaml: unsupported type %s", v.Type())
+}
+
+// quoteIfNeeded returns s as a plain YAML scalar when that's unambiguous, and as
+// a double-quoted string otherwise (e.g. "true", "42", "", "a: b", "#tag").
+func quoteIfNeeded(s string) string {
+	if needsQuotes(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuotes reports whether a plain scalar would be misread by a YAML parser.
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
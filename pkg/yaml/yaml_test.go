@@ -0,0 +1,92 @@
+// Description: This file contains tests for the YAML encoder.
+
+package yaml
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMarshal tests encoding a nested structure.
+func TestMarshal(t *testing.T) {
+	type limits struct {
+		MaxBody int64 `json:"max_body"`
+	}
+	type config struct {
+		Name     string            `yaml:"name"`
+		Port     int               `json:"port"`
+		Debug    bool              `json:"debug"`
+		Started  time.Time         `json:"started"`
+		Hosts    []string          `json:"hosts"`
+		Labels   map[string]string `json:"labels"`
+		Limits   limits            `json:"limits"`
+		Backends []limits          `json:"backends"`
+		Empty    []string          `json:"empty"`
+		Skipped  string            `json:"-"`
+		Omitted  string            `json:"omitted,omitempty"`
+		Nothing  *int              `json:"nothing"`
+	}
+	cfg := config{
+		Name:     "api: main",
+		Port:     8080,
+		Started:  time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC),
+		Hosts:    []string{"a.example.com", "true"},
+		Labels:   map[string]string{"team": "core", "env": "prod"},
+		Limits:   limits{MaxBody: 1024},
+		Backends: []limits{{MaxBody: 1}, {MaxBody: 2}},
+		Skipped:  "x",
+	}
+
+	want := `name: "api: main"
+port: 8080
+debug: false
+started: 2024-06-07T12:00:00Z
+hosts:
+  - a.example.com
+  - "true"
+labels:
+  env: prod
+  team: core
+limits:
+  max_body: 1024
+backends:
+  - max_body: 1
+  - max_body: 2
+empty: []
+nothing: null
+`
+	got, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected YAML:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestMarshal_Scalars tests top-level scalars and quoting.
+func TestMarshal_Scalars(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"plain", "plain\n"},
+		{"", "\"\"\n"},
+		{"42", "\"42\"\n"},
+		{"- dash", "\"- dash\"\n"},
+		{"line\nbreak", "\"line\\nbreak\"\n"},
+		{3.5, "3.5\n"},
+		{nil, "null\n"},
+		{[]int{}, "[]\n"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.in)
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%#v: expected %q, but got %q", tt.in, tt.want, got)
+		}
+	}
+}